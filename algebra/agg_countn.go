@@ -0,0 +1,128 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function COUNTN(expr). It returns the
+count of the number values in the group. Type CountN is a struct
+that inherits from AggregateBase.
+*/
+type CountN struct {
+	AggregateBase
+}
+
+/*
+The function NewCountN calls NewAggregateBase to create an
+aggregate function named COUNTN with one expression as input.
+*/
+func NewCountN(operand expression.Expression) Aggregate {
+	rv := &CountN{
+		*NewAggregateBase("countn", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *CountN) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *CountN) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *CountN) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewCountN with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *CountN) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewCountN(operands[0])
+	}
+}
+
+/*
+If no input to the COUNTN function, then the default value
+returned is a zero value.
+*/
+func (this *CountN) Default() value.Value { return value.ZERO_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other than
+numbers are not counted.
+*/
+func (this *CountN) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return this.cumulatePart(value.ONE_VALUE, cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *CountN) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Returns input cumulative value as the Final result.
+*/
+func (this *CountN) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return cumulative, nil
+}
+
+/*
+Aggregate input partial values into cumulative result number value.
+If the partial and current cumulative result are both float64
+numbers, add them and return.
+*/
+func (this *CountN) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	actual := part.Actual()
+	switch actual := actual.(type) {
+	case float64:
+		count := cumulative.Actual()
+		switch count := count.(type) {
+		case float64:
+			return value.NewValue(count + actual), nil
+		default:
+			return nil, fmt.Errorf("Invalid COUNTN %v of type %T.", count, count)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid partial COUNTN %v of type %T.", actual, actual)
+	}
+}
@@ -0,0 +1,176 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function OBJECT_AGG(name, value). It
+returns an object built from the name/value pairs in the group.
+Pairs whose name is not a string, or whose value is MISSING, are
+ignored. If a name occurs more than once, one of its values is
+chosen arbitrarily. Type ObjectAgg is a struct that inherits from
+AggregateBase.
+*/
+type ObjectAgg struct {
+	AggregateBase
+}
+
+/*
+The function NewObjectAgg calls NewBinaryAggregateBase to create an
+aggregate function named OBJECT_AGG with two expressions as input.
+*/
+func NewObjectAgg(name, val expression.Expression) Aggregate {
+	rv := &ObjectAgg{
+		*NewBinaryAggregateBase("object_agg", name, val),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *ObjectAgg) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type OBJECT.
+*/
+func (this *ObjectAgg) Type() value.Type { return value.OBJECT }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *ObjectAgg) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum number of arguments to the OBJECT_AGG function is 2.
+*/
+func (this *ObjectAgg) MinArgs() int { return 2 }
+
+/*
+Maximum number of arguments to the OBJECT_AGG function is 2.
+*/
+func (this *ObjectAgg) MaxArgs() int { return 2 }
+
+/*
+The constructor returns a NewObjectAgg with the input operands
+cast to a Function as the FunctionConstructor.
+*/
+func (this *ObjectAgg) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewObjectAgg(operands[0], operands[1])
+	}
+}
+
+/*
+If no input to the OBJECT_AGG function, then the default value
+returned is a null.
+*/
+func (this *ObjectAgg) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating both operands. If the name is
+not a string or the value is missing, return the input value itself.
+Otherwise set the field in the cumulative object, which is modified
+in place, and return it.
+*/
+func (this *ObjectAgg) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	name, e := this.Operands()[0].Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if name.Type() != value.STRING {
+		return cumulative, nil
+	}
+
+	val, e := this.Operands()[1].Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if val.Type() == value.MISSING {
+		return cumulative, nil
+	}
+
+	if cumulative == value.NULL_VALUE {
+		return value.NewValue(map[string]interface{}{name.Actual().(string): val}), nil
+	}
+
+	if _, ok := cumulative.Actual().(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("Invalid OBJECT_AGG %v of type %T.", cumulative.Actual(), cumulative.Actual())
+	}
+
+	cumulative.SetField(name.Actual().(string), val)
+	return cumulative, nil
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *ObjectAgg) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Returns input cumulative value as the Final result.
+*/
+func (this *ObjectAgg) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return cumulative, nil
+}
+
+/*
+Aggregate input partial values into cumulative result object. If no
+partial result exists(its value is a null) return the cumulative
+value. If the cumulative input value is null, return the partial
+value. Otherwise merge both objects into a new object, so that
+neither input is modified.
+*/
+func (this *ObjectAgg) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	p, ok := part.Actual().(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid partial OBJECT_AGG %v of type %T.", part.Actual(), part.Actual())
+	}
+
+	c, ok := cumulative.Actual().(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid OBJECT_AGG %v of type %T.", cumulative.Actual(), cumulative.Actual())
+	}
+
+	rv := make(map[string]interface{}, len(c)+len(p))
+	for k, v := range c {
+		rv[k] = v
+	}
+
+	for k, v := range p {
+		rv[k] = v
+	}
+
+	return value.NewValue(rv), nil
+}
@@ -0,0 +1,318 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Base class for the MEDIAN, PERCENTILE_CONT and PERCENTILE_DISC
+aggregates. The cumulative value is an array of the number values in
+the group; other values are ignored. The array is sorted when the
+final value is computed.
+*/
+type percentileBase struct {
+	AggregateBase
+}
+
+/*
+If no input to the function, then the default value returned is a
+null.
+*/
+func (this *percentileBase) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other than
+numbers are ignored. The number is appended to the cumulative array
+in place.
+*/
+func (this *percentileBase) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	if cumulative == value.NULL_VALUE {
+		return value.NewValue([]interface{}{item.Actual()}), nil
+	}
+
+	c, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid percentile %v of type %T.", cumulative.Actual(), cumulative.Actual())
+	}
+
+	return value.NewValue(append(c, item.Actual())), nil
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *percentileBase) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateNumbers(part, cumulative)
+}
+
+/*
+Concatenates the partial and cumulative arrays into a new array, so
+that neither input is modified. Partial results are only merged once
+per producer, so the copy is not made for every item.
+*/
+func cumulateNumbers(part, cumulative value.Value) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	p, ok := part.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid partial percentile %v of type %T.", part.Actual(), part.Actual())
+	}
+
+	c, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid percentile %v of type %T.", cumulative.Actual(), cumulative.Actual())
+	}
+
+	rv := make([]interface{}, 0, len(c)+len(p))
+	rv = append(rv, c...)
+	rv = append(rv, p...)
+	return value.NewValue(rv), nil
+}
+
+/*
+Returns the sorted numbers of the cumulative value.
+*/
+func sortedNumbers(cumulative value.Value) ([]float64, error) {
+	actual, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid percentile %v of type %T.", cumulative.Actual(), cumulative.Actual())
+	}
+
+	rv := make([]float64, len(actual))
+	for i, a := range actual {
+		switch a := a.(type) {
+		case float64:
+			rv[i] = a
+		case value.Value:
+			f, ok := a.Actual().(float64)
+			if !ok {
+				return nil, fmt.Errorf("Invalid percentile value %v.", a.Actual())
+			}
+			rv[i] = f
+		default:
+			return nil, fmt.Errorf("Invalid percentile value %v of type %T.", a, a)
+		}
+	}
+
+	sort.Float64s(rv)
+	return rv, nil
+}
+
+/*
+Evaluates the fraction operand, which must be a number between 0 and
+1. The fraction does not depend on the group, so it is evaluated
+against a NULL item.
+*/
+func (this *percentileBase) fraction(context Context) (float64, error) {
+	f, e := this.Operands()[1].Evaluate(value.NULL_VALUE, context)
+	if e != nil {
+		return 0, e
+	}
+
+	p, ok := f.Actual().(float64)
+	if f.Type() != value.NUMBER || !ok || p < 0 || p > 1 {
+		return 0, fmt.Errorf("%s() fraction must be a number between 0 and 1: %v.",
+			this.Name(), f.Actual())
+	}
+
+	return p, nil
+}
+
+func (this *percentileBase) MinArgs() int { return 2 }
+
+func (this *percentileBase) MaxArgs() int { return 2 }
+
+/*
+Returns the value at the fraction p of the sorted numbers, linearly
+interpolating between the two nearest numbers.
+*/
+func percentileCont(numbers []float64, p float64) value.Value {
+	rn := p * float64(len(numbers)-1)
+	lo := math.Floor(rn)
+	hi := math.Ceil(rn)
+	if lo == hi {
+		return value.NewValue(numbers[int(lo)])
+	}
+
+	return value.NewValue(numbers[int(lo)] + (rn-lo)*(numbers[int(hi)]-numbers[int(lo)]))
+}
+
+/*
+This represents the Aggregate function PERCENTILE_CONT(expr,
+fraction). It returns the value at the given fraction of the sorted
+number values in the group, interpolating between adjacent values.
+*/
+type PercentileCont struct {
+	percentileBase
+}
+
+func NewPercentileCont(operand, fraction expression.Expression) Aggregate {
+	rv := &PercentileCont{
+		percentileBase{*NewBinaryAggregateBase("percentile_cont", operand, fraction)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *PercentileCont) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *PercentileCont) Type() value.Type { return value.NUMBER }
+
+func (this *PercentileCont) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *PercentileCont) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewPercentileCont(operands[0], operands[1])
+	}
+}
+
+func (this *PercentileCont) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	p, e := this.fraction(context)
+	if e != nil || cumulative == value.NULL_VALUE {
+		return value.NULL_VALUE, e
+	}
+
+	numbers, e := sortedNumbers(cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	return percentileCont(numbers, p), nil
+}
+
+/*
+This represents the Aggregate function PERCENTILE_DISC(expr,
+fraction). It returns the first of the sorted number values in the
+group whose cumulative distribution is at least the given fraction.
+*/
+type PercentileDisc struct {
+	percentileBase
+}
+
+func NewPercentileDisc(operand, fraction expression.Expression) Aggregate {
+	rv := &PercentileDisc{
+		percentileBase{*NewBinaryAggregateBase("percentile_disc", operand, fraction)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *PercentileDisc) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *PercentileDisc) Type() value.Type { return value.NUMBER }
+
+func (this *PercentileDisc) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *PercentileDisc) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewPercentileDisc(operands[0], operands[1])
+	}
+}
+
+func (this *PercentileDisc) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	p, e := this.fraction(context)
+	if e != nil || cumulative == value.NULL_VALUE {
+		return value.NULL_VALUE, e
+	}
+
+	numbers, e := sortedNumbers(cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	i := int(math.Ceil(p*float64(len(numbers)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return value.NewValue(numbers[i]), nil
+}
+
+/*
+This represents the Aggregate function MEDIAN(expr). It returns the
+median of the number values in the group, which is the average of
+the two middle values if there is an even number of them. It is
+equivalent to PERCENTILE_CONT(expr, 0.5).
+*/
+type Median struct {
+	percentileBase
+}
+
+func NewMedian(operand expression.Expression) Aggregate {
+	rv := &Median{
+		percentileBase{*NewAggregateBase("median", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Median) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Median) Type() value.Type { return value.NUMBER }
+
+func (this *Median) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Median) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewMedian(operands[0])
+	}
+}
+
+func (this *Median) MinArgs() int { return 1 }
+
+func (this *Median) MaxArgs() int { return 1 }
+
+func (this *Median) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	numbers, e := sortedNumbers(cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	return percentileCont(numbers, 0.5), nil
+}
@@ -10,6 +10,7 @@
 package algebra
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -31,6 +32,20 @@ func GetAggregate(name string, distinct bool) (Aggregate, bool) {
 	}
 }
 
+/*
+Returns the names of all the aggregate functions, in lower case
+and sorted.
+*/
+func AggregateNames() []string {
+	rv := make([]string, 0, len(_OTHER_AGGREGATES))
+	for name, _ := range _OTHER_AGGREGATES {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
 /*
 Aggregate functions with a DISTINCT specified. The variable
 represents a map from string to Aggregate Function. The
@@ -48,13 +63,26 @@ var _DISTINCT_AGGREGATES = map[string]Aggregate{
 /*
 Non Distinct Aggregate functions. The variable represents a
 map from string to Aggregate Function. Contains aggregate
-functions ARRAY_AGG, AVG, COUNT, MAX, MIN and SUM.
+functions ARRAY_AGG, AVG, COUNT, COUNTN, MAX, MEDIAN, MIN,
+OBJECT_AGG, PERCENTILE_CONT, PERCENTILE_DISC, STDDEV, STDDEV_POP,
+STDDEV_SAMP, SUM, VARIANCE, VAR_POP and VAR_SAMP.
 */
 var _OTHER_AGGREGATES = map[string]Aggregate{
-	"array_agg": &ArrayAgg{},
-	"avg":       &Avg{},
-	"count":     &Count{},
-	"max":       &Max{},
-	"min":       &Min{},
-	"sum":       &Sum{},
+	"array_agg":       &ArrayAgg{},
+	"avg":             &Avg{},
+	"count":           &Count{},
+	"countn":          &CountN{},
+	"max":             &Max{},
+	"median":          &Median{},
+	"min":             &Min{},
+	"object_agg":      &ObjectAgg{},
+	"percentile_cont": &PercentileCont{},
+	"percentile_disc": &PercentileDisc{},
+	"stddev":          &Stddev{},
+	"stddev_pop":      &StddevPop{},
+	"stddev_samp":     &StddevSamp{},
+	"sum":             &Sum{},
+	"var_pop":         &VarPop{},
+	"var_samp":        &VarSamp{},
+	"variance":        &Variance{},
 }
@@ -0,0 +1,139 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"math"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+func stddevValue(cumulative value.Value, sample, single bool) (value.Value, error) {
+	v, ok, e := computeVariance(cumulative, sample, single)
+	if e != nil || !ok {
+		return value.NULL_VALUE, e
+	}
+
+	return value.NewValue(math.Sqrt(v)), nil
+}
+
+/*
+This represents the Aggregate function STDDEV_POP(expr). It returns
+the population standard deviation of the number values in the group.
+*/
+type StddevPop struct {
+	varianceBase
+}
+
+func NewStddevPop(operand expression.Expression) Aggregate {
+	rv := &StddevPop{
+		varianceBase{*NewAggregateBase("stddev_pop", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *StddevPop) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StddevPop) Type() value.Type { return value.NUMBER }
+
+func (this *StddevPop) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *StddevPop) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddevPop(operands[0])
+	}
+}
+
+func (this *StddevPop) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return stddevValue(cumulative, false, false)
+}
+
+/*
+This represents the Aggregate function STDDEV_SAMP(expr). It returns
+the sample standard deviation of the number values in the group, or
+NULL if there are fewer than two.
+*/
+type StddevSamp struct {
+	varianceBase
+}
+
+func NewStddevSamp(operand expression.Expression) Aggregate {
+	rv := &StddevSamp{
+		varianceBase{*NewAggregateBase("stddev_samp", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *StddevSamp) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StddevSamp) Type() value.Type { return value.NUMBER }
+
+func (this *StddevSamp) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *StddevSamp) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddevSamp(operands[0])
+	}
+}
+
+func (this *StddevSamp) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return stddevValue(cumulative, true, false)
+}
+
+/*
+This represents the Aggregate function STDDEV(expr). It returns the
+sample standard deviation of the number values in the group, or 0 if
+there is only one.
+*/
+type Stddev struct {
+	varianceBase
+}
+
+func NewStddev(operand expression.Expression) Aggregate {
+	rv := &Stddev{
+		varianceBase{*NewAggregateBase("stddev", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Stddev) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Stddev) Type() value.Type { return value.NUMBER }
+
+func (this *Stddev) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Stddev) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddev(operands[0])
+	}
+}
+
+func (this *Stddev) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return stddevValue(cumulative, true, true)
+}
@@ -0,0 +1,253 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Base class for the variance and standard deviation aggregates. The
+cumulative value is an object holding the count, the mean, and the
+sum of squared deviations from the mean (m2) of the number values.
+Partial values are combined using the parallel algorithm of Chan et
+al., which is numerically stable and independent of the order of
+the input.
+*/
+type varianceBase struct {
+	AggregateBase
+}
+
+/*
+If no input to the function, then the default value returned is a
+null.
+*/
+func (this *varianceBase) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other than
+numbers are ignored.
+*/
+func (this *varianceBase) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	part := value.NewValue(map[string]interface{}{
+		"count": 1.0,
+		"mean":  item.Actual(),
+		"m2":    0.0,
+	})
+	return cumulateVariance(part, cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *varianceBase) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance(part, cumulative)
+}
+
+func cumulateVariance(part, cumulative value.Value) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	pcount, pmean, pm2, e := varianceFields(part)
+	if e != nil {
+		return nil, e
+	}
+
+	ccount, cmean, cm2, e := varianceFields(cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	count := pcount + ccount
+	delta := pmean - cmean
+	return value.NewValue(map[string]interface{}{
+		"count": count,
+		"mean":  cmean + delta*pcount/count,
+		"m2":    cm2 + pm2 + delta*delta*pcount*ccount/count,
+	}), nil
+}
+
+func varianceFields(cumulative value.Value) (count, mean, m2 float64, e error) {
+	c, _ := cumulative.Field("count")
+	m, _ := cumulative.Field("mean")
+	s, _ := cumulative.Field("m2")
+
+	if c.Type() != value.NUMBER || m.Type() != value.NUMBER || s.Type() != value.NUMBER {
+		e = fmt.Errorf("Missing or invalid count, mean, or m2 in variance: %v, %v, %v.",
+			c.Actual(), m.Actual(), s.Actual())
+		return
+	}
+
+	return c.Actual().(float64), m.Actual().(float64), s.Actual().(float64), nil
+}
+
+/*
+Returns the population variance (sample false) or the sample
+variance (sample true) of the cumulative value, or NULL if there are
+too few values. If single is true, the sample variance of a single
+value is 0.
+*/
+func computeVariance(cumulative value.Value, sample, single bool) (float64, bool, error) {
+	if cumulative == value.NULL_VALUE {
+		return 0, false, nil
+	}
+
+	count, _, m2, e := varianceFields(cumulative)
+	if e != nil {
+		return 0, false, e
+	}
+
+	if !sample {
+		return m2 / count, count > 0, nil
+	}
+
+	if count == 1 {
+		return 0, single, nil
+	}
+
+	return m2 / (count - 1), count > 1, nil
+}
+
+func varianceValue(cumulative value.Value, sample, single bool) (value.Value, error) {
+	v, ok, e := computeVariance(cumulative, sample, single)
+	if e != nil || !ok {
+		return value.NULL_VALUE, e
+	}
+
+	return value.NewValue(v), nil
+}
+
+/*
+This represents the Aggregate function VAR_POP(expr). It returns the
+population variance of the number values in the group.
+*/
+type VarPop struct {
+	varianceBase
+}
+
+func NewVarPop(operand expression.Expression) Aggregate {
+	rv := &VarPop{
+		varianceBase{*NewAggregateBase("var_pop", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *VarPop) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *VarPop) Type() value.Type { return value.NUMBER }
+
+func (this *VarPop) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *VarPop) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVarPop(operands[0])
+	}
+}
+
+func (this *VarPop) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return varianceValue(cumulative, false, false)
+}
+
+/*
+This represents the Aggregate function VAR_SAMP(expr). It returns the
+sample variance of the number values in the group, or NULL if there
+are fewer than two.
+*/
+type VarSamp struct {
+	varianceBase
+}
+
+func NewVarSamp(operand expression.Expression) Aggregate {
+	rv := &VarSamp{
+		varianceBase{*NewAggregateBase("var_samp", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *VarSamp) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *VarSamp) Type() value.Type { return value.NUMBER }
+
+func (this *VarSamp) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *VarSamp) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVarSamp(operands[0])
+	}
+}
+
+func (this *VarSamp) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return varianceValue(cumulative, true, false)
+}
+
+/*
+This represents the Aggregate function VARIANCE(expr). It returns the
+sample variance of the number values in the group, or 0 if there is
+only one.
+*/
+type Variance struct {
+	varianceBase
+}
+
+func NewVariance(operand expression.Expression) Aggregate {
+	rv := &Variance{
+		varianceBase{*NewAggregateBase("variance", operand)},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Variance) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Variance) Type() value.Type { return value.NUMBER }
+
+func (this *Variance) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Variance) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVariance(operands[0])
+	}
+}
+
+func (this *Variance) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return varianceValue(cumulative, true, true)
+}
@@ -92,6 +92,21 @@ func NewAggregateBase(name string, operand expression.Expression) *AggregateBase
 	}
 }
 
+/*
+This method creates an aggregate function with two operands, such
+as PERCENTILE_CONT(expr, fraction). The first operand is the one
+aggregated; aggregates created this way must override MinArgs() and
+MaxArgs().
+*/
+func NewBinaryAggregateBase(name string, first, second expression.Expression) *AggregateBase {
+	return &AggregateBase{
+		expression.UnaryFunctionBase{
+			FunctionBase: *expression.NewFunctionBase(name, first, second),
+		},
+		"",
+	}
+}
+
 /*
 This method evaluates the input aggregate, by retrieving the
 aggregates map from the attachments and performing a lookup
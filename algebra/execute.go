@@ -20,7 +20,8 @@ import (
 Represents the Execute command. The argument to EXECUTE must
 evaluate to a prepared statement or a string. Type Execute
 is a struct that contains a json object value that represents
-a plan.Prepared.
+a plan.Prepared, or a string value that names a cached
+prepared statement.
 */
 type Execute struct {
 	statementBase
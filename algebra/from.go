@@ -84,7 +84,8 @@ inputs to the query.
 
 Type KeyspaceTerm is a struct that contains namespace,
 keyspace as strings, projection as the path, the alias
-string as, and the keys expression.
+string as, the keys expression, and the indexes of a USE INDEX
+hint.
 */
 type KeyspaceTerm struct {
 	namespace  string
@@ -92,6 +93,7 @@ type KeyspaceTerm struct {
 	projection expression.Path
 	as         string
 	keys       expression.Expression
+	indexes    IndexRefs
 }
 
 /*
@@ -99,7 +101,7 @@ The function NewKeyspaceTerm returns a pointer to the KeyspaceTerm
 struct by assigning the input attributes to the fields of the struct.
 */
 func NewKeyspaceTerm(namespace, keyspace string, projection expression.Path, as string, keys expression.Expression) *KeyspaceTerm {
-	return &KeyspaceTerm{namespace, keyspace, projection, as, keys}
+	return &KeyspaceTerm{namespace, keyspace, projection, as, keys, nil}
 }
 
 /*
@@ -195,6 +197,10 @@ func (this *KeyspaceTerm) toString(join bool) string {
 		}
 	}
 
+	if len(this.indexes) > 0 {
+		s += " use index (" + this.indexes.String() + ")"
+	}
+
 	return s
 }
 
@@ -297,6 +303,20 @@ func (this *KeyspaceTerm) Keys() expression.Expression {
 	return this.keys
 }
 
+/*
+Returns the indexes of the USE INDEX hint.
+*/
+func (this *KeyspaceTerm) Indexes() IndexRefs {
+	return this.indexes
+}
+
+/*
+Set the indexes of the USE INDEX hint.
+*/
+func (this *KeyspaceTerm) SetIndexes(indexes IndexRefs) {
+	this.indexes = indexes
+}
+
 /*
 Marshals the input keyspace into a byte array.
 */
@@ -311,6 +331,9 @@ func (this *KeyspaceTerm) MarshalJSON() ([]byte, error) {
 	if this.projection != nil {
 		r["projection"] = expression.NewStringer().Visit(this.projection)
 	}
+	if len(this.indexes) > 0 {
+		r["indexes"] = this.indexes
+	}
 	return json.Marshal(r)
 }
 
@@ -421,12 +444,15 @@ fields left and right that represent the two source
 objects being joined (one is a from term and the
 other a keyspace term), and outer which is a bool
 value representing if the join is an outer or inner
-join.
+join. A lookup join matches the right keyspace by its
+keys (ON KEYS); an ANSI join has an onclause that is
+evaluated against both sources.
 */
 type Join struct {
-	left  FromTerm
-	right *KeyspaceTerm
-	outer bool
+	left     FromTerm
+	right    *KeyspaceTerm
+	outer    bool
+	onclause expression.Expression
 }
 
 /*
@@ -434,7 +460,14 @@ The function NewJoin returns a pointer to the Join struct
 by assigning the input attributes to the fields of the struct.
 */
 func NewJoin(left FromTerm, outer bool, right *KeyspaceTerm) *Join {
-	return &Join{left, right, outer}
+	return &Join{left, right, outer, nil}
+}
+
+/*
+Returns an ANSI join, JOIN ... ON onclause.
+*/
+func NewAnsiJoin(left FromTerm, outer bool, right *KeyspaceTerm, onclause expression.Expression) *Join {
+	return &Join{left, right, outer, onclause}
 }
 
 /*
@@ -454,14 +487,28 @@ func (this *Join) MapExpressions(mapper expression.Mapper) (err error) {
 		return
 	}
 
-	return this.right.MapExpressions(mapper)
+	err = this.right.MapExpressions(mapper)
+	if err != nil {
+		return
+	}
+
+	if this.onclause != nil {
+		this.onclause, err = mapper.Map(this.onclause)
+	}
+
+	return
 }
 
 /*
    Returns all contained Expressions.
 */
 func (this *Join) Expressions() expression.Expressions {
-	return append(this.left.Expressions(), this.right.Expressions()...)
+	exprs := append(this.left.Expressions(), this.right.Expressions()...)
+	if this.onclause != nil {
+		exprs = append(exprs, this.onclause)
+	}
+
+	return exprs
 }
 
 /*
@@ -495,12 +542,18 @@ func (this *Join) String() string {
 	}
 
 	s += this.right.toString(true)
+
+	if this.onclause != nil {
+		s += " on " + this.onclause.String()
+	}
+
 	return s
 }
 
 /*
 Qualify all identifiers for the parent expression. Checks is
-a join alias exists and if it is a duplicate alias.
+a join alias exists and if it is a duplicate alias. The onclause
+of an ANSI join may refer to the join alias itself.
 */
 func (this *Join) Formalize(parent *expression.Formalizer) (f *expression.Formalizer, err error) {
 	f, err = this.left.Formalize(parent)
@@ -509,9 +562,11 @@ func (this *Join) Formalize(parent *expression.Formalizer) (f *expression.Formal
 	}
 
 	f.Keyspace = ""
-	this.right.keys, err = f.Map(this.right.keys)
-	if err != nil {
-		return
+	if this.right.keys != nil {
+		this.right.keys, err = f.Map(this.right.keys)
+		if err != nil {
+			return
+		}
 	}
 
 	alias := this.Alias()
@@ -527,6 +582,11 @@ func (this *Join) Formalize(parent *expression.Formalizer) (f *expression.Formal
 	}
 
 	f.Allowed.SetField(alias, alias)
+
+	if this.onclause != nil {
+		this.onclause, err = f.Map(this.onclause)
+	}
+
 	return
 }
 
@@ -567,6 +627,14 @@ func (this *Join) Outer() bool {
 	return this.outer
 }
 
+/*
+Returns the onclause of an ANSI join, or nil for a
+lookup join.
+*/
+func (this *Join) Onclause() expression.Expression {
+	return this.onclause
+}
+
 /*
 Marshals input join terms.
 */
@@ -575,6 +643,9 @@ func (this *Join) MarshalJSON() ([]byte, error) {
 	r["left"] = this.left
 	r["right"] = this.right
 	r["outer"] = this.outer
+	if this.onclause != nil {
+		r["on"] = expression.NewStringer().Visit(this.onclause)
+	}
 	return json.Marshal(r)
 }
 
@@ -0,0 +1,208 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the CREATE FUNCTION ddl statement, which defines a
+user-defined function, e.g. CREATE FUNCTION celsius(f) { (f - 32) *
+5 / 9 }. The body is an expression over the parameters. Once
+created, the function can be called like a built-in function.
+*/
+type CreateFunction struct {
+	statementBase
+
+	name       string                `json:"name"`
+	parameters []string              `json:"parameters"`
+	body       expression.Expression `json:"body"`
+}
+
+/*
+The function NewCreateFunction returns a pointer to the
+CreateFunction struct with the input argument values as fields.
+Function names are case-insensitive, and kept in lower case.
+*/
+func NewCreateFunction(name string, parameters []string, body expression.Expression) *CreateFunction {
+	rv := &CreateFunction{
+		name:       strings.ToLower(name),
+		parameters: parameters,
+		body:       body,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCreateFunction method by passing in the receiver
+and returns the interface. It is a visitor pattern.
+*/
+func (this *CreateFunction) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateFunction(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateFunction) Signature() value.Value {
+	return nil
+}
+
+/*
+Checks the name and parameters, and fully qualifies the body, which
+may only refer to the parameters. The body cannot contain
+aggregates, window functions, subqueries or query parameters, as it
+is evaluated against the arguments alone.
+*/
+func (this *CreateFunction) Formalize() (err error) {
+	_, builtin := expression.GetFunction(this.name)
+	if !builtin {
+		_, builtin = GetAggregate(this.name, false)
+	}
+
+	if !builtin {
+		_, builtin = GetWindowFunction(this.name)
+	}
+
+	if builtin {
+		return fmt.Errorf("Cannot redefine built-in function %s.", this.name)
+	}
+
+	f := expression.NewFormalizer()
+	for _, p := range this.parameters {
+		_, ok := f.Allowed.Field(p)
+		if ok {
+			return fmt.Errorf("Duplicate parameter %s in function %s.", p, this.name)
+		}
+
+		f.Allowed.SetField(p, p)
+	}
+
+	err = checkFunctionBody(this.name, this.body)
+	if err != nil {
+		return
+	}
+
+	this.body, err = f.Map(this.body)
+	return
+}
+
+func checkFunctionBody(name string, exprs ...expression.Expression) error {
+	for _, expr := range exprs {
+		switch expr.(type) {
+		case Aggregate, WindowFunction:
+			return fmt.Errorf("Aggregates and window functions are not allowed in function %s.", name)
+		case *Subquery:
+			return fmt.Errorf("Subqueries are not allowed in function %s.", name)
+		case *NamedParameter, *PositionalParameter:
+			return fmt.Errorf("Query parameters are not allowed in function %s.", name)
+		}
+
+		err := checkFunctionBody(name, expr.Children()...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Maps the body.
+*/
+func (this *CreateFunction) MapExpressions(mapper expression.Mapper) (err error) {
+	this.body, err = mapper.Map(this.body)
+	return
+}
+
+/*
+Returns the body.
+*/
+func (this *CreateFunction) Expressions() expression.Expressions {
+	return expression.Expressions{this.body}
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *CreateFunction) Privileges() (datastore.Privileges, errors.Error) {
+	return datastore.Privileges{
+		"#system:functions": datastore.PRIV_DDL,
+	}, nil
+}
+
+/*
+Returns the name of the function.
+*/
+func (this *CreateFunction) Name() string {
+	return this.name
+}
+
+/*
+Returns the parameter names of the function.
+*/
+func (this *CreateFunction) Parameters() []string {
+	return this.parameters
+}
+
+/*
+Returns the body of the function.
+*/
+func (this *CreateFunction) Body() expression.Expression {
+	return this.body
+}
+
+/*
+Returns the statement as N1QL text. Parsing the text yields an
+equivalent statement, so it is used to store the definition.
+*/
+func (this *CreateFunction) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("create function `")
+	buf.WriteString(this.name)
+	buf.WriteString("`(")
+
+	for i, p := range this.parameters {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString("`")
+		buf.WriteString(p)
+		buf.WriteString("`")
+	}
+
+	buf.WriteString(") { ")
+	buf.WriteString(expression.NewStringer().Visit(this.body))
+	buf.WriteString(" }")
+	return buf.String()
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CreateFunction) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "createFunction"}
+	r["name"] = this.name
+	r["parameters"] = this.parameters
+	r["body"] = expression.NewStringer().Visit(this.body)
+	return json.Marshal(r)
+}
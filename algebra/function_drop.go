@@ -0,0 +1,105 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the DROP FUNCTION ddl statement, which removes a
+user-defined function.
+*/
+type DropFunction struct {
+	statementBase
+
+	name string `json:"name"`
+}
+
+/*
+The function NewDropFunction returns a pointer to the
+DropFunction struct with the input argument values as fields.
+Function names are case-insensitive, and kept in lower case.
+*/
+func NewDropFunction(name string) *DropFunction {
+	rv := &DropFunction{
+		name: strings.ToLower(name),
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitDropFunction method by passing in the receiver
+and returns the interface. It is a visitor pattern.
+*/
+func (this *DropFunction) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropFunction(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *DropFunction) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropFunction) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropFunction) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *DropFunction) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *DropFunction) Privileges() (datastore.Privileges, errors.Error) {
+	return datastore.Privileges{
+		"#system:functions": datastore.PRIV_DDL,
+	}, nil
+}
+
+/*
+Return the name of the function to be dropped.
+*/
+func (this *DropFunction) Name() string {
+	return this.name
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *DropFunction) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "dropFunction"}
+	r["name"] = this.name
+	return json.Marshal(r)
+}
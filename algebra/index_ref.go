@@ -0,0 +1,102 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+)
+
+/*
+Represents an index named in a USE INDEX hint, such as
+USE INDEX (idx1, idx2 USING VIEW). If no USING clause is given, the
+type is DEFAULT, and the hint matches an index of that name of any
+type.
+*/
+type IndexRef struct {
+	name  string              `json:"name"`
+	using datastore.IndexType `json:"using"`
+}
+
+type IndexRefs []*IndexRef
+
+/*
+The function NewIndexRef returns a pointer to the IndexRef struct
+with the input argument values as fields.
+*/
+func NewIndexRef(name string, using datastore.IndexType) *IndexRef {
+	return &IndexRef{
+		name:  name,
+		using: using,
+	}
+}
+
+/*
+Returns the index name.
+*/
+func (this *IndexRef) Name() string {
+	return this.name
+}
+
+/*
+Returns the index type.
+*/
+func (this *IndexRef) Using() datastore.IndexType {
+	return this.using
+}
+
+/*
+Returns true if the hint refers to the index.
+*/
+func (this *IndexRef) Matches(index datastore.Index) bool {
+	return this.name == index.Name() &&
+		(this.using == datastore.DEFAULT || this.using == index.Type())
+}
+
+/*
+Representation as a N1QL string.
+*/
+func (this *IndexRef) String() string {
+	s := "`" + this.name + "`"
+	if this.using != datastore.DEFAULT {
+		s += " using " + string(this.using)
+	}
+
+	return s
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *IndexRef) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"name": this.name}
+	if this.using != datastore.DEFAULT {
+		r["using"] = this.using
+	}
+
+	return json.Marshal(r)
+}
+
+/*
+Representation as a N1QL string.
+*/
+func (this IndexRefs) String() string {
+	s := ""
+	for i, ref := range this {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += ref.String()
+	}
+
+	return s
+}
@@ -22,7 +22,7 @@ struct that contains fields mapping to each clause in
 an insert statement. Keyspace is the keyspace-ref for
 the insert stmt. Inserts can be performed using
 the insert select clause or the insert-values clause.
-key and value represent expressions and query represents
+key, value and options represent expressions and query represents
 the select statement in an insert-select clause. values
 represents pairs for the insert values. Returning
 represents the returning clause.
@@ -33,6 +33,7 @@ type Insert struct {
 	keyspace  *KeyspaceRef          `json:"keyspace"`
 	key       expression.Expression `json:"key"`
 	value     expression.Expression `json:"value"`
+	options   expression.Expression `json:"options"`
 	values    Pairs                 `json:"values"`
 	query     *Select               `json:"select"`
 	returning *Projection           `json:"returning"`
@@ -49,6 +50,7 @@ func NewInsertValues(keyspace *KeyspaceRef, values Pairs, returning *Projection)
 		keyspace:  keyspace,
 		key:       nil,
 		value:     nil,
+		options:   nil,
 		values:    values,
 		query:     nil,
 		returning: returning,
@@ -64,12 +66,13 @@ struct by assigning the input attributes to the fields of the
 struct, and setting values to nil. This represents the insert
 select clause.
 */
-func NewInsertSelect(keyspace *KeyspaceRef, key, value expression.Expression,
+func NewInsertSelect(keyspace *KeyspaceRef, key, value, options expression.Expression,
 	query *Select, returning *Projection) *Insert {
 	rv := &Insert{
 		keyspace:  keyspace,
 		key:       key,
 		value:     value,
+		options:   options,
 		values:    nil,
 		query:     query,
 		returning: returning,
@@ -117,6 +120,13 @@ func (this *Insert) MapExpressions(mapper expression.Mapper) (err error) {
 		}
 	}
 
+	if this.options != nil {
+		this.options, err = mapper.Map(this.options)
+		if err != nil {
+			return
+		}
+	}
+
 	if this.values != nil {
 		err = this.values.MapExpressions(mapper)
 		if err != nil {
@@ -152,6 +162,10 @@ func (this *Insert) Expressions() expression.Expressions {
 		exprs = append(exprs, this.value)
 	}
 
+	if this.options != nil {
+		exprs = append(exprs, this.options)
+	}
+
 	if this.values != nil {
 		exprs = append(exprs, this.values.Expressions()...)
 	}
@@ -247,6 +261,14 @@ func (this *Insert) Value() expression.Expression {
 	return this.value
 }
 
+/*
+Returns the options expression for the insert select
+clause.
+*/
+func (this *Insert) Options() expression.Expression {
+	return this.options
+}
+
 /*
 Returns the value pairs for the insert values
 clause.
@@ -18,21 +18,25 @@ import (
 
 /*
 Represents a prepared statement. Type Prepare is a
-struct that contains a statement (json statement).
+struct that contains a statement (json statement) and
+an optional name.
 */
 type Prepare struct {
 	statementBase
 
+	name string
 	stmt Statement `json:"stmt"`
 }
 
 /*
 The function NewPrepare returns a pointer to the
-Prepare struct with the input argument statement
-as a field.
+Prepare struct with the input arguments name and
+statement as fields. The name is empty for unnamed
+prepared statements.
 */
-func NewPrepare(stmt Statement) *Prepare {
+func NewPrepare(name string, stmt Statement) *Prepare {
 	rv := &Prepare{
+		name: name,
 		stmt: stmt,
 	}
 
@@ -90,3 +94,11 @@ Return the prepared statement.
 func (this *Prepare) Statement() Statement {
 	return this.stmt
 }
+
+/*
+Return the name of the prepared statement, or the empty
+string if it is unnamed.
+*/
+func (this *Prepare) Name() string {
+	return this.name
+}
@@ -91,6 +91,27 @@ func (this *Projection) Signature() value.Value {
 	return rv
 }
 
+/*
+Returns the names of the result terms in projection order, with * for
+each star term, or nil for a raw projection.
+*/
+func (this *Projection) Columns() []string {
+	if this.raw {
+		return nil
+	}
+
+	rv := make([]string, len(this.terms))
+	for i, term := range this.terms {
+		if term.star {
+			rv[i] = "*"
+		} else {
+			rv[i] = term.alias
+		}
+	}
+
+	return rv
+}
+
 /*
 This method maps the result expressions.
 */
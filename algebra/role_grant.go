@@ -0,0 +1,124 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the GRANT ROLE role ON keyspace TO user, ... statement.
+The role is one of the privileges read, write, ddl and admin, and the
+keyspace may be namespace:* for every keyspace of the namespace.
+*/
+type GrantRole struct {
+	statementBase
+
+	role     datastore.Privilege `json:"role"`
+	keyspace *KeyspaceRef        `json:"keyspace"`
+	users    []string            `json:"users"`
+}
+
+/*
+The function NewGrantRole returns a pointer to the GrantRole struct
+with the input argument values as fields.
+*/
+func NewGrantRole(role datastore.Privilege, keyspace *KeyspaceRef, users []string) *GrantRole {
+	rv := &GrantRole{
+		role:     role,
+		keyspace: keyspace,
+		users:    users,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitGrantRole method by passing in the receiver and
+returns the interface. It is a visitor pattern.
+*/
+func (this *GrantRole) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGrantRole(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *GrantRole) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *GrantRole) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *GrantRole) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *GrantRole) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *GrantRole) Privileges() (datastore.Privileges, errors.Error) {
+	return datastore.Privileges{
+		this.keyspace.Namespace() + ":" + this.keyspace.Keyspace(): datastore.PRIV_ADMIN,
+	}, nil
+}
+
+/*
+Returns the role to be granted.
+*/
+func (this *GrantRole) Role() datastore.Privilege {
+	return this.role
+}
+
+/*
+Returns the keyspace of the role.
+*/
+func (this *GrantRole) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the users to whom the role is granted.
+*/
+func (this *GrantRole) Users() []string {
+	return this.users
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *GrantRole) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "grantRole"}
+	r["role"] = this.role.String()
+	r["keyspaceRef"] = this.keyspace
+	r["users"] = this.users
+	return json.Marshal(r)
+}
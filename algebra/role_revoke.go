@@ -0,0 +1,124 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the REVOKE ROLE role ON keyspace FROM user, ... statement.
+As roles are cumulative, the users keep any lesser role on the
+keyspace.
+*/
+type RevokeRole struct {
+	statementBase
+
+	role     datastore.Privilege `json:"role"`
+	keyspace *KeyspaceRef        `json:"keyspace"`
+	users    []string            `json:"users"`
+}
+
+/*
+The function NewRevokeRole returns a pointer to the RevokeRole struct
+with the input argument values as fields.
+*/
+func NewRevokeRole(role datastore.Privilege, keyspace *KeyspaceRef, users []string) *RevokeRole {
+	rv := &RevokeRole{
+		role:     role,
+		keyspace: keyspace,
+		users:    users,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitRevokeRole method by passing in the receiver and
+returns the interface. It is a visitor pattern.
+*/
+func (this *RevokeRole) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRevokeRole(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *RevokeRole) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *RevokeRole) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *RevokeRole) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *RevokeRole) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *RevokeRole) Privileges() (datastore.Privileges, errors.Error) {
+	return datastore.Privileges{
+		this.keyspace.Namespace() + ":" + this.keyspace.Keyspace(): datastore.PRIV_ADMIN,
+	}, nil
+}
+
+/*
+Returns the role to be revoked.
+*/
+func (this *RevokeRole) Role() datastore.Privilege {
+	return this.role
+}
+
+/*
+Returns the keyspace of the role.
+*/
+func (this *RevokeRole) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the users from whom the role is revoked.
+*/
+func (this *RevokeRole) Users() []string {
+	return this.users
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *RevokeRole) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "revokeRole"}
+	r["role"] = this.role.String()
+	r["keyspaceRef"] = this.keyspace
+	r["users"] = this.users
+	return json.Marshal(r)
+}
@@ -67,6 +67,27 @@ func (this *Select) Signature() value.Value {
 	return this.subresult.Signature()
 }
 
+/*
+Returns the names of the result terms in projection order, with * for
+each star term. The terms of a union are those of its first select.
+Returns nil for a raw projection.
+*/
+func (this *Select) Columns() []string {
+	subresult := this.subresult
+	for {
+		switch sub := subresult.(type) {
+		case *Subselect:
+			return sub.projection.Columns()
+		case interface {
+			First() Subresult
+		}:
+			subresult = sub.First()
+		default:
+			return nil
+		}
+	}
+}
+
 /*
 This method calls FormalizeSubquery to qualify all the children
 of the query, and returns an error if any.
@@ -0,0 +1,120 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the UPDATE STATISTICS FOR keyspace(expr, ...) statement,
+also written ANALYZE KEYSPACE keyspace(expr, ...). It samples the
+documents of the keyspace and collects a histogram of the values of
+each expression. Like index keys, the expressions refer to the
+fields of the documents directly.
+*/
+type UpdateStatistics struct {
+	statementBase
+
+	keyspace *KeyspaceRef           `json:"keyspace"`
+	terms    expression.Expressions `json:"terms"`
+}
+
+/*
+The function NewUpdateStatistics returns a pointer to the
+UpdateStatistics struct with the input argument values as fields.
+*/
+func NewUpdateStatistics(keyspace *KeyspaceRef, terms expression.Expressions) *UpdateStatistics {
+	rv := &UpdateStatistics{
+		keyspace: keyspace,
+		terms:    terms,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitUpdateStatistics method by passing in the receiver
+and returns the interface. It is a visitor pattern.
+*/
+func (this *UpdateStatistics) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitUpdateStatistics(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *UpdateStatistics) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *UpdateStatistics) Formalize() error {
+	return nil
+}
+
+/*
+Maps the expressions.
+*/
+func (this *UpdateStatistics) MapExpressions(mapper expression.Mapper) error {
+	return this.terms.MapExpressions(mapper)
+}
+
+/*
+Returns the expressions.
+*/
+func (this *UpdateStatistics) Expressions() expression.Expressions {
+	return this.terms
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *UpdateStatistics) Privileges() (datastore.Privileges, errors.Error) {
+	return datastore.Privileges{
+		this.keyspace.Namespace() + ":" + this.keyspace.Keyspace(): datastore.PRIV_DDL,
+	}, nil
+}
+
+/*
+Returns the keyspace.
+*/
+func (this *UpdateStatistics) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the expressions whose statistics are collected.
+*/
+func (this *UpdateStatistics) Terms() expression.Expressions {
+	return this.terms
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *UpdateStatistics) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "updateStatistics"}
+	r["keyspaceRef"] = this.keyspace
+	terms := make([]string, len(this.terms))
+	for i, term := range this.terms {
+		terms[i] = expression.NewStringer().Visit(term)
+	}
+	r["terms"] = terms
+	return json.Marshal(r)
+}
@@ -24,19 +24,21 @@ struct that contains fields mapping to each clause in
 an update statement. Keyspace is the keyspace-ref for
 the update stmt. The keys expression represents the
 keys clause, set and unset represent the set and
-unset clause, the limit expression represents the
-limit clause and returning is the returning clause.
+unset clause, the expiration expression represents the
+with expiration clause, the limit expression represents
+the limit clause and returning is the returning clause.
 */
 type Update struct {
 	statementBase
 
-	keyspace  *KeyspaceRef          `json:"keyspace"`
-	keys      expression.Expression `json:"keys"`
-	set       *Set                  `json:"set"`
-	unset     *Unset                `json:"unset"`
-	where     expression.Expression `json:"where"`
-	limit     expression.Expression `json:"limit"`
-	returning *Projection           `json:"returning"`
+	keyspace   *KeyspaceRef          `json:"keyspace"`
+	keys       expression.Expression `json:"keys"`
+	set        *Set                  `json:"set"`
+	unset      *Unset                `json:"unset"`
+	expiration expression.Expression `json:"expiration"`
+	where      expression.Expression `json:"where"`
+	limit      expression.Expression `json:"limit"`
+	returning  *Projection           `json:"returning"`
 }
 
 /*
@@ -45,15 +47,16 @@ struct by assigning the input attributes to the fields
 of the struct.
 */
 func NewUpdate(keyspace *KeyspaceRef, keys expression.Expression, set *Set, unset *Unset,
-	where, limit expression.Expression, returning *Projection) *Update {
+	expiration, where, limit expression.Expression, returning *Projection) *Update {
 	rv := &Update{
-		keyspace:  keyspace,
-		keys:      keys,
-		set:       set,
-		unset:     unset,
-		where:     where,
-		limit:     limit,
-		returning: returning,
+		keyspace:   keyspace,
+		keys:       keys,
+		set:        set,
+		unset:      unset,
+		expiration: expiration,
+		where:      where,
+		limit:      limit,
+		returning:  returning,
 	}
 
 	rv.stmt = rv
@@ -105,6 +108,13 @@ func (this *Update) MapExpressions(mapper expression.Mapper) (err error) {
 		}
 	}
 
+	if this.expiration != nil {
+		this.expiration, err = mapper.Map(this.expiration)
+		if err != nil {
+			return
+		}
+	}
+
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
 		if err != nil {
@@ -144,6 +154,10 @@ func (this *Update) Expressions() expression.Expressions {
 		exprs = append(exprs, this.unset.Expressions()...)
 	}
 
+	if this.expiration != nil {
+		exprs = append(exprs, this.expiration)
+	}
+
 	if this.where != nil {
 		exprs = append(exprs, this.where)
 	}
@@ -208,6 +222,13 @@ func (this *Update) Formalize() (err error) {
 		}
 	}
 
+	if this.expiration != nil {
+		this.expiration, err = f.Map(this.expiration)
+		if err != nil {
+			return
+		}
+	}
+
 	if this.where != nil {
 		this.where, err = f.Map(this.where)
 		if err != nil {
@@ -260,6 +281,14 @@ func (this *Update) Unset() *Unset {
 	return this.unset
 }
 
+/*
+Returns the expression of the with expiration clause
+in an update statement.
+*/
+func (this *Update) Expiration() expression.Expression {
+	return this.expiration
+}
+
 /*
 Returns the where clause expression in an update
 statement.
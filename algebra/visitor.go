@@ -37,6 +37,24 @@ type Visitor interface {
 	VisitAlterIndex(stmt *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(stmt *BuildIndexes) (interface{}, error)
 
+	/*
+	   Visitor for user-defined function statements, CREATE
+	   FUNCTION and DROP FUNCTION.
+	*/
+	VisitCreateFunction(stmt *CreateFunction) (interface{}, error)
+	VisitDropFunction(stmt *DropFunction) (interface{}, error)
+
+	/*
+	   Visitor for UPDATE STATISTICS statements.
+	*/
+	VisitUpdateStatistics(stmt *UpdateStatistics) (interface{}, error)
+
+	/*
+	   Visitor for role statements, GRANT and REVOKE.
+	*/
+	VisitGrantRole(stmt *GrantRole) (interface{}, error)
+	VisitRevokeRole(stmt *RevokeRole) (interface{}, error)
+
 	/*
 	   Visitor for EXPLAIN statements.
 	*/
@@ -0,0 +1,661 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+type WindowFunctions []WindowFunction
+
+/*
+The WindowFunction interface represents window (analytic) functions
+such as ROW_NUMBER(), RANK(), LAG(), and aggregates computed over a
+window, such as SUM(x) OVER (ORDER BY y).
+
+The items of a query are divided into partitions by the PARTITION BY
+terms of the window, and each partition is sorted by the ORDER BY
+terms of the window. ComputeWindow() is then called once for each
+partition, and returns the value of the function for each item of
+the partition. Unlike aggregates, window functions do not reduce the
+number of items.
+*/
+type WindowFunction interface {
+	/*
+	   Represents the window function.
+	*/
+	expression.Function
+
+	/*
+	   The window, i.e. the OVER clause, of this function.
+	*/
+	Window() *WindowTerm
+
+	/*
+	   Computes the function for each item of a sorted partition.
+	*/
+	ComputeWindow(partition *WindowPartition, context Context) (value.Values, error)
+
+	/*
+	   Constructs a function of this type over the given window.
+	*/
+	WindowConstructor() WindowConstructor
+}
+
+/*
+WindowConstructor enables dynamic construction of window functions.
+*/
+type WindowConstructor func(window *WindowTerm, operands ...expression.Expression) WindowFunction
+
+/*
+Base class for window functions. It inherits from FunctionBase, and
+has field window which represents the OVER clause.
+*/
+type WindowFunctionBase struct {
+	expression.FunctionBase
+	window *WindowTerm
+}
+
+func NewWindowFunctionBase(name string, window *WindowTerm,
+	operands ...expression.Expression) *WindowFunctionBase {
+	return &WindowFunctionBase{
+		*expression.NewFunctionBase(name, operands...),
+		window,
+	}
+}
+
+/*
+This method evaluates the window function, by retrieving the windows
+map from the attachments and performing a lookup using the string
+representation of the function.
+*/
+func (this *WindowFunctionBase) evaluate(fn WindowFunction, item value.Value,
+	context expression.Context) (result value.Value, err error) {
+	av, ok := item.(value.AnnotatedValue)
+	if ok {
+		windows, _ := av.GetAttachment("windows").(map[string]value.Value)
+		result = windows[fn.String()]
+	}
+
+	if result == nil {
+		err = fmt.Errorf("Window function %s not found.", fn.String())
+	}
+
+	return
+}
+
+/*
+Returns the window of the function.
+*/
+func (this *WindowFunctionBase) Window() *WindowTerm {
+	return this.window
+}
+
+/*
+Representation of the window as a N1QL string.
+*/
+func (this *WindowFunctionBase) WindowString() string {
+	return " " + this.window.String()
+}
+
+/*
+Window functions depend on other items, and are never constant.
+*/
+func (this *WindowFunctionBase) Value() value.Value {
+	return nil
+}
+
+/*
+Not indexable.
+*/
+func (this *WindowFunctionBase) Indexable() bool {
+	return false
+}
+
+/*
+Return false.
+*/
+func (this *WindowFunctionBase) EquivalentTo(other expression.Expression) bool {
+	return false
+}
+
+/*
+Return false.
+*/
+func (this *WindowFunctionBase) SubsetOf(other expression.Expression) bool {
+	return false
+}
+
+/*
+Return the operands of the function, followed by the expressions of
+the window.
+*/
+func (this *WindowFunctionBase) Children() expression.Expressions {
+	exprs := this.window.Expressions()
+	children := make(expression.Expressions, 0, len(this.Operands())+len(exprs))
+	for _, op := range this.Operands() {
+		if op != nil {
+			children = append(children, op)
+		}
+	}
+
+	return append(children, exprs...)
+}
+
+/*
+Map the operands of the function and the expressions of the window.
+*/
+func (this *WindowFunctionBase) MapChildren(mapper expression.Mapper) error {
+	operands := this.Operands()
+	for i, op := range operands {
+		if op == nil {
+			continue
+		}
+
+		expr, err := mapper.Map(op)
+		if err != nil {
+			return err
+		}
+
+		operands[i] = expr
+	}
+
+	return this.window.MapExpressions(mapper)
+}
+
+/*
+The window of a function: OVER ([PARTITION BY exprs] [ORDER BY
+sort_terms] [frame]).
+*/
+type WindowTerm struct {
+	partitionBy expression.Expressions
+	orderBy     SortTerms
+	frame       *WindowFrame
+}
+
+func NewWindowTerm(partitionBy expression.Expressions, orderBy SortTerms, frame *WindowFrame) *WindowTerm {
+	return &WindowTerm{
+		partitionBy: partitionBy,
+		orderBy:     orderBy,
+		frame:       frame,
+	}
+}
+
+/*
+Returns nil if the window is well formed.
+*/
+func (this *WindowTerm) Validate() error {
+	frame := this.frame
+	if frame == nil {
+		return nil
+	}
+
+	if frame.start.typ == UNBOUNDED_FOLLOWING {
+		return fmt.Errorf("Window frame cannot start at UNBOUNDED FOLLOWING.")
+	}
+
+	if frame.end.typ == UNBOUNDED_PRECEDING {
+		return fmt.Errorf("Window frame cannot end at UNBOUNDED PRECEDING.")
+	}
+
+	if frame.start.typ > frame.end.typ {
+		return fmt.Errorf("Window frame cannot start after its end.")
+	}
+
+	if !frame.rows && (frame.start.offset != nil || frame.end.offset != nil) &&
+		len(this.orderBy) != 1 {
+		return fmt.Errorf("RANGE window frame with an offset requires exactly one ORDER BY term.")
+	}
+
+	return nil
+}
+
+/*
+Returns the PARTITION BY terms.
+*/
+func (this *WindowTerm) PartitionBy() expression.Expressions {
+	return this.partitionBy
+}
+
+/*
+Returns the ORDER BY terms.
+*/
+func (this *WindowTerm) OrderBy() SortTerms {
+	return this.orderBy
+}
+
+/*
+Returns the frame, or nil if there is no frame clause.
+*/
+func (this *WindowTerm) Frame() *WindowFrame {
+	return this.frame
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *WindowTerm) Expressions() expression.Expressions {
+	if this == nil {
+		return nil
+	}
+
+	exprs := make(expression.Expressions, 0, len(this.partitionBy)+len(this.orderBy)+2)
+	exprs = append(exprs, this.partitionBy...)
+	exprs = append(exprs, this.orderBy.Expressions()...)
+
+	if this.frame != nil {
+		for _, bound := range []*FrameBound{this.frame.start, this.frame.end} {
+			if bound.offset != nil {
+				exprs = append(exprs, bound.offset)
+			}
+		}
+	}
+
+	return exprs
+}
+
+/*
+Map all contained Expressions.
+*/
+func (this *WindowTerm) MapExpressions(mapper expression.Mapper) (err error) {
+	if this == nil {
+		return
+	}
+
+	err = this.partitionBy.MapExpressions(mapper)
+	if err != nil {
+		return
+	}
+
+	err = this.orderBy.MapExpressions(mapper)
+	if err != nil {
+		return
+	}
+
+	if this.frame != nil {
+		for _, bound := range []*FrameBound{this.frame.start, this.frame.end} {
+			if bound.offset != nil {
+				bound.offset, err = mapper.Map(bound.offset)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	return
+}
+
+/*
+Returns a deep copy of the window.
+*/
+func (this *WindowTerm) Copy() *WindowTerm {
+	if this == nil {
+		return nil
+	}
+
+	partitionBy := make(expression.Expressions, len(this.partitionBy))
+	for i, expr := range this.partitionBy {
+		partitionBy[i] = expr.Copy()
+	}
+
+	var orderBy SortTerms
+	if this.orderBy != nil {
+		orderBy = make(SortTerms, len(this.orderBy))
+		for i, term := range this.orderBy {
+			orderBy[i] = NewSortTerm(term.expr.Copy(), term.descending)
+		}
+	}
+
+	var frame *WindowFrame
+	if this.frame != nil {
+		frame = NewWindowFrame(this.frame.rows,
+			this.frame.start.copy(), this.frame.end.copy())
+	}
+
+	return NewWindowTerm(partitionBy, orderBy, frame)
+}
+
+/*
+Representation as a N1QL string.
+*/
+func (this *WindowTerm) String() string {
+	s := "over ("
+	sep := ""
+
+	if len(this.partitionBy) > 0 {
+		s += "partition by "
+		for i, expr := range this.partitionBy {
+			if i > 0 {
+				s += ", "
+			}
+
+			s += expr.String()
+		}
+
+		sep = " "
+	}
+
+	if len(this.orderBy) > 0 {
+		s += sep + "order by " + this.orderBy.String()
+		sep = " "
+	}
+
+	if this.frame != nil {
+		s += sep + this.frame.String()
+	}
+
+	return s + ")"
+}
+
+/*
+The frame of a window: ROWS or RANGE, and the start and end bounds.
+*/
+type WindowFrame struct {
+	rows  bool
+	start *FrameBound
+	end   *FrameBound
+}
+
+func NewWindowFrame(rows bool, start, end *FrameBound) *WindowFrame {
+	return &WindowFrame{
+		rows:  rows,
+		start: start,
+		end:   end,
+	}
+}
+
+/*
+Returns true for ROWS, and false for RANGE.
+*/
+func (this *WindowFrame) Rows() bool {
+	return this.rows
+}
+
+func (this *WindowFrame) Start() *FrameBound {
+	return this.start
+}
+
+func (this *WindowFrame) End() *FrameBound {
+	return this.end
+}
+
+/*
+Representation as a N1QL string.
+*/
+func (this *WindowFrame) String() string {
+	s := "range"
+	if this.rows {
+		s = "rows"
+	}
+
+	return s + " between " + this.start.String() + " and " + this.end.String()
+}
+
+type FrameBoundType int
+
+const (
+	UNBOUNDED_PRECEDING FrameBoundType = iota
+	PRECEDING
+	CURRENT_ROW
+	FOLLOWING
+	UNBOUNDED_FOLLOWING
+)
+
+/*
+A bound of a window frame. PRECEDING and FOLLOWING bounds have an
+offset.
+*/
+type FrameBound struct {
+	typ    FrameBoundType
+	offset expression.Expression
+}
+
+func NewFrameBound(typ FrameBoundType, offset expression.Expression) *FrameBound {
+	return &FrameBound{
+		typ:    typ,
+		offset: offset,
+	}
+}
+
+func (this *FrameBound) Type() FrameBoundType {
+	return this.typ
+}
+
+func (this *FrameBound) Offset() expression.Expression {
+	return this.offset
+}
+
+func (this *FrameBound) copy() *FrameBound {
+	var offset expression.Expression
+	if this.offset != nil {
+		offset = this.offset.Copy()
+	}
+
+	return NewFrameBound(this.typ, offset)
+}
+
+/*
+Representation as a N1QL string.
+*/
+func (this *FrameBound) String() string {
+	switch this.typ {
+	case UNBOUNDED_PRECEDING:
+		return "unbounded preceding"
+	case PRECEDING:
+		return this.offset.String() + " preceding"
+	case CURRENT_ROW:
+		return "current row"
+	case FOLLOWING:
+		return this.offset.String() + " following"
+	default:
+		return "unbounded following"
+	}
+}
+
+/*
+A partition of items, sorted by the ORDER BY terms of a window. The
+evaluated ORDER BY terms of each item are in orders. Items with equal
+ORDER BY terms are peers; without ORDER BY terms, all the items of
+the partition are peers.
+*/
+type WindowPartition struct {
+	items  value.AnnotatedValues
+	orders []value.Values
+	terms  SortTerms
+	starts []int // Index of the first peer of each item
+	ends   []int // Index after the last peer of each item
+}
+
+func NewWindowPartition(items value.AnnotatedValues, orders []value.Values, terms SortTerms) *WindowPartition {
+	n := len(items)
+	rv := &WindowPartition{
+		items:  items,
+		orders: orders,
+		terms:  terms,
+		starts: make([]int, n),
+		ends:   make([]int, n),
+	}
+
+	start := 0
+	for i := 1; i <= n; i++ {
+		if i < n && (len(terms) == 0 || equalValues(orders[i], orders[start])) {
+			continue
+		}
+
+		for j := start; j < i; j++ {
+			rv.starts[j], rv.ends[j] = start, i
+		}
+
+		start = i
+	}
+
+	return rv
+}
+
+func equalValues(v1, v2 value.Values) bool {
+	for i, v := range v1 {
+		if v.Collate(v2[i]) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (this *WindowPartition) Items() value.AnnotatedValues {
+	return this.items
+}
+
+/*
+Returns the index of the first peer of item i.
+*/
+func (this *WindowPartition) PeerStart(i int) int {
+	return this.starts[i]
+}
+
+/*
+Returns the index after the last peer of item i.
+*/
+func (this *WindowPartition) PeerEnd(i int) int {
+	return this.ends[i]
+}
+
+/*
+Returns the range [start, end) of the items in the frame of item i.
+Without a frame clause, the frame is the whole partition if there is
+no ORDER BY, and otherwise RANGE BETWEEN UNBOUNDED PRECEDING AND
+CURRENT ROW.
+*/
+func (this *WindowPartition) Frame(i int, frame *WindowFrame, context Context) (start, end int, err error) {
+	if frame == nil {
+		if len(this.terms) == 0 {
+			return 0, len(this.items), nil
+		}
+
+		return 0, this.ends[i], nil
+	}
+
+	start, err = this.bound(i, frame.rows, frame.start, true, context)
+	if err != nil {
+		return
+	}
+
+	end, err = this.bound(i, frame.rows, frame.end, false, context)
+	if err != nil {
+		return
+	}
+
+	if end < start {
+		end = start
+	}
+
+	return
+}
+
+/*
+Returns the index of a frame bound of item i. Start bounds are
+inclusive, and end bounds are exclusive.
+*/
+func (this *WindowPartition) bound(i int, rows bool, bound *FrameBound, start bool,
+	context Context) (int, error) {
+	n := len(this.items)
+
+	switch bound.typ {
+	case UNBOUNDED_PRECEDING:
+		return 0, nil
+	case UNBOUNDED_FOLLOWING:
+		return n, nil
+	case CURRENT_ROW:
+		if !rows {
+			if start {
+				return this.starts[i], nil
+			}
+
+			return this.ends[i], nil
+		}
+
+		if start {
+			return i, nil
+		}
+
+		return i + 1, nil
+	}
+
+	ov, err := bound.offset.Evaluate(this.items[i], context)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, ok := ov.Actual().(float64)
+	if !ok || offset < 0 || (rows && offset != math.Trunc(offset)) {
+		return 0, fmt.Errorf("Invalid window frame offset %v.", ov)
+	}
+
+	preceding := bound.typ == PRECEDING
+
+	if rows {
+		k := n
+		if offset < float64(n) {
+			k = int(offset)
+		}
+
+		pos := i + k
+		if preceding {
+			pos = i - k
+		}
+
+		if !start {
+			pos++
+		}
+
+		if pos < 0 {
+			return 0, nil
+		} else if pos > n {
+			return n, nil
+		}
+
+		return pos, nil
+	}
+
+	// RANGE: compare the single ORDER BY term with that of item i
+	key := this.orders[i][0]
+	if key.Type() != value.NUMBER {
+		if start {
+			return this.starts[i], nil
+		}
+
+		return this.ends[i], nil
+	}
+
+	desc := this.terms[0].Descending()
+	if preceding != desc {
+		offset = -offset
+	}
+
+	target := value.NewValue(key.Actual().(float64) + offset)
+	return sort.Search(n, func(j int) bool {
+		c := this.orders[j][0].Collate(target)
+		if desc {
+			c = -c
+		}
+
+		if start {
+			return c >= 0
+		}
+
+		return c > 0
+	}), nil
+}
@@ -0,0 +1,124 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents an aggregate function computed over a window, such
+as SUM(x) OVER (ORDER BY y). The aggregate is cumulated over the
+frame of each item, using CumulateInitial() and ComputeFinal(). The
+aggregate itself is not a child of this function, so that it is not
+computed by GROUP BY.
+*/
+type WindowAggregate struct {
+	WindowFunctionBase
+	agg Aggregate
+}
+
+func NewWindowAggregate(agg Aggregate, window *WindowTerm) WindowFunction {
+	rv := &WindowAggregate{
+		*NewWindowFunctionBase(agg.Name(), window, agg.Operands()...),
+		agg,
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *WindowAggregate) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *WindowAggregate) Type() value.Type { return this.agg.Type() }
+
+func (this *WindowAggregate) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *WindowAggregate) MinArgs() int { return this.agg.MinArgs() }
+
+func (this *WindowAggregate) MaxArgs() int { return this.agg.MaxArgs() }
+
+/*
+Returns the aggregate function.
+*/
+func (this *WindowAggregate) Aggregate() Aggregate {
+	return this.agg
+}
+
+/*
+The operands are shared with the aggregate.
+*/
+func (this *WindowAggregate) MapChildren(mapper expression.Mapper) error {
+	err := this.agg.MapChildren(mapper)
+	if err != nil {
+		return err
+	}
+
+	return this.window.MapExpressions(mapper)
+}
+
+func (this *WindowAggregate) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		agg := this.agg.Constructor()(operands...).(Aggregate)
+		return NewWindowAggregate(agg, this.window.Copy())
+	}
+}
+
+func (this *WindowAggregate) WindowConstructor() WindowConstructor {
+	return func(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+		agg := this.agg.Constructor()(operands...).(Aggregate)
+		return NewWindowAggregate(agg, window)
+	}
+}
+
+/*
+Frames that start where the previous frame started, and end at or
+after where it ended, extend the previous cumulative value. This makes
+running aggregates linear in the size of the partition.
+*/
+func (this *WindowAggregate) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	items := partition.Items()
+	rv := make(value.Values, len(items))
+
+	var cumulative value.Value
+	from, pos := 0, 0
+
+	for i, _ := range items {
+		start, end, err := partition.Frame(i, this.window.Frame(), context)
+		if err != nil {
+			return nil, err
+		}
+
+		if cumulative == nil || start != from || end < pos {
+			cumulative = this.agg.Default()
+			from, pos = start, start
+		}
+
+		for ; pos < end; pos++ {
+			cumulative, err = this.agg.CumulateInitial(items[pos], cumulative, context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// ComputeFinal() may modify its argument
+		rv[i], err = this.agg.ComputeFinal(cumulative.Copy(), context)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rv, nil
+}
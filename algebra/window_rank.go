@@ -0,0 +1,178 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the window function ROW_NUMBER(). It returns the
+1-based position of each item within its sorted partition.
+*/
+type RowNumber struct {
+	WindowFunctionBase
+}
+
+func NewRowNumber(window *WindowTerm) WindowFunction {
+	rv := &RowNumber{
+		*NewWindowFunctionBase("row_number", window),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *RowNumber) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *RowNumber) Type() value.Type { return value.NUMBER }
+
+func (this *RowNumber) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *RowNumber) MinArgs() int { return 0 }
+
+func (this *RowNumber) MaxArgs() int { return 0 }
+
+func (this *RowNumber) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewRowNumber(this.window.Copy())
+	}
+}
+
+func (this *RowNumber) WindowConstructor() WindowConstructor {
+	return func(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+		return NewRowNumber(window)
+	}
+}
+
+func (this *RowNumber) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	rv := make(value.Values, len(partition.Items()))
+	for i, _ := range rv {
+		rv[i] = value.NewValue(float64(i + 1))
+	}
+
+	return rv, nil
+}
+
+/*
+This represents the window function RANK(). It returns the 1-based
+position of the first peer of each item within its sorted partition,
+leaving gaps after ties.
+*/
+type Rank struct {
+	WindowFunctionBase
+}
+
+func NewRank(window *WindowTerm) WindowFunction {
+	rv := &Rank{
+		*NewWindowFunctionBase("rank", window),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Rank) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Rank) Type() value.Type { return value.NUMBER }
+
+func (this *Rank) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Rank) MinArgs() int { return 0 }
+
+func (this *Rank) MaxArgs() int { return 0 }
+
+func (this *Rank) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewRank(this.window.Copy())
+	}
+}
+
+func (this *Rank) WindowConstructor() WindowConstructor {
+	return func(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+		return NewRank(window)
+	}
+}
+
+func (this *Rank) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	rv := make(value.Values, len(partition.Items()))
+	for i, _ := range rv {
+		rv[i] = value.NewValue(float64(partition.PeerStart(i) + 1))
+	}
+
+	return rv, nil
+}
+
+/*
+This represents the window function DENSE_RANK(). It returns the
+1-based position of the peer group of each item within its sorted
+partition, without gaps after ties.
+*/
+type DenseRank struct {
+	WindowFunctionBase
+}
+
+func NewDenseRank(window *WindowTerm) WindowFunction {
+	rv := &DenseRank{
+		*NewWindowFunctionBase("dense_rank", window),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *DenseRank) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *DenseRank) Type() value.Type { return value.NUMBER }
+
+func (this *DenseRank) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *DenseRank) MinArgs() int { return 0 }
+
+func (this *DenseRank) MaxArgs() int { return 0 }
+
+func (this *DenseRank) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewDenseRank(this.window.Copy())
+	}
+}
+
+func (this *DenseRank) WindowConstructor() WindowConstructor {
+	return func(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+		return NewDenseRank(window)
+	}
+}
+
+func (this *DenseRank) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	rv := make(value.Values, len(partition.Items()))
+	rank := 0
+	for i, _ := range rv {
+		if partition.PeerStart(i) == i {
+			rank++
+		}
+
+		rv[i] = value.NewValue(float64(rank))
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,49 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+This method is used to retrieve a window function by the parser.
+Aggregates over a window are retrieved using GetAggregate(), and
+wrapped with NewWindowAggregate().
+*/
+func GetWindowFunction(name string) (WindowFunction, bool) {
+	rv, ok := _WINDOW_FUNCTIONS[strings.ToLower(name)]
+	return rv, ok
+}
+
+/*
+Returns the names of all the window functions, in lower case and
+sorted.
+*/
+func WindowFunctionNames() []string {
+	rv := make([]string, 0, len(_WINDOW_FUNCTIONS))
+	for name, _ := range _WINDOW_FUNCTIONS {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+var _WINDOW_FUNCTIONS = map[string]WindowFunction{
+	"dense_rank":  &DenseRank{},
+	"first_value": &FirstValue{},
+	"lag":         &Lag{},
+	"last_value":  &LastValue{},
+	"lead":        &Lead{},
+	"rank":        &Rank{},
+	"row_number":  &RowNumber{},
+}
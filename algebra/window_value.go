@@ -0,0 +1,283 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the window function LAG(expr [, offset [, default]]).
+It returns expr evaluated on the item offset positions before each
+item in its sorted partition, or default if there is no such item.
+The offset defaults to 1, and the default to NULL.
+*/
+type Lag struct {
+	WindowFunctionBase
+}
+
+func NewLag(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+	rv := &Lag{
+		*NewWindowFunctionBase("lag", window, operands...),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Lag) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Lag) Type() value.Type { return value.JSON }
+
+func (this *Lag) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Lag) MinArgs() int { return 1 }
+
+func (this *Lag) MaxArgs() int { return 3 }
+
+func (this *Lag) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewLag(this.window.Copy(), operands...)
+	}
+}
+
+func (this *Lag) WindowConstructor() WindowConstructor {
+	return NewLag
+}
+
+func (this *Lag) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	return shiftWindow(this, partition, -1, context)
+}
+
+/*
+This represents the window function LEAD(expr [, offset [,
+default]]). It returns expr evaluated on the item offset positions
+after each item in its sorted partition, or default if there is no
+such item. The offset defaults to 1, and the default to NULL.
+*/
+type Lead struct {
+	WindowFunctionBase
+}
+
+func NewLead(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+	rv := &Lead{
+		*NewWindowFunctionBase("lead", window, operands...),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *Lead) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Lead) Type() value.Type { return value.JSON }
+
+func (this *Lead) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *Lead) MinArgs() int { return 1 }
+
+func (this *Lead) MaxArgs() int { return 3 }
+
+func (this *Lead) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewLead(this.window.Copy(), operands...)
+	}
+}
+
+func (this *Lead) WindowConstructor() WindowConstructor {
+	return NewLead
+}
+
+func (this *Lead) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	return shiftWindow(this, partition, 1, context)
+}
+
+/*
+Computes LAG (direction -1) and LEAD (direction 1).
+*/
+func shiftWindow(fn WindowFunction, partition *WindowPartition, direction int,
+	context Context) (value.Values, error) {
+	operands := fn.Operands()
+	items := partition.Items()
+	rv := make(value.Values, len(items))
+
+	for i, item := range items {
+		offset := 1
+		if len(operands) > 1 {
+			ov, err := operands[1].Evaluate(item, context)
+			if err != nil {
+				return nil, err
+			}
+
+			o, ok := ov.Actual().(float64)
+			if !ok || o < 0 || o != math.Trunc(o) {
+				return nil, fmt.Errorf("Invalid %s offset %v.", fn.Name(), ov)
+			}
+
+			offset = len(items)
+			if o < float64(len(items)) {
+				offset = int(o)
+			}
+		}
+
+		var err error
+		j := i + direction*offset
+		if j >= 0 && j < len(items) {
+			rv[i], err = operands[0].Evaluate(items[j], context)
+		} else if len(operands) > 2 {
+			rv[i], err = operands[2].Evaluate(item, context)
+		} else {
+			rv[i] = value.NULL_VALUE
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rv, nil
+}
+
+/*
+This represents the window function FIRST_VALUE(expr). It returns
+expr evaluated on the first item in the frame of each item, or NULL
+if the frame is empty.
+*/
+type FirstValue struct {
+	WindowFunctionBase
+}
+
+func NewFirstValue(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+	rv := &FirstValue{
+		*NewWindowFunctionBase("first_value", window, operands...),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *FirstValue) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *FirstValue) Type() value.Type { return value.JSON }
+
+func (this *FirstValue) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *FirstValue) MinArgs() int { return 1 }
+
+func (this *FirstValue) MaxArgs() int { return 1 }
+
+func (this *FirstValue) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewFirstValue(this.window.Copy(), operands...)
+	}
+}
+
+func (this *FirstValue) WindowConstructor() WindowConstructor {
+	return NewFirstValue
+}
+
+func (this *FirstValue) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	return frameValue(this, partition, true, context)
+}
+
+/*
+This represents the window function LAST_VALUE(expr). It returns
+expr evaluated on the last item in the frame of each item, or NULL
+if the frame is empty.
+*/
+type LastValue struct {
+	WindowFunctionBase
+}
+
+func NewLastValue(window *WindowTerm, operands ...expression.Expression) WindowFunction {
+	rv := &LastValue{
+		*NewWindowFunctionBase("last_value", window, operands...),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+func (this *LastValue) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *LastValue) Type() value.Type { return value.JSON }
+
+func (this *LastValue) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return this.evaluate(this, item, context)
+}
+
+func (this *LastValue) MinArgs() int { return 1 }
+
+func (this *LastValue) MaxArgs() int { return 1 }
+
+func (this *LastValue) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewLastValue(this.window.Copy(), operands...)
+	}
+}
+
+func (this *LastValue) WindowConstructor() WindowConstructor {
+	return NewLastValue
+}
+
+func (this *LastValue) ComputeWindow(partition *WindowPartition, context Context) (value.Values, error) {
+	return frameValue(this, partition, false, context)
+}
+
+/*
+Computes FIRST_VALUE (first true) and LAST_VALUE (first false).
+*/
+func frameValue(fn WindowFunction, partition *WindowPartition, first bool,
+	context Context) (value.Values, error) {
+	items := partition.Items()
+	rv := make(value.Values, len(items))
+
+	for i, _ := range items {
+		start, end, err := partition.Frame(i, fn.Window().Frame(), context)
+		if err != nil {
+			return nil, err
+		}
+
+		if start == end {
+			rv[i] = value.NULL_VALUE
+			continue
+		}
+
+		j := end - 1
+		if first {
+			j = start
+		}
+
+		rv[i], err = fn.Operands()[0].Evaluate(items[j], context)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rv, nil
+}
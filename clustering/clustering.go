@@ -56,6 +56,22 @@ type ConfigurationStore interface {
 	ConfigurationManager() ConfigurationManager        // Get a ConfigurationManager for this ConfigurationStore
 }
 
+// FunctionStore is implemented by ConfigurationStores that can persist the definitions of
+// user-defined functions. A definition is the text of the CREATE FUNCTION statement.
+type FunctionStore interface {
+	FunctionDefinitions() (map[string]string, errors.Error)        // All the stored definitions, by function name
+	CreateFunctionDefinition(name, definition string) errors.Error // Store the definition of a new function, if there is none of that name
+	DeleteFunctionDefinition(name string) errors.Error             // Remove the definition of a function
+}
+
+// HistogramStore is implemented by ConfigurationStores that can persist the histograms collected
+// by UPDATE STATISTICS, so that they outlive the Query Node and are shared by the Query Nodes of
+// a cluster. A histogram is stored as the JSON of its system:histograms document.
+type HistogramStore interface {
+	Histograms() (map[string][]byte, errors.Error)          // All the stored histograms, by key
+	SetHistogram(key string, histogram []byte) errors.Error // Store a histogram
+}
+
 // Cluster is a named collection of Query Nodes. It is basically a single-level namespace for one or more Query Nodes.
 // It also provides configuration common to all the Query Nodes in a cluster: Datastore, AccountingStore and ConfigurationStore.
 type Cluster interface {
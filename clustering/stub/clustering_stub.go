@@ -21,6 +21,8 @@ Query Node Name, Query Endpoint and Cluster Endpoint.
 package clustering_stub
 
 import (
+	"sync"
+
 	"github.com/couchbase/query/accounting"
 	"github.com/couchbase/query/accounting/stub"
 	"github.com/couchbase/query/clustering"
@@ -78,6 +80,73 @@ func (ConfigurationStoreStub) ConfigurationManager() clustering.ConfigurationMan
 	return ConfigurationManagerStub{}
 }
 
+// ConfigurationStoreStub also implements clustering.FunctionStore, keeping the
+// definitions in memory.
+func (ConfigurationStoreStub) FunctionDefinitions() (map[string]string, errors.Error) {
+	_FUNCTIONS.Lock()
+	defer _FUNCTIONS.Unlock()
+
+	rv := make(map[string]string, len(_FUNCTIONS.definitions))
+	for name, definition := range _FUNCTIONS.definitions {
+		rv[name] = definition
+	}
+	return rv, nil
+}
+
+func (ConfigurationStoreStub) CreateFunctionDefinition(name, definition string) errors.Error {
+	_FUNCTIONS.Lock()
+	defer _FUNCTIONS.Unlock()
+
+	if _, ok := _FUNCTIONS.definitions[name]; ok {
+		return errors.NewFunctionExistsError(name)
+	}
+	_FUNCTIONS.definitions[name] = definition
+	return nil
+}
+
+func (ConfigurationStoreStub) DeleteFunctionDefinition(name string) errors.Error {
+	_FUNCTIONS.Lock()
+	defer _FUNCTIONS.Unlock()
+
+	delete(_FUNCTIONS.definitions, name)
+	return nil
+}
+
+// ConfigurationStoreStub also implements clustering.HistogramStore, keeping the
+// histograms in memory.
+func (ConfigurationStoreStub) Histograms() (map[string][]byte, errors.Error) {
+	_HISTOGRAMS.Lock()
+	defer _HISTOGRAMS.Unlock()
+
+	rv := make(map[string][]byte, len(_HISTOGRAMS.histograms))
+	for key, histogram := range _HISTOGRAMS.histograms {
+		rv[key] = histogram
+	}
+	return rv, nil
+}
+
+func (ConfigurationStoreStub) SetHistogram(key string, histogram []byte) errors.Error {
+	_HISTOGRAMS.Lock()
+	defer _HISTOGRAMS.Unlock()
+
+	_HISTOGRAMS.histograms[key] = histogram
+	return nil
+}
+
+var _HISTOGRAMS = struct {
+	sync.Mutex
+	histograms map[string][]byte
+}{
+	histograms: make(map[string][]byte),
+}
+
+var _FUNCTIONS = struct {
+	sync.Mutex
+	definitions map[string]string
+}{
+	definitions: make(map[string]string),
+}
+
 func NewConfigurationStore() (clustering.ConfigurationStore, errors.Error) {
 	return ConfigurationStoreStub{}, nil
 }
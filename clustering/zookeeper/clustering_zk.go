@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -17,6 +18,8 @@ import (
 
 const _PREFIX = "zookeeper:"
 const _RESERVED_NAME = "zookeeper"
+const _FUNCTIONS_NAME = "query_functions"
+const _HISTOGRAMS_NAME = "query_histograms"
 
 // zkConfigStore implements clustering.ConfigurationStore
 type zkConfigStore struct {
@@ -61,6 +64,9 @@ func (z *zkConfigStore) ClusterNames() ([]string, errors.Error) {
 		return nil, errors.NewAdminGetClusterError(err, "/")
 	}
 	for _, name := range nodes {
+		if name == _FUNCTIONS_NAME || name == _HISTOGRAMS_NAME {
+			continue
+		}
 		clusterIds = append(clusterIds, name)
 	}
 	return clusterIds, nil
@@ -124,7 +130,7 @@ func (z *zkConfigStore) GetClusters() ([]clustering.Cluster, errors.Error) {
 		return nil, errors.NewAdminGetClusterError(err, "/")
 	}
 	for _, name := range nodes {
-		if name == _RESERVED_NAME {
+		if name == _RESERVED_NAME || name == _FUNCTIONS_NAME || name == _HISTOGRAMS_NAME {
 			continue
 		}
 		data, _, err := z.conn.Get("/" + name)
@@ -141,6 +147,94 @@ func (z *zkConfigStore) GetClusters() ([]clustering.Cluster, errors.Error) {
 	return clusters, nil
 }
 
+// zkConfigStore also implements clustering.FunctionStore; each definition
+// is stored in a child of the /query_functions node
+func (z *zkConfigStore) FunctionDefinitions() (map[string]string, errors.Error) {
+	nodes, _, err := z.conn.Children("/" + _FUNCTIONS_NAME)
+	if err == zk.ErrNoNode {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, errors.NewAdminFunctionStoreError(err, "/"+_FUNCTIONS_NAME)
+	}
+	definitions := make(map[string]string, len(nodes))
+	for _, name := range nodes {
+		data, _, err := z.conn.Get("/" + _FUNCTIONS_NAME + "/" + name)
+		if err != nil {
+			return nil, errors.NewAdminFunctionStoreError(err, name)
+		}
+		definitions[name] = string(data)
+	}
+	return definitions, nil
+}
+
+func (z *zkConfigStore) CreateFunctionDefinition(name, definition string) errors.Error {
+	flags := int32(0)
+	acl := zk.WorldACL(zk.PermAll) // TODO: expose authentication in the API
+	_, err := z.conn.Create("/"+_FUNCTIONS_NAME, []byte{}, flags, acl)
+	if err != nil && err != zk.ErrNodeExists {
+		return errors.NewAdminFunctionStoreError(err, name)
+	}
+	key := "/" + _FUNCTIONS_NAME + "/" + name
+	_, err = z.conn.Create(key, []byte(definition), flags, acl)
+	if err == zk.ErrNodeExists {
+		return errors.NewFunctionExistsError(name)
+	}
+	if err != nil {
+		return errors.NewAdminFunctionStoreError(err, name)
+	}
+	return nil
+}
+
+func (z *zkConfigStore) DeleteFunctionDefinition(name string) errors.Error {
+	err := z.conn.Delete("/"+_FUNCTIONS_NAME+"/"+name, -1)
+	if err != nil && err != zk.ErrNoNode {
+		return errors.NewAdminFunctionStoreError(err, name)
+	}
+	return nil
+}
+
+// zkConfigStore also implements clustering.HistogramStore; each histogram is
+// stored in a child of the /query_histograms node, named by its escaped key
+func (z *zkConfigStore) Histograms() (map[string][]byte, errors.Error) {
+	nodes, _, err := z.conn.Children("/" + _HISTOGRAMS_NAME)
+	if err == zk.ErrNoNode {
+		return map[string][]byte{}, nil
+	} else if err != nil {
+		return nil, errors.NewAdminHistogramStoreError(err, "/"+_HISTOGRAMS_NAME)
+	}
+	histograms := make(map[string][]byte, len(nodes))
+	for _, name := range nodes {
+		key, err := url.QueryUnescape(name)
+		if err != nil {
+			return nil, errors.NewAdminHistogramStoreError(err, name)
+		}
+		data, _, err := z.conn.Get("/" + _HISTOGRAMS_NAME + "/" + name)
+		if err != nil {
+			return nil, errors.NewAdminHistogramStoreError(err, key)
+		}
+		histograms[key] = data
+	}
+	return histograms, nil
+}
+
+func (z *zkConfigStore) SetHistogram(key string, histogram []byte) errors.Error {
+	flags := int32(0)
+	acl := zk.WorldACL(zk.PermAll) // TODO: expose authentication in the API
+	_, err := z.conn.Create("/"+_HISTOGRAMS_NAME, []byte{}, flags, acl)
+	if err != nil && err != zk.ErrNodeExists {
+		return errors.NewAdminHistogramStoreError(err, key)
+	}
+	path := "/" + _HISTOGRAMS_NAME + "/" + url.QueryEscape(key)
+	_, err = z.conn.Create(path, histogram, flags, acl)
+	if err == zk.ErrNodeExists {
+		_, err = z.conn.Set(path, histogram, -1)
+	}
+	if err != nil {
+		return errors.NewAdminHistogramStoreError(err, key)
+	}
+	return nil
+}
+
 // zkCluster implements clustering.Cluster
 type zkCluster struct {
 	configStore    clustering.ConfigurationStore `json:"-"`
@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/couchbase/cbauth"
+	"github.com/couchbase/gomemcached"
+	memcached "github.com/couchbase/gomemcached/client"
 	gsi "github.com/couchbase/indexing/secondary/queryport/n1ql"
 	cb "github.com/couchbaselabs/go-couchbase"
 	"github.com/couchbase/query/datastore"
@@ -89,7 +91,9 @@ func doAuth(username, password, bucket string, requested datastore.Privilege) (b
 		return false, err
 	}
 
-	if requested == datastore.PRIV_DDL {
+	// Roles are managed by Couchbase itself, so GRANT and REVOKE are
+	// not supported; authorize them as DDL, and let them fail later
+	if requested == datastore.PRIV_DDL || requested == datastore.PRIV_ADMIN {
 		authResult, err := creds.CanDDLBucket(bucket)
 		if err != nil || authResult == false {
 			return false, err
@@ -605,12 +609,15 @@ func (b *keyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error
 		if Value.Type() == value.BINARY {
 			meta_type = "base64"
 		}
-		Value.SetAttachment("meta", map[string]interface{}{
+		meta := map[string]interface{}{
 			"id":    k,
 			"cas":   float64(v.Cas),
 			"type":  meta_type,
 			"flags": float64(meta_flags),
-		})
+		}
+
+		Value.SetAttachment("meta", meta)
+		Value.SetAttachment(datastore.CAS_ATTACHMENT, v.Cas)
 
 		logging.Debugf("CAS Value for key %v is %v", k, float64(v.Cas))
 
@@ -625,6 +632,54 @@ func (b *keyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error
 	return rv, nil
 }
 
+// Bulk gets do not return the expiration, so it is only fetched for
+// the requests that read it, with a get-meta for each document.
+func (b *keyspace) FetchExpirations(pairs []datastore.AnnotatedPair) errors.Errors {
+	var warnings errors.Errors
+	for _, pair := range pairs {
+		meta, ok := pair.Value.GetAttachment("meta").(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		expiration, err := b.getExpiration(pair.Key)
+		if err != nil {
+			warnings = append(warnings, errors.NewCbGetMetaWarning(err, pair.Key))
+			continue
+		}
+
+		meta["expiration"] = float64(expiration)
+	}
+
+	return warnings
+}
+
+// getExpiration returns the expiration of a document, which is in the
+// extras of a get-meta response after the deleted flag and the flags.
+func (b *keyspace) getExpiration(key string) (uint32, error) {
+	var expiration uint32
+
+	err := b.cbbucket.Do(key, func(mc *memcached.Client, vb uint16) error {
+		res, err := mc.Send(&gomemcached.MCRequest{
+			Opcode:  gomemcached.GET_META,
+			VBucket: vb,
+			Key:     []byte(key),
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(res.Extras) < 12 {
+			return fmt.Errorf("Invalid get-meta response of %d bytes.", len(res.Extras))
+		}
+
+		expiration = binary.BigEndian.Uint32(res.Extras[8:12])
+		return nil
+	})
+
+	return expiration, err
+}
+
 const (
 	INSERT = 0x01
 	UPDATE = 0x02
@@ -661,6 +716,7 @@ func (b *keyspace) performOp(op int, inserts []datastore.Pair) ([]datastore.Pair
 	for _, kv := range inserts {
 		key := kv.Key
 		val := kv.Value.Actual()
+		exp := int(kv.Expiration)
 
 		//mv := kv.Value.GetAttachment("meta")
 
@@ -670,30 +726,22 @@ func (b *keyspace) performOp(op int, inserts []datastore.Pair) ([]datastore.Pair
 		case INSERT:
 			var added bool
 			// add the key to the backend
-			added, err = b.cbbucket.Add(key, 0, val)
+			added, err = b.cbbucket.Add(key, exp, val)
 			if added == false {
 				err = errors.NewError(err, "For Key "+key)
 			}
 		case UPDATE:
-			// check if the key exists and if so then use the cas value
-			// to update the key
-			var meta map[string]interface{}
-			var cas float64
-
-			an := kv.Value.(value.AnnotatedValue)
-			meta = an.GetAttachment("meta").(map[string]interface{})
-
-			cas = meta["cas"].(float64)
-			logging.Infof("CAS Value (Update) for key %v is %v", key, float64(cas))
-			if cas != 0 {
-				err = b.cbbucket.Cas(key, 0, uint64(cas), val)
+			// use the cas value, if any, to update the key
+			logging.Infof("CAS Value (Update) for key %v is %v", key, kv.Cas)
+			if kv.Cas != 0 {
+				err = b.cbbucket.Cas(key, exp, kv.Cas, val)
 			} else {
 				logging.Warnf("Warning: Cas value not found for key %v", key)
-				err = b.cbbucket.Set(key, 0, val)
+				err = b.cbbucket.Set(key, exp, val)
 			}
 
 		case UPSERT:
-			err = b.cbbucket.Set(key, 0, val)
+			err = b.cbbucket.Set(key, exp, val)
 		}
 
 		if err != nil {
@@ -747,6 +795,82 @@ func (b *keyspace) Delete(deletes []string) ([]string, errors.Error) {
 	return actualDeletes, nil
 }
 
+func isCasMismatchError(err error) bool {
+	return strings.Contains(err.Error(), "KEY_EEXISTS")
+}
+
+func (b *keyspace) UpdateCas(updates []datastore.Pair) ([]datastore.Pair, errors.Errors) {
+
+	if len(updates) == 0 {
+		return nil, errors.Errors{errors.NewCbNoKeysInsertError(nil, ":(")}
+	}
+
+	updated := make([]datastore.Pair, 0, len(updates))
+	var errs errors.Errors
+
+	for _, kv := range updates {
+		var err error
+
+		key := kv.Key
+		val := kv.Value.Actual()
+		exp := int(kv.Expiration)
+
+		if kv.Cas != 0 {
+			err = b.cbbucket.Cas(key, exp, kv.Cas, val)
+		} else {
+			err = b.cbbucket.Set(key, exp, val)
+		}
+
+		if err == nil {
+			updated = append(updated, kv)
+		} else if isCasMismatchError(err) || isNotFoundError(err) {
+			errs = append(errs, errors.NewCbCasMismatchError(err, "for key "+key))
+		} else {
+			logging.Errorf("Failed to perform update on key %s Error %v", key, err)
+			errs = append(errs, errors.NewCbDMLError(err, "Failed to perform update on key "+key))
+		}
+	}
+
+	return updated, errs
+}
+
+func (b *keyspace) DeleteCas(deletes []datastore.Pair) ([]string, errors.Errors) {
+
+	deleted := make([]string, 0, len(deletes))
+	var errs errors.Errors
+
+	for _, kv := range deletes {
+		var err error
+
+		key := kv.Key
+
+		if kv.Cas != 0 {
+			err = b.cbbucket.Do(key, func(mc *memcached.Client, vb uint16) error {
+				_, err := mc.Send(&gomemcached.MCRequest{
+					Opcode:  gomemcached.DELETE,
+					VBucket: vb,
+					Key:     []byte(key),
+					Cas:     kv.Cas,
+				})
+				return err
+			})
+		} else {
+			err = b.cbbucket.Delete(key)
+		}
+
+		if err == nil {
+			deleted = append(deleted, key)
+		} else if kv.Cas != 0 && (isCasMismatchError(err) || isNotFoundError(err)) {
+			errs = append(errs, errors.NewCbCasMismatchError(err, "for key "+key))
+		} else if !isNotFoundError(err) {
+			logging.Infof("Failed to delete key %s", key)
+			errs = append(errs, errors.NewCbDeleteFailedError(err, "Failed to delete key "+key))
+		}
+	}
+
+	return deleted, errs
+}
+
 func (b *keyspace) Release() {
 	b.deleted = true
 	b.cbbucket.Close()
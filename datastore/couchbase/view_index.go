@@ -156,6 +156,65 @@ func (view *viewIndexer) BuildIndexes(names ...string) errors.Error {
 	return errors.NewCbViewsNotSupportedError(nil, "BUILD INDEXES is not supported for VIEW.")
 }
 
+// Views cannot be renamed, so the index is moved to a new design
+// document named after it, and the old design document is deleted.
+func (view *viewIndexer) RenameIndex(index datastore.Index, name string) errors.Error {
+	oldName := index.Name()
+	if view.indexes[oldName] != index {
+		return errors.NewCbViewNotFoundError(nil, oldName)
+	}
+
+	vi, ok := index.(*viewIndex)
+	if !ok || oldName == PRIMARY_INDEX {
+		return errors.NewCbViewsNotSupportedError(nil, "Primary index "+oldName+" cannot be renamed.")
+	}
+
+	if name == oldName {
+		return nil
+	}
+
+	if _, exists := view.indexes[name]; exists {
+		return errors.NewCbViewExistsError(nil, name)
+	}
+
+	// if the name matches any of the unusable indexes, return an error
+	for _, iname := range view.nonUsableIndexes {
+		if name == iname {
+			return errors.NewCbViewExistsError(nil, "Non usuable index "+name)
+		}
+	}
+
+	logging.Infof("Renaming index %s to %s", oldName, name)
+
+	ddoc := *vi.ddoc
+	ddoc.name = "ddl_" + name
+	ddoc.viewname = name
+
+	renamed := *vi
+	renamed.name = name
+	renamed.ddoc = &ddoc
+
+	err := renamed.putDesignDoc()
+	if err == nil {
+		err = renamed.WaitForIndex()
+	}
+
+	if err != nil {
+		return errors.NewCbViewCreateError(err, name)
+	}
+
+	err = vi.DropViewIndex()
+	if err != nil {
+		logging.Errorf("Unable to drop design document %s of renamed index %s: %v", vi.DDocName(), name, err)
+	}
+
+	// TODO need mutex
+	*vi = renamed
+	delete(view.indexes, oldName)
+	view.indexes[name] = vi
+	return nil
+}
+
 func (view *viewIndexer) loadViewIndexes() errors.Error {
 	// #alldocs implicitly exists
 
@@ -308,7 +367,7 @@ func (vi *viewIndex) Scan(span *datastore.Span, distinct bool, limit int64,
 	// can just enforce that directly
 
 	viewOptions := map[string]interface{}{}
-	viewOptions = generateViewOptions(cons, span) /*span.Range.Low, span.Range.High, span.Range.Inclusion) */
+	viewOptions = generateViewOptions(cons, span, len(vi.on)) /*span.Range.Low, span.Range.High, span.Range.Inclusion) */
 	viewRowChannel := make(chan cb.ViewRow)
 	viewErrChannel := make(chan errors.Error)
 	go WalkViewInBatches(viewRowChannel, viewErrChannel, vi.keyspace.cbbucket, vi.DDocName(), vi.ViewName(), viewOptions, 1000, limit)
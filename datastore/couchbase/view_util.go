@@ -105,24 +105,29 @@ func WalkViewInBatches(result chan cb.ViewRow, errs chan errors.Error, bucket *c
 	}
 }
 
-func generateViewOptions(cons datastore.ScanConsistency, span *datastore.Span) map[string]interface{} {
+func generateViewOptions(cons datastore.ScanConsistency, span *datastore.Span, nkeys int) map[string]interface{} {
 	viewOptions := map[string]interface{}{}
 	if span != nil {
 		low := span.Range.Low
 		high := span.Range.High
 		inclusion := span.Range.Inclusion
 		if low != nil {
-			viewOptions["startkey"] = encodeValuesAsMapKey(low)
+			startkey := encodeValuesAsMapKey(low)
 			if inclusion == datastore.NEITHER || inclusion == datastore.HIGH {
 				viewOptions["startkey_docid"] = MAX_ID
+				startkey = padPrefixKey(startkey, nkeys)
 			}
+			viewOptions["startkey"] = startkey
 		}
 
 		if high != nil {
-			viewOptions["endkey"] = encodeValuesAsMapKey(high)
+			endkey := encodeValuesAsMapKey(high)
 			if inclusion == datastore.NEITHER || inclusion == datastore.LOW {
 				viewOptions["endkey_docid"] = MIN_ID
+			} else {
+				endkey = padPrefixKey(endkey, nkeys)
 			}
+			viewOptions["endkey"] = endkey
 		}
 	}
 
@@ -135,7 +140,19 @@ func generateViewOptions(cons datastore.ScanConsistency, span *datastore.Span) m
 	return viewOptions
 }
 
-func encodeValuesAsMapKey(keys value.Values) interface{} {
+// A span bound on a composite key may cover only a prefix of the
+// keys. Views collate a shorter array before any longer array with
+// the same prefix, so pad the bound with a key that collates after
+// every encoded value.
+func padPrefixKey(key []interface{}, nkeys int) []interface{} {
+	if len(key) == 0 || len(key) >= nkeys {
+		return key
+	}
+
+	return append(key, []interface{}{TYPE_OBJECT + 1})
+}
+
+func encodeValuesAsMapKey(keys value.Values) []interface{} {
 	rv := make([]interface{}, len(keys))
 	for i, lv := range keys {
 		val := lv.Actual()
@@ -0,0 +1,97 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package couchbase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/value"
+)
+
+func TestPadPrefixKey(t *testing.T) {
+	one := []interface{}{TYPE_NUMBER, 1.0}
+	two := []interface{}{TYPE_NUMBER, 2.0}
+	pad := []interface{}{TYPE_OBJECT + 1}
+
+	cases := []struct {
+		key      []interface{}
+		nkeys    int
+		expected []interface{}
+	}{
+		// A prefix of the keys is padded
+		{[]interface{}{one}, 2, []interface{}{one, pad}},
+		{[]interface{}{one}, 3, []interface{}{one, pad}},
+		{[]interface{}{one, two}, 3, []interface{}{one, two, pad}},
+
+		// Whole and empty keys are not
+		{[]interface{}{one}, 1, []interface{}{one}},
+		{[]interface{}{one, two}, 2, []interface{}{one, two}},
+		{[]interface{}{}, 2, []interface{}{}},
+	}
+
+	for _, c := range cases {
+		actual := padPrefixKey(c.key, c.nkeys)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%v of %d keys: expected %v, got %v", c.key, c.nkeys, c.expected, actual)
+		}
+	}
+}
+
+func TestGenerateViewOptions(t *testing.T) {
+	one := []interface{}{TYPE_NUMBER, 1.0}
+	pad := []interface{}{TYPE_OBJECT + 1}
+	low := value.Values{value.NewValue(1)}
+
+	cases := []struct {
+		inclusion datastore.Inclusion
+		nkeys     int
+		expected  map[string]interface{}
+	}{
+		// An inclusive high bound on a prefix covers every longer key
+		{datastore.BOTH, 2, map[string]interface{}{
+			"startkey": []interface{}{one},
+			"endkey":   []interface{}{one, pad},
+			"stale":    "ok",
+		}},
+
+		// An exclusive low bound on a prefix skips every longer key
+		{datastore.NEITHER, 2, map[string]interface{}{
+			"startkey":       []interface{}{one, pad},
+			"startkey_docid": MAX_ID,
+			"endkey":         []interface{}{one},
+			"endkey_docid":   MIN_ID,
+			"stale":          "ok",
+		}},
+
+		// Bounds on every key are not padded
+		{datastore.BOTH, 1, map[string]interface{}{
+			"startkey": []interface{}{one},
+			"endkey":   []interface{}{one},
+			"stale":    "ok",
+		}},
+		{datastore.NEITHER, 1, map[string]interface{}{
+			"startkey":       []interface{}{one},
+			"startkey_docid": MAX_ID,
+			"endkey":         []interface{}{one},
+			"endkey_docid":   MIN_ID,
+			"stale":          "ok",
+		}},
+	}
+
+	for _, c := range cases {
+		span := &datastore.Span{Range: datastore.Range{Low: low, High: low, Inclusion: c.inclusion}}
+		actual := generateViewOptions(datastore.UNBOUNDED, span, c.nkeys)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("inclusion %v of %d keys: expected %v, got %v", c.inclusion, c.nkeys, c.expected, actual)
+		}
+	}
+}
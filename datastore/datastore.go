@@ -80,10 +80,46 @@ type Keyspace interface {
 	Release() // Release any resources held by this object
 }
 
-// Key-value pair
+// CasKeyspace is implemented by keyspaces that can make mutations
+// conditional on the CAS of each document. A pair whose Cas is 0 is
+// mutated unconditionally; any other pair is only mutated if its Cas
+// matches the current CAS of the document. Each mismatch is reported
+// as a separate error and does not prevent the other mutations.
+type CasKeyspace interface {
+	UpdateCas(updates []Pair) ([]Pair, errors.Errors)   // Bulk CAS-checked updates into this keyspace
+	DeleteCas(deletes []Pair) ([]string, errors.Errors) // Bulk CAS-checked deletes from this keyspace; values are ignored
+}
+
+// ExpirationKeyspace is implemented by keyspaces whose Fetch leaves
+// the expiration out of the meta of each document, because it costs
+// another lookup per document. FetchExpirations adds it to the meta of
+// the fetched pairs, and returns a warning for each document whose
+// expiration could not be fetched.
+type ExpirationKeyspace interface {
+	FetchExpirations(pairs []AnnotatedPair) errors.Errors
+}
+
+// RoleManager is implemented by datastores whose users and roles are
+// managed by the GRANT and REVOKE statements. Roles are privileges on
+// keyspaces of the form "namespace:keyspace", and are cumulative: a
+// user with a role also has every lesser role on the keyspace.
+type RoleManager interface {
+	GrantRole(role Privilege, keyspace string, users []string) errors.Error  // Grant the role on the keyspace to each user
+	RevokeRole(role Privilege, keyspace string, users []string) errors.Error // Revoke the role, and any greater one, from each user
+	UserRoles() (map[string]Privileges, errors.Error)                        // Roles of each user, by user name
+}
+
+// Meta holds the CAS as a float64, which is only exact up to 2^53.
+// Keyspaces whose CAS may be larger also attach the raw uint64 CAS to
+// each fetched document under this name.
+const CAS_ATTACHMENT = "cas"
+
+// Key-value pair, with the expected CAS of the document, if any
 type Pair struct {
-	Key   string
-	Value value.Value
+	Key        string
+	Value      value.Value
+	Cas        uint64
+	Expiration uint32 // As in Couchbase: 0 for none, seconds from now up to 30 days, else Unix time
 }
 
 // Key-value pair
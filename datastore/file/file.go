@@ -18,11 +18,13 @@ package file
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
@@ -36,6 +38,8 @@ type store struct {
 	path           string
 	namespaces     map[string]*namespace
 	namespaceNames []string
+	users          datastore.Users
+	usersLock      sync.RWMutex
 }
 
 func (s *store) Id() string {
@@ -67,8 +71,11 @@ func (s *store) NamespaceByName(name string) (p datastore.Namespace, e errors.Er
 	return
 }
 
-func (s *store) Authorize(datastore.Privileges, datastore.Credentials) errors.Error {
-	return nil
+func (s *store) Authorize(privileges datastore.Privileges, credentials datastore.Credentials) errors.Error {
+	s.usersLock.RLock()
+	defer s.usersLock.RUnlock()
+
+	return s.users.Authorize(privileges, credentials)
 }
 
 // NewStore creates a new file-based store for the given filepath.
@@ -85,6 +92,11 @@ func NewDatastore(path string) (s datastore.Datastore, e errors.Error) {
 		return
 	}
 
+	e = fs.loadUsers()
+	if e != nil {
+		return
+	}
+
 	s = fs
 	return
 }
@@ -206,10 +218,15 @@ func (p *namespace) loadKeyspaces() (e errors.Error) {
 
 // keyspace is a file-based keyspace.
 type keyspace struct {
-	namespace *namespace
-	name      string
-	fi        datastore.Indexer
-	fileLock  sync.Mutex
+	namespace   *namespace
+	name        string
+	fi          *fileIndexer
+	fileLock    sync.Mutex
+	expiryLock  sync.RWMutex
+	expirations map[string]int64 // Unix times of expiring documents
+	sweeper     sync.Once
+	stop        chan bool // Closed to stop the sweeper
+	released    sync.Once
 }
 
 func (b *keyspace) NamespaceId() string {
@@ -229,7 +246,14 @@ func (b *keyspace) Count() (int64, errors.Error) {
 	if er != nil {
 		return 0, errors.NewFileDatastoreError(er, "")
 	}
-	return int64(len(dirEntries)), nil
+
+	var count int64
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() && !b.expired(documentPathToId(dirEntry.Name())) {
+			count++
+		}
+	}
+	return count, nil
 }
 
 func (b *keyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
@@ -276,11 +300,18 @@ func (b *keyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error
 func (b *keyspace) fetchOne(key string) (value.AnnotatedValue, errors.Error) {
 	path := filepath.Join(b.path(), key+".json")
 	item, e := fetch(path)
-	if e != nil {
-		item = nil
+	if e != nil || item == nil {
+		return nil, e
+	}
+
+	t := b.expiration(key)
+	if t != 0 && t <= time.Now().Unix() {
+		// expired, even if not yet removed
+		return nil, nil
 	}
 
-	return item, e
+	item.GetAttachment("meta").(map[string]interface{})["expiration"] = float64(t)
+	return item, nil
 }
 
 const (
@@ -309,21 +340,33 @@ func (b *keyspace) performOp(op int, kvPairs []datastore.Pair) ([]datastore.Pair
 		return nil, errors.NewFileNoKeysInsertError(nil, "keyspace "+b.Name())
 	}
 
-	insertedKeys := make([]datastore.Pair, 0)
-	var returnErr errors.Error
-
 	// this lock can be mode more granular FIXME
 	b.fileLock.Lock()
 	defer b.fileLock.Unlock()
 
+	return b.performOpLocked(op, kvPairs)
+}
+
+// performOpLocked must be called with the file lock held.
+func (b *keyspace) performOpLocked(op int, kvPairs []datastore.Pair) ([]datastore.Pair, errors.Error) {
+
+	insertedKeys := make([]datastore.Pair, 0)
+	var returnErr errors.Error
+
 	for _, kv := range kvPairs {
 		var file *os.File
 		var err error
 
 		key := kv.Key
-		value, _ := json.Marshal(kv.Value.Actual())
+		body, _ := json.Marshal(kv.Value.Actual())
 		filename := filepath.Join(b.path(), key+".json")
 
+		// an expired document is missing, even if not yet removed
+		if e := b.removeExpired(key); e != nil {
+			returnErr = errors.NewFileDMLError(returnErr, opToString(op)+" Failed "+e.Error())
+			continue
+		}
+
 		switch op {
 
 		case INSERT:
@@ -333,7 +376,7 @@ func (b *keyspace) performOp(op int, kvPairs []datastore.Pair) ([]datastore.Pair
 			} else {
 				// create and write the file
 				if file, err = os.Create(filename); err == nil {
-					_, err = file.Write(value)
+					_, err = file.Write(body)
 					file.Close()
 				}
 			}
@@ -342,7 +385,7 @@ func (b *keyspace) performOp(op int, kvPairs []datastore.Pair) ([]datastore.Pair
 			if _, err = os.Stat(filename); err == nil {
 				// open and write the file
 				if file, err = os.OpenFile(filename, os.O_TRUNC|os.O_RDWR, 0666); err == nil {
-					_, err = file.Write(value)
+					_, err = file.Write(body)
 					file.Close()
 				}
 			}
@@ -350,11 +393,21 @@ func (b *keyspace) performOp(op int, kvPairs []datastore.Pair) ([]datastore.Pair
 		case UPSERT:
 			// open the file for writing, if doesn't exist then create
 			if file, err = os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666); err == nil {
-				_, err = file.Write(value)
+				_, err = file.Write(body)
 				file.Close()
 			}
 		}
 
+		if err == nil {
+			err = b.setExpiration(key, kv.Expiration)
+		}
+
+		if err == nil {
+			doc := value.NewAnnotatedValue(value.NewValue(body))
+			doc.SetAttachment("meta", map[string]interface{}{"id": key, "cas": float64(version(body))})
+			err = b.fi.updateIndexes(key, doc)
+		}
+
 		if err != nil {
 			returnErr = errors.NewFileDMLError(returnErr, opToString(op)+" Failed "+err.Error())
 		} else {
@@ -380,16 +433,34 @@ func (b *keyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Er
 
 func (b *keyspace) Delete(deletes []string) ([]string, errors.Error) {
 
+	b.fileLock.Lock()
+	defer b.fileLock.Unlock()
+
+	return b.deleteLocked(deletes)
+}
+
+// deleteLocked must be called with the file lock held.
+func (b *keyspace) deleteLocked(deletes []string) ([]string, errors.Error) {
+
 	var fileError []string
 	var deleted []string
+
 	for _, key := range deletes {
 		filename := filepath.Join(b.path(), key+".json")
 		if err := os.Remove(filename); err != nil {
 			if !os.IsNotExist(err) {
 				fileError = append(fileError, err.Error())
+				continue
 			}
 		} else {
 			deleted = append(deleted, key)
+			if err := b.fi.updateIndexes(key, nil); err != nil {
+				fileError = append(fileError, err.Error())
+			}
+		}
+
+		if err := b.setExpiration(key, 0); err != nil {
+			fileError = append(fileError, err.Error())
 		}
 	}
 
@@ -401,7 +472,78 @@ func (b *keyspace) Delete(deletes []string) ([]string, errors.Error) {
 	return deleted, nil
 }
 
+func (b *keyspace) UpdateCas(updates []datastore.Pair) ([]datastore.Pair, errors.Errors) {
+	if len(updates) == 0 {
+		return nil, errors.Errors{errors.NewFileNoKeysInsertError(nil, "keyspace "+b.Name())}
+	}
+
+	b.fileLock.Lock()
+	defer b.fileLock.Unlock()
+
+	matched, errs := b.checkCas(updates)
+	if len(matched) == 0 {
+		return nil, errs
+	}
+
+	updated, err := b.performOpLocked(UPDATE, matched)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return updated, errs
+}
+
+func (b *keyspace) DeleteCas(deletes []datastore.Pair) ([]string, errors.Errors) {
+	b.fileLock.Lock()
+	defer b.fileLock.Unlock()
+
+	matched, errs := b.checkCas(deletes)
+	keys := make([]string, len(matched))
+	for i, kv := range matched {
+		keys[i] = kv.Key
+	}
+
+	deleted, err := b.deleteLocked(keys)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return deleted, errs
+}
+
+// checkCas returns the pairs whose CAS is 0 or matches the version of
+// the document on disk, and an error for each of the other pairs. It
+// must be called with the file lock held.
+func (b *keyspace) checkCas(kvPairs []datastore.Pair) ([]datastore.Pair, errors.Errors) {
+	matched := make([]datastore.Pair, 0, len(kvPairs))
+	var errs errors.Errors
+
+	for _, kv := range kvPairs {
+		if kv.Cas != 0 {
+			filename := filepath.Join(b.path(), kv.Key+".json")
+			body, err := ioutil.ReadFile(filename)
+			if err != nil && !os.IsNotExist(err) {
+				errs = append(errs, errors.NewFileDatastoreError(err, ""))
+				continue
+			}
+
+			if err != nil || version(body) != kv.Cas {
+				errs = append(errs, errors.NewFileCasMismatchError(nil, "Key (File) "+filename))
+				continue
+			}
+		}
+
+		matched = append(matched, kv)
+	}
+
+	return matched, errs
+}
+
+// Release stops the sweeper of expired documents, if any
 func (b *keyspace) Release() {
+	b.released.Do(func() {
+		close(b.stop)
+	})
 }
 
 func (b *keyspace) path() string {
@@ -413,6 +555,7 @@ func newKeyspace(p *namespace, dir string) (b *keyspace, e errors.Error) {
 	b = new(keyspace)
 	b.namespace = p
 	b.name = dir
+	b.stop = make(chan bool)
 
 	fi, er := os.Stat(b.path())
 	if er != nil {
@@ -426,16 +569,27 @@ func newKeyspace(p *namespace, dir string) (b *keyspace, e errors.Error) {
 	b.fi = newFileIndexer(b)
 	b.fi.CreatePrimaryIndex("#primary", nil)
 
+	e = b.fi.loadIndexes()
+	if e != nil {
+		return nil, e
+	}
+
+	e = b.loadExpirations()
+	if e != nil {
+		return nil, e
+	}
+
 	return
 }
 
 type fileIndexer struct {
+	sync.RWMutex
 	keyspace *keyspace
 	indexes  map[string]datastore.Index
 	primary  datastore.PrimaryIndex
 }
 
-func newFileIndexer(keyspace *keyspace) datastore.Indexer {
+func newFileIndexer(keyspace *keyspace) *fileIndexer {
 
 	return &fileIndexer{
 		keyspace: keyspace,
@@ -452,6 +606,9 @@ func (fi *fileIndexer) Name() datastore.IndexType {
 }
 
 func (fi *fileIndexer) IndexIds() ([]string, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
 	rv := make([]string, 0, len(fi.indexes))
 	for name, _ := range fi.indexes {
 		rv = append(rv, name)
@@ -460,6 +617,9 @@ func (fi *fileIndexer) IndexIds() ([]string, errors.Error) {
 }
 
 func (fi *fileIndexer) IndexNames() ([]string, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
 	rv := make([]string, 0, len(fi.indexes))
 	for name, _ := range fi.indexes {
 		rv = append(rv, name)
@@ -472,6 +632,9 @@ func (fi *fileIndexer) IndexById(id string) (datastore.Index, errors.Error) {
 }
 
 func (fi *fileIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
 	index, ok := fi.indexes[name]
 	if !ok {
 		return nil, errors.NewFileIdxNotFound(nil, name)
@@ -484,11 +647,21 @@ func (fi *fileIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error)
 }
 
 func (fi *fileIndexer) Indexes() ([]datastore.Index, errors.Error) {
-	return []datastore.Index{fi.primary}, nil
+	fi.RLock()
+	defer fi.RUnlock()
+
+	rv := make([]datastore.Index, 0, len(fi.indexes))
+	for _, index := range fi.indexes {
+		rv = append(rv, index)
+	}
+	return rv, nil
 }
 
 func (fi *fileIndexer) CreatePrimaryIndex(name string, with value.Value) (
 	datastore.PrimaryIndex, errors.Error) {
+	fi.Lock()
+	defer fi.Unlock()
+
 	if fi.primary == nil {
 		pi := new(primaryIndex)
 		fi.primary = pi
@@ -500,13 +673,89 @@ func (fi *fileIndexer) CreatePrimaryIndex(name string, with value.Value) (
 	return fi.primary, nil
 }
 
-func (b *fileIndexer) CreateIndex(name string, equalKey, rangeKey expression.Expressions,
+func (fi *fileIndexer) CreateIndex(name string, equalKey, rangeKey expression.Expressions,
 	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
-	return nil, errors.NewFileNotSupported(nil, "CREATE INDEX is not supported for file-based datastore.")
+	if len(equalKey) > 0 {
+		return nil, errors.NewFileNotSupported(nil, "PARTITION BY is not supported for file-based datastore.")
+	}
+
+	if len(rangeKey) == 0 {
+		return nil, errors.NewFileIdxDefinitionError(nil, name+" has no index keys")
+	}
+
+	// WITH {"defer_build": true} creates the index in the pending
+	// state; it is populated by a subsequent BUILD INDEX
+	deferred := false
+	if with != nil {
+		defer_build, ok := with.Field("defer_build")
+		if ok && defer_build.Type() == value.BOOLEAN {
+			deferred = defer_build.Truth()
+		}
+	}
+
+	fi.Lock()
+	defer fi.Unlock()
+
+	if _, exists := fi.indexes[name]; exists {
+		return nil, errors.NewFileIdxExists(nil, name)
+	}
+
+	index, e := newFileIndex(fi.keyspace, name, rangeKey, where, deferred)
+	if e != nil {
+		return nil, e
+	}
+
+	if !deferred {
+		e = index.build()
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	e = fi.saveIndex(index)
+	if e != nil {
+		return nil, e
+	}
+
+	fi.indexes[name] = index
+	return index, nil
 }
 
-func (b *fileIndexer) BuildIndexes(names ...string) errors.Error {
-	return errors.NewFileNotSupported(nil, "BUILD INDEXES is not supported for file-based datastore.")
+func (fi *fileIndexer) BuildIndexes(names ...string) errors.Error {
+	fi.Lock()
+	defer fi.Unlock()
+
+	indexes := make([]*fileIndex, len(names))
+	for i, name := range names {
+		index, ok := fi.indexes[name].(*fileIndex)
+		if !ok {
+			return errors.NewFileIdxNotFound(nil, name)
+		}
+
+		indexes[i] = index
+	}
+
+	for _, index := range indexes {
+		if !index.deferred {
+			continue
+		}
+
+		index.Lock()
+		index.deferred = false
+		index.Unlock()
+
+		e := index.build()
+		if e != nil {
+			return e
+		}
+
+		e = fi.saveIndex(index)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
 }
 
 func (b *fileIndexer) Refresh() errors.Error {
@@ -622,7 +871,7 @@ func (pi *primaryIndex) Scan(span *datastore.Span, distinct bool, limit int64,
 			break
 		}
 
-		if !dirEntry.IsDir() {
+		if !dirEntry.IsDir() && !pi.keyspace.expired(id) {
 			entry := datastore.IndexEntry{PrimaryKey: id}
 			conn.EntryChannel() <- &entry
 			n++
@@ -644,8 +893,9 @@ func (pi *primaryIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
 		if limit > 0 && int64(i) > limit {
 			break
 		}
-		if !dirEntry.IsDir() {
-			entry := datastore.IndexEntry{PrimaryKey: documentPathToId(dirEntry.Name())}
+		id := documentPathToId(dirEntry.Name())
+		if !dirEntry.IsDir() && !pi.keyspace.expired(id) {
+			entry := datastore.IndexEntry{PrimaryKey: id}
 			conn.EntryChannel() <- &entry
 		}
 	}
@@ -662,12 +912,29 @@ func fetch(path string) (item value.AnnotatedValue, e errors.Error) {
 	}
 
 	doc := value.NewAnnotatedValue(value.NewValue(bytes))
-	doc.SetAttachment("meta", map[string]interface{}{"id": documentPathToId(path)})
+	doc.SetAttachment("meta", map[string]interface{}{
+		"id":  documentPathToId(path),
+		"cas": float64(version(bytes)),
+	})
 	item = doc
 
 	return
 }
 
+// version is the CAS of a document stored in a file. It is a hash of
+// the contents, so that it changes whenever the document does, and is
+// limited to 53 bits so that it is exact as a JSON number.
+func version(body []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(body)
+	cas := h.Sum64() & (1<<53 - 1)
+	if cas == 0 {
+		cas = 1
+	}
+
+	return cas
+}
+
 func documentPathToId(p string) string {
 	_, file := filepath.Split(p)
 	ext := filepath.Ext(file)
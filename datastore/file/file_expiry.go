@@ -0,0 +1,200 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/couchbase/query/errors"
+)
+
+// Document expirations are persisted in this subdirectory of the
+// keyspace directory, one JSON file per expiring document, holding the
+// Unix time at which the document expires.
+const _EXPIRY_DIR = ".expirations"
+
+// As in Couchbase, expirations of up to 30 days are relative to the
+// time of the mutation, and longer ones are Unix times.
+const _MAX_RELATIVE_EXPIRATION = 30 * 24 * 60 * 60
+
+// Expired documents are invisible as soon as they expire, and are
+// removed by a sweeper that runs at this interval.
+const _EXPIRY_SWEEP_INTERVAL = time.Second
+
+// expiryTime returns the Unix time of an expiration, or 0 for none.
+func expiryTime(expiration uint32, now time.Time) int64 {
+	switch {
+	case expiration == 0:
+		return 0
+	case expiration <= _MAX_RELATIVE_EXPIRATION:
+		return now.Unix() + int64(expiration)
+	default:
+		return int64(expiration)
+	}
+}
+
+func (b *keyspace) expiryPath() string {
+	return filepath.Join(b.path(), _EXPIRY_DIR)
+}
+
+// loadExpirations restores the persisted expirations, and starts the
+// sweeper if any document expires.
+func (b *keyspace) loadExpirations() errors.Error {
+	b.expirations = make(map[string]int64)
+
+	dirEntries, er := ioutil.ReadDir(b.expiryPath())
+	if er != nil {
+		if os.IsNotExist(er) {
+			return nil
+		}
+
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		bytes, er := ioutil.ReadFile(filepath.Join(b.expiryPath(), dirEntry.Name()))
+		if er != nil {
+			return errors.NewFileDatastoreError(er, "")
+		}
+
+		var t int64
+		er = json.Unmarshal(bytes, &t)
+		if er != nil {
+			return errors.NewFileDatastoreError(er, "Invalid expiration "+dirEntry.Name())
+		}
+
+		b.expirations[documentPathToId(dirEntry.Name())] = t
+	}
+
+	if len(b.expirations) > 0 {
+		b.startSweeper()
+	}
+
+	return nil
+}
+
+// expiration returns the Unix time at which the document expires, or
+// 0 if it does not expire.
+func (b *keyspace) expiration(key string) int64 {
+	b.expiryLock.RLock()
+	defer b.expiryLock.RUnlock()
+
+	return b.expirations[key]
+}
+
+// expired returns true if the document has expired, whether or not it
+// has been removed yet.
+func (b *keyspace) expired(key string) bool {
+	t := b.expiration(key)
+	return t != 0 && t <= time.Now().Unix()
+}
+
+// setExpiration sets or, if the expiration is 0, clears the expiration
+// of a document. It must be called with the file lock held.
+func (b *keyspace) setExpiration(key string, expiration uint32) error {
+	t := expiryTime(expiration, time.Now())
+	filename := filepath.Join(b.expiryPath(), key+".json")
+
+	b.expiryLock.Lock()
+	defer b.expiryLock.Unlock()
+
+	if t == 0 {
+		if _, ok := b.expirations[key]; !ok {
+			return nil
+		}
+
+		delete(b.expirations, key)
+		if er := os.Remove(filename); er != nil && !os.IsNotExist(er) {
+			return er
+		}
+
+		return nil
+	}
+
+	if er := os.MkdirAll(b.expiryPath(), 0755); er != nil {
+		return er
+	}
+
+	bytes, _ := json.Marshal(t)
+	if er := ioutil.WriteFile(filename, bytes, 0666); er != nil {
+		return er
+	}
+
+	b.expirations[key] = t
+	b.startSweeper()
+	return nil
+}
+
+// removeExpired removes the document if it has expired, so that a
+// mutation treats it as missing. It must be called with the file lock
+// held.
+func (b *keyspace) removeExpired(key string) errors.Error {
+	if !b.expired(key) {
+		return nil
+	}
+
+	_, e := b.deleteLocked([]string{key})
+	return e
+}
+
+// sweepExpired removes all expired documents.
+func (b *keyspace) sweepExpired() errors.Error {
+	b.fileLock.Lock()
+	defer b.fileLock.Unlock()
+
+	now := time.Now().Unix()
+	keys := make([]string, 0, 16)
+
+	b.expiryLock.RLock()
+	for key, t := range b.expirations {
+		if t <= now {
+			keys = append(keys, key)
+		}
+	}
+	b.expiryLock.RUnlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, e := b.deleteLocked(keys)
+	return e
+}
+
+// startSweeper starts the sweeper of the keyspace, if it is not
+// already running. Keyspaces without expirations have no sweeper.
+// The sweeper runs until the keyspace is released.
+func (b *keyspace) startSweeper() {
+	b.sweeper.Do(func() {
+		go b.sweep()
+	})
+}
+
+func (b *keyspace) sweep() {
+	ticker := time.NewTicker(_EXPIRY_SWEEP_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.sweepExpired()
+		}
+	}
+}
@@ -0,0 +1,706 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// Index definitions are persisted in this subdirectory of the keyspace
+// directory, one JSON file per index.
+const _INDEX_DIR = ".indexes"
+
+// indexDefinition is the persisted form of a secondary index.
+type indexDefinition struct {
+	Name     string   `json:"name"`
+	RangeKey []string `json:"range_key"`
+	Where    string   `json:"where,omitempty"`
+	Deferred bool     `json:"deferred,omitempty"`
+}
+
+// indexEntry is a single (key, document id) pair held by a fileIndex.
+type indexEntry struct {
+	key value.Values
+	id  string
+}
+
+// fileIndex is an ordered, in-memory secondary index over a file-based
+// keyspace. It is rebuilt from the documents on disk whenever the
+// keyspace is loaded, and maintained by the keyspace on each mutation.
+type fileIndex struct {
+	sync.RWMutex
+	name     string
+	keyspace *keyspace
+	rangeKey expression.Expressions // As defined by CREATE INDEX
+	where    expression.Expression  // As defined by CREATE INDEX
+	keys     expression.Expressions // Formalized range key, for evaluation
+	cond     expression.Expression  // Formalized condition, for evaluation
+	deferred bool
+	entries  []*indexEntry
+	docs     map[string]*indexEntry
+}
+
+func newFileIndex(keyspace *keyspace, name string, rangeKey expression.Expressions,
+	where expression.Expression, deferred bool) (*fileIndex, errors.Error) {
+	fi := &fileIndex{
+		name:     name,
+		keyspace: keyspace,
+		rangeKey: rangeKey,
+		where:    where,
+		deferred: deferred,
+	}
+
+	formalizer := expression.NewFormalizer()
+	formalizer.Keyspace = keyspace.Name()
+
+	fi.keys = make(expression.Expressions, len(rangeKey))
+	for i, key := range rangeKey {
+		expr, err := formalizer.Map(key.Copy())
+		if err != nil {
+			return nil, errors.NewFileIdxDefinitionError(err, name)
+		}
+
+		fi.keys[i] = expr
+	}
+
+	if where != nil {
+		expr, err := formalizer.Map(where.Copy())
+		if err != nil {
+			return nil, errors.NewFileIdxDefinitionError(err, name)
+		}
+
+		fi.cond = expr
+	}
+
+	return fi, nil
+}
+
+func (fi *fileIndex) KeyspaceId() string {
+	return fi.keyspace.Id()
+}
+
+func (fi *fileIndex) Id() string {
+	return fi.Name()
+}
+
+func (fi *fileIndex) Name() string {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	return fi.name
+}
+
+func (fi *fileIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (fi *fileIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (fi *fileIndex) RangeKey() expression.Expressions {
+	return fi.rangeKey
+}
+
+func (fi *fileIndex) Condition() expression.Expression {
+	return fi.where
+}
+
+func (fi *fileIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	if fi.deferred {
+		return datastore.PENDING, "Index build has been deferred.", nil
+	}
+
+	return datastore.ONLINE, "", nil
+}
+
+func (fi *fileIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	// The statistics are computed under the lock, as mutations
+	// change the entries in place
+	return newFileStatistics(fi.live(fi.entries[fi.first(span):fi.last(span)])), nil
+}
+
+// live returns the entries of documents that have not expired. Expired
+// documents stay in the index until the sweeper removes them.
+func (fi *fileIndex) live(entries []*indexEntry) []*indexEntry {
+	var rv []*indexEntry
+	for i, ie := range entries {
+		if fi.keyspace.expired(ie.id) {
+			if rv == nil {
+				rv = make([]*indexEntry, i, len(entries))
+				copy(rv, entries[:i])
+			}
+
+			continue
+		}
+
+		if rv != nil {
+			rv = append(rv, ie)
+		}
+	}
+
+	if rv == nil {
+		return entries
+	}
+
+	return rv
+}
+
+func (fi *fileIndex) Drop() errors.Error {
+	return fi.keyspace.fi.dropIndex(fi)
+}
+
+func (fi *fileIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	// Copy out the matching entries, so that the lock is not held
+	// while the consumer drains the channel
+	fi.RLock()
+	if fi.deferred {
+		fi.RUnlock()
+		conn.Error(errors.NewFileIdxNotOnline(nil, fi.name))
+		return
+	}
+
+	entries := fi.entries[fi.first(span):fi.last(span)]
+	matched := make([]*indexEntry, len(entries))
+	copy(matched, entries)
+	fi.RUnlock()
+
+	// Distinct scans return each document once
+	var sent map[string]bool
+	if distinct {
+		sent = make(map[string]bool, len(matched))
+	}
+
+	var n int64
+	for _, ie := range matched {
+		if limit > 0 && n >= limit {
+			return
+		}
+
+		// Expired documents are skipped, even if not yet removed
+		if fi.keyspace.expired(ie.id) || (distinct && sent[ie.id]) {
+			continue
+		}
+
+		if distinct {
+			sent[ie.id] = true
+		}
+
+		select {
+		case <-conn.StopChannel():
+			return
+		default:
+		}
+
+		entry := datastore.IndexEntry{EntryKey: ie.key, PrimaryKey: ie.id}
+		conn.EntryChannel() <- &entry
+		n++
+	}
+}
+
+// first returns the position of the first entry at or above the low
+// bound of the span.
+func (fi *fileIndex) first(span *datastore.Span) int {
+	low, incl := spanLow(span)
+	if len(low) == 0 {
+		return 0
+	}
+
+	return sort.Search(len(fi.entries), func(i int) bool {
+		c := comparePrefix(fi.entries[i].key, low)
+		return c > 0 || (c == 0 && incl)
+	})
+}
+
+// last returns the position just past the last entry at or below the
+// high bound of the span.
+func (fi *fileIndex) last(span *datastore.Span) int {
+	high, incl := spanHigh(span)
+	n := len(fi.entries)
+	if len(high) == 0 {
+		return n
+	}
+
+	last := sort.Search(n, func(i int) bool {
+		c := comparePrefix(fi.entries[i].key, high)
+		return c > 0 || (c == 0 && !incl)
+	})
+
+	if first := fi.first(span); last < first {
+		return first
+	}
+
+	return last
+}
+
+func spanLow(span *datastore.Span) (value.Values, bool) {
+	if span == nil {
+		return nil, true
+	}
+
+	if len(span.Seek) > 0 {
+		return span.Seek, true
+	}
+
+	return span.Range.Low, span.Range.Inclusion&datastore.LOW != 0
+}
+
+func spanHigh(span *datastore.Span) (value.Values, bool) {
+	if span == nil {
+		return nil, true
+	}
+
+	if len(span.Seek) > 0 {
+		return span.Seek, true
+	}
+
+	return span.Range.High, span.Range.Inclusion&datastore.HIGH != 0
+}
+
+// comparePrefix collates key against bound, considering only as many
+// leading positions as the bound has. Nil positions in the bound are
+// unconstrained.
+func comparePrefix(key, bound value.Values) int {
+	for i, b := range bound {
+		if b == nil {
+			continue
+		}
+
+		if i >= len(key) {
+			return -1
+		}
+
+		c := key[i].Collate(b)
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func compareKeys(key1, key2 value.Values) int {
+	for i := 0; i < len(key1) && i < len(key2); i++ {
+		c := key1[i].Collate(key2[i])
+		if c != 0 {
+			return c
+		}
+	}
+
+	return len(key1) - len(key2)
+}
+
+func lessEntry(ie1, ie2 *indexEntry) bool {
+	c := compareKeys(ie1.key, ie2.key)
+	return c < 0 || (c == 0 && ie1.id < ie2.id)
+}
+
+// build (re)populates the index from the documents on disk.
+func (fi *fileIndex) build() errors.Error {
+	dirEntries, er := ioutil.ReadDir(fi.keyspace.path())
+	if er != nil {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	entries := make([]*indexEntry, 0, len(dirEntries))
+	docs := make(map[string]*indexEntry, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		doc, e := fetch(filepath.Join(fi.keyspace.path(), dirEntry.Name()))
+		if e != nil {
+			return e
+		}
+
+		if doc == nil {
+			continue
+		}
+
+		ie, e := fi.evaluate(documentPathToId(dirEntry.Name()), doc)
+		if e != nil {
+			return e
+		}
+
+		if ie != nil {
+			entries = append(entries, ie)
+			docs[ie.id] = ie
+		}
+	}
+
+	sort.Sort(indexEntries(entries))
+
+	fi.Lock()
+	fi.entries = entries
+	fi.docs = docs
+	fi.Unlock()
+	return nil
+}
+
+// evaluate computes the index entry for a document. It returns nil if
+// the document is not covered by the index.
+func (fi *fileIndex) evaluate(id string, doc value.AnnotatedValue) (*indexEntry, errors.Error) {
+	item := value.NewAnnotatedValue(map[string]interface{}{fi.keyspace.Name(): doc})
+	context := &indexContext{now: time.Now()}
+
+	if fi.cond != nil {
+		cv, err := fi.cond.Evaluate(item, context)
+		if err != nil {
+			return nil, errors.NewFileDatastoreError(err, "evaluating index condition of "+fi.Name())
+		}
+
+		if !cv.Truth() {
+			return nil, nil
+		}
+	}
+
+	key := make(value.Values, len(fi.keys))
+	for i, expr := range fi.keys {
+		kv, err := expr.Evaluate(item, context)
+		if err != nil {
+			return nil, errors.NewFileDatastoreError(err, "evaluating index key of "+fi.Name())
+		}
+
+		key[i] = kv
+	}
+
+	// Documents lacking the leading key are not indexed
+	if len(key) > 0 && key[0].Type() == value.MISSING {
+		return nil, nil
+	}
+
+	return &indexEntry{key: key, id: id}, nil
+}
+
+// update replaces the entry for a document. A nil doc removes it.
+func (fi *fileIndex) update(id string, doc value.AnnotatedValue) errors.Error {
+	var ie *indexEntry
+	if doc != nil {
+		var e errors.Error
+		ie, e = fi.evaluate(id, doc)
+		if e != nil {
+			return e
+		}
+	}
+
+	fi.Lock()
+	defer fi.Unlock()
+
+	if fi.deferred {
+		return nil
+	}
+
+	if old, ok := fi.docs[id]; ok {
+		fi.remove(old)
+		delete(fi.docs, id)
+	}
+
+	if ie != nil {
+		fi.insert(ie)
+		fi.docs[id] = ie
+	}
+
+	return nil
+}
+
+func (fi *fileIndex) insert(ie *indexEntry) {
+	i := sort.Search(len(fi.entries), func(i int) bool {
+		return !lessEntry(fi.entries[i], ie)
+	})
+
+	fi.entries = append(fi.entries, nil)
+	copy(fi.entries[i+1:], fi.entries[i:])
+	fi.entries[i] = ie
+}
+
+func (fi *fileIndex) remove(ie *indexEntry) {
+	i := sort.Search(len(fi.entries), func(i int) bool {
+		return !lessEntry(fi.entries[i], ie)
+	})
+
+	for ; i < len(fi.entries); i++ {
+		if fi.entries[i] == ie {
+			fi.entries = append(fi.entries[:i], fi.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (fi *fileIndex) definition() *indexDefinition {
+	def := &indexDefinition{
+		Name:     fi.name,
+		RangeKey: make([]string, len(fi.rangeKey)),
+		Deferred: fi.deferred,
+	}
+
+	stringer := expression.NewStringer()
+	for i, key := range fi.rangeKey {
+		def.RangeKey[i] = stringer.Visit(key)
+	}
+
+	if fi.where != nil {
+		def.Where = stringer.Visit(fi.where)
+	}
+
+	return def
+}
+
+type indexEntries []*indexEntry
+
+func (this indexEntries) Len() int           { return len(this) }
+func (this indexEntries) Less(i, j int) bool { return lessEntry(this[i], this[j]) }
+func (this indexEntries) Swap(i, j int)      { this[i], this[j] = this[j], this[i] }
+
+// indexContext is the expression context used to evaluate index keys.
+type indexContext struct {
+	now time.Time
+}
+
+func (this *indexContext) Now() time.Time {
+	return this.now
+}
+
+// fileStatistics summarizes a contiguous run of index entries. It is
+// computed when it is created, and does not refer to the entries.
+type fileStatistics struct {
+	count    int64
+	distinct int64
+	min      value.Values
+	max      value.Values
+}
+
+func newFileStatistics(entries []*indexEntry) *fileStatistics {
+	rv := &fileStatistics{count: int64(len(entries))}
+	if len(entries) == 0 {
+		return rv
+	}
+
+	rv.min = entries[0].key
+	rv.max = entries[len(entries)-1].key
+
+	for i, ie := range entries {
+		if i == 0 || compareKeys(entries[i-1].key, ie.key) != 0 {
+			rv.distinct++
+		}
+	}
+
+	return rv
+}
+
+func (this *fileStatistics) Count() (int64, errors.Error) {
+	return this.count, nil
+}
+
+func (this *fileStatistics) Min() (value.Values, errors.Error) {
+	return this.min, nil
+}
+
+func (this *fileStatistics) Max() (value.Values, errors.Error) {
+	return this.max, nil
+}
+
+func (this *fileStatistics) DistinctCount() (int64, errors.Error) {
+	return this.distinct, nil
+}
+
+func (this *fileStatistics) Bins() ([]datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (fi *fileIndexer) indexPath() string {
+	return filepath.Join(fi.keyspace.path(), _INDEX_DIR)
+}
+
+// loadIndexes restores and builds the persisted secondary indexes.
+func (fi *fileIndexer) loadIndexes() errors.Error {
+	dirEntries, er := ioutil.ReadDir(fi.indexPath())
+	if er != nil {
+		if os.IsNotExist(er) {
+			return nil
+		}
+
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		bytes, er := ioutil.ReadFile(filepath.Join(fi.indexPath(), dirEntry.Name()))
+		if er != nil {
+			return errors.NewFileDatastoreError(er, "")
+		}
+
+		var def indexDefinition
+		er = json.Unmarshal(bytes, &def)
+		if er != nil {
+			return errors.NewFileIdxDefinitionError(er, dirEntry.Name())
+		}
+
+		rangeKey := make(expression.Expressions, len(def.RangeKey))
+		for i, key := range def.RangeKey {
+			rangeKey[i], er = parser.Parse(key)
+			if er != nil {
+				return errors.NewFileIdxDefinitionError(er, def.Name)
+			}
+		}
+
+		var where expression.Expression
+		if def.Where != "" {
+			where, er = parser.Parse(def.Where)
+			if er != nil {
+				return errors.NewFileIdxDefinitionError(er, def.Name)
+			}
+		}
+
+		index, e := newFileIndex(fi.keyspace, def.Name, rangeKey, where, def.Deferred)
+		if e != nil {
+			return e
+		}
+
+		if !index.deferred {
+			e = index.build()
+			if e != nil {
+				return e
+			}
+		}
+
+		fi.indexes[index.name] = index
+	}
+
+	return nil
+}
+
+func (fi *fileIndexer) saveIndex(index *fileIndex) errors.Error {
+	er := os.MkdirAll(fi.indexPath(), 0755)
+	if er != nil {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	bytes, er := json.Marshal(index.definition())
+	if er != nil {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	er = ioutil.WriteFile(filepath.Join(fi.indexPath(), index.name+".json"), bytes, 0666)
+	if er != nil {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	return nil
+}
+
+func (fi *fileIndexer) dropIndex(index *fileIndex) errors.Error {
+	fi.Lock()
+	defer fi.Unlock()
+
+	if fi.indexes[index.name] != index {
+		return errors.NewFileIdxNotFound(nil, index.name)
+	}
+
+	er := os.Remove(filepath.Join(fi.indexPath(), index.name+".json"))
+	if er != nil && !os.IsNotExist(er) {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	delete(fi.indexes, index.name)
+	return nil
+}
+
+// RenameIndex renames a secondary index and its persisted definition.
+func (fi *fileIndexer) RenameIndex(index datastore.Index, name string) errors.Error {
+	fi.Lock()
+	defer fi.Unlock()
+
+	oldName := index.Name()
+	if fi.indexes[oldName] != index {
+		return errors.NewFileIdxNotFound(nil, oldName)
+	}
+
+	findex, ok := index.(*fileIndex)
+	if !ok {
+		return errors.NewFileNotSupported(nil, "renaming primary index "+oldName)
+	}
+
+	if name == oldName {
+		return nil
+	}
+
+	if _, exists := fi.indexes[name]; exists {
+		return errors.NewFileIdxExists(nil, name)
+	}
+
+	findex.Lock()
+	findex.name = name
+	findex.Unlock()
+
+	e := fi.saveIndex(findex)
+	if e != nil {
+		findex.Lock()
+		findex.name = oldName
+		findex.Unlock()
+		return e
+	}
+
+	er := os.Remove(filepath.Join(fi.indexPath(), oldName+".json"))
+	if er != nil && !os.IsNotExist(er) {
+		return errors.NewFileDatastoreError(er, "")
+	}
+
+	delete(fi.indexes, oldName)
+	fi.indexes[name] = findex
+	return nil
+}
+
+// updateIndexes maintains the secondary indexes after a document has
+// been written. A nil doc means the document has been deleted.
+func (fi *fileIndexer) updateIndexes(id string, doc value.AnnotatedValue) errors.Error {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	for _, index := range fi.indexes {
+		index, ok := index.(*fileIndex)
+		if !ok {
+			continue
+		}
+
+		e := index.update(id, doc)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
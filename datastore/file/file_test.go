@@ -11,11 +11,18 @@ package file
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
 )
 
 func TestFile(t *testing.T) {
@@ -180,6 +187,542 @@ func TestFile(t *testing.T) {
 
 }
 
+func TestFileIndex(t *testing.T) {
+	dir, er := ioutil.TempDir("", "file_index")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	ksdir := filepath.Join(dir, "default", "people")
+	if er = os.MkdirAll(ksdir, 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	docs := map[string]string{
+		"p1": `{"name": "ann", "age": 30, "type": "person"}`,
+		"p2": `{"name": "bob", "age": 20, "type": "person"}`,
+		"p3": `{"name": "cat", "age": 40, "type": "person"}`,
+		"p4": `{"name": "dog", "age": 5, "type": "pet"}`,
+		"p5": `{"name": "eel", "type": "person"}`,
+	}
+
+	for k, v := range docs {
+		if er = ioutil.WriteFile(filepath.Join(ksdir, k+".json"), []byte(v), 0666); er != nil {
+			t.Fatalf("failed to write %s: %v", k, er)
+		}
+	}
+
+	keyspace := fileKeyspace(t, dir)
+	indexer, err := keyspace.Indexer(datastore.DEFAULT)
+	if err != nil {
+		t.Fatalf("failed to get indexer: %v", err)
+	}
+
+	age := expression.NewIdentifier("age")
+	cond := expression.NewEq(expression.NewIdentifier("type"), expression.NewConstant("person"))
+	_, err = indexer.CreateIndex("age_idx", nil, expression.Expressions{age}, cond, nil)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	_, err = indexer.CreateIndex("age_idx", nil, expression.Expressions{age}, cond, nil)
+	if err == nil {
+		t.Errorf("expected duplicate index creation to fail")
+	}
+
+	index, err := indexer.IndexByName("age_idx")
+	if err != nil {
+		t.Fatalf("failed to get index: %v", err)
+	}
+
+	span := &datastore.Span{}
+	span.Range.Low = value.Values{value.NewValue(20.0)}
+	span.Range.High = value.Values{value.NewValue(40.0)}
+	span.Range.Inclusion = datastore.LOW
+
+	checkScan(t, index, span, []string{"p2", "p1"})
+
+	span.Range.Inclusion = datastore.BOTH
+	checkScan(t, index, span, []string{"p2", "p1", "p3"})
+
+	stats, err := index.Statistics(span)
+	if err != nil {
+		t.Fatalf("failed to get statistics: %v", err)
+	}
+
+	count, _ := stats.Count()
+	if count != 3 {
+		t.Errorf("expected statistics count 3, got %d", count)
+	}
+
+	distinct, _ := stats.DistinctCount()
+	min, _ := stats.Min()
+	max, _ := stats.Max()
+	if distinct != 3 || len(min) != 1 || min[0].Actual() != 20.0 || len(max) != 1 || max[0].Actual() != 40.0 {
+		t.Errorf("expected statistics distinct 3, min 20 and max 40, got %d, %v and %v", distinct, min, max)
+	}
+
+	checkLimitedScan(t, index, span, true, 2, []string{"p2", "p1"})
+
+	// Mutations maintain the index
+	_, err = keyspace.Insert([]datastore.Pair{{Key: "p6", Value: value.NewValue(map[string]interface{}{
+		"name": "fox", "age": 25.0, "type": "person"})}})
+	if err != nil {
+		t.Fatalf("failed to insert p6: %v", err)
+	}
+
+	_, err = keyspace.Upsert([]datastore.Pair{{Key: "p1", Value: value.NewValue(map[string]interface{}{
+		"name": "ann", "age": 50.0, "type": "person"})}})
+	if err != nil {
+		t.Fatalf("failed to upsert p1: %v", err)
+	}
+
+	_, err = keyspace.Delete([]string{"p3"})
+	if err != nil {
+		t.Fatalf("failed to delete p3: %v", err)
+	}
+
+	checkScan(t, index, span, []string{"p2", "p6"})
+	checkScan(t, index, &datastore.Span{}, []string{"p2", "p6", "p1"})
+
+	// Statistics do not change with the index
+	count, _ = stats.Count()
+	min, _ = stats.Min()
+	if count != 3 || min[0].Actual() != 20.0 {
+		t.Errorf("expected statistics count 3 and min 20 after mutations, got %d and %v", count, min)
+	}
+
+	// Index definitions survive a reload of the datastore
+	keyspace = fileKeyspace(t, dir)
+	indexer, _ = keyspace.Indexer(datastore.DEFAULT)
+	index, err = indexer.IndexByName("age_idx")
+	if err != nil {
+		t.Fatalf("failed to reload index: %v", err)
+	}
+
+	checkScan(t, index, span, []string{"p2", "p6"})
+
+	count, err = keyspace.Count()
+	if err != nil || count != 5 {
+		t.Errorf("expected keyspace count 5, got %d, %v", count, err)
+	}
+
+	// Renamed indexes survive a reload of the datastore
+	renamer := indexer.(datastore.IndexRenamer)
+	err = renamer.RenameIndex(index, "person_age")
+	if err != nil {
+		t.Fatalf("failed to rename index: %v", err)
+	}
+
+	primary, _ := indexer.IndexByName("#primary")
+	err = renamer.RenameIndex(primary, "age_idx")
+	if err == nil {
+		t.Errorf("expected primary index rename to fail")
+	}
+
+	keyspace = fileKeyspace(t, dir)
+	indexer, _ = keyspace.Indexer(datastore.DEFAULT)
+	_, err = indexer.IndexByName("age_idx")
+	if err == nil {
+		t.Errorf("expected renamed index to be gone")
+	}
+
+	index, err = indexer.IndexByName("person_age")
+	if err != nil {
+		t.Fatalf("failed to reload renamed index: %v", err)
+	}
+
+	checkScan(t, index, span, []string{"p2", "p6"})
+
+	err = index.Drop()
+	if err != nil {
+		t.Errorf("failed to drop index: %v", err)
+	}
+
+	_, err = indexer.IndexByName("person_age")
+	if err == nil {
+		t.Errorf("expected dropped index to be gone")
+	}
+}
+
+func TestFileCas(t *testing.T) {
+	dir, er := ioutil.TempDir("", "file_cas")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	ksdir := filepath.Join(dir, "default", "people")
+	if er = os.MkdirAll(ksdir, 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	for _, k := range []string{"p1", "p2"} {
+		if er = ioutil.WriteFile(filepath.Join(ksdir, k+".json"), []byte(`{"name": "`+k+`"}`), 0666); er != nil {
+			t.Fatalf("failed to write %s: %v", k, er)
+		}
+	}
+
+	keyspace := fileKeyspace(t, dir)
+	casKeyspace, ok := keyspace.(datastore.CasKeyspace)
+	if !ok {
+		t.Fatalf("expected file keyspace to support CAS")
+	}
+
+	pairs, err := keyspace.Fetch([]string{"p1", "p2"})
+	if err != nil || len(pairs) != 2 {
+		t.Fatalf("failed to fetch p1 and p2: %v", err)
+	}
+
+	cas := make([]uint64, len(pairs))
+	for i, pair := range pairs {
+		meta := pair.Value.GetAttachment("meta").(map[string]interface{})
+		cas[i] = uint64(meta["cas"].(float64))
+		if cas[i] == 0 {
+			t.Errorf("expected non-zero CAS for %s", pair.Key)
+		}
+	}
+
+	// Updating p1 changes its CAS, so a second update with the old CAS fails
+	updates := []datastore.Pair{{Key: "p1", Value: value.NewValue(map[string]interface{}{"name": "ann"}), Cas: cas[0]}}
+	updated, errs := casKeyspace.UpdateCas(updates)
+	if len(errs) != 0 || len(updated) != 1 {
+		t.Fatalf("failed to update p1: %v", errs)
+	}
+
+	updated, errs = casKeyspace.UpdateCas(updates)
+	if len(errs) != 1 || len(updated) != 0 || errs[0].Code() != 15015 {
+		t.Errorf("expected CAS mismatch updating p1, got %v", errs)
+	}
+
+	// A mismatch does not prevent the other deletes
+	deletes := []datastore.Pair{{Key: "p1", Cas: cas[0]}, {Key: "p2", Cas: cas[1]}}
+	deleted, errs := casKeyspace.DeleteCas(deletes)
+	if len(errs) != 1 || len(deleted) != 1 || deleted[0] != "p2" {
+		t.Errorf("expected to delete p2 alone, got %v and %v", deleted, errs)
+	}
+
+	pairs, err = keyspace.Fetch([]string{"p1", "p2"})
+	if err != nil || len(pairs) != 1 || pairs[0].Key != "p1" {
+		t.Errorf("expected p1 alone to remain: %v", err)
+	}
+}
+
+func TestFileExpiration(t *testing.T) {
+	dir, er := ioutil.TempDir("", "file_expiration")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	ksdir := filepath.Join(dir, "default", "people")
+	if er = os.MkdirAll(ksdir, 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	sessions := fileKeyspace(t, dir)
+	doc := value.NewValue(map[string]interface{}{"user": "ann"})
+
+	// An expiration over 30 days is a Unix time; s2 has long expired
+	_, err := sessions.Upsert([]datastore.Pair{
+		{Key: "s1", Value: doc, Expiration: 4000000000},
+		{Key: "s2", Value: doc, Expiration: 3000000},
+		{Key: "s3", Value: doc},
+	})
+	if err != nil {
+		t.Fatalf("failed to upsert sessions: %v", err)
+	}
+
+	pairs, err := sessions.Fetch([]string{"s1", "s2", "s3"})
+	if err != nil || len(pairs) != 2 {
+		t.Fatalf("expected s1 and s3 alone, got %v: %v", pairs, err)
+	}
+
+	for _, pair := range pairs {
+		meta := pair.Value.GetAttachment("meta").(map[string]interface{})
+		expected := map[string]float64{"s1": 4000000000, "s3": 0}[pair.Key]
+		if meta["expiration"] != expected {
+			t.Errorf("expected expiration %v for %s, got %v", expected, pair.Key, meta["expiration"])
+		}
+	}
+
+	// Covering scans read the indexes alone, which skip s2 until it is swept
+	indexer, _ := sessions.Indexer(datastore.DEFAULT)
+	index, err := indexer.CreateIndex("user_idx", nil,
+		expression.Expressions{expression.NewIdentifier("user")}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	checkScan(t, index, &datastore.Span{}, []string{"s1", "s3"})
+	checkLimitedScan(t, index, &datastore.Span{}, false, 1, []string{"s1"})
+
+	primary, _ := indexer.IndexByName("#primary")
+	checkScan(t, primary, &datastore.Span{}, []string{"s1", "s3"})
+
+	stats, err := index.Statistics(nil)
+	if err != nil {
+		t.Fatalf("failed to get statistics: %v", err)
+	}
+
+	if count, _ := stats.Count(); count != 2 {
+		t.Errorf("expected statistics count 2, got %d", count)
+	}
+
+	if count, _ := sessions.Count(); count != 2 {
+		t.Errorf("expected keyspace count 2, got %d", count)
+	}
+
+	// Expirations survive a restart, and the sweeper removes expired documents
+	sessions = fileKeyspace(t, dir)
+	e := sessions.(*keyspace).sweepExpired()
+	if e != nil {
+		t.Fatalf("failed to sweep: %v", e)
+	}
+
+	if _, er = os.Stat(filepath.Join(ksdir, "s2.json")); !os.IsNotExist(er) {
+		t.Errorf("expected s2 to be removed: %v", er)
+	}
+
+	pairs, err = sessions.Fetch([]string{"s1"})
+	if err != nil || len(pairs) != 1 {
+		t.Fatalf("failed to fetch s1: %v", err)
+	}
+
+	// A mutation without an expiration clears it
+	_, err = sessions.Update([]datastore.Pair{{Key: "s1", Value: doc}})
+	if err != nil {
+		t.Fatalf("failed to update s1: %v", err)
+	}
+
+	pairs, err = sessions.Fetch([]string{"s1"})
+	if err != nil || len(pairs) != 1 {
+		t.Fatalf("failed to fetch s1: %v", err)
+	}
+
+	meta := pairs[0].Value.GetAttachment("meta").(map[string]interface{})
+	if meta["expiration"] != 0.0 {
+		t.Errorf("expected no expiration for s1, got %v", meta["expiration"])
+	}
+
+	// Releasing the keyspace stops its sweeper, and can be repeated
+	done := make(chan bool)
+	go func() {
+		sessions.(*keyspace).sweep()
+		close(done)
+	}()
+
+	sessions.Release()
+	sessions.Release()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("expected the sweeper to stop")
+	}
+}
+
+func TestFileAuthorize(t *testing.T) {
+	dir, er := ioutil.TempDir("", "file_authorize")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	if er = os.MkdirAll(filepath.Join(dir, "default", "people"), 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	// Without a users file, every request is authorized
+	store, err := NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	read := datastore.Privileges{"default:people": datastore.PRIV_READ}
+	if err = store.Authorize(read, nil); err != nil {
+		t.Errorf("expected anonymous read without users, got %v", err)
+	}
+
+	users := `{
+	    "ann": { "password": "a", "roles": { "default:*": "read", "Default:People": "write" } },
+	    "bob": { "password": "b", "roles": { "default:orders": "ddl" } },
+	    "root": { "password": "r", "roles": { "*": "ddl" } }
+	}`
+	if er = ioutil.WriteFile(filepath.Join(dir, _USERS_FILE), []byte(users), 0666); er != nil {
+		t.Fatalf("failed to write users: %v", er)
+	}
+
+	store, err = NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	write := datastore.Privileges{"default:people": datastore.PRIV_WRITE}
+	ddl := datastore.Privileges{"default:people": datastore.PRIV_DDL}
+	system := datastore.Privileges{"#system:keyspaces": datastore.PRIV_READ}
+
+	cases := []struct {
+		privileges  datastore.Privileges
+		credentials datastore.Credentials
+		ok          bool
+	}{
+		{read, nil, false},
+		{system, nil, true},
+		{read, datastore.Credentials{"ann": "x"}, false},
+		{read, datastore.Credentials{"nobody": "a"}, false},
+		{read, datastore.Credentials{"ann": "a"}, true},
+		{write, datastore.Credentials{"ann": "a"}, true},
+		{ddl, datastore.Credentials{"ann": "a"}, false},
+		{read, datastore.Credentials{"bob": "b"}, false},
+		{ddl, datastore.Credentials{"bob": "b", "root": "r"}, true},
+		{datastore.Privileges{"DEFAULT:orders": datastore.PRIV_DDL}, datastore.Credentials{"bob": "b"}, true},
+	}
+
+	for i, c := range cases {
+		err = store.Authorize(c.privileges, c.credentials)
+		if c.ok && err != nil {
+			t.Errorf("case %d: expected authorization, got %v", i, err)
+		} else if !c.ok && err == nil {
+			t.Errorf("case %d: expected authorization failure", i)
+		}
+	}
+
+	// An invalid role fails the store
+	users = `{ "ann": { "password": "a", "roles": { "default:people": "owner" } } }`
+	if er = ioutil.WriteFile(filepath.Join(dir, _USERS_FILE), []byte(users), 0666); er != nil {
+		t.Fatalf("failed to write users: %v", er)
+	}
+
+	if _, err = NewDatastore(dir); err == nil {
+		t.Errorf("expected invalid role to fail")
+	}
+}
+
+func TestFileRoles(t *testing.T) {
+	dir, er := ioutil.TempDir("", "file_roles")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	// Roles cannot be managed without a users file
+	manager := store.(datastore.RoleManager)
+	if err = manager.GrantRole(datastore.PRIV_READ, "default:people", []string{"ann"}); err == nil {
+		t.Errorf("expected grant without users to fail")
+	}
+
+	users := `{
+	    "ann": { "password": "a", "roles": { "default:*": "read" } },
+	    "bob": { "password": "b" }
+	}`
+	if er = ioutil.WriteFile(filepath.Join(dir, _USERS_FILE), []byte(users), 0666); er != nil {
+		t.Fatalf("failed to write users: %v", er)
+	}
+
+	store, err = NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	manager = store.(datastore.RoleManager)
+	err = manager.GrantRole(datastore.PRIV_DDL, "default:people", []string{"ann", "bob"})
+	if err != nil {
+		t.Fatalf("failed to grant role: %v", err)
+	}
+
+	// Revoking ddl leaves write; no user changes if any is unknown
+	err = manager.RevokeRole(datastore.PRIV_DDL, "default:people", []string{"ann"})
+	if err != nil {
+		t.Fatalf("failed to revoke role: %v", err)
+	}
+
+	err = manager.RevokeRole(datastore.PRIV_READ, "default:people", []string{"bob", "carl"})
+	if err == nil {
+		t.Errorf("expected revoke from unknown user to fail")
+	}
+
+	// Roles survive a restart
+	store, err = NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	roles, err := store.(datastore.RoleManager).UserRoles()
+	if err != nil {
+		t.Fatalf("failed to get roles: %v", err)
+	}
+
+	expected := map[string]datastore.Privileges{
+		"ann": {"default:*": datastore.PRIV_READ, "default:people": datastore.PRIV_WRITE},
+		"bob": {"default:people": datastore.PRIV_DDL},
+	}
+	if !reflect.DeepEqual(roles, expected) {
+		t.Errorf("expected roles %v, got %v", expected, roles)
+	}
+
+	ddl := datastore.Privileges{"default:people": datastore.PRIV_DDL}
+	if err = store.Authorize(ddl, datastore.Credentials{"ann": "a"}); err == nil {
+		t.Errorf("expected ddl to be revoked from ann")
+	}
+
+	if err = store.Authorize(ddl, datastore.Credentials{"bob": "b"}); err != nil {
+		t.Errorf("expected ddl to be granted to bob, got %v", err)
+	}
+}
+
+func fileKeyspace(t *testing.T, dir string) datastore.Keyspace {
+	store, err := NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, err := store.NamespaceByName("default")
+	if err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+
+	keyspace, err := namespace.KeyspaceByName("people")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	return keyspace
+}
+
+func checkScan(t *testing.T, index datastore.Index, span *datastore.Span, expected []string) {
+	checkLimitedScan(t, index, span, false, math.MaxInt64, expected)
+}
+
+func checkLimitedScan(t *testing.T, index datastore.Index, span *datastore.Span, distinct bool,
+	limit int64, expected []string) {
+	conn := datastore.NewIndexConnection(&testingContext{t})
+	go index.Scan(span, distinct, limit, datastore.UNBOUNDED, nil, conn)
+
+	keys := make([]string, 0, len(expected))
+	for entry := range conn.EntryChannel() {
+		keys = append(keys, entry.PrimaryKey)
+	}
+
+	if len(keys) != len(expected) {
+		t.Errorf("expected scan %v, got %v", expected, keys)
+		return
+	}
+
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("expected scan %v, got %v", expected, keys)
+			return
+		}
+	}
+}
+
 type testingContext struct {
 	t *testing.T
 }
@@ -0,0 +1,88 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+)
+
+// If this file exists at the root of the store, it defines the users
+// of the store and their roles, and Authorize requires credentials.
+// GRANT and REVOKE rewrite it.
+const _USERS_FILE = "users.json"
+
+func (s *store) usersPath() string {
+	return filepath.Join(s.path, _USERS_FILE)
+}
+
+// loadUsers loads the users file, if any. Without one, every request
+// is authorized.
+func (s *store) loadUsers() errors.Error {
+	if _, er := os.Stat(s.usersPath()); os.IsNotExist(er) {
+		return nil
+	}
+
+	users, e := datastore.LoadUsers(s.usersPath())
+	if e != nil {
+		return e
+	}
+
+	s.users = users
+	return nil
+}
+
+func (s *store) GrantRole(role datastore.Privilege, keyspace string, users []string) errors.Error {
+	return s.updateUsers(func(u datastore.Users) errors.Error {
+		return u.Grant(role, keyspace, users)
+	})
+}
+
+func (s *store) RevokeRole(role datastore.Privilege, keyspace string, users []string) errors.Error {
+	return s.updateUsers(func(u datastore.Users) errors.Error {
+		return u.Revoke(role, keyspace, users)
+	})
+}
+
+func (s *store) UserRoles() (map[string]datastore.Privileges, errors.Error) {
+	s.usersLock.RLock()
+	defer s.usersLock.RUnlock()
+
+	return s.users.Roles(), nil
+}
+
+// updateUsers applies the update to a copy of the users, and replaces
+// the users only once the copy is saved. Roles can only be managed
+// for users defined in the users file.
+func (s *store) updateUsers(update func(datastore.Users) errors.Error) errors.Error {
+	s.usersLock.Lock()
+	defer s.usersLock.Unlock()
+
+	if s.users == nil {
+		return errors.NewDatastoreRolesNotSupportedError(nil, s.URL()+" without "+_USERS_FILE)
+	}
+
+	users := s.users.Copy()
+	e := update(users)
+	if e != nil {
+		return e
+	}
+
+	e = users.Save(s.usersPath())
+	if e != nil {
+		return e
+	}
+
+	s.users = users
+	return nil
+}
@@ -0,0 +1,346 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package datastore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Histogram is an equi-depth histogram of the values of an expression
+over the documents of a keyspace, collected by UPDATE STATISTICS. It
+implements Statistics, so that the optimizer can estimate spans on
+indexes that do not provide statistics of their own, in any
+datastore. Histograms are kept in memory, and are listed by the
+system:histograms keyspace. If the configuration store can persist
+them, they are also stored there as the JSON of their documents, and
+reloaded by each query node.
+*/
+type Histogram struct {
+	namespace  string
+	keyspace   string
+	expression string
+	updated    time.Time
+	count      int64
+	distinct   int64
+	bins       []*histogramBin
+}
+
+/*
+NewHistogram builds a histogram of at most nbins bins from a sample of
+the values of the expression. The counts are scaled from the size of
+the sample to total, the number of documents in the keyspace; the
+distinct counts are those of the sample.
+*/
+func NewHistogram(namespace, keyspace, expression string, sample value.Values,
+	total int64, nbins int) *Histogram {
+	rv := &Histogram{
+		namespace:  namespace,
+		keyspace:   keyspace,
+		expression: expression,
+		updated:    time.Now(),
+	}
+
+	if len(sample) == 0 || nbins <= 0 {
+		return rv
+	}
+
+	sorted := make(value.Values, len(sample))
+	copy(sorted, sample)
+	sort.Sort(collatedValues(sorted))
+
+	scale := float64(total) / float64(len(sorted))
+	depth := (len(sorted) + nbins - 1) / nbins
+
+	for start := 0; start < len(sorted); start += depth {
+		end := start + depth
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+
+		bin := &histogramBin{
+			count: int64(float64(end-start)*scale + 0.5),
+			min:   sorted[start],
+			max:   sorted[end-1],
+		}
+
+		for i := start; i < end; i++ {
+			if i == start || sorted[i].Collate(sorted[i-1]) != 0 {
+				bin.distinct++
+			}
+
+			if i == 0 || sorted[i].Collate(sorted[i-1]) != 0 {
+				rv.distinct++
+			}
+		}
+
+		rv.count += bin.count
+		rv.bins = append(rv.bins, bin)
+	}
+
+	return rv
+}
+
+/*
+Returns the key of the histogram in the system:histograms keyspace.
+*/
+func (this *Histogram) Key() string {
+	return histogramKey(this.namespace, this.keyspace, this.expression)
+}
+
+func (this *Histogram) Namespace() string {
+	return this.namespace
+}
+
+func (this *Histogram) Keyspace() string {
+	return this.keyspace
+}
+
+func (this *Histogram) Expression() string {
+	return this.expression
+}
+
+func (this *Histogram) Updated() time.Time {
+	return this.updated
+}
+
+func (this *Histogram) Count() (int64, errors.Error) {
+	return this.count, nil
+}
+
+func (this *Histogram) Min() (value.Values, errors.Error) {
+	if len(this.bins) == 0 {
+		return nil, nil
+	}
+
+	return this.bins[0].Min()
+}
+
+func (this *Histogram) Max() (value.Values, errors.Error) {
+	if len(this.bins) == 0 {
+		return nil, nil
+	}
+
+	return this.bins[len(this.bins)-1].Max()
+}
+
+func (this *Histogram) DistinctCount() (int64, errors.Error) {
+	return this.distinct, nil
+}
+
+func (this *Histogram) Bins() ([]Statistics, errors.Error) {
+	rv := make([]Statistics, len(this.bins))
+	for i, bin := range this.bins {
+		rv[i] = bin
+	}
+
+	return rv, nil
+}
+
+/*
+Returns the histogram as a document of the system:histograms
+keyspace.
+*/
+func (this *Histogram) Value() value.Value {
+	bins := make([]interface{}, len(this.bins))
+	for i, bin := range this.bins {
+		bins[i] = map[string]interface{}{
+			"count":    bin.count,
+			"distinct": bin.distinct,
+			"min":      bin.min,
+			"max":      bin.max,
+		}
+	}
+
+	return value.NewValue(map[string]interface{}{
+		"namespace":  this.namespace,
+		"keyspace":   this.keyspace,
+		"expression": this.expression,
+		"updated":    this.updated.Format(time.RFC3339),
+		"count":      this.count,
+		"distinct":   this.distinct,
+		"bins":       bins,
+	})
+}
+
+/*
+Rebuilds a histogram from its system:histograms document, as returned
+by Value().
+*/
+func NewHistogramFromValue(val value.Value) (*Histogram, errors.Error) {
+	if val.Type() != value.OBJECT {
+		return nil, errors.NewError(nil, "Invalid histogram: not an object.")
+	}
+
+	rv := &Histogram{}
+	ok := histogramString(val, "namespace", &rv.namespace) &&
+		histogramString(val, "keyspace", &rv.keyspace) &&
+		histogramString(val, "expression", &rv.expression) &&
+		histogramInt(val, "count", &rv.count) &&
+		histogramInt(val, "distinct", &rv.distinct)
+	if !ok {
+		return nil, errors.NewError(nil, "Invalid histogram: missing or invalid field.")
+	}
+
+	var updated string
+	if !histogramString(val, "updated", &updated) {
+		return nil, errors.NewError(nil, "Invalid histogram "+rv.Key()+": missing updated time.")
+	}
+
+	var er error
+	rv.updated, er = time.Parse(time.RFC3339, updated)
+	if er != nil {
+		return nil, errors.NewError(er, "Invalid histogram "+rv.Key()+": invalid updated time.")
+	}
+
+	bins, _ := val.Field("bins")
+	if bins == nil || bins.Type() != value.ARRAY {
+		return nil, errors.NewError(nil, "Invalid histogram "+rv.Key()+": missing bins.")
+	}
+
+	for i := 0; ; i++ {
+		b, ok := bins.Index(i)
+		if !ok {
+			break
+		}
+
+		bin := &histogramBin{}
+		var minOk, maxOk bool
+		bin.min, minOk = b.Field("min")
+		bin.max, maxOk = b.Field("max")
+		if !histogramInt(b, "count", &bin.count) || !histogramInt(b, "distinct", &bin.distinct) ||
+			!minOk || !maxOk {
+			return nil, errors.NewError(nil, "Invalid histogram "+rv.Key()+": invalid bin.")
+		}
+
+		rv.bins = append(rv.bins, bin)
+	}
+
+	return rv, nil
+}
+
+func histogramString(val value.Value, field string, s *string) bool {
+	v, ok := val.Field(field)
+	if !ok || v.Type() != value.STRING {
+		return false
+	}
+
+	*s = v.Actual().(string)
+	return true
+}
+
+func histogramInt(val value.Value, field string, i *int64) bool {
+	v, ok := val.Field(field)
+	if !ok || v.Type() != value.NUMBER {
+		return false
+	}
+
+	*i = int64(v.Actual().(float64))
+	return true
+}
+
+type histogramBin struct {
+	count    int64
+	distinct int64
+	min      value.Value
+	max      value.Value
+}
+
+func (this *histogramBin) Count() (int64, errors.Error) {
+	return this.count, nil
+}
+
+func (this *histogramBin) Min() (value.Values, errors.Error) {
+	return value.Values{this.min}, nil
+}
+
+func (this *histogramBin) Max() (value.Values, errors.Error) {
+	return value.Values{this.max}, nil
+}
+
+func (this *histogramBin) DistinctCount() (int64, errors.Error) {
+	return this.distinct, nil
+}
+
+func (this *histogramBin) Bins() ([]Statistics, errors.Error) {
+	return nil, nil
+}
+
+type collatedValues value.Values
+
+func (this collatedValues) Len() int {
+	return len(this)
+}
+
+func (this collatedValues) Less(i, j int) bool {
+	return this[i].Collate(this[j]) < 0
+}
+
+func (this collatedValues) Swap(i, j int) {
+	this[i], this[j] = this[j], this[i]
+}
+
+/*
+Stores a histogram, replacing any earlier histogram of the same
+expression over the same keyspace.
+*/
+func SetHistogram(histogram *Histogram) {
+	_HISTOGRAMS.Lock()
+	defer _HISTOGRAMS.Unlock()
+
+	_HISTOGRAMS.histograms[histogram.Key()] = histogram
+}
+
+/*
+Returns the histogram of the expression over the keyspace, or nil if
+there is none. The expression is identified by its string form.
+*/
+func GetHistogram(namespace, keyspace, expression string) *Histogram {
+	return HistogramByKey(histogramKey(namespace, keyspace, expression))
+}
+
+func HistogramByKey(key string) *Histogram {
+	_HISTOGRAMS.RLock()
+	defer _HISTOGRAMS.RUnlock()
+
+	return _HISTOGRAMS.histograms[key]
+}
+
+/*
+Returns the keys of all the histograms, sorted.
+*/
+func HistogramKeys() []string {
+	_HISTOGRAMS.RLock()
+	defer _HISTOGRAMS.RUnlock()
+
+	rv := make([]string, 0, len(_HISTOGRAMS.histograms))
+	for key, _ := range _HISTOGRAMS.histograms {
+		rv = append(rv, key)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+func histogramKey(namespace, keyspace, expression string) string {
+	return namespace + ":" + keyspace + ":" + expression
+}
+
+var _HISTOGRAMS = struct {
+	sync.RWMutex
+	histograms map[string]*Histogram
+}{
+	histograms: make(map[string]*Histogram),
+}
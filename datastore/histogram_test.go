@@ -0,0 +1,123 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestHistogram(t *testing.T) {
+	// A sample of 10 values, from a keyspace of 100 documents
+	sample := make(value.Values, 0, 10)
+	for _, n := range []float64{9, 3, 3, 1, 7, 3, 5, 1, 9, 3} {
+		sample = append(sample, value.NewValue(n))
+	}
+
+	histogram := NewHistogram("default", "test", "`n`", sample, 100, 4)
+
+	count, _ := histogram.Count()
+	if count != 100 {
+		t.Errorf("Expected count 100, got %v", count)
+	}
+
+	distinct, _ := histogram.DistinctCount()
+	if distinct != 5 {
+		t.Errorf("Expected distinct count 5, got %v", distinct)
+	}
+
+	min, _ := histogram.Min()
+	max, _ := histogram.Max()
+	if min[0].Actual() != 1.0 || max[0].Actual() != 9.0 {
+		t.Errorf("Expected min 1 and max 9, got %v and %v", min[0], max[0])
+	}
+
+	// Bins of 3 sorted values: 1 1 3 | 3 3 3 | 5 7 9 | 9
+	bins, _ := histogram.Bins()
+	if len(bins) != 4 {
+		t.Fatalf("Expected 4 bins, got %v", len(bins))
+	}
+
+	expected := []struct {
+		count, distinct int64
+		min, max        float64
+	}{
+		{30, 2, 1, 3},
+		{30, 1, 3, 3},
+		{30, 3, 5, 9},
+		{10, 1, 9, 9},
+	}
+
+	for i, e := range expected {
+		count, _ := bins[i].Count()
+		distinct, _ := bins[i].DistinctCount()
+		min, _ := bins[i].Min()
+		max, _ := bins[i].Max()
+
+		if count != e.count || distinct != e.distinct ||
+			min[0].Actual() != e.min || max[0].Actual() != e.max {
+			t.Errorf("Bin %d: expected %v, got count %v, distinct %v, min %v, max %v",
+				i, e, count, distinct, min[0], max[0])
+		}
+	}
+
+	SetHistogram(histogram)
+	if GetHistogram("default", "test", "`n`") != histogram {
+		t.Errorf("Expected to find the stored histogram")
+	}
+
+	keys := HistogramKeys()
+	if len(keys) != 1 || keys[0] != "default:test:`n`" {
+		t.Errorf("Expected key default:test:`n`, got %v", keys)
+	}
+}
+
+func TestHistogramFromValue(t *testing.T) {
+	sample := make(value.Values, 0, 10)
+	for _, s := range []string{"b", "a", "c", "a", "d", "e", "a"} {
+		sample = append(sample, value.NewValue(s))
+	}
+
+	histogram := NewHistogram("default", "test", "`s`", sample, 70, 3)
+	bytes, err := histogram.Value().MarshalJSON()
+	if err != nil {
+		t.Fatalf("Failed to marshal histogram: %v", err)
+	}
+
+	loaded, e := NewHistogramFromValue(value.NewValue(bytes))
+	if e != nil {
+		t.Fatalf("Failed to load histogram: %v", e)
+	}
+
+	if loaded.Key() != histogram.Key() || !loaded.Updated().Equal(histogram.Updated().Truncate(time.Second)) {
+		t.Errorf("Expected key %s updated at %v, got %s at %v", histogram.Key(),
+			histogram.Updated(), loaded.Key(), loaded.Updated())
+	}
+
+	reloaded, _ := loaded.Value().MarshalJSON()
+	if string(reloaded) != string(bytes) {
+		t.Errorf("Expected %s, got %s", bytes, reloaded)
+	}
+
+	for _, invalid := range []string{
+		`[]`,
+		`{"namespace": "default", "keyspace": "test", "expression": "s"}`,
+		`{"namespace": "default", "keyspace": "test", "expression": "s", "count": 1, "distinct": 1,
+			"updated": "yesterday", "bins": []}`,
+		`{"namespace": "default", "keyspace": "test", "expression": "s", "count": 1, "distinct": 1,
+			"updated": "2015-01-02T03:04:05Z", "bins": [{"count": 1, "distinct": 1}]}`,
+	} {
+		if _, e := NewHistogramFromValue(value.NewValue([]byte(invalid))); e == nil {
+			t.Errorf("Expected an error loading %s", invalid)
+		}
+	}
+}
@@ -40,6 +40,15 @@ type Indexer interface {
 	Refresh() errors.Error                    // Refresh list of indexes from metadata
 }
 
+/*
+IndexRenamer is implemented by indexers that support ALTER INDEX
+... RENAME TO. It is separate from Indexer, so that index providers
+outside this repository are not required to implement it.
+*/
+type IndexRenamer interface {
+	RenameIndex(index Index, name string) errors.Error // Rename an index of this indexer
+}
+
 type IndexState string
 
 const (
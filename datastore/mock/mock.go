@@ -33,6 +33,7 @@ const (
 	DEFAULT_NUM_NAMESPACES = 1
 	DEFAULT_NUM_KEYSPACES  = 1
 	DEFAULT_NUM_ITEMS      = 100000
+	MOCK_CAS               = 1 // Mock documents never change, so they share one CAS
 )
 
 // store is the root for the mock-based Store.
@@ -41,6 +42,7 @@ type store struct {
 	namespaces     map[string]*namespace
 	namespaceNames []string
 	params         map[string]int
+	users          datastore.Users
 }
 
 func (s *store) Id() string {
@@ -72,8 +74,8 @@ func (s *store) NamespaceByName(name string) (p datastore.Namespace, e errors.Er
 	return
 }
 
-func (s *store) Authorize(datastore.Privileges, datastore.Credentials) errors.Error {
-	return nil
+func (s *store) Authorize(privileges datastore.Privileges, credentials datastore.Credentials) errors.Error {
+	return s.users.Authorize(privileges, credentials)
 }
 
 // namespace represents a mock-based Namespace.
@@ -181,7 +183,7 @@ func genItem(i int, nitems int) (value.AnnotatedValue, errors.Error) {
 	}
 	id := strconv.Itoa(i)
 	doc := value.NewAnnotatedValue(map[string]interface{}{"id": id, "i": float64(i)})
-	doc.SetAttachment("meta", map[string]interface{}{"id": id})
+	doc.SetAttachment("meta", map[string]interface{}{"id": id, "cas": float64(MOCK_CAS)})
 	return doc, nil
 }
 
@@ -205,6 +207,56 @@ func (b *keyspace) Delete(deletes []string) ([]string, errors.Error) {
 	return nil, errors.NewOtherNotImplementedError(nil, "for Mock datastore")
 }
 
+func (b *keyspace) UpdateCas(updates []datastore.Pair) ([]datastore.Pair, errors.Errors) {
+	matched, errs := checkCas(updates)
+	if len(matched) == 0 {
+		return nil, errs
+	}
+
+	updated, err := b.Update(matched)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return updated, errs
+}
+
+func (b *keyspace) DeleteCas(deletes []datastore.Pair) ([]string, errors.Errors) {
+	matched, errs := checkCas(deletes)
+	if len(matched) == 0 {
+		return nil, errs
+	}
+
+	keys := make([]string, len(matched))
+	for i, kv := range matched {
+		keys[i] = kv.Key
+	}
+
+	deleted, err := b.Delete(keys)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return deleted, errs
+}
+
+// returns the pairs whose CAS is 0 or MOCK_CAS, and an error for each of the others
+func checkCas(kvPairs []datastore.Pair) ([]datastore.Pair, errors.Errors) {
+	matched := make([]datastore.Pair, 0, len(kvPairs))
+	var errs errors.Errors
+
+	for _, kv := range kvPairs {
+		if kv.Cas != 0 && kv.Cas != MOCK_CAS {
+			errs = append(errs, errors.NewOtherCasMismatchError(nil, fmt.Sprintf("for mock item: %v", kv.Key)))
+			continue
+		}
+
+		matched = append(matched, kv)
+	}
+
+	return matched, errs
+}
+
 func (b *keyspace) Release() {
 }
 
@@ -287,6 +339,23 @@ func (mi *mockIndexer) BuildIndexes(names ...string) errors.Error {
 	return errors.NewOtherNotSupportedError(nil, "BUILD INDEXES is not supported for mock datastore.")
 }
 
+func (mi *mockIndexer) RenameIndex(index datastore.Index, name string) errors.Error {
+	oldName := index.Name()
+	pi, ok := index.(*primaryIndex)
+	if !ok || mi.indexes[oldName] != index {
+		return errors.NewOtherIdxNotFoundError(nil, oldName+" for Mock datastore")
+	}
+
+	if _, exists := mi.indexes[name]; exists && name != oldName {
+		return errors.NewOtherDatastoreError(nil, "index "+name+" already exists for Mock datastore")
+	}
+
+	delete(mi.indexes, oldName)
+	pi.name = name
+	mi.indexes[name] = pi
+	return nil
+}
+
 func (mi *mockIndexer) Refresh() errors.Error {
 	return nil
 }
@@ -298,17 +367,32 @@ func (mi *mockIndexer) Refresh() errors.Error {
 // namespaces.  And, each namespace has 5 keyspaces.  And, each
 // keyspace with 50000 items.  By default, you get...
 // mock:namespaces=1,keyspaces=1,items=100000 Which is what you'd get
-// by specifying a path of just...  mock:
+// by specifying a path of just...  mock: The param users=<file> loads
+// the users and roles of the store from a JSON file, and makes
+// Authorize require credentials.
 func NewDatastore(path string) (datastore.Datastore, errors.Error) {
 	if strings.HasPrefix(path, "mock:") {
 		path = path[5:]
 	}
 	params := map[string]int{}
+	var users datastore.Users
 	for _, kv := range strings.Split(path, ",") {
 		if kv == "" {
 			continue
 		}
-		pair := strings.Split(kv, "=")
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) < 2 {
+			return nil, errors.NewOtherDatastoreError(nil,
+				fmt.Sprintf("could not parse mock param: %s", kv))
+		}
+		if pair[0] == "users" {
+			u, e := datastore.LoadUsers(pair[1])
+			if e != nil {
+				return nil, e
+			}
+			users = u
+			continue
+		}
 		v, e := strconv.Atoi(pair[1])
 		if e != nil {
 			return nil, errors.NewOtherDatastoreError(e,
@@ -320,7 +404,7 @@ func NewDatastore(path string) (datastore.Datastore, errors.Error) {
 	nnamespaces := paramVal(params, "namespaces", DEFAULT_NUM_NAMESPACES)
 	nkeyspaces := paramVal(params, "keyspaces", DEFAULT_NUM_KEYSPACES)
 	nitems := paramVal(params, "items", DEFAULT_NUM_ITEMS)
-	s := &store{path: path, params: params, namespaces: map[string]*namespace{}, namespaceNames: []string{}}
+	s := &store{path: path, params: params, users: users, namespaces: map[string]*namespace{}, namespaceNames: []string{}}
 	for i := 0; i < nnamespaces; i++ {
 		p := &namespace{store: s, name: "p" + strconv.Itoa(i), keyspaces: map[string]*keyspace{}, keyspaceNames: []string{}}
 		for j := 0; j < nkeyspaces; j++ {
@@ -9,7 +9,9 @@
 
 package datastore
 
-import ()
+import (
+	"strings"
+)
 
 type Privilege int
 
@@ -17,8 +19,40 @@ const (
 	PRIV_READ  Privilege = 1
 	PRIV_WRITE Privilege = 2
 	PRIV_DDL   Privilege = 3
+	PRIV_ADMIN Privilege = 4 // Required to GRANT and REVOKE roles
 )
 
+var _PRIVILEGE_NAMES = map[Privilege]string{
+	PRIV_READ:  "read",
+	PRIV_WRITE: "write",
+	PRIV_DDL:   "ddl",
+	PRIV_ADMIN: "admin",
+}
+
+func (this Privilege) String() string {
+	name, ok := _PRIVILEGE_NAMES[this]
+	if !ok {
+		return "unknown"
+	}
+
+	return name
+}
+
+/*
+Returns the privilege with the given name, which is not case
+sensitive.
+*/
+func PrivilegeByName(name string) (Privilege, bool) {
+	name = strings.ToLower(name)
+	for priv, n := range _PRIVILEGE_NAMES {
+		if n == name {
+			return priv, true
+		}
+	}
+
+	return 0, false
+}
+
 /*
 Type Privileges maps string of the form "namespace:keyspace" to
 privileges.
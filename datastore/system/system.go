@@ -21,10 +21,18 @@ const KEYSPACE_NAME_NAMESPACES = "namespaces"
 const KEYSPACE_NAME_KEYSPACES = "keyspaces"
 const KEYSPACE_NAME_INDEXES = "indexes"
 const KEYSPACE_NAME_DUAL = "dual"
+const KEYSPACE_NAME_PREPAREDS = "prepareds"
+const KEYSPACE_NAME_FUNCTIONS = "functions"
+const KEYSPACE_NAME_HISTOGRAMS = "histograms"
+const KEYSPACE_NAME_USER_INFO = "user_info"
+const KEYSPACE_NAME_ACTIVE_REQUESTS = "active_requests"
+const KEYSPACE_NAME_COMPLETED_REQUESTS = "completed_requests"
 
 type store struct {
 	actualStore              datastore.Datastore
 	systemDatastoreNamespace *namespace
+	active                   RequestLog
+	completed                RequestLog
 }
 
 func (s *store) Id() string {
@@ -71,8 +79,12 @@ func (s *store) Authorize(datastore.Privileges, datastore.Credentials) errors.Er
 	return nil
 }
 
-func NewDatastore(actualStore datastore.Datastore) (datastore.Datastore, errors.Error) {
-	s := &store{actualStore: actualStore}
+// NewDatastore returns the system datastore over actualStore. The
+// request logs back system:active_requests and
+// system:completed_requests of this datastore; either may be nil.
+func NewDatastore(actualStore datastore.Datastore,
+	active, completed RequestLog) (datastore.Datastore, errors.Error) {
+	s := &store{actualStore: actualStore, active: active, completed: completed}
 
 	e := s.loadNamespace()
 	if e != nil {
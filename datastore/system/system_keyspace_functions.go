@@ -0,0 +1,179 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+type functionsKeyspace struct {
+	namespace *namespace
+	name      string
+	indexer   datastore.Indexer
+}
+
+func (b *functionsKeyspace) Release() {
+}
+
+func (b *functionsKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *functionsKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *functionsKeyspace) Name() string {
+	return b.name
+}
+
+func (b *functionsKeyspace) Count() (int64, errors.Error) {
+	return int64(len(expression.UserFunctionNames())), nil
+}
+
+func (b *functionsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *functionsKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *functionsKeyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error) {
+	rv := make([]datastore.AnnotatedPair, 0, len(keys))
+	for _, k := range keys {
+		fn, ok := expression.GetUserFunction(k)
+
+		// The function may have been dropped since the scan
+		if !ok {
+			continue
+		}
+
+		rv = append(rv, datastore.AnnotatedPair{Key: k, Value: functionValue(fn.(*expression.UserFunction))})
+	}
+	return rv, nil
+}
+
+func functionValue(fn *expression.UserFunction) value.AnnotatedValue {
+	parameters := make([]interface{}, len(fn.Parameters()))
+	for i, p := range fn.Parameters() {
+		parameters[i] = p
+	}
+
+	return value.NewAnnotatedValue(map[string]interface{}{
+		"name":       fn.Name(),
+		"parameters": parameters,
+		"body":       expression.NewStringer().Visit(fn.Body()),
+	})
+}
+
+func (b *functionsKeyspace) Insert(inserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *functionsKeyspace) Update(updates []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *functionsKeyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+// Functions are dropped using DROP FUNCTION, which also removes the
+// stored definition
+func (b *functionsKeyspace) Delete(deletes []string) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func newFunctionsKeyspace(p *namespace) (*functionsKeyspace, errors.Error) {
+	b := new(functionsKeyspace)
+	b.namespace = p
+	b.name = KEYSPACE_NAME_FUNCTIONS
+
+	primary := &functionsIndex{name: "#primary", keyspace: b}
+	b.indexer = &systemIndexer{keyspace: b, indexes: make(map[string]datastore.Index), primary: primary}
+
+	return b, nil
+}
+
+type functionsIndex struct {
+	name     string
+	keyspace *functionsKeyspace
+}
+
+func (pi *functionsIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *functionsIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *functionsIndex) Name() string {
+	return pi.name
+}
+
+func (pi *functionsIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *functionsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *functionsIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *functionsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *functionsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *functionsIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *functionsIndex) Drop() errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *functionsIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for _, name := range expression.UserFunctionNames() {
+		if spanContains(span, name) {
+			entry := datastore.IndexEntry{PrimaryKey: name}
+			conn.EntryChannel() <- &entry
+		}
+	}
+}
+
+func (pi *functionsIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for i, name := range expression.UserFunctionNames() {
+		if limit > 0 && int64(i) >= limit {
+			break
+		}
+
+		entry := datastore.IndexEntry{PrimaryKey: name}
+		conn.EntryChannel() <- &entry
+	}
+}
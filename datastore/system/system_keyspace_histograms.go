@@ -0,0 +1,162 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+type histogramsKeyspace struct {
+	namespace *namespace
+	name      string
+	indexer   datastore.Indexer
+}
+
+func (b *histogramsKeyspace) Release() {
+}
+
+func (b *histogramsKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *histogramsKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *histogramsKeyspace) Name() string {
+	return b.name
+}
+
+func (b *histogramsKeyspace) Count() (int64, errors.Error) {
+	return int64(len(datastore.HistogramKeys())), nil
+}
+
+func (b *histogramsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *histogramsKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *histogramsKeyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error) {
+	rv := make([]datastore.AnnotatedPair, 0, len(keys))
+	for _, k := range keys {
+		histogram := datastore.HistogramByKey(k)
+		if histogram == nil {
+			continue
+		}
+
+		rv = append(rv, datastore.AnnotatedPair{Key: k, Value: value.NewAnnotatedValue(histogram.Value())})
+	}
+	return rv, nil
+}
+
+func (b *histogramsKeyspace) Insert(inserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *histogramsKeyspace) Update(updates []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *histogramsKeyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *histogramsKeyspace) Delete(deletes []string) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func newHistogramsKeyspace(p *namespace) (*histogramsKeyspace, errors.Error) {
+	b := new(histogramsKeyspace)
+	b.namespace = p
+	b.name = KEYSPACE_NAME_HISTOGRAMS
+
+	primary := &histogramsIndex{name: "#primary", keyspace: b}
+	b.indexer = &systemIndexer{keyspace: b, indexes: make(map[string]datastore.Index), primary: primary}
+
+	return b, nil
+}
+
+type histogramsIndex struct {
+	name     string
+	keyspace *histogramsKeyspace
+}
+
+func (pi *histogramsIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *histogramsIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *histogramsIndex) Name() string {
+	return pi.name
+}
+
+func (pi *histogramsIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *histogramsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *histogramsIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *histogramsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *histogramsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *histogramsIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *histogramsIndex) Drop() errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *histogramsIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for _, key := range datastore.HistogramKeys() {
+		if spanContains(span, key) {
+			entry := datastore.IndexEntry{PrimaryKey: key}
+			conn.EntryChannel() <- &entry
+		}
+	}
+}
+
+func (pi *histogramsIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for i, key := range datastore.HistogramKeys() {
+		if limit > 0 && int64(i) >= limit {
+			break
+		}
+
+		entry := datastore.IndexEntry{PrimaryKey: key}
+		conn.EntryChannel() <- &entry
+	}
+}
@@ -0,0 +1,252 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+type preparedsKeyspace struct {
+	namespace *namespace
+	name      string
+	indexer   datastore.Indexer
+}
+
+func (b *preparedsKeyspace) Release() {
+}
+
+func (b *preparedsKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *preparedsKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *preparedsKeyspace) Name() string {
+	return b.name
+}
+
+func (b *preparedsKeyspace) Count() (int64, errors.Error) {
+	return int64(plan.PreparedCache().Count()), nil
+}
+
+func (b *preparedsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *preparedsKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *preparedsKeyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error) {
+	rv := make([]datastore.AnnotatedPair, 0, len(keys))
+	for _, k := range keys {
+		item, e := b.fetchOne(k)
+		if e != nil {
+			return nil, e
+		}
+
+		// The statement may have been evicted since the scan
+		if item == nil {
+			continue
+		}
+
+		rv = append(rv, datastore.AnnotatedPair{Key: k, Value: item})
+	}
+	return rv, nil
+}
+
+func (b *preparedsKeyspace) fetchOne(key string) (value.AnnotatedValue, errors.Error) {
+	entry := plan.PreparedCache().Entry(key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(entry.Prepared)
+	if err != nil {
+		return nil, errors.NewSystemDatastoreError(err, "Unable to marshal prepared "+key)
+	}
+
+	doc := map[string]interface{}{
+		"name":  entry.Name,
+		"uses":  entry.Uses,
+		"plan":  value.NewValue(body),
+		"store": b.namespace.store.actualStore.Id(),
+	}
+
+	if !entry.LastUse.IsZero() {
+		doc["lastUse"] = entry.LastUse.Format(time.RFC3339Nano)
+	}
+
+	return value.NewAnnotatedValue(doc), nil
+}
+
+func (b *preparedsKeyspace) Insert(inserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *preparedsKeyspace) Update(updates []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *preparedsKeyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+// Returns the names that were removed, leaving out those that were not
+// cached.
+func (b *preparedsKeyspace) Delete(deletes []string) ([]string, errors.Error) {
+	rv := make([]string, 0, len(deletes))
+	for _, name := range deletes {
+		if plan.PreparedCache().DeletePrepared(name) {
+			rv = append(rv, name)
+		}
+	}
+	return rv, nil
+}
+
+func newPreparedsKeyspace(p *namespace) (*preparedsKeyspace, errors.Error) {
+	b := new(preparedsKeyspace)
+	b.namespace = p
+	b.name = KEYSPACE_NAME_PREPAREDS
+
+	primary := &preparedsIndex{name: "#primary", keyspace: b}
+	b.indexer = &systemIndexer{keyspace: b, indexes: make(map[string]datastore.Index), primary: primary}
+
+	return b, nil
+}
+
+type preparedsIndex struct {
+	name     string
+	keyspace *preparedsKeyspace
+}
+
+func (pi *preparedsIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *preparedsIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *preparedsIndex) Name() string {
+	return pi.name
+}
+
+func (pi *preparedsIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *preparedsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *preparedsIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *preparedsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *preparedsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *preparedsIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *preparedsIndex) Drop() errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *preparedsIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var n int64
+	for _, name := range plan.PreparedCache().Names() {
+		if limit > 0 && n >= limit {
+			return
+		}
+
+		if !spanContains(span, name) {
+			continue
+		}
+
+		if !sendEntry(name, conn) {
+			return
+		}
+		n++
+	}
+}
+
+func (pi *preparedsIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for i, name := range plan.PreparedCache().Names() {
+		if limit > 0 && int64(i) >= limit {
+			return
+		}
+
+		if !sendEntry(name, conn) {
+			return
+		}
+	}
+}
+
+// Sends the primary key, unless the scan has been stopped.
+func sendEntry(key string, conn *datastore.IndexConnection) bool {
+	select {
+	case <-conn.StopChannel():
+		return false
+	default:
+	}
+
+	entry := datastore.IndexEntry{PrimaryKey: key}
+	conn.EntryChannel() <- &entry
+	return true
+}
+
+// Returns true if the primary key falls within the span.
+func spanContains(span *datastore.Span, key string) bool {
+	if len(span.Seek) > 0 {
+		return span.Seek[0].Actual() == key
+	}
+
+	k := value.NewValue(key)
+
+	if len(span.Range.Low) > 0 && span.Range.Low[0] != nil {
+		c := k.Collate(span.Range.Low[0])
+		if c < 0 || (c == 0 && span.Range.Inclusion&datastore.LOW == 0) {
+			return false
+		}
+	}
+
+	if len(span.Range.High) > 0 && span.Range.High[0] != nil {
+		c := k.Collate(span.Range.High[0])
+		if c > 0 || (c == 0 && span.Range.Inclusion&datastore.HIGH == 0) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,205 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// RequestLog is implemented by the query service to expose its
+// requests through system:active_requests and system:completed_requests.
+type RequestLog interface {
+	RequestIds() []string                     // Ids of the logged requests
+	Request(id string) map[string]interface{} // Document for a request; nil if not found
+	DeleteRequest(id string) bool             // Stop or forget a request
+}
+
+type requestsKeyspace struct {
+	namespace *namespace
+	name      string
+	log       RequestLog // nil if the datastore has no such log
+	indexer   datastore.Indexer
+}
+
+func (b *requestsKeyspace) Release() {
+}
+
+func (b *requestsKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *requestsKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *requestsKeyspace) Name() string {
+	return b.name
+}
+
+func (b *requestsKeyspace) ids() []string {
+	if b.log == nil {
+		return nil
+	}
+	return b.log.RequestIds()
+}
+
+func (b *requestsKeyspace) Count() (int64, errors.Error) {
+	return int64(len(b.ids())), nil
+}
+
+func (b *requestsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *requestsKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *requestsKeyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error) {
+	rv := make([]datastore.AnnotatedPair, 0, len(keys))
+	if b.log == nil {
+		return rv, nil
+	}
+
+	for _, k := range keys {
+		doc := b.log.Request(k)
+
+		// The request may have finished since the scan
+		if doc == nil {
+			continue
+		}
+
+		rv = append(rv, datastore.AnnotatedPair{Key: k, Value: value.NewAnnotatedValue(doc)})
+	}
+	return rv, nil
+}
+
+func (b *requestsKeyspace) Insert(inserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *requestsKeyspace) Update(updates []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *requestsKeyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *requestsKeyspace) Delete(deletes []string) ([]string, errors.Error) {
+	if b.log == nil {
+		return nil, nil
+	}
+
+	rv := make([]string, 0, len(deletes))
+	for _, id := range deletes {
+		if b.log.DeleteRequest(id) {
+			rv = append(rv, id)
+		}
+	}
+	return rv, nil
+}
+
+func newRequestsKeyspace(p *namespace, name string, log RequestLog) (*requestsKeyspace, errors.Error) {
+	b := new(requestsKeyspace)
+	b.namespace = p
+	b.name = name
+	b.log = log
+
+	primary := &requestsIndex{name: "#primary", keyspace: b}
+	b.indexer = &systemIndexer{keyspace: b, indexes: make(map[string]datastore.Index), primary: primary}
+
+	return b, nil
+}
+
+type requestsIndex struct {
+	name     string
+	keyspace *requestsKeyspace
+}
+
+func (pi *requestsIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *requestsIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *requestsIndex) Name() string {
+	return pi.name
+}
+
+func (pi *requestsIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *requestsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *requestsIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *requestsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *requestsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *requestsIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *requestsIndex) Drop() errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *requestsIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	n := int64(0)
+	for _, id := range pi.keyspace.ids() {
+		if limit > 0 && n >= limit {
+			return
+		}
+
+		if !spanContains(span, id) {
+			continue
+		}
+
+		if !sendEntry(id, conn) {
+			return
+		}
+		n++
+	}
+}
+
+func (pi *requestsIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for i, id := range pi.keyspace.ids() {
+		if limit > 0 && int64(i) >= limit {
+			return
+		}
+
+		if !sendEntry(id, conn) {
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"sort"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+type userInfoKeyspace struct {
+	namespace *namespace
+	name      string
+	indexer   datastore.Indexer
+}
+
+func (b *userInfoKeyspace) Release() {
+}
+
+func (b *userInfoKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *userInfoKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *userInfoKeyspace) Name() string {
+	return b.name
+}
+
+// userRoles returns the roles of each user, if the actual datastore
+// manages roles, and none otherwise.
+func (b *userInfoKeyspace) userRoles() (map[string]datastore.Privileges, errors.Error) {
+	manager, ok := b.namespace.store.actualStore.(datastore.RoleManager)
+	if !ok {
+		return nil, nil
+	}
+
+	return manager.UserRoles()
+}
+
+// userNames returns the sorted names of the users.
+func (b *userInfoKeyspace) userNames() ([]string, errors.Error) {
+	roles, e := b.userRoles()
+	if e != nil {
+		return nil, e
+	}
+
+	names := make([]string, 0, len(roles))
+	for name, _ := range roles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func (b *userInfoKeyspace) Count() (int64, errors.Error) {
+	roles, e := b.userRoles()
+	return int64(len(roles)), e
+}
+
+func (b *userInfoKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *userInfoKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *userInfoKeyspace) Fetch(keys []string) ([]datastore.AnnotatedPair, errors.Error) {
+	roles, e := b.userRoles()
+	if e != nil {
+		return nil, e
+	}
+
+	rv := make([]datastore.AnnotatedPair, 0, len(keys))
+	for _, k := range keys {
+		privileges, ok := roles[k]
+		if !ok {
+			continue
+		}
+
+		rv = append(rv, datastore.AnnotatedPair{Key: k, Value: userInfoValue(k, privileges)})
+	}
+	return rv, nil
+}
+
+// Passwords are never exposed; each role is listed with its keyspace,
+// in keyspace order.
+func userInfoValue(name string, privileges datastore.Privileges) value.AnnotatedValue {
+	keyspaces := make([]string, 0, len(privileges))
+	for keyspace, _ := range privileges {
+		keyspaces = append(keyspaces, keyspace)
+	}
+
+	sort.Strings(keyspaces)
+	roles := make([]interface{}, len(keyspaces))
+	for i, keyspace := range keyspaces {
+		roles[i] = map[string]interface{}{
+			"keyspace": keyspace,
+			"role":     privileges[keyspace].String(),
+		}
+	}
+
+	return value.NewAnnotatedValue(map[string]interface{}{
+		"id":    name,
+		"roles": roles,
+	})
+}
+
+func (b *userInfoKeyspace) Insert(inserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+// Roles are changed using GRANT and REVOKE
+func (b *userInfoKeyspace) Update(updates []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *userInfoKeyspace) Upsert(upserts []datastore.Pair) ([]datastore.Pair, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func (b *userInfoKeyspace) Delete(deletes []string) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotSupportedError(nil, "")
+}
+
+func newUserInfoKeyspace(p *namespace) (*userInfoKeyspace, errors.Error) {
+	b := new(userInfoKeyspace)
+	b.namespace = p
+	b.name = KEYSPACE_NAME_USER_INFO
+
+	primary := &userInfoIndex{name: "#primary", keyspace: b}
+	b.indexer = &systemIndexer{keyspace: b, indexes: make(map[string]datastore.Index), primary: primary}
+
+	return b, nil
+}
+
+type userInfoIndex struct {
+	name     string
+	keyspace *userInfoKeyspace
+}
+
+func (pi *userInfoIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *userInfoIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *userInfoIndex) Name() string {
+	return pi.name
+}
+
+func (pi *userInfoIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *userInfoIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *userInfoIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *userInfoIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *userInfoIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *userInfoIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *userInfoIndex) Drop() errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *userInfoIndex) Scan(span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	names, e := pi.keyspace.userNames()
+	if e != nil {
+		conn.Error(e)
+		return
+	}
+
+	for _, name := range names {
+		if spanContains(span, name) {
+			entry := datastore.IndexEntry{PrimaryKey: name}
+			conn.EntryChannel() <- &entry
+		}
+	}
+}
+
+func (pi *userInfoIndex) ScanEntries(limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	names, e := pi.keyspace.userNames()
+	if e != nil {
+		conn.Error(e)
+		return
+	}
+
+	for i, name := range names {
+		if limit > 0 && int64(i) >= limit {
+			break
+		}
+
+		entry := datastore.IndexEntry{PrimaryKey: name}
+		conn.EntryChannel() <- &entry
+	}
+}
@@ -107,5 +107,41 @@ func (p *namespace) loadKeyspaces() (e errors.Error) {
 	}
 	p.keyspaces[ib.Name()] = ib
 
+	rb, e := newPreparedsKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[rb.Name()] = rb
+
+	fb, e := newFunctionsKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[fb.Name()] = fb
+
+	tb, e := newHistogramsKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[tb.Name()] = tb
+
+	ub, e := newUserInfoKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[ub.Name()] = ub
+
+	ab, e := newRequestsKeyspace(p, KEYSPACE_NAME_ACTIVE_REQUESTS, p.store.active)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[ab.Name()] = ab
+
+	cb, e := newRequestsKeyspace(p, KEYSPACE_NAME_COMPLETED_REQUESTS, p.store.completed)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[cb.Name()] = cb
+
 	return nil
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/mock"
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
 )
 
 func TestSystem(t *testing.T) {
@@ -25,7 +26,7 @@ func TestSystem(t *testing.T) {
 	}
 
 	// Create systems store with mock m as the ActualStore
-	s, err := NewDatastore(m)
+	s, err := NewDatastore(m, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create system store: %v", err)
 	}
@@ -125,6 +126,259 @@ func TestSystem(t *testing.T) {
 
 }
 
+func TestPrepareds(t *testing.T) {
+	m, err := mock.NewDatastore("mock:namespaces=1,keyspaces=1,items=10")
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+
+	s, err := NewDatastore(m, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create system store: %v", err)
+	}
+
+	p, err := s.NamespaceByName("#system")
+	if err != nil {
+		t.Fatalf("failed to get system namespace: %v", err)
+	}
+
+	rb, err := p.KeyspaceByName("prepareds")
+	if err != nil {
+		t.Fatalf("failed to get keyspace by name %v", err)
+	}
+
+	prepared := &plan.Prepared{}
+	e := prepared.UnmarshalJSON([]byte(`{"name": "test_prepared", "signature": {"$1": "number"},
+		"operator": {"#operator": "DummyScan"}}`))
+	if e != nil {
+		t.Fatalf("failed to unmarshal prepared: %v", e)
+	}
+
+	e = plan.PreparedCache().AddPrepared(prepared)
+	if e != nil {
+		t.Fatalf("failed to add prepared: %v", e)
+	}
+
+	rb_e, err := doPrimaryIndexScan(t, rb)
+	if !rb_e["test_prepared"] {
+		t.Fatalf("failed to get expected prepared name from index scan: test_prepared")
+	}
+
+	vals, err := rb.Fetch([]string{"test_prepared"})
+	if err != nil || len(vals) != 1 {
+		t.Fatalf("failed to fetch expected key from prepareds keyspace: %v", err)
+	}
+
+	uses, _ := vals[0].Value.Field("uses")
+	if uses.Actual() != float64(0) {
+		t.Fatalf("expected no uses of prepared, got %v", uses)
+	}
+
+	other := &plan.Prepared{}
+	e = other.UnmarshalJSON([]byte(`{"name": "other_prepared", "operator": {"#operator": "DummyScan"}}`))
+	if e == nil {
+		e = plan.PreparedCache().AddPrepared(other)
+	}
+	if e != nil {
+		t.Fatalf("failed to add prepared: %v", e)
+	}
+	defer plan.PreparedCache().DeletePrepared("other_prepared")
+
+	// Scans stop at the limit, or when asked to
+	indexers, _ := rb.Indexers()
+	pindexes, _ := indexers[0].PrimaryIndexes()
+	span := &datastore.Span{Range: datastore.Range{Inclusion: datastore.BOTH}}
+
+	scan := func(limit int64, stop bool) int {
+		conn := datastore.NewIndexConnection(&testingContext{t})
+		if stop {
+			conn.StopChannel() <- false
+		}
+
+		go pindexes[0].Scan(span, false, limit, datastore.UNBOUNDED, nil, conn)
+		n := 0
+		for range conn.EntryChannel() {
+			n++
+		}
+		return n
+	}
+
+	if n := scan(0, false); n != 2 {
+		t.Fatalf("expected 2 prepareds from index scan, got %d", n)
+	}
+
+	if n := scan(1, false); n != 1 {
+		t.Fatalf("expected index scan to stop at the limit, got %d", n)
+	}
+
+	if n := scan(0, true); n != 0 {
+		t.Fatalf("expected stopped index scan to return nothing, got %d", n)
+	}
+
+	// Only the prepareds that were cached are deleted
+	deleted, err := rb.Delete([]string{"test_prepared", "missing_prepared"})
+	if err != nil {
+		t.Fatalf("failed to delete prepared: %v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "test_prepared" {
+		t.Fatalf("expected only test_prepared to be deleted, got %v", deleted)
+	}
+
+	vals, err = rb.Fetch([]string{"test_prepared"})
+	if err != nil || len(vals) != 0 {
+		t.Fatalf("found unexpected key in prepareds keyspace")
+	}
+}
+
+type testingRequestLog map[string]map[string]interface{}
+
+func (this testingRequestLog) RequestIds() []string {
+	rv := make([]string, 0, len(this))
+	for id := range this {
+		rv = append(rv, id)
+	}
+	return rv
+}
+
+func (this testingRequestLog) Request(id string) map[string]interface{} {
+	return this[id]
+}
+
+func (this testingRequestLog) DeleteRequest(id string) bool {
+	_, ok := this[id]
+	delete(this, id)
+	return ok
+}
+
+func TestActiveRequests(t *testing.T) {
+	m, err := mock.NewDatastore("mock:namespaces=1,keyspaces=1,items=10")
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+
+	log := testingRequestLog{
+		"request1": {"requestId": "request1", "statement": "SELECT 1", "state": "running"},
+		"request2": {"requestId": "request2", "statement": "SELECT 2", "state": "running"},
+	}
+
+	s, err := NewDatastore(m, log, nil)
+	if err != nil {
+		t.Fatalf("failed to create system store: %v", err)
+	}
+
+	p, err := s.NamespaceByName("#system")
+	if err != nil {
+		t.Fatalf("failed to get system namespace: %v", err)
+	}
+
+	ab, err := p.KeyspaceByName("active_requests")
+	if err != nil {
+		t.Fatalf("failed to get keyspace by name %v", err)
+	}
+
+	ab_c, err := ab.Count()
+	if err != nil || ab_c != 2 {
+		t.Fatalf("failed to get expected active requests count %v", err)
+	}
+
+	ab_e, err := doPrimaryIndexScan(t, ab)
+	if !ab_e["request1"] || !ab_e["request2"] {
+		t.Fatalf("failed to get expected request ids from index scan: %v", ab_e)
+	}
+
+	vals, err := ab.Fetch([]string{"request1"})
+	if err != nil || len(vals) != 1 {
+		t.Fatalf("failed to fetch expected key from active requests keyspace: %v", err)
+	}
+
+	stmt, _ := vals[0].Value.Field("statement")
+	if stmt.Actual() != "SELECT 1" {
+		t.Fatalf("expected statement SELECT 1, got %v", stmt)
+	}
+
+	deleted, err := ab.Delete([]string{"request1", "request3"})
+	if err != nil || len(deleted) != 1 || deleted[0] != "request1" {
+		t.Fatalf("failed to delete active request: %v %v", deleted, err)
+	}
+
+	vals, err = ab.Fetch([]string{"request1"})
+	if err != nil || len(vals) != 0 {
+		t.Fatalf("found unexpected key in active requests keyspace")
+	}
+}
+
+func TestRequestLogs(t *testing.T) {
+	m, err := mock.NewDatastore("mock:namespaces=1,keyspaces=1,items=10")
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+
+	// Each system datastore exposes its own logs
+	logs := []testingRequestLog{
+		{"request1": {"requestId": "request1", "state": "completed"}},
+		{"request2": {"requestId": "request2", "state": "completed"},
+			"request3": {"requestId": "request3", "state": "errors"}},
+	}
+
+	keyspaces := make([]datastore.Keyspace, len(logs))
+	for i, log := range logs {
+		s, err := NewDatastore(m, nil, log)
+		if err != nil {
+			t.Fatalf("failed to create system store: %v", err)
+		}
+
+		p, err := s.NamespaceByName("#system")
+		if err != nil {
+			t.Fatalf("failed to get system namespace: %v", err)
+		}
+
+		keyspaces[i], err = p.KeyspaceByName("completed_requests")
+		if err != nil {
+			t.Fatalf("failed to get keyspace by name %v", err)
+		}
+	}
+
+	for i, keyspace := range keyspaces {
+		ids, _ := doPrimaryIndexScan(t, keyspace)
+		if len(ids) != len(logs[i]) {
+			t.Fatalf("expected %d completed requests, got %v", len(logs[i]), ids)
+		}
+
+		for id := range logs[i] {
+			if !ids[id] {
+				t.Fatalf("expected completed request %s, got %v", id, ids)
+			}
+		}
+	}
+
+	// Without a log, the keyspace is empty
+	s, err := NewDatastore(m, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create system store: %v", err)
+	}
+
+	p, err := s.NamespaceByName("#system")
+	if err != nil {
+		t.Fatalf("failed to get system namespace: %v", err)
+	}
+
+	ab, err := p.KeyspaceByName("active_requests")
+	if err != nil {
+		t.Fatalf("failed to get keyspace by name %v", err)
+	}
+
+	count, err := ab.Count()
+	if err != nil || count != 0 {
+		t.Fatalf("expected no active requests, got %d %v", count, err)
+	}
+
+	deleted, err := ab.Delete([]string{"request1"})
+	if err != nil || len(deleted) != 0 {
+		t.Fatalf("expected nothing to be deleted, got %v %v", deleted, err)
+	}
+}
+
 type testingContext struct {
 	t *testing.T
 }
@@ -0,0 +1,292 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/couchbase/query/errors"
+)
+
+/*
+Type Users authenticates and authorizes requests for datastores that
+have no security of their own, such as the file and mock datastores.
+It maps user names to users, and is loaded from a JSON file such as:
+
+	{
+	    "alice": {
+	        "password": "secret",
+	        "roles": { "default:*": "read", "default:orders": "write" }
+	    },
+	    "admin": { "password": "admin", "roles": { "*": "ddl" } }
+	}
+
+Roles map "namespace:keyspace" to the name of a privilege: read,
+write, ddl or admin, which allows granting and revoking roles. A
+keyspace of * stands for every keyspace in the namespace, and a role
+on * for every keyspace in the datastore. Namespaces and keyspaces
+are not case sensitive.
+*/
+type Users map[string]*User
+
+type User struct {
+	Password string            `json:"password"`
+	Roles    map[string]string `json:"roles"`
+
+	privileges Privileges
+}
+
+/*
+Loads and validates the users in the given file.
+*/
+func LoadUsers(filename string) (Users, errors.Error) {
+	bytes, er := ioutil.ReadFile(filename)
+	if er != nil {
+		return nil, errors.NewDatastoreUsersError(er, filename)
+	}
+
+	users := make(Users)
+	er = json.Unmarshal(bytes, &users)
+	if er != nil {
+		return nil, errors.NewDatastoreUsersError(er, filename)
+	}
+
+	for name, user := range users {
+		if user == nil {
+			return nil, errors.NewDatastoreUsersError(nil, filename+": no definition for user "+name)
+		}
+
+		user.privileges = make(Privileges, len(user.Roles))
+		for keyspace, role := range user.Roles {
+			priv, ok := PrivilegeByName(role)
+			if !ok {
+				return nil, errors.NewDatastoreUsersError(nil,
+					fmt.Sprintf("%s: invalid role %s of user %s", filename, role, name))
+			}
+
+			user.privileges[strings.ToLower(keyspace)] = priv
+		}
+	}
+
+	return users, nil
+}
+
+/*
+Returns nil if the credentials grant every privilege. A privilege is
+granted if any of the credentials names a user with a matching
+password and at least that privilege on the keyspace. If there are
+no users, every privilege is granted. As in Couchbase, privileges on
+the system keyspaces are always granted.
+*/
+func (this Users) Authorize(privileges Privileges, credentials Credentials) errors.Error {
+	if this == nil {
+		return nil
+	}
+
+	authenticated := make([]*User, 0, len(credentials))
+	for name, password := range credentials {
+		user, ok := this[name]
+		if ok && user.Password == password {
+			authenticated = append(authenticated, user)
+		}
+	}
+
+	for keyspace, priv := range privileges {
+		if strings.HasPrefix(keyspace, "#system:") {
+			continue
+		}
+
+		granted := false
+		for _, user := range authenticated {
+			if user.privilege(keyspace) >= priv {
+				granted = true
+				break
+			}
+		}
+
+		if !granted {
+			if len(authenticated) == 0 {
+				return errors.NewDatastoreAuthorizationError(
+					fmt.Errorf("no valid credentials"), "Keyspace "+keyspace)
+			}
+
+			return errors.NewDatastoreAuthorizationError(
+				fmt.Errorf("%s privilege required", priv), "Keyspace "+keyspace)
+		}
+	}
+
+	return nil
+}
+
+/*
+Returns the highest privilege of the user on the keyspace, which is
+of the form "namespace:keyspace", or 0 if the user has none.
+*/
+func (this *User) privilege(keyspace string) Privilege {
+	keyspace = strings.ToLower(keyspace)
+	rv := this.privileges[keyspace]
+
+	if i := strings.Index(keyspace, ":"); i >= 0 {
+		if priv := this.privileges[keyspace[:i+1]+"*"]; priv > rv {
+			rv = priv
+		}
+	}
+
+	if priv := this.privileges["*"]; priv > rv {
+		rv = priv
+	}
+
+	return rv
+}
+
+/*
+Grants the role on the keyspace, which is of the form
+"namespace:keyspace", to each of the users. Roles are cumulative, so
+granting a role lesser than one the user has changes nothing. No
+user is changed unless all of them exist.
+*/
+func (this Users) Grant(role Privilege, keyspace string, names []string) errors.Error {
+	users, e := this.lookup(names)
+	if e != nil {
+		return e
+	}
+
+	keyspace = strings.ToLower(keyspace)
+	for _, user := range users {
+		if user.privileges[keyspace] < role {
+			user.privileges[keyspace] = role
+			user.setRoles()
+		}
+	}
+
+	return nil
+}
+
+/*
+Revokes the role on the keyspace from each of the users, who keep
+any lesser role. Roles on a * keyspace are only revoked on that *
+keyspace. No user is changed unless all of them exist.
+*/
+func (this Users) Revoke(role Privilege, keyspace string, names []string) errors.Error {
+	users, e := this.lookup(names)
+	if e != nil {
+		return e
+	}
+
+	keyspace = strings.ToLower(keyspace)
+	for _, user := range users {
+		if user.privileges[keyspace] < role {
+			continue
+		}
+
+		if role > PRIV_READ {
+			user.privileges[keyspace] = role - 1
+		} else {
+			delete(user.privileges, keyspace)
+		}
+
+		user.setRoles()
+	}
+
+	return nil
+}
+
+func (this Users) lookup(names []string) ([]*User, errors.Error) {
+	rv := make([]*User, len(names))
+	for i, name := range names {
+		user, ok := this[name]
+		if !ok {
+			return nil, errors.NewDatastoreUserNotFoundError(nil, name)
+		}
+
+		rv[i] = user
+	}
+
+	return rv, nil
+}
+
+/*
+Returns the roles of each user.
+*/
+func (this Users) Roles() map[string]Privileges {
+	rv := make(map[string]Privileges, len(this))
+	for name, user := range this {
+		privileges := make(Privileges, len(user.privileges))
+		privileges.Add(user.privileges)
+		rv[name] = privileges
+	}
+
+	return rv
+}
+
+/*
+Returns a copy of the users, which can be changed without affecting
+the original.
+*/
+func (this Users) Copy() Users {
+	rv := make(Users, len(this))
+	for name, user := range this {
+		privileges := make(Privileges, len(user.privileges))
+		privileges.Add(user.privileges)
+		rv[name] = &User{Password: user.Password, privileges: privileges}
+		rv[name].setRoles()
+	}
+
+	return rv
+}
+
+/*
+Saves the users to the given file. The file is replaced atomically,
+so that it is never left partially written.
+*/
+func (this Users) Save(filename string) errors.Error {
+	bytes, er := json.MarshalIndent(this, "", "    ")
+	if er != nil {
+		return errors.NewDatastoreUsersError(er, filename)
+	}
+
+	file, er := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+	if er != nil {
+		return errors.NewDatastoreUsersError(er, filename)
+	}
+
+	_, er = file.Write(bytes)
+	if er == nil {
+		er = file.Close()
+	} else {
+		file.Close()
+	}
+
+	if er == nil {
+		er = os.Rename(file.Name(), filename)
+	}
+
+	if er != nil {
+		os.Remove(file.Name())
+		return errors.NewDatastoreUsersError(er, filename)
+	}
+
+	return nil
+}
+
+/*
+Sets the roles of the user from its privileges.
+*/
+func (this *User) setRoles() {
+	this.Roles = make(map[string]string, len(this.privileges))
+	for keyspace, priv := range this.privileges {
+		this.Roles[keyspace] = priv.String()
+	}
+}
@@ -223,6 +223,49 @@ func NewPlanError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 4000, IKey: "plan_error", ICause: e, InternalMsg: msg, InternalCaller: CallerN(1)}
 }
 
+func NewNoSuchPreparedError(name string) Error {
+	return &err{level: EXCEPTION, ICode: 4050, IKey: "plan.no_such_prepared",
+		InternalMsg: fmt.Sprintf("No such prepared statement: %s", name), InternalCaller: CallerN(1)}
+}
+
+func NewIndexHintWarning(msg string) Error {
+	return &err{level: WARNING, ICode: 4060, IKey: "plan.index_hint_not_honored",
+		InternalMsg: msg, InternalCaller: CallerN(1)}
+}
+
+// Execution errors - errors that are created in the execution package
+
+func NewSortSpillError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 5010, IKey: "execution.sort_spill_error", ICause: e,
+		InternalMsg: msg, InternalCaller: CallerN(1)}
+}
+
+func NewOrderLimitError(limit int64) Error {
+	return &err{level: EXCEPTION, ICode: 5020, IKey: "execution.order_limit_exceeded",
+		InternalMsg: fmt.Sprintf("ORDER BY exceeded the order limit of %d", limit), InternalCaller: CallerN(1)}
+}
+
+func NewMutationLimitError(limit int64) Error {
+	return &err{level: EXCEPTION, ICode: 5050, IKey: "execution.mutation_limit_exceeded",
+		InternalMsg: fmt.Sprintf("Statement exceeded the mutation limit of %d", limit), InternalCaller: CallerN(1)}
+}
+
+func NewHashJoinMemoryError(size int64) Error {
+	return &err{level: EXCEPTION, ICode: 5060, IKey: "execution.hash_join_memory_exceeded",
+		InternalMsg: fmt.Sprintf("JOIN exceeded the memory of %d bytes building its hash table; "+
+			"create an index on the join keys of the right keyspace", size), InternalCaller: CallerN(1)}
+}
+
+func NewFunctionExistsError(name string) Error {
+	return &err{level: EXCEPTION, ICode: 5030, IKey: "execution.function_exists",
+		InternalMsg: fmt.Sprintf("Function %s already exists", name), InternalCaller: CallerN(1)}
+}
+
+func NewFunctionNotFoundError(name string) Error {
+	return &err{level: EXCEPTION, ICode: 5040, IKey: "execution.function_not_found",
+		InternalMsg: fmt.Sprintf("Function %s not found", name), InternalCaller: CallerN(1)}
+}
+
 // admin level errors - errors that are created in the clustering and accounting packages
 
 func NewAdminConnectionError(e error, msg string) Error {
@@ -285,12 +328,37 @@ func NewAdminMakeMetricError(e error, msg string) Error {
 		InternalMsg: "Error creating metric " + msg, InternalCaller: CallerN(1)}
 }
 
+func NewAdminFunctionStoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 2120, IKey: "admin.clustering.function_store_error", ICause: e,
+		InternalMsg: "Error storing definition of function " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewAdminHistogramStoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 2130, IKey: "admin.clustering.histogram_store_error", ICause: e,
+		InternalMsg: "Error storing histogram " + msg, InternalCaller: CallerN(1)}
+}
+
 // Authorization Errors
 func NewDatastoreAuthorizationError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 10000, IKey: "datastore.couchbase.authorization_error", ICause: e,
 		InternalMsg: "Authorization Failed " + msg, InternalCaller: CallerN(1)}
 }
 
+func NewDatastoreUsersError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 10001, IKey: "datastore.users_error", ICause: e,
+		InternalMsg: "Error loading users " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewDatastoreUserNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 10002, IKey: "datastore.user_not_found", ICause: e,
+		InternalMsg: "User not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewDatastoreRolesNotSupportedError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 10003, IKey: "datastore.roles_not_supported", ICause: e,
+		InternalMsg: "Roles cannot be managed in datastore " + msg, InternalCaller: CallerN(1)}
+}
+
 // System datastore error codes
 func NewSystemDatastoreError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 11000, IKey: "datastore.system.generic_error", ICause: e,
@@ -406,6 +474,16 @@ func NewCbBucketTypeNotSupportedError(e error, msg string) Error {
 		InternalMsg: "This bucket type is not supported " + msg, InternalCaller: CallerN(1)}
 }
 
+func NewCbCasMismatchError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 12014, IKey: "datastore.couchbase.cas_mismatch", ICause: e,
+		InternalMsg: "Document was modified after it was read " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewCbGetMetaWarning(e error, msg string) Error {
+	return &err{level: WARNING, ICode: 12015, IKey: "datastore.couchbase.get_meta_failed", ICause: e,
+		InternalMsg: "Failed to get the expiration of document " + msg, InternalCaller: CallerN(1)}
+}
+
 // Datastore/couchbase/view index error codes
 func NewCbViewCreateError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 13000, IKey: "datastore.couchbase.view.create_failed", ICause: e,
@@ -509,6 +587,26 @@ func NewFilePrimaryIdxNoDropError(e error, msg string) Error {
 		InternalMsg: "Primary Index cannot be dropped " + msg, InternalCaller: CallerN(1)}
 }
 
+func NewFileIdxExists(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 15012, IKey: "datastore.file.idx_exists", ICause: e,
+		InternalMsg: "Index already exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewFileIdxDefinitionError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 15013, IKey: "datastore.file.idx_definition_error", ICause: e,
+		InternalMsg: "Invalid index definition " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewFileIdxNotOnline(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 15014, IKey: "datastore.file.idx_not_online", ICause: e,
+		InternalMsg: "Index is not online " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewFileCasMismatchError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 15015, IKey: "datastore.file.cas_mismatch", ICause: e,
+		InternalMsg: "Document was modified after it was read " + msg, InternalCaller: CallerN(1)}
+}
+
 // Error codes for all other datastores, e.g Mock
 func NewOtherDatastoreError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 16000, IKey: "datastore.other.datastore_generic_error", ICause: e,
@@ -550,6 +648,11 @@ func NewOtherKeyNotFoundError(e error, msg string) Error {
 		InternalMsg: "Key not found " + msg, InternalCaller: CallerN(1)}
 }
 
+func NewOtherCasMismatchError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 16008, IKey: "datastore.other.cas_mismatch", ICause: e,
+		InternalMsg: "Document was modified after it was read " + msg, InternalCaller: CallerN(1)}
+}
+
 // Returns "FileName:LineNum" of caller.
 func Caller() string {
 	return CallerN(1)
@@ -0,0 +1,100 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/mock"
+	"github.com/couchbase/query/plan"
+)
+
+const _TEST_USERS = `{
+    "reader": { "password": "r", "roles": { "p0:b0": "read" } },
+    "writer": { "password": "w", "roles": { "p0:*": "write" } }
+}`
+
+func TestAuthorize(t *testing.T) {
+	file, er := ioutil.TempFile("", "authorize_users")
+	if er != nil {
+		t.Fatalf("failed to create users file: %v", er)
+	}
+	defer os.Remove(file.Name())
+
+	_, er = file.WriteString(_TEST_USERS)
+	file.Close()
+	if er != nil {
+		t.Fatalf("failed to write users file: %v", er)
+	}
+
+	store, err := mock.NewDatastore("mock:users=" + file.Name())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	saved := datastore.GetDatastore()
+	datastore.SetDatastore(store)
+	defer datastore.SetDatastore(saved)
+
+	read := datastore.Privileges{"p0:b0": datastore.PRIV_READ}
+	write := datastore.Privileges{"p0:b0": datastore.PRIV_WRITE}
+	both := datastore.Privileges{"p0:b0": datastore.PRIV_READ, "p0:b1": datastore.PRIV_READ}
+
+	cases := []struct {
+		privileges  datastore.Privileges
+		credentials datastore.Credentials
+		ok          bool
+	}{
+		{read, nil, false},
+		{read, datastore.Credentials{"reader": "w"}, false},
+		{read, datastore.Credentials{"unknown": "r"}, false},
+		{write, datastore.Credentials{"reader": "r"}, false},
+		{both, datastore.Credentials{"reader": "r"}, false},
+		{read, datastore.Credentials{"reader": "r"}, true},
+		{write, datastore.Credentials{"writer": "w"}, true},
+		{both, datastore.Credentials{"reader": "r", "writer": "w"}, true},
+		{datastore.Privileges{}, nil, true},
+	}
+
+	for i, c := range cases {
+		output := &testingOutput{}
+		context := NewContext(store, nil, "p0", false, nil, nil, c.credentials,
+			datastore.UNBOUNDED, nil, output)
+
+		op := NewAuthorize(plan.NewAuthorize(c.privileges, plan.NewDummyScan()), NewDummyScan())
+		op.RunOnce(context, nil)
+
+		items := 0
+		for _ = range op.ItemChannel() {
+			items++
+		}
+
+		if c.ok {
+			if len(output.fatals) != 0 || items != 1 {
+				t.Errorf("case %d: expected authorization, got %v and %d items", i, output.fatals, items)
+			}
+
+			continue
+		}
+
+		if len(output.fatals) != 1 || items != 0 {
+			t.Errorf("case %d: expected one fatal error and no items, got %v and %d items",
+				i, output.fatals, items)
+			continue
+		}
+
+		if code := output.fatals[0].Code(); code != 10000 {
+			t.Errorf("case %d: expected authorization error, got %d: %v", i, code, output.fatals[0])
+		}
+	}
+}
@@ -11,8 +11,10 @@ package execution
 
 import (
 	"fmt"
+	"math"
 	"sync"
 
+	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/value"
 )
@@ -230,6 +232,71 @@ func (this *base) enbatch(item value.AnnotatedValue, b batcher, context *Context
 	return true
 }
 
+// Record the CAS of the document from which the item was fetched, so
+// that mutations of the item can be made conditional on it. The raw
+// CAS attached by the datastore is preferred to the float64 in meta,
+// which cannot represent a CAS over 2^53 exactly.
+func setCas(item, doc value.AnnotatedValue) {
+	if cas, ok := doc.GetAttachment(datastore.CAS_ATTACHMENT).(uint64); ok {
+		item.SetAttachment("cas", cas)
+		return
+	}
+
+	if meta, ok := doc.GetAttachment("meta").(map[string]interface{}); ok {
+		if cas, ok := meta["cas"]; ok {
+			item.SetAttachment("cas", cas)
+		}
+	}
+}
+
+// Return the CAS recorded by setCas, or 0 if there is none.
+func (this *base) fetchedCas(item value.AnnotatedValue) uint64 {
+	switch cas := item.GetAttachment("cas").(type) {
+	case float64:
+		return uint64(cas)
+	case uint64:
+		return cas
+	default:
+		return 0
+	}
+}
+
+// Return the expiration given by the options of a mutation, such as
+// {"expiration": 3600}, or 0 if there is none.
+func optionsExpiration(options value.Value) (uint32, errors.Error) {
+	if options == nil || options.Type() <= value.NULL {
+		return 0, nil
+	}
+
+	if options.Type() != value.OBJECT {
+		return 0, errors.NewError(nil, fmt.Sprintf("Invalid OPTIONS %v; expected an object.", options))
+	}
+
+	for name, _ := range options.Fields() {
+		if name != "expiration" {
+			return 0, errors.NewError(nil, fmt.Sprintf("Unsupported option %s in OPTIONS %v.", name, options))
+		}
+	}
+
+	exp, _ := options.Field("expiration")
+	return expiration(exp)
+}
+
+// Return an expiration in seconds, as accepted by datastore.Pair.
+func expiration(exp value.Value) (uint32, errors.Error) {
+	if exp == nil || exp.Type() <= value.NULL {
+		return 0, nil
+	}
+
+	e, ok := exp.Actual().(float64)
+	if !ok || e < 0 || e > math.MaxUint32 || e != math.Trunc(e) {
+		return 0, errors.NewError(nil, fmt.Sprintf(
+			"Invalid expiration %v; expected a non-negative integer number of seconds.", exp))
+	}
+
+	return uint32(e), nil
+}
+
 func (this *base) requireKey(item value.AnnotatedValue, context *Context) (string, bool) {
 	mv := item.GetAttachment("meta")
 	if mv == nil {
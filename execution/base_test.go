@@ -0,0 +1,55 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/value"
+)
+
+func TestFetchedCas(t *testing.T) {
+	// Above 2^53, so not exactly representable as a float64
+	const large = uint64(1)<<60 + 1
+	if uint64(float64(large)) == large {
+		t.Fatalf("expected %d to lose precision as a float64", large)
+	}
+
+	b := newBase()
+
+	// The raw CAS attached by the datastore survives exactly
+	doc := value.NewAnnotatedValue(map[string]interface{}{"a": 1})
+	doc.SetAttachment("meta", map[string]interface{}{"id": "k", "cas": float64(large)})
+	doc.SetAttachment(datastore.CAS_ATTACHMENT, large)
+
+	item := value.NewAnnotatedValue(map[string]interface{}{})
+	setCas(item, doc)
+	if cas := b.fetchedCas(item); cas != large {
+		t.Errorf("expected CAS %d, got %d", large, cas)
+	}
+
+	// Without it, the CAS in meta is used
+	doc = value.NewAnnotatedValue(map[string]interface{}{"a": 1})
+	doc.SetAttachment("meta", map[string]interface{}{"id": "k", "cas": float64(12345)})
+
+	item = value.NewAnnotatedValue(map[string]interface{}{})
+	setCas(item, doc)
+	if cas := b.fetchedCas(item); cas != 12345 {
+		t.Errorf("expected CAS 12345, got %d", cas)
+	}
+
+	// Without either, mutations are unconditional
+	item = value.NewAnnotatedValue(map[string]interface{}{})
+	setCas(item, value.NewAnnotatedValue(map[string]interface{}{}))
+	if cas := b.fetchedCas(item); cas != 0 {
+		t.Errorf("expected no CAS, got %d", cas)
+	}
+}
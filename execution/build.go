@@ -98,6 +98,14 @@ func (this *builder) VisitJoin(plan *plan.Join) (interface{}, error) {
 	return NewJoin(plan), nil
 }
 
+func (this *builder) VisitIndexJoin(plan *plan.IndexJoin) (interface{}, error) {
+	return NewIndexJoin(plan), nil
+}
+
+func (this *builder) VisitHashJoin(plan *plan.HashJoin) (interface{}, error) {
+	return NewHashJoin(plan), nil
+}
+
 func (this *builder) VisitNest(plan *plan.Nest) (interface{}, error) {
 	return NewNest(plan), nil
 }
@@ -129,6 +137,11 @@ func (this *builder) VisitFinalGroup(plan *plan.FinalGroup) (interface{}, error)
 	return NewFinalGroup(plan), nil
 }
 
+// Window
+func (this *builder) VisitWindow(plan *plan.Window) (interface{}, error) {
+	return NewWindow(plan), nil
+}
+
 // Project
 func (this *builder) VisitInitialProject(plan *plan.InitialProject) (interface{}, error) {
 	return NewInitialProject(plan), nil
@@ -349,6 +362,31 @@ func (this *builder) VisitBuildIndexes(plan *plan.BuildIndexes) (interface{}, er
 	return NewBuildIndexes(plan), nil
 }
 
+// CreateFunction
+func (this *builder) VisitCreateFunction(plan *plan.CreateFunction) (interface{}, error) {
+	return NewCreateFunction(plan), nil
+}
+
+// DropFunction
+func (this *builder) VisitDropFunction(plan *plan.DropFunction) (interface{}, error) {
+	return NewDropFunction(plan), nil
+}
+
+// UpdateStatistics
+func (this *builder) VisitUpdateStatistics(plan *plan.UpdateStatistics) (interface{}, error) {
+	return NewUpdateStatistics(plan), nil
+}
+
+// GrantRole
+func (this *builder) VisitGrantRole(plan *plan.GrantRole) (interface{}, error) {
+	return NewGrantRole(plan), nil
+}
+
+// RevokeRole
+func (this *builder) VisitRevokeRole(plan *plan.RevokeRole) (interface{}, error) {
+	return NewRevokeRole(plan), nil
+}
+
 // Prepare
 func (this *builder) VisitPrepare(plan *plan.Prepare) (interface{}, error) {
 	return NewPrepare(plan.Prepared()), nil
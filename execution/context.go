@@ -14,9 +14,11 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/clustering"
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/logging"
@@ -49,8 +51,22 @@ type Context struct {
 	output         Output
 	subplans       *subqueryMap
 	subresults     *subqueryMap
+	orderLimit     int64
+	mutationLimit  int64
+	mutations      int64
+	sortMemory     int64
+	sortMemoryUsed int64
+	functionStore  clustering.FunctionStore
+	histogramStore clustering.HistogramStore
+	warned         *warningSet
+	expiration     bool // Whether the request reads META().expiration
 }
 
+// Default memory budget, in bytes, of the ORDER BY and hash JOIN
+// operators of a request. Sorts spill to disk once the budget is
+// exceeded, and hash joins fail.
+const SORT_MEMORY_DEFAULT = 64 << 20
+
 func NewContext(datastore, systemstore datastore.Datastore, namespace string,
 	readonly bool, namedArgs map[string]value.Value, positionalArgs value.Values,
 	credentials datastore.Credentials, consistency datastore.ScanConsistency,
@@ -69,6 +85,8 @@ func NewContext(datastore, systemstore datastore.Datastore, namespace string,
 		output:         output,
 		subplans:       newSubqueryMap(),
 		subresults:     newSubqueryMap(),
+		sortMemory:     SORT_MEMORY_DEFAULT,
+		warned:         newWarningSet(),
 	}
 }
 
@@ -120,6 +138,84 @@ func (this *Context) ScanVector() timestamp.Vector {
 	return this.vector
 }
 
+// Maximum number of items sorted by ORDER BY; zero or negative
+// disables the limit
+func (this *Context) SetOrderLimit(limit int64) {
+	this.orderLimit = limit
+}
+
+func (this *Context) OrderLimit() int64 {
+	return this.orderLimit
+}
+
+// Maximum number of documents modified by a DELETE, UPDATE or MERGE;
+// zero or negative disables the limit
+func (this *Context) SetMutationLimit(limit int64) {
+	this.mutationLimit = limit
+}
+
+func (this *Context) MutationLimit() int64 {
+	return this.mutationLimit
+}
+
+// Counts a document about to be modified. Returns false if the
+// request exceeds the mutation limit.
+func (this *Context) reserveMutation() bool {
+	mutations := atomic.AddInt64(&this.mutations, 1)
+	return this.mutationLimit <= 0 || mutations <= this.mutationLimit
+}
+
+// Memory budget of the ORDER BY and hash JOIN operators; zero or
+// negative disables spilling to disk, and limiting hash joins
+func (this *Context) SetSortMemory(size int64) {
+	this.sortMemory = size
+}
+
+func (this *Context) SortMemory() int64 {
+	return this.sortMemory
+}
+
+// Store of user-defined function definitions; nil if definitions are
+// not persisted
+// Set if the request reads META().expiration, which some keyspaces
+// only fetch when asked to.
+func (this *Context) SetFetchExpiration(fetch bool) {
+	this.expiration = fetch
+}
+
+func (this *Context) FetchExpiration() bool {
+	return this.expiration
+}
+
+func (this *Context) SetFunctionStore(store clustering.FunctionStore) {
+	this.functionStore = store
+}
+
+func (this *Context) FunctionStore() clustering.FunctionStore {
+	return this.functionStore
+}
+
+// Store of the histograms collected by UPDATE STATISTICS; nil if
+// histograms are not persisted
+func (this *Context) SetHistogramStore(store clustering.HistogramStore) {
+	this.histogramStore = store
+}
+
+func (this *Context) HistogramStore() clustering.HistogramStore {
+	return this.histogramStore
+}
+
+// Returns false if the reservation exceeds the sort memory budget.
+// The size is reserved in either case.
+func (this *Context) reserveSortMemory(size int64) bool {
+	used := atomic.AddInt64(&this.sortMemoryUsed, size)
+	return this.sortMemory <= 0 || used <= this.sortMemory
+}
+
+func (this *Context) releaseSortMemory(size int64) {
+	atomic.AddInt64(&this.sortMemoryUsed, -size)
+}
+
 func (this *Context) AddMutationCount(i uint64) {
 	this.output.AddMutationCount(i)
 }
@@ -148,6 +244,14 @@ func (this *Context) Warning(wrn errors.Error) {
 	this.output.Warning(wrn)
 }
 
+// Issue the warning unless the request already has, e.g. when the
+// scans of a correlated subquery run for each outer item.
+func (this *Context) warnOnce(wrn errors.Error) {
+	if this.warned.add(fmt.Sprintf("%d %s", wrn.Code(), wrn.Error())) {
+		this.output.Warning(wrn)
+	}
+}
+
 func (this *Context) EvaluateSubquery(query *algebra.Select, parent value.Value) (value.Value, error) {
 	subresult, ok := this.subresults.get(query)
 	if ok {
@@ -218,6 +322,29 @@ func (this *subqueryMap) set(key *algebra.Select, value interface{}) {
 	this.mutex.Unlock()
 }
 
+// Synchronized set
+type warningSet struct {
+	mutex   sync.Mutex
+	entries map[string]bool
+}
+
+func newWarningSet() *warningSet {
+	return &warningSet{entries: make(map[string]bool)}
+}
+
+// Returns false if the key was already in the set.
+func (this *warningSet) add(key string) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.entries[key] {
+		return false
+	}
+
+	this.entries[key] = true
+	return true
+}
+
 func (this *Context) Recover() {
 	err := recover()
 	if err != nil {
@@ -12,6 +12,7 @@ package execution
 import (
 	"fmt"
 
+	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/value"
@@ -47,6 +48,11 @@ func (this *SendDelete) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *SendDelete) processItem(item value.AnnotatedValue, context *Context) bool {
+	if this.limit != 0 && !context.reserveMutation() {
+		context.Error(errors.NewMutationLimitError(context.MutationLimit()))
+		return false
+	}
+
 	rv := this.limit != 0 && this.enbatch(item, this, context)
 
 	if this.limit > 0 {
@@ -87,22 +93,38 @@ func (this *SendDelete) flushBatch(context *Context) bool {
 		return true
 	}
 
-	keys := make([]string, len(this.batch))
+	pairs := make([]datastore.Pair, len(this.batch))
 
 	for i, av := range this.batch {
 		key, ok := this.requireKey(av, context)
 		if !ok {
 			return false
 		}
-		keys[i] = key
+		pairs[i].Key = key
+		pairs[i].Cas = this.fetchedCas(av)
 	}
 
-	deleted_keys, e := this.plan.Keyspace().Delete(keys)
+	var deleted_keys []string
+	var errs errors.Errors
+	if keyspace, ok := this.plan.Keyspace().(datastore.CasKeyspace); ok {
+		deleted_keys, errs = keyspace.DeleteCas(pairs)
+	} else {
+		keys := make([]string, len(pairs))
+		for i, pair := range pairs {
+			keys[i] = pair.Key
+		}
+
+		var e errors.Error
+		deleted_keys, e = this.plan.Keyspace().Delete(keys)
+		if e != nil {
+			errs = errors.Errors{e}
+		}
+	}
 
 	// Update mutation count with number of deleted docs:
 	context.AddMutationCount(uint64(len(deleted_keys)))
 
-	if e != nil {
+	for _, e := range errs {
 		context.Error(e)
 	}
 
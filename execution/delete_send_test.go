@@ -0,0 +1,87 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchbase/query/datastore/file"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+func TestSendDeleteMutationLimit(t *testing.T) {
+	dir, er := ioutil.TempDir("", "delete_send")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	ksdir := filepath.Join(dir, "default", "t")
+	if er = os.MkdirAll(ksdir, 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	items := make(value.AnnotatedValues, 5)
+	for i := range items {
+		key := fmt.Sprintf("k%d", i)
+		if er = ioutil.WriteFile(filepath.Join(ksdir, key+".json"), []byte(`{"a": 1}`), 0666); er != nil {
+			t.Fatalf("failed to write %s: %v", key, er)
+		}
+
+		items[i] = value.NewAnnotatedValue(map[string]interface{}{"a": 1})
+		items[i].SetAttachment("meta", map[string]interface{}{"id": key})
+	}
+
+	store, err := file.NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, _ := store.NamespaceByName("default")
+	keyspace, err := namespace.KeyspaceByName("t")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	// The fourth document exceeds the limit of three mutations
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	context.SetMutationLimit(3)
+	runTesting(NewSendDelete(plan.NewSendDelete(keyspace, nil)), items, context)
+
+	if len(output.errors) != 1 || output.errors[0].Code() != 5050 {
+		t.Fatalf("expected mutation limit error, got %v", output.errors)
+	}
+
+	if count, _ := keyspace.Count(); count != 2 {
+		t.Errorf("expected 2 documents to remain, got %d", count)
+	}
+
+	// The limit is shared by the operators of the request
+	context.mutations = 0
+	if !context.reserveMutation() || !context.reserveMutation() || !context.reserveMutation() {
+		t.Errorf("expected 3 mutations to be reserved")
+	}
+
+	if context.reserveMutation() {
+		t.Errorf("expected the fourth mutation to exceed the limit")
+	}
+
+	// Without a limit, every mutation is reserved
+	context.SetMutationLimit(0)
+	if !context.reserveMutation() {
+		t.Errorf("expected mutation to be reserved without a limit")
+	}
+}
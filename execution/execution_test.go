@@ -0,0 +1,86 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+// testingOutput records the errors of a request.
+type testingOutput struct {
+	fatals errors.Errors
+	errors errors.Errors
+}
+
+func (this *testingOutput) Result(item value.Value) bool { return true }
+func (this *testingOutput) CloseResults()                {}
+func (this *testingOutput) Fatal(err errors.Error)       { this.fatals = append(this.fatals, err) }
+func (this *testingOutput) Error(err errors.Error)       { this.errors = append(this.errors, err) }
+func (this *testingOutput) Warning(wrn errors.Error)     {}
+func (this *testingOutput) AddMutationCount(uint64)      {}
+func (this *testingOutput) MutationCount() uint64        { return 0 }
+
+func newTestingContext(output Output) *Context {
+	return NewContext(nil, nil, "default", false, nil, nil, nil, datastore.UNBOUNDED, nil, output)
+}
+
+// testingSource is an operator that produces the given items.
+type testingSource struct {
+	base
+	items value.AnnotatedValues
+}
+
+func newTestingSource(items value.AnnotatedValues) *testingSource {
+	rv := &testingSource{
+		base:  newBase(),
+		items: items,
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *testingSource) Accept(visitor Visitor) (interface{}, error) {
+	return nil, nil
+}
+
+func (this *testingSource) Copy() Operator {
+	return &testingSource{this.base.copy(), this.items}
+}
+
+func (this *testingSource) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer close(this.itemChannel) // Broadcast that I have stopped
+		defer this.notify()           // Notify that I have stopped
+
+		for _, item := range this.items {
+			if !this.sendItem(item) {
+				return
+			}
+		}
+	})
+}
+
+// runTesting runs the operator on the items, and returns its output.
+func runTesting(op Operator, items value.AnnotatedValues, context *Context) value.AnnotatedValues {
+	source := newTestingSource(items)
+	op.SetInput(source)
+
+	go op.RunOnce(context, nil)
+
+	rv := make(value.AnnotatedValues, 0, len(items))
+	for item := range op.ItemChannel() {
+		rv = append(rv, item)
+	}
+
+	return rv
+}
@@ -12,6 +12,7 @@ package execution
 import (
 	"fmt"
 
+	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/value"
@@ -83,7 +84,7 @@ func (this *Fetch) flushBatch(context *Context) bool {
 	}
 
 	// Fetch
-	pairs, err := this.plan.Keyspace().Fetch(keys)
+	pairs, err := fetchKeys(this.plan.Keyspace(), keys, context)
 	if err != nil {
 		context.Error(err)
 		return false
@@ -116,8 +117,8 @@ func (this *Fetch) flushBatch(context *Context) bool {
 		av := this.batch[i]
 		switch item := item.(type) {
 		case value.AnnotatedValue:
-			meta := item.(value.AnnotatedValue).GetAttachment("meta")
-			fv.SetAttachment("meta", meta)
+			fv.SetAttachment("meta", item.GetAttachment("meta"))
+			setCas(av, item)
 		default:
 			fv.SetAttachment("meta", av.GetAttachment("meta"))
 		}
@@ -130,3 +131,23 @@ func (this *Fetch) flushBatch(context *Context) bool {
 
 	return true
 }
+
+// Fetches the keys, adding the expiration to the meta of each document
+// if the request reads it and the keyspace leaves it out. Documents
+// whose expiration cannot be fetched are returned without it, with a
+// warning.
+func fetchKeys(keyspace datastore.Keyspace, keys []string,
+	context *Context) ([]datastore.AnnotatedPair, errors.Error) {
+	pairs, err := keyspace.Fetch(keys)
+	if err != nil || !context.FetchExpiration() {
+		return pairs, err
+	}
+
+	if expirations, ok := keyspace.(datastore.ExpirationKeyspace); ok {
+		for _, wrn := range expirations.FetchExpirations(pairs) {
+			context.Warning(wrn)
+		}
+	}
+
+	return pairs, nil
+}
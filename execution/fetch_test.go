@@ -0,0 +1,89 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"os"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+)
+
+// expirationKeyspace fetches expirations separately, and fails for the
+// keys in failed.
+type expirationKeyspace struct {
+	datastore.Keyspace
+	failed map[string]bool
+	calls  int
+}
+
+func (this *expirationKeyspace) FetchExpirations(pairs []datastore.AnnotatedPair) errors.Errors {
+	this.calls++
+
+	var warnings errors.Errors
+	for _, pair := range pairs {
+		if this.failed[pair.Key] {
+			warnings = append(warnings, errors.NewWarning("no expiration for "+pair.Key))
+			continue
+		}
+
+		pair.Value.GetAttachment("meta").(map[string]interface{})["expiration"] = float64(42)
+	}
+
+	return warnings
+}
+
+func TestFetchExpiration(t *testing.T) {
+	store, dir := newJoinTestStore(t)
+	defer os.RemoveAll(dir)
+
+	namespace, _ := store.NamespaceByName("default")
+	r, err := namespace.KeyspaceByName("r")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	keyspace := &expirationKeyspace{Keyspace: r, failed: map[string]bool{"r1": true}}
+	keys := []string{"r0", "r1"}
+
+	// Expirations are only fetched for requests that read them
+	output := &warningOutput{}
+	context := newTestingContext(output)
+	pairs, err := fetchKeys(keyspace, keys, context)
+	if err != nil || len(pairs) != 2 || keyspace.calls != 0 {
+		t.Fatalf("expected 2 documents without expirations, got %v %v %d", pairs, err, keyspace.calls)
+	}
+
+	context.SetFetchExpiration(true)
+	pairs, err = fetchKeys(keyspace, keys, context)
+	if err != nil || len(pairs) != 2 || keyspace.calls != 1 {
+		t.Fatalf("expected 2 documents with expirations, got %v %v %d", pairs, err, keyspace.calls)
+	}
+
+	for _, pair := range pairs {
+		expiration := pair.Value.GetAttachment("meta").(map[string]interface{})["expiration"]
+		if (expiration == float64(42)) == keyspace.failed[pair.Key] {
+			t.Errorf("unexpected expiration %v for %s", expiration, pair.Key)
+		}
+	}
+
+	// Failures are reported as warnings
+	if len(output.warnings) != 1 || output.warnings[0].Error() != "no expiration for r1" {
+		t.Errorf("expected a warning for r1, got %v", output.warnings)
+	}
+
+	// Keyspaces that fetch expirations along with the documents are
+	// not asked again
+	pairs, err = fetchKeys(r, keys, context)
+	if err != nil || len(pairs) != 2 || len(output.warnings) != 1 {
+		t.Errorf("expected 2 documents, got %v %v %v", pairs, err, output.warnings)
+	}
+}
@@ -0,0 +1,103 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+type CreateFunction struct {
+	base
+	plan *plan.CreateFunction
+}
+
+func NewCreateFunction(plan *plan.CreateFunction) *CreateFunction {
+	rv := &CreateFunction{
+		base: newBase(),
+		plan: plan,
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *CreateFunction) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateFunction(this)
+}
+
+func (this *CreateFunction) Copy() Operator {
+	return &CreateFunction{this.base.copy(), this.plan}
+}
+
+func (this *CreateFunction) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover()       // Recover from any panic
+		defer close(this.itemChannel) // Broadcast that I have stopped
+		defer this.notify()           // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		node := this.plan.Node()
+		store := context.FunctionStore()
+		if store == nil {
+			if !expression.RegisterUserFunction(node.Name(), node.Parameters(), node.Body()) {
+				context.Error(errors.NewFunctionExistsError(node.Name()))
+			}
+			return
+		}
+
+		if _, ok := expression.GetUserFunction(node.Name()); ok {
+			context.Error(errors.NewFunctionExistsError(node.Name()))
+			return
+		}
+
+		// Persist the definition first, so that it outlives the server,
+		// and is not dropped by a reload from the store in between. The
+		// store fails if another node or request has created the function.
+		err := store.CreateFunctionDefinition(node.Name(), node.String())
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		// A reload from the store may have registered the definition
+		// already; anything else is a conflict
+		if !expression.RegisterUserFunction(node.Name(), node.Parameters(), node.Body()) &&
+			!registered(node.Name(), node.Parameters(), node.Body()) {
+			context.Error(errors.NewFunctionExistsError(node.Name()))
+		}
+	})
+}
+
+// Whether the function is registered with this definition
+func registered(name string, parameters []string, body expression.Expression) bool {
+	fn, ok := expression.GetUserFunction(name)
+	if !ok {
+		return false
+	}
+
+	userFn, ok := fn.(*expression.UserFunction)
+	if !ok || len(userFn.Parameters()) != len(parameters) {
+		return false
+	}
+
+	for i, parameter := range parameters {
+		if userFn.Parameters()[i] != parameter {
+			return false
+		}
+	}
+
+	return userFn.Body().EquivalentTo(body)
+}
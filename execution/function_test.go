@@ -0,0 +1,141 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+)
+
+type testingFunctionStore map[string]string
+
+func (this testingFunctionStore) FunctionDefinitions() (map[string]string, errors.Error) {
+	return this, nil
+}
+
+func (this testingFunctionStore) CreateFunctionDefinition(name, definition string) errors.Error {
+	if _, ok := this[name]; ok {
+		return errors.NewFunctionExistsError(name)
+	}
+	this[name] = definition
+	return nil
+}
+
+func (this testingFunctionStore) DeleteFunctionDefinition(name string) errors.Error {
+	delete(this, name)
+	return nil
+}
+
+func runFunctionOp(op Operator, context *Context) {
+	go op.RunOnce(context, nil)
+	for _ = range op.ItemChannel() {
+	}
+}
+
+func TestCreateDropFunction(t *testing.T) {
+	store := testingFunctionStore{}
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	context.SetFunctionStore(store)
+	defer expression.UnregisterUserFunction("fn_twice")
+
+	body, _ := n1ql.ParseExpression("x * 2")
+	create := algebra.NewCreateFunction("fn_twice", []string{"x"}, body)
+	runFunctionOp(NewCreateFunction(plan.NewCreateFunction(create)), context)
+
+	if _, ok := expression.GetUserFunction("fn_twice"); !ok || store["fn_twice"] == "" {
+		t.Fatalf("expected fn_twice to be registered and stored, got %v and %v", ok, store)
+	}
+
+	// Creating the function again fails, and keeps its definition
+	runFunctionOp(NewCreateFunction(plan.NewCreateFunction(create)), context)
+	if len(output.errors) != 1 || output.errors[0].Code() != 5030 {
+		t.Fatalf("expected function exists error, got %v", output.errors)
+	}
+
+	// Prepared statements that call the function are invalidated
+	stmt, err := n1ql.ParseStatement("SELECT fn_twice(1) AS t")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	prepared, err := plan.BuildPrepared(stmt, nil, nil, "default", false)
+	if err != nil {
+		t.Fatalf("failed to prepare: %v", err)
+	}
+
+	if functions := prepared.Functions(); len(functions) != 1 || functions[0] != "fn_twice" {
+		t.Errorf("expected prepared statement to call fn_twice, got %v", functions)
+	}
+
+	prepared.SetName("fn_p1")
+	plan.PreparedCache().AddPrepared(prepared)
+	defer plan.PreparedCache().DeletePrepared("fn_p1")
+
+	output.errors = nil
+	runFunctionOp(NewDropFunction(plan.NewDropFunction(algebra.NewDropFunction("fn_twice"))), context)
+	if len(output.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", output.errors)
+	}
+
+	if _, ok := expression.GetUserFunction("fn_twice"); ok || len(store) != 0 {
+		t.Errorf("expected fn_twice to be unregistered and deleted, got %v and %v", ok, store)
+	}
+
+	if plan.PreparedCache().Entry("fn_p1") != nil {
+		t.Errorf("expected prepared statement to be invalidated")
+	}
+
+	// Dropping it again fails
+	runFunctionOp(NewDropFunction(plan.NewDropFunction(algebra.NewDropFunction("fn_twice"))), context)
+	if len(output.errors) != 1 {
+		t.Errorf("expected function not found error, got %v", output.errors)
+	}
+}
+
+func TestCreateFunctionConflict(t *testing.T) {
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	defer expression.UnregisterUserFunction("fn_conflict")
+
+	// Another node has stored the function, which this node has not loaded yet
+	store := testingFunctionStore{"fn_conflict": "CREATE FUNCTION fn_conflict(x) { x + 1 }"}
+	context.SetFunctionStore(store)
+
+	body, _ := n1ql.ParseExpression("x * 2")
+	create := algebra.NewCreateFunction("fn_conflict", []string{"x"}, body)
+	runFunctionOp(NewCreateFunction(plan.NewCreateFunction(create)), context)
+
+	if len(output.errors) != 1 || output.errors[0].Code() != 5030 {
+		t.Fatalf("expected function exists error, got %v", output.errors)
+	}
+
+	if _, ok := expression.GetUserFunction("fn_conflict"); ok {
+		t.Errorf("expected fn_conflict not to be registered")
+	}
+
+	if store["fn_conflict"] != "CREATE FUNCTION fn_conflict(x) { x + 1 }" {
+		t.Errorf("expected the stored definition to be kept, got %v", store)
+	}
+
+	// A reload from the store may register the function in between;
+	// only another definition is a conflict
+	other, _ := n1ql.ParseExpression("x + 1")
+	expression.RegisterUserFunction("fn_conflict", []string{"x"}, other)
+	if registered("fn_conflict", []string{"x"}, body) || !registered("fn_conflict", []string{"x"}, other) ||
+		registered("fn_conflict", []string{"y"}, other) {
+		t.Errorf("expected the registered definition to be compared")
+	}
+}
@@ -10,6 +10,10 @@
 package execution
 
 import (
+	"fmt"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/value"
 )
@@ -47,6 +51,35 @@ func (this *AlterIndex) RunOnce(context *Context, parent value.Value) {
 			return
 		}
 
-		// Actually alter index
+		node := this.plan.Node()
+		if node.Rename() == "" {
+			return
+		}
+
+		index := this.plan.Index()
+		indexer, err := this.plan.Keyspace().Indexer(index.Type())
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		renamer, ok := indexer.(datastore.IndexRenamer)
+		if !ok {
+			context.Error(errors.NewOtherNotSupportedError(nil,
+				fmt.Sprintf("ALTER INDEX RENAME is not supported for %s indexes.", index.Type())))
+			return
+		}
+
+		// Actually rename index
+		oldName := index.Name()
+		err = renamer.RenameIndex(index, node.Rename())
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		// Invalidate cached plans that use the old name
+		keyspace := this.plan.Keyspace()
+		plan.PreparedCache().InvalidateIndex(keyspace.NamespaceId(), keyspace.Name(), oldName)
 	})
 }
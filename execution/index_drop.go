@@ -51,6 +51,12 @@ func (this *DropIndex) RunOnce(context *Context, parent value.Value) {
 		err := this.plan.Index().Drop()
 		if err != nil {
 			context.Error(err)
+			return
 		}
+
+		// Invalidate cached plans that use the index
+		node := this.plan.Node()
+		plan.PreparedCache().InvalidateIndex(node.Keyspace().Namespace(),
+			node.Keyspace().Keyspace(), this.plan.Index().Name())
 	})
 }
@@ -90,8 +90,9 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 
 	keyExpr := this.plan.Key()
 	valExpr := this.plan.Value()
+	optsExpr := this.plan.Options()
 	dpairs := make([]datastore.Pair, len(this.batch))
-	var key, val value.Value
+	var key, val, opts value.Value
 	var err error
 	var ok bool
 	i := 0
@@ -118,6 +119,15 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 			} else {
 				val = av
 			}
+
+			if optsExpr != nil {
+				opts, err = optsExpr.Evaluate(av, context)
+				if err != nil {
+					context.Error(errors.NewError(err,
+						fmt.Sprintf("Error evaluating INSERT options for %v", av.GetValue())))
+					continue
+				}
+			}
 		} else {
 			// INSERT ... VALUES
 			key, ok = av.GetAttachment("key").(value.Value)
@@ -133,6 +143,8 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 					fmt.Sprintf("No INSERT value for %v", av.GetValue())))
 				continue
 			}
+
+			opts, _ = av.GetAttachment("options").(value.Value)
 		}
 
 		dpair.Key, ok = key.Actual().(string)
@@ -143,6 +155,14 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 		}
 
 		dpair.Value = val
+
+		var e errors.Error
+		dpair.Expiration, e = optionsExpiration(opts)
+		if e != nil {
+			context.Error(e)
+			continue
+		}
+
 		i++
 	}
 
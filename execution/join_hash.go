@@ -0,0 +1,167 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"math"
+	"sync"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// Hash join: the right keyspace is read once into an in-memory hash
+// table on its join keys, which is then probed with each left item.
+// The table is held for the rest of the request, within the sort
+// memory budget of the request.
+type HashJoin struct {
+	base
+	plan  *plan.HashJoin
+	table *hashTable // Shared by all copies
+}
+
+type hashTable struct {
+	once    sync.Once
+	ok      bool
+	buckets map[string][]value.AnnotatedValue
+}
+
+const _HASH_BATCH = 1024
+
+func NewHashJoin(plan *plan.HashJoin) *HashJoin {
+	rv := &HashJoin{
+		base:  newBase(),
+		plan:  plan,
+		table: &hashTable{},
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *HashJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitHashJoin(this)
+}
+
+func (this *HashJoin) Copy() Operator {
+	return &HashJoin{this.base.copy(), this.plan, this.table}
+}
+
+func (this *HashJoin) RunOnce(context *Context, parent value.Value) {
+	this.runConsumer(this, context, parent)
+}
+
+func (this *HashJoin) beforeItems(context *Context, parent value.Value) bool {
+	this.table.once.Do(func() {
+		this.table.ok = this.build(context)
+	})
+
+	return this.table.ok
+}
+
+func (this *HashJoin) processItem(item value.AnnotatedValue, context *Context) bool {
+	vals, ok, e := evalJoinKeys(item, this.plan.ProbeExprs(), context)
+	if e != nil {
+		context.Error(errors.NewError(e, "Error evaluating JOIN keys."))
+		return false
+	}
+
+	var docs []value.AnnotatedValue
+	if ok {
+		docs = this.table.buckets[hashKey(vals)]
+	}
+
+	return joinValues(&this.base, item, docs, this.plan.Term().Alias(),
+		this.plan.Onclause(), this.plan.Outer(), context)
+}
+
+// Read the right keyspace through its primary index and hash each
+// document on the join keys.
+func (this *HashJoin) build(context *Context) bool {
+	this.table.buckets = make(map[string][]value.AnnotatedValue)
+
+	conn := datastore.NewIndexConnection(context)
+	go this.plan.Index().ScanEntries(math.MaxInt64,
+		context.ScanConsistency(), context.ScanVector(), conn)
+
+	ok := true
+	keys := make([]string, 0, _HASH_BATCH)
+
+	// Drain the scan even after an error, so that it can finish
+	for entry := range conn.EntryChannel() {
+		if !ok {
+			continue
+		}
+
+		keys = append(keys, entry.PrimaryKey)
+		if len(keys) == _HASH_BATCH {
+			ok = this.add(keys, context)
+			keys = keys[:0]
+		}
+	}
+
+	return ok && this.add(keys, context)
+}
+
+func (this *HashJoin) add(keys []string, context *Context) bool {
+	if len(keys) == 0 {
+		return true
+	}
+
+	pairs, err := fetchKeys(this.plan.Keyspace(), keys, context)
+	if err != nil {
+		context.Error(err)
+		return false
+	}
+
+	alias := this.plan.Term().Alias()
+	for _, pair := range pairs {
+		jv, e := joinValue(pair, this.plan.Term(), context)
+		if e != nil {
+			context.Error(errors.NewError(e, "Error evaluating join path."))
+			return false
+		}
+
+		if jv == nil {
+			continue
+		}
+
+		item := value.NewValue(map[string]interface{}{alias: jv})
+		vals, ok, e := evalJoinKeys(item, this.plan.BuildExprs(), context)
+		if e != nil {
+			context.Error(errors.NewError(e, "Error evaluating JOIN keys."))
+			return false
+		}
+
+		if ok {
+			if !context.reserveSortMemory(sortSize(jv)) {
+				context.Error(errors.NewHashJoinMemoryError(context.SortMemory()))
+				return false
+			}
+
+			key := hashKey(vals)
+			this.table.buckets[key] = append(this.table.buckets[key], jv)
+		}
+	}
+
+	return true
+}
+
+func hashKey(vals value.Values) string {
+	actuals := make([]interface{}, len(vals))
+	for i, val := range vals {
+		actuals[i] = val
+	}
+
+	bytes, _ := value.NewValue(actuals).MarshalJSON()
+	return string(bytes)
+}
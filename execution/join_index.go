@@ -0,0 +1,198 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"math"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// Index nested-loop join: for each left item, scan an index of the
+// right keyspace for the values of the join keys.
+type IndexJoin struct {
+	base
+	plan *plan.IndexJoin
+}
+
+func NewIndexJoin(plan *plan.IndexJoin) *IndexJoin {
+	rv := &IndexJoin{
+		base: newBase(),
+		plan: plan,
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *IndexJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexJoin(this)
+}
+
+func (this *IndexJoin) Copy() Operator {
+	return &IndexJoin{this.base.copy(), this.plan}
+}
+
+func (this *IndexJoin) RunOnce(context *Context, parent value.Value) {
+	this.runConsumer(this, context, parent)
+}
+
+func (this *IndexJoin) processItem(item value.AnnotatedValue, context *Context) bool {
+	vals, ok, e := evalJoinKeys(item, this.plan.Keys(), context)
+	if e != nil {
+		context.Error(errors.NewError(e, "Error evaluating JOIN keys."))
+		return false
+	}
+
+	if !ok {
+		// NULL and MISSING keys match nothing
+		return !this.plan.Outer() || this.sendItem(item)
+	}
+
+	var keys []string
+	if _, primary := this.plan.Index().(datastore.PrimaryIndex); primary {
+		// Look up the document directly
+		if key, ok := vals[0].Actual().(string); ok {
+			keys = []string{key}
+		}
+	} else {
+		keys = this.scan(vals, context)
+	}
+
+	var pairs []datastore.AnnotatedPair
+	if len(keys) > 0 {
+		var err errors.Error
+		pairs, err = fetchKeys(this.plan.Keyspace(), keys, context)
+		if err != nil {
+			context.Error(err)
+			return false
+		}
+	}
+
+	return joinPairs(&this.base, item, pairs, this.plan.Term(),
+		this.plan.Onclause(), this.plan.Outer(), context)
+}
+
+// Returns the primary keys of the index entries equal to vals.
+func (this *IndexJoin) scan(vals value.Values, context *Context) []string {
+	span := &datastore.Span{}
+	span.Range.Low = vals
+	span.Range.High = vals
+	span.Range.Inclusion = datastore.BOTH
+
+	conn := datastore.NewIndexConnection(context)
+	go this.plan.Index().Scan(span, false, math.MaxInt64,
+		context.ScanConsistency(), context.ScanVector(), conn)
+
+	keys := make([]string, 0, 16)
+	for entry := range conn.EntryChannel() {
+		keys = append(keys, entry.PrimaryKey)
+	}
+
+	return keys
+}
+
+// Evaluate the join keys of an item. Returns false if any key is
+// NULL or MISSING, as such keys cannot be equal to anything.
+func evalJoinKeys(item value.Value, keys expression.Expressions, context *Context) (value.Values, bool, error) {
+	vals := make(value.Values, len(keys))
+	for i, key := range keys {
+		val, e := key.Evaluate(item, context)
+		if e != nil {
+			return nil, false, e
+		}
+
+		if val.Type() <= value.NULL {
+			return nil, false, nil
+		}
+
+		vals[i] = val
+	}
+
+	return vals, true, nil
+}
+
+// Returns the right document of a join, with its projection applied,
+// or nil if the projection is MISSING.
+func joinValue(pair datastore.AnnotatedPair, term *algebra.KeyspaceTerm, context *Context) (value.AnnotatedValue, error) {
+	projection := term.Projection()
+	if projection == nil {
+		jv := value.NewAnnotatedValue(pair.Value)
+		if jv.GetAttachment("meta") == nil {
+			jv.SetAttachment("meta", map[string]interface{}{"id": pair.Key})
+		}
+		return jv, nil
+	}
+
+	projected, e := projection.Evaluate(pair.Value, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if projected.Type() == value.MISSING {
+		return nil, nil
+	}
+
+	jv := value.NewAnnotatedValue(projected)
+	jv.SetAttachment("meta", map[string]interface{}{"id": pair.Key})
+	return jv, nil
+}
+
+// Join each of the right documents to the item, and send the joined
+// items that satisfy the onclause. If none do, an outer join sends
+// the item by itself.
+func joinPairs(this *base, item value.AnnotatedValue, pairs []datastore.AnnotatedPair,
+	term *algebra.KeyspaceTerm, onclause expression.Expression, outer bool, context *Context) bool {
+	docs := make([]value.AnnotatedValue, 0, len(pairs))
+	for _, pair := range pairs {
+		jv, e := joinValue(pair, term, context)
+		if e != nil {
+			context.Error(errors.NewError(e, "Error evaluating join path."))
+			return false
+		}
+
+		if jv != nil {
+			docs = append(docs, jv)
+		}
+	}
+
+	return joinValues(this, item, docs, term.Alias(), onclause, outer, context)
+}
+
+func joinValues(this *base, item value.AnnotatedValue, docs []value.AnnotatedValue,
+	alias string, onclause expression.Expression, outer bool, context *Context) bool {
+	found := false
+	for _, doc := range docs {
+		av := value.NewAnnotatedValue(item.Copy())
+		av.SetField(alias, doc)
+
+		match, e := onclause.Evaluate(av, context)
+		if e != nil {
+			context.Error(errors.NewError(e, "Error evaluating JOIN condition."))
+			return false
+		}
+
+		if !match.Truth() {
+			continue
+		}
+
+		found = true
+		if !this.sendItem(av) {
+			return false
+		}
+	}
+
+	return found || !outer || this.sendItem(item)
+}
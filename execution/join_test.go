@@ -0,0 +1,225 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/file"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// collectingOutput keeps the results of a statement.
+type collectingOutput struct {
+	testingOutput
+	results []string
+	done    chan bool
+}
+
+func (this *collectingOutput) Result(item value.Value) bool {
+	bytes, _ := item.MarshalJSON()
+	this.results = append(this.results, string(bytes))
+	return true
+}
+
+func (this *collectingOutput) CloseResults() {
+	close(this.done)
+}
+
+// Creates a file datastore with a keyspace l, where x is 0 to 5 and
+// one document has no x, and a keyspace r, where x is 1, 2, 2 and 4
+// and v is about x * 10. Only x of r is indexed.
+func newJoinTestStore(t *testing.T) (datastore.Datastore, string) {
+	dir, er := ioutil.TempDir("", "join")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+
+	docs := map[string][]string{
+		"l": {`{"x": 0}`, `{"x": 1}`, `{"x": 2}`, `{"x": 3}`, `{"x": 4}`, `{"x": 5}`, `{"y": 1}`},
+		"r": {`{"x": 1, "v": 10}`, `{"x": 2, "v": 20}`, `{"x": 2, "v": 21}`, `{"x": 4, "v": 40}`},
+	}
+
+	for keyspace, values := range docs {
+		ksdir := filepath.Join(dir, "default", keyspace)
+		if er = os.MkdirAll(ksdir, 0755); er != nil {
+			t.Fatalf("failed to create keyspace dir: %v", er)
+		}
+
+		for i, doc := range values {
+			name := filepath.Join(ksdir, fmt.Sprintf("%s%d.json", keyspace, i))
+			if er = ioutil.WriteFile(name, []byte(doc), 0666); er != nil {
+				t.Fatalf("failed to write %s: %v", name, er)
+			}
+		}
+	}
+
+	store, err := file.NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, _ := store.NamespaceByName("default")
+	keyspace, err := namespace.KeyspaceByName("r")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	indexer, err := keyspace.Indexer(datastore.DEFAULT)
+	if err != nil {
+		t.Fatalf("failed to get indexer: %v", err)
+	}
+
+	_, err = indexer.CreateIndex("r_x", nil,
+		expression.Expressions{expression.NewIdentifier("x")}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	return store, dir
+}
+
+// Plans and runs the statement, and returns the names of its join
+// operators and its results.
+func runJoinStatement(t *testing.T, store datastore.Datastore, statement string,
+	context func(*Context)) ([]string, *collectingOutput) {
+	stmt, err := n1ql.ParseStatement(statement)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", statement, err)
+	}
+
+	prepared, err := plan.Build(stmt, store, nil, "default", false)
+	if err != nil {
+		t.Fatalf("failed to plan %s: %v", statement, err)
+	}
+
+	bytes, _ := json.Marshal(prepared)
+	joins := make([]string, 0, 2)
+	for _, name := range []string{"IndexJoin", "HashJoin"} {
+		if strings.Contains(string(bytes), `"#operator":"`+name+`"`) {
+			joins = append(joins, name)
+		}
+	}
+
+	op, err := Build(prepared)
+	if err != nil {
+		t.Fatalf("failed to build %s: %v", statement, err)
+	}
+
+	output := &collectingOutput{done: make(chan bool)}
+	ctx := NewContext(store, nil, "default", false, nil, nil, nil, datastore.UNBOUNDED, nil, output)
+	if context != nil {
+		context(ctx)
+	}
+
+	go op.RunOnce(ctx, nil)
+	<-output.done
+	return joins, output
+}
+
+func TestIndexJoin(t *testing.T) {
+	store, dir := newJoinTestStore(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		statement string
+		join      string
+		results   string
+	}{
+		// Each left item scans the index on r.x
+		{"SELECT l.x, r.v FROM l JOIN r ON r.x = l.x ORDER BY r.v", "IndexJoin",
+			`{"v":10,"x":1} {"v":20,"x":2} {"v":21,"x":2} {"v":40,"x":4}`},
+
+		// Left items without matches, or without a join key
+		{"SELECT l.x, r.v FROM l LEFT JOIN r ON r.x = l.x ORDER BY l.x, r.v", "IndexJoin",
+			`{} {"x":0} {"v":10,"x":1} {"v":20,"x":2} {"v":21,"x":2} {"x":3} {"v":40,"x":4} {"x":5}`},
+
+		// The whole onclause filters the joined items
+		{"SELECT r.v FROM l JOIN r ON r.x = l.x AND r.v > 20 ORDER BY r.v", "IndexJoin",
+			`{"v":21} {"v":40}`},
+
+		// The join key may be an expression on the left items
+		{"SELECT l.x, r.v FROM l JOIN r ON r.x = l.x + 1 ORDER BY r.v", "IndexJoin",
+			`{"v":10,"x":0} {"v":20,"x":1} {"v":21,"x":1} {"v":40,"x":3}`},
+
+		// Without an index on the join key, a hash join
+		{"SELECT l.x, r.v FROM l JOIN r ON r.v = l.x * 10 ORDER BY r.v", "HashJoin",
+			`{"v":10,"x":1} {"v":20,"x":2} {"v":40,"x":4}`},
+
+		// A JOIN in a subquery scans the index for each outer item
+		{"SELECT l.x, (SELECT RAW r2.v FROM l AS l2 USE KEYS meta(l).id JOIN r AS r2 ON r2.x = l2.x " +
+			"ORDER BY r2.v) AS vs FROM l WHERE l.x BETWEEN 1 AND 3 ORDER BY l.x", "",
+			`{"vs":[10],"x":1} {"vs":[20,21],"x":2} {"vs":[],"x":3}`},
+	}
+
+	for _, c := range cases {
+		joins, output := runJoinStatement(t, store, c.statement, nil)
+		if len(output.errors) != 0 || len(output.fatals) != 0 {
+			t.Errorf("%s: unexpected errors: %v %v", c.statement, output.errors, output.fatals)
+			continue
+		}
+
+		if c.join != "" && strings.Join(joins, " ") != c.join {
+			t.Errorf("%s: expected %s, got %v", c.statement, c.join, joins)
+		}
+
+		if results := strings.Join(output.results, " "); results != c.results {
+			t.Errorf("%s: expected %s, got %s", c.statement, c.results, results)
+		}
+	}
+
+	// A JOIN in a subquery cannot read the whole right keyspace for
+	// each outer item
+	_, output := runJoinStatement(t, store, "SELECT (SELECT r2.v FROM l AS l2 USE KEYS meta(l).id "+
+		"JOIN r AS r2 ON r2.v = l2.x) AS s FROM l", nil)
+	if len(output.errors) == 0 || !strings.Contains(output.errors[0].Error(), "JOIN in subquery") {
+		t.Errorf("expected JOIN without an index in a subquery to fail, got %v", output.errors)
+	}
+}
+
+func TestHashJoinMemory(t *testing.T) {
+	store, dir := newJoinTestStore(t)
+	defer os.RemoveAll(dir)
+
+	statement := "SELECT l.x, r.v FROM l JOIN r ON r.v = l.x * 10"
+
+	// The hash table counts against the sort memory of the request
+	var context *Context
+	_, output := runJoinStatement(t, store, statement, func(c *Context) {
+		c.SetSortMemory(1)
+		context = c
+	})
+	if len(output.errors) != 1 || output.errors[0].Code() != 5060 || len(output.results) != 0 {
+		t.Fatalf("expected hash join memory error, got %v and %v", output.errors, output.results)
+	}
+
+	// Without a budget, the table is not limited
+	_, output = runJoinStatement(t, store, statement, func(c *Context) {
+		c.SetSortMemory(0)
+		context = c
+	})
+	if len(output.errors) != 0 || len(output.results) != 3 {
+		t.Fatalf("expected 3 results, got %v and %v", output.errors, output.results)
+	}
+
+	if context.sortMemoryUsed <= 0 {
+		t.Errorf("expected the hash table to be counted, got %d", context.sortMemoryUsed)
+	}
+}
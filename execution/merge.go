@@ -129,7 +129,7 @@ func (this *Merge) processMatch(item value.AnnotatedValue,
 		return false
 	}
 
-	bvs, err := this.plan.Keyspace().Fetch([]string{k})
+	bvs, err := fetchKeys(this.plan.Keyspace(), []string{k}, context)
 	if err != nil {
 		context.Error(err)
 		return false
@@ -138,6 +138,9 @@ func (this *Merge) processMatch(item value.AnnotatedValue,
 	if len(bvs) > 0 {
 		bv := bvs[0].Value
 
+		// Matched; UPDATE and DELETE are conditional on the target's CAS
+		setCas(item, bv)
+
 		// Matched; join source and target
 		if update != nil {
 			item.SetAttachment("target", bv)
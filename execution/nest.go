@@ -75,7 +75,7 @@ func (this *Nest) processItem(item value.AnnotatedValue, context *Context) bool
 	}
 
 	// Fetch
-	pairs, err := this.plan.Keyspace().Fetch(keys)
+	pairs, err := fetchKeys(this.plan.Keyspace(), keys, context)
 	if err != nil {
 		context.Error(err)
 		return false
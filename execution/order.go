@@ -10,9 +10,12 @@
 package execution
 
 import (
+	"container/heap"
+	"math"
 	"strconv"
 
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/sort"
 	"github.com/couchbase/query/value"
@@ -20,9 +23,14 @@ import (
 
 type Order struct {
 	base
-	plan    *plan.Order
-	values  value.AnnotatedValues
-	context *Context
+	plan   *plan.Order
+	values value.AnnotatedValues
+	size   int64      // Estimated size of values, reserved from the context
+	runs   []*sortRun // Sorted runs spilled to disk
+	count  int64
+	failed bool
+	topN   int64 // offset+limit, or -1 if there is no limit
+	heap   bool  // Keeping only the top N values in a heap
 }
 
 const _ORDER_CAP = 1024
@@ -54,7 +62,84 @@ func (this *Order) RunOnce(context *Context, parent value.Value) {
 	this.runConsumer(this, context, parent)
 }
 
+// With a LIMIT, only the first offset+limit items are kept.
+func (this *Order) beforeItems(context *Context, parent value.Value) bool {
+	this.topN = -1
+	this.heap = false
+	if this.plan.Limit() == nil {
+		return true
+	}
+
+	limit, ok := evalOrderBound(this.plan.Limit(), context, parent)
+	if !ok {
+		return true
+	}
+
+	offset := int64(0)
+	if this.plan.Offset() != nil {
+		offset, ok = evalOrderBound(this.plan.Offset(), context, parent)
+		if !ok {
+			return true
+		}
+	}
+
+	if limit < 0 {
+		limit = 0
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if limit > math.MaxInt64-offset {
+		return true
+	}
+
+	this.topN = limit + offset
+	this.heap = true
+	return true
+}
+
+// Invalid values are reported by the Offset and Limit operators.
+func evalOrderBound(expr expression.Expression, context *Context, parent value.Value) (int64, bool) {
+	val, e := expr.Evaluate(parent, context)
+	if e != nil {
+		return 0, false
+	}
+
+	actual, ok := val.Actual().(float64)
+	if !ok || math.Trunc(actual) != actual || math.Abs(actual) >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(actual), true
+}
+
 func (this *Order) processItem(item value.AnnotatedValue, context *Context) bool {
+	this.count++
+	limit := context.OrderLimit()
+	if limit > 0 && this.count > limit && (this.topN < 0 || this.topN > limit) {
+		context.Error(errors.NewOrderLimitError(limit))
+		this.failed = true
+		return false
+	}
+
+	// Evaluate the sort terms up front, so that spilled items carry them
+	for i, term := range this.plan.Terms() {
+		v, e := term.Expression().Evaluate(item, context)
+		if e != nil {
+			context.Error(errors.NewError(e, "Error evaluating ORDER BY."))
+			this.failed = true
+			return false
+		}
+
+		item.SetAttachment(strconv.Itoa(i), v)
+	}
+
+	if this.heap {
+		return this.processTopN(item, context)
+	}
+
 	if len(this.values) == cap(this.values) {
 		values := make(value.AnnotatedValues, len(this.values), len(this.values)<<1)
 		copy(values, this.values)
@@ -62,15 +147,70 @@ func (this *Order) processItem(item value.AnnotatedValue, context *Context) bool
 	}
 
 	this.values = append(this.values, item)
+
+	size := sortSize(item)
+	this.size += size
+	if !context.reserveSortMemory(size) {
+		return this.spill(context)
+	}
+
+	return true
+}
+
+// Keep the top N values in a max-heap, replacing the greatest value
+// when a lesser one arrives. If the values exceed the sort memory, fall
+// back to sorting all the items and spilling.
+func (this *Order) processTopN(item value.AnnotatedValue, context *Context) bool {
+	n := int64(len(this.values))
+	if n >= this.topN {
+		if n == 0 || !this.lessValues(item, this.values[0]) {
+			return true
+		}
+
+		size := sortSize(item) - sortSize(this.values[0])
+		this.values[0] = item
+		heap.Fix((*orderHeap)(this), 0)
+
+		this.size += size
+		if size > 0 && !context.reserveSortMemory(size) {
+			this.heap = false
+			return this.spill(context)
+		} else if size < 0 {
+			context.releaseSortMemory(-size)
+		}
+
+		return true
+	}
+
+	this.values = append(this.values, item)
+
+	size := sortSize(item)
+	this.size += size
+	if !context.reserveSortMemory(size) {
+		this.heap = false
+		return this.spill(context)
+	}
+
+	if n+1 == this.topN {
+		heap.Init((*orderHeap)(this))
+	}
+
 	return true
 }
 
 func (this *Order) afterItems(context *Context) {
-	defer func() { this.values = nil }()
+	defer this.cleanup(context)
+
+	if this.failed {
+		return
+	}
 
-	this.context = context
 	sort.Sort(this)
-	this.context = nil
+
+	if len(this.runs) > 0 {
+		this.merge(context, _MERGE_MAX_RUNS)
+		return
+	}
 
 	for _, av := range this.values {
 		if !this.sendItem(av) {
@@ -79,47 +219,35 @@ func (this *Order) afterItems(context *Context) {
 	}
 }
 
+func (this *Order) cleanup(context *Context) {
+	for _, run := range this.runs {
+		run.close()
+	}
+
+	context.releaseSortMemory(this.size)
+	this.size = 0
+	this.values = nil
+	this.runs = nil
+}
+
 func (this *Order) Len() int {
 	return len(this.values)
 }
 
 func (this *Order) Less(i, j int) bool {
-	v1 := this.values[i]
-	v2 := this.values[j]
+	return this.lessValues(this.values[i], this.values[j])
+}
 
-	var ev1, ev2 value.Value
+func (this *Order) lessValues(v1, v2 value.AnnotatedValue) bool {
 	var c int
-	var e error
 
 	for i, term := range this.plan.Terms() {
 		s := strconv.Itoa(i)
 
-		sv1 := v1.GetAttachment(s)
-		switch sv1 := sv1.(type) {
-		case value.Value:
-			ev1 = sv1
-		default:
-			ev1, e = term.Expression().Evaluate(v1, this.context)
-			if e != nil {
-				this.context.Error(errors.NewError(e, "Error evaluating ORDER BY."))
-				return false
-			}
-
-			v1.SetAttachment(s, ev1)
-		}
-
-		sv2 := v2.GetAttachment(s)
-		switch sv2 := sv2.(type) {
-		case value.Value:
-			ev2 = sv2
-		default:
-			ev2, e = term.Expression().Evaluate(v2, this.context)
-			if e != nil {
-				this.context.Error(errors.NewError(e, "Error evaluating ORDER BY."))
-				return false
-			}
-
-			v2.SetAttachment(s, ev2)
+		ev1, _ := v1.GetAttachment(s).(value.Value)
+		ev2, _ := v2.GetAttachment(s).(value.Value)
+		if ev1 == nil || ev2 == nil {
+			return false
 		}
 
 		c = ev1.Collate(ev2)
@@ -139,3 +267,29 @@ func (this *Order) Less(i, j int) bool {
 func (this *Order) Swap(i, j int) {
 	this.values[i], this.values[j] = this.values[j], this.values[i]
 }
+
+// orderHeap is a max-heap of the Order values.
+type orderHeap Order
+
+func (this *orderHeap) Len() int {
+	return len(this.values)
+}
+
+func (this *orderHeap) Less(i, j int) bool {
+	return (*Order)(this).lessValues(this.values[j], this.values[i])
+}
+
+func (this *orderHeap) Swap(i, j int) {
+	this.values[i], this.values[j] = this.values[j], this.values[i]
+}
+
+func (this *orderHeap) Push(x interface{}) {
+	this.values = append(this.values, x.(value.AnnotatedValue))
+}
+
+func (this *orderHeap) Pop() interface{} {
+	n := len(this.values)
+	rv := this.values[n-1]
+	this.values = this.values[:n-1]
+	return rv
+}
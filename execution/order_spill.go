@@ -0,0 +1,576 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/sort"
+	"github.com/couchbase/query/value"
+)
+
+// A sort spills only once it holds this many items, even if the sort
+// memory of the request is exhausted, so that a sort starved by other
+// operators does not spill every item to its own file.
+const _SPILL_MIN_ITEMS = 1024
+
+// At most this many runs are merged at once, each with an open file.
+// More runs are merged in several passes.
+const _MERGE_MAX_RUNS = 64
+
+// Sort the buffered values and write them to a temporary file as a
+// sorted run, releasing their memory. Returns true without spilling
+// if too few values are buffered.
+func (this *Order) spill(context *Context) bool {
+	if len(this.values) < _SPILL_MIN_ITEMS {
+		return true
+	}
+
+	sort.Sort(this)
+
+	run, err := newSortRun(this.values)
+	if err != nil {
+		context.Error(err)
+		this.failed = true
+		return false
+	}
+
+	this.runs = append(this.runs, run)
+	this.values = make(value.AnnotatedValues, 0, _ORDER_CAP)
+	context.releaseSortMemory(this.size)
+	this.size = 0
+	return true
+}
+
+// Merge the spilled runs and the sorted values still in memory. While
+// there are more than maxRuns runs, the first maxRuns runs are merged
+// into a new spilled run.
+func (this *Order) merge(context *Context, maxRuns int) {
+	for len(this.runs) > maxRuns {
+		w, err := newSortRunWriter()
+		if err == nil {
+			err = this.mergeRuns(this.runs[:maxRuns], w.write)
+		}
+
+		var run *sortRun
+		if err == nil {
+			run, err = w.finish()
+		} else if w != nil {
+			w.run.close()
+		}
+
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		for _, merged := range this.runs[:maxRuns] {
+			merged.close()
+		}
+
+		this.runs = append(this.runs[maxRuns:], run)
+	}
+
+	runs := make([]*sortRun, 0, len(this.runs)+1)
+	runs = append(runs, this.runs...)
+	if len(this.values) > 0 {
+		runs = append(runs, &sortRun{values: this.values})
+	}
+
+	err := this.mergeRuns(runs, func(av value.AnnotatedValue) (bool, errors.Error) {
+		return this.sendItem(av), nil
+	})
+	if err != nil {
+		context.Error(err)
+	}
+}
+
+// Merge the runs, passing each item in order to the emit function
+// until it returns false or an error.
+func (this *Order) mergeRuns(runs []*sortRun,
+	emit func(value.AnnotatedValue) (bool, errors.Error)) errors.Error {
+	m := &sortMerge{order: this, runs: make([]*sortRun, 0, len(runs))}
+	for _, run := range runs {
+		err := run.open()
+		if err != nil {
+			return err
+		}
+
+		ok, err := run.next()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			m.runs = append(m.runs, run)
+		}
+	}
+
+	heap.Init(m)
+	for m.Len() > 0 {
+		run := m.runs[0]
+		ok, err := emit(run.current)
+		if !ok || err != nil {
+			return err
+		}
+
+		ok, err = run.next()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			heap.Fix(m, 0)
+		} else {
+			heap.Pop(m)
+		}
+	}
+
+	return nil
+}
+
+// sortMerge is a heap of sorted runs, ordered by their current items.
+type sortMerge struct {
+	order *Order
+	runs  []*sortRun
+}
+
+func (this *sortMerge) Len() int {
+	return len(this.runs)
+}
+
+func (this *sortMerge) Less(i, j int) bool {
+	return this.order.lessValues(this.runs[i].current, this.runs[j].current)
+}
+
+func (this *sortMerge) Swap(i, j int) {
+	this.runs[i], this.runs[j] = this.runs[j], this.runs[i]
+}
+
+func (this *sortMerge) Push(x interface{}) {
+	this.runs = append(this.runs, x.(*sortRun))
+}
+
+func (this *sortMerge) Pop() interface{} {
+	n := len(this.runs)
+	run := this.runs[n-1]
+	this.runs = this.runs[:n-1]
+	return run
+}
+
+// sortRun is a sorted sequence of items, either spilled to a file or
+// held in memory. The file of a spilled run is only open while the
+// run is merged.
+type sortRun struct {
+	name    string
+	file    *os.File
+	decoder *json.Decoder
+	values  value.AnnotatedValues
+	current value.AnnotatedValue
+}
+
+func newSortRun(values value.AnnotatedValues) (*sortRun, errors.Error) {
+	w, err := newSortRunWriter()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, av := range values {
+		_, err = w.write(av)
+		if err != nil {
+			w.run.close()
+			return nil, err
+		}
+	}
+
+	return w.finish()
+}
+
+// Open the file of a spilled run for reading.
+func (this *sortRun) open() errors.Error {
+	if this.name == "" || this.file != nil {
+		return nil
+	}
+
+	file, e := os.Open(this.name)
+	if e != nil {
+		return errors.NewSortSpillError(e, "Error reading ORDER BY spill file "+this.name)
+	}
+
+	this.file = file
+	this.decoder = json.NewDecoder(bufio.NewReader(file))
+	return nil
+}
+
+// Advance to the next item. Returns false when the run is exhausted.
+func (this *sortRun) next() (bool, errors.Error) {
+	if this.decoder == nil {
+		if len(this.values) == 0 {
+			this.current = nil
+			return false, nil
+		}
+
+		this.current = this.values[0]
+		this.values[0] = nil
+		this.values = this.values[1:]
+		return true, nil
+	}
+
+	var item spilledItem
+	e := this.decoder.Decode(&item)
+	if e == io.EOF {
+		this.current = nil
+		return false, nil
+	} else if e != nil {
+		return false, errors.NewSortSpillError(e, "Error reading ORDER BY spill file "+this.name)
+	}
+
+	av, err := decodeSortItem(&item)
+	if err != nil {
+		return false, err
+	}
+
+	this.current = av
+	return true, nil
+}
+
+// Release the run, removing its file.
+func (this *sortRun) close() {
+	if this.file != nil {
+		this.file.Close()
+		this.file = nil
+	}
+
+	if this.name != "" {
+		os.Remove(this.name)
+		this.name = ""
+	}
+
+	this.decoder = nil
+	this.values = nil
+}
+
+// sortRunWriter writes sorted items to the file of a new run.
+type sortRunWriter struct {
+	run    *sortRun
+	writer *bufio.Writer
+}
+
+func newSortRunWriter() (*sortRunWriter, errors.Error) {
+	file, e := ioutil.TempFile("", "cbq-order-")
+	if e != nil {
+		return nil, errors.NewSortSpillError(e, "Unable to create ORDER BY spill file.")
+	}
+
+	return &sortRunWriter{
+		run:    &sortRun{name: file.Name(), file: file},
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+func (this *sortRunWriter) write(av value.AnnotatedValue) (bool, errors.Error) {
+	bytes, err := encodeSortItem(av)
+	if err != nil {
+		return false, err
+	}
+
+	_, e := this.writer.Write(bytes)
+	if e == nil {
+		e = this.writer.WriteByte('\n')
+	}
+
+	if e != nil {
+		return false, errors.NewSortSpillError(e, "Error writing ORDER BY spill file "+this.run.name)
+	}
+
+	return true, nil
+}
+
+// Flush and close the file, and return the run.
+func (this *sortRunWriter) finish() (*sortRun, errors.Error) {
+	run := this.run
+	e := this.writer.Flush()
+	if e == nil {
+		e = run.file.Close()
+	} else {
+		run.file.Close()
+	}
+
+	run.file = nil
+	if e != nil {
+		run.close()
+		return nil, errors.NewSortSpillError(e, "Error writing ORDER BY spill file "+run.name)
+	}
+
+	return run, nil
+}
+
+// spilledItem is the encoding of an item and its attachments.
+type spilledItem struct {
+	Value       *spilledValue            `json:"v"`
+	Attachments map[string]*spilledValue `json:"a,omitempty"`
+}
+
+type spilledValue struct {
+	Type string          `json:"t"`
+	Data json.RawMessage `json:"d,omitempty"`
+}
+
+const (
+	_SPILLED_VALUE     = "v"
+	_SPILLED_MISSING   = "x"
+	_SPILLED_MAP       = "m"
+	_SPILLED_VALUE_MAP = "a"
+	_SPILLED_FLOAT     = "f"
+	_SPILLED_BINARY    = "b"
+	_SPILLED_NUMBER    = "n" // Attachments of type float64, such as the CAS
+	_SPILLED_UINT64    = "u" // Attachments of type uint64, such as the raw CAS
+)
+
+func encodeSortItem(av value.AnnotatedValue) ([]byte, errors.Error) {
+	v, err := encodeSpilledValue(av.GetValue())
+	if err != nil {
+		return nil, err
+	}
+
+	item := &spilledItem{Value: v}
+
+	atmts := av.Attachments()
+	if len(atmts) > 0 {
+		item.Attachments = make(map[string]*spilledValue, len(atmts))
+		for key, atmt := range atmts {
+			a, err := encodeSpilledValue(atmt)
+			if err != nil {
+				return nil, err
+			}
+
+			item.Attachments[key] = a
+		}
+	}
+
+	bytes, e := json.Marshal(item)
+	if e != nil {
+		return nil, errors.NewSortSpillError(e, "Error encoding ORDER BY item.")
+	}
+
+	return bytes, nil
+}
+
+func encodeSpilledValue(val interface{}) (*spilledValue, errors.Error) {
+	var bytes []byte
+	var e error
+
+	switch val := val.(type) {
+	case value.Value:
+		switch val.Type() {
+		case value.MISSING:
+			return &spilledValue{Type: _SPILLED_MISSING}, nil
+		case value.BINARY:
+			// Binary values do not marshal as JSON
+			raw, _ := val.Actual().([]byte)
+			bytes, e = json.Marshal(raw)
+			if e == nil {
+				return &spilledValue{Type: _SPILLED_BINARY, Data: bytes}, nil
+			}
+			return nil, errors.NewSortSpillError(e, "Error encoding ORDER BY item.")
+		case value.NUMBER:
+			// NaN and infinities are marshalled as strings
+			f, ok := val.Actual().(float64)
+			if ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				bytes, e = json.Marshal(strconv.FormatFloat(f, 'g', -1, 64))
+				if e == nil {
+					return &spilledValue{Type: _SPILLED_FLOAT, Data: bytes}, nil
+				}
+			}
+		}
+
+		bytes, e = val.MarshalJSON()
+		if e == nil {
+			return &spilledValue{Type: _SPILLED_VALUE, Data: bytes}, nil
+		}
+	case map[string]interface{}:
+		bytes, e = json.Marshal(val)
+		if e == nil {
+			return &spilledValue{Type: _SPILLED_MAP, Data: bytes}, nil
+		}
+	case float64:
+		bytes, e = json.Marshal(strconv.FormatFloat(val, 'g', -1, 64))
+		if e == nil {
+			return &spilledValue{Type: _SPILLED_NUMBER, Data: bytes}, nil
+		}
+	case uint64:
+		bytes, e = json.Marshal(strconv.FormatUint(val, 10))
+		if e == nil {
+			return &spilledValue{Type: _SPILLED_UINT64, Data: bytes}, nil
+		}
+	case map[string]value.Value:
+		// Aggregates
+		m := make(map[string]*spilledValue, len(val))
+		for k, v := range val {
+			sv, err := encodeSpilledValue(v)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = sv
+		}
+
+		bytes, e = json.Marshal(m)
+		if e == nil {
+			return &spilledValue{Type: _SPILLED_VALUE_MAP, Data: bytes}, nil
+		}
+	default:
+		return nil, errors.NewSortSpillError(nil, fmt.Sprintf("Unable to encode ORDER BY value of type %T.", val))
+	}
+
+	return nil, errors.NewSortSpillError(e, "Error encoding ORDER BY item.")
+}
+
+func decodeSortItem(item *spilledItem) (value.AnnotatedValue, errors.Error) {
+	v, err := decodeSpilledValue(item.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	av := value.NewAnnotatedValue(v)
+	for key, a := range item.Attachments {
+		atmt, err := decodeSpilledValue(a)
+		if err != nil {
+			return nil, err
+		}
+
+		av.SetAttachment(key, atmt)
+	}
+
+	return av, nil
+}
+
+func decodeSpilledValue(sv *spilledValue) (interface{}, errors.Error) {
+	if sv == nil {
+		return nil, errors.NewSortSpillError(nil, "Missing value in ORDER BY spill file.")
+	}
+
+	switch sv.Type {
+	case _SPILLED_MISSING:
+		return value.NewMissingValue(), nil
+	case _SPILLED_VALUE:
+		return value.NewValue([]byte(sv.Data)), nil
+	case _SPILLED_MAP:
+		var m map[string]interface{}
+		e := json.Unmarshal(sv.Data, &m)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+		return m, nil
+	case _SPILLED_FLOAT, _SPILLED_NUMBER:
+		var str string
+		e := json.Unmarshal(sv.Data, &str)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+
+		f, e := strconv.ParseFloat(str, 64)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+
+		if sv.Type == _SPILLED_NUMBER {
+			return f, nil
+		}
+		return value.NewValue(f), nil
+	case _SPILLED_UINT64:
+		var str string
+		e := json.Unmarshal(sv.Data, &str)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+
+		u, e := strconv.ParseUint(str, 10, 64)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+		return u, nil
+	case _SPILLED_BINARY:
+		var raw []byte
+		e := json.Unmarshal(sv.Data, &raw)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+		return value.NewValue(raw), nil
+	case _SPILLED_VALUE_MAP:
+		var m map[string]*spilledValue
+		e := json.Unmarshal(sv.Data, &m)
+		if e != nil {
+			return nil, errors.NewSortSpillError(e, "Error decoding ORDER BY item.")
+		}
+
+		rv := make(map[string]value.Value, len(m))
+		for k, v := range m {
+			dv, err := decodeSpilledValue(v)
+			if err != nil {
+				return nil, err
+			}
+
+			val, ok := dv.(value.Value)
+			if !ok {
+				return nil, errors.NewSortSpillError(nil, "Invalid value in ORDER BY spill file.")
+			}
+			rv[k] = val
+		}
+		return rv, nil
+	default:
+		return nil, errors.NewSortSpillError(nil, "Unknown value type in ORDER BY spill file: "+sv.Type)
+	}
+}
+
+// Estimated memory size of an item and its attachments.
+func sortSize(av value.AnnotatedValue) int64 {
+	size := estimateSize(av.Actual())
+	for key, atmt := range av.Attachments() {
+		size += int64(len(key)) + estimateSize(atmt)
+	}
+
+	return size
+}
+
+func estimateSize(val interface{}) int64 {
+	switch val := val.(type) {
+	case nil, bool, float64, int64, int:
+		return 8
+	case string:
+		return int64(len(val)) + 16
+	case []byte:
+		return int64(len(val)) + 24
+	case value.Value:
+		return estimateSize(val.Actual())
+	case []interface{}:
+		size := int64(24)
+		for _, v := range val {
+			size += estimateSize(v) + 16
+		}
+		return size
+	case map[string]interface{}:
+		size := int64(48)
+		for k, v := range val {
+			size += int64(len(k)) + estimateSize(v) + 32
+		}
+		return size
+	default:
+		return 16
+	}
+}
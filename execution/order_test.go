@@ -0,0 +1,410 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// orderItems returns n items with a shuffled field a of 0 to n-1.
+func orderItems(n int) value.AnnotatedValues {
+	items := make(value.AnnotatedValues, n)
+	for i, a := range rand.New(rand.NewSource(1)).Perm(n) {
+		items[i] = value.NewAnnotatedValue(map[string]interface{}{"a": a})
+		items[i].SetAttachment("meta", map[string]interface{}{"id": strconv.Itoa(a)})
+	}
+
+	return items
+}
+
+func newTestingOrder(descending bool, offset, limit expression.Expression) *Order {
+	terms := algebra.SortTerms{algebra.NewSortTerm(expression.NewIdentifier("a"), descending)}
+	return NewOrder(plan.NewOrder(algebra.NewOrder(terms), offset, limit))
+}
+
+// runOrder feeds the items to the order, and returns its output and
+// the number of runs spilled before the merge.
+func runOrder(order *Order, items value.AnnotatedValues, context *Context) (value.AnnotatedValues, int) {
+	done := make(chan value.AnnotatedValues)
+	go func() {
+		rv := make(value.AnnotatedValues, 0, len(items))
+		for item := range order.ItemChannel() {
+			rv = append(rv, item)
+		}
+		done <- rv
+	}()
+
+	ok := order.beforeItems(context, nil)
+	for _, item := range items {
+		if !ok {
+			break
+		}
+		ok = order.processItem(item, context)
+	}
+
+	runs := len(order.runs)
+	order.afterItems(context)
+	close(order.itemChannel)
+	return <-done, runs
+}
+
+func checkOrder(t *testing.T, output value.AnnotatedValues, expected []int) {
+	if len(output) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(output))
+	}
+
+	for i, item := range output {
+		a, _ := item.Field("a")
+		if a.Actual() != float64(expected[i]) {
+			t.Fatalf("expected a = %d at %d, got %v", expected[i], i, a)
+		}
+
+		meta, ok := item.GetAttachment("meta").(map[string]interface{})
+		if !ok || meta["id"] != strconv.Itoa(expected[i]) {
+			t.Fatalf("expected meta of item %v, got %v", a, item.GetAttachment("meta"))
+		}
+	}
+}
+
+func sequence(from, to, step int) []int {
+	rv := make([]int, 0, 16)
+	for i := from; (step > 0 && i < to) || (step < 0 && i > to); i += step {
+		rv = append(rv, i)
+	}
+
+	return rv
+}
+
+func TestOrderSpill(t *testing.T) {
+	items := orderItems(5 * _SPILL_MIN_ITEMS)
+
+	// Within the budget, nothing is spilled
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	result, runs := runOrder(newTestingOrder(false, nil, nil), items, context)
+	if runs != 0 || len(output.errors) != 0 {
+		t.Fatalf("expected no spill, got %d runs and %v", runs, output.errors)
+	}
+	checkOrder(t, result, sequence(0, len(items), 1))
+
+	// With a tiny budget, each run holds the minimum number of items
+	items = orderItems(5 * _SPILL_MIN_ITEMS)
+	output = &testingOutput{}
+	context = newTestingContext(output)
+	context.SetSortMemory(1)
+	result, runs = runOrder(newTestingOrder(true, nil, nil), items, context)
+	if runs != 5 || len(output.errors) != 0 {
+		t.Fatalf("expected 5 runs, got %d and %v", runs, output.errors)
+	}
+	checkOrder(t, result, sequence(len(items)-1, -1, -1))
+
+	if context.sortMemoryUsed != 0 {
+		t.Errorf("expected sort memory to be released, got %d", context.sortMemoryUsed)
+	}
+
+	// A budget used up by other operators does not spill every item
+	output = &testingOutput{}
+	context = newTestingContext(output)
+	context.SetSortMemory(1024)
+	context.reserveSortMemory(1024)
+	result, runs = runOrder(newTestingOrder(false, nil, nil), orderItems(100), context)
+	if runs != 0 || len(output.errors) != 0 {
+		t.Fatalf("expected no spill, got %d runs and %v", runs, output.errors)
+	}
+	checkOrder(t, result, sequence(0, 100, 1))
+}
+
+func TestOrderMergePasses(t *testing.T) {
+	order := newTestingOrder(false, nil, nil)
+	output := &testingOutput{}
+	context := newTestingContext(output)
+
+	// Ten runs of 0-9, 10-19, ..., to be merged three at a time
+	names := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		values := make(value.AnnotatedValues, 0, 10)
+		for j := 0; j < 10; j++ {
+			av := value.NewAnnotatedValue(map[string]interface{}{"a": j*10 + i})
+			av.SetAttachment("meta", map[string]interface{}{"id": strconv.Itoa(j*10 + i)})
+			av.SetAttachment("0", value.NewValue(j*10+i))
+			values = append(values, av)
+		}
+
+		run, err := newSortRun(values)
+		if err != nil {
+			t.Fatalf("failed to spill run: %v", err)
+		}
+
+		names = append(names, run.name)
+		order.runs = append(order.runs, run)
+	}
+
+	done := make(chan value.AnnotatedValues)
+	go func() {
+		rv := make(value.AnnotatedValues, 0, 100)
+		for item := range order.ItemChannel() {
+			rv = append(rv, item)
+		}
+		done <- rv
+	}()
+
+	order.merge(context, 3)
+	if len(order.runs) > 3 {
+		t.Errorf("expected at most 3 runs in the last pass, got %d", len(order.runs))
+	}
+
+	order.cleanup(context)
+	close(order.itemChannel)
+	result := <-done
+
+	if len(output.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", output.errors)
+	}
+	checkOrder(t, result, sequence(0, 100, 1))
+
+	for _, name := range names {
+		if _, e := os.Stat(name); !os.IsNotExist(e) {
+			t.Errorf("expected spill file %s to be removed", name)
+		}
+	}
+}
+
+func TestSortItemEncoding(t *testing.T) {
+	av := value.NewAnnotatedValue(map[string]interface{}{"a": 1, "b": []interface{}{"x", nil}})
+	av.SetAttachment("0", value.NewMissingValue())
+	av.SetAttachment("1", value.NewValue(math.Inf(-1)))
+	av.SetAttachment("2", value.NewValue([]byte{0xff, 0x00, 0x01}))
+	av.SetAttachment("meta", map[string]interface{}{"id": "k1", "cas": float64(7)})
+	av.SetAttachment("cas", float64(7))
+	av.SetAttachment("raw_cas", uint64(1)<<60+1)
+	av.SetAttachment("aggregates", map[string]value.Value{"count": value.NewValue(3)})
+
+	bytes, err := encodeSortItem(av)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	var item spilledItem
+	if e := json.Unmarshal(bytes, &item); e != nil {
+		t.Fatalf("failed to unmarshal: %v", e)
+	}
+
+	dv, err := decodeSortItem(&item)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	expected, _ := av.MarshalJSON()
+	actual, _ := dv.MarshalJSON()
+	if string(actual) != string(expected) {
+		t.Errorf("expected value %s, got %s", expected, actual)
+	}
+
+	if v := dv.GetAttachment("0").(value.Value); v.Type() != value.MISSING {
+		t.Errorf("expected MISSING, got %v", v)
+	}
+
+	if v := dv.GetAttachment("1").(value.Value); v.Actual() != math.Inf(-1) {
+		t.Errorf("expected -Inf, got %v", v)
+	}
+
+	if v := dv.GetAttachment("2").(value.Value); v.Type() != value.BINARY || v.Collate(av.GetAttachment("2").(value.Value)) != 0 {
+		t.Errorf("expected binary value, got %v", v)
+	}
+
+	meta := dv.GetAttachment("meta").(map[string]interface{})
+	if meta["id"] != "k1" || meta["cas"] != float64(7) {
+		t.Errorf("expected meta, got %v", meta)
+	}
+
+	if dv.GetAttachment("cas") != float64(7) {
+		t.Errorf("expected float64 CAS, got %#v", dv.GetAttachment("cas"))
+	}
+
+	if dv.GetAttachment("raw_cas") != uint64(1)<<60+1 {
+		t.Errorf("expected uint64 CAS, got %#v", dv.GetAttachment("raw_cas"))
+	}
+
+	aggs := dv.GetAttachment("aggregates").(map[string]value.Value)
+	if aggs["count"].Actual() != float64(3) {
+		t.Errorf("expected aggregates, got %v", aggs)
+	}
+}
+
+func TestOrderLimit(t *testing.T) {
+	items := orderItems(20)
+
+	// More items than the order limit are rejected
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	context.SetOrderLimit(10)
+	result, _ := runOrder(newTestingOrder(false, nil, nil), items, context)
+	if len(output.errors) != 1 || output.errors[0].Code() != 5020 || len(result) != 0 {
+		t.Fatalf("expected order limit error and no items, got %v and %d items",
+			output.errors, len(result))
+	}
+
+	// A LIMIT within the order limit keeps only the top items
+	output = &testingOutput{}
+	context = newTestingContext(output)
+	context.SetOrderLimit(10)
+	result, _ = runOrder(newTestingOrder(false, nil, expression.NewConstant(5)), items, context)
+	if len(output.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", output.errors)
+	}
+	checkOrder(t, result, sequence(0, 5, 1))
+}
+
+func TestOrderTopN(t *testing.T) {
+	constant := func(v interface{}) expression.Expression {
+		return expression.NewConstant(v)
+	}
+
+	cases := []struct {
+		descending bool
+		offset     expression.Expression
+		limit      expression.Expression
+		topN       int64
+		expected   []int
+	}{
+		// Without a LIMIT, every item is sorted
+		{false, nil, nil, -1, sequence(0, 100, 1)},
+		{false, constant(5), nil, -1, sequence(0, 100, 1)},
+
+		// Only the first offset+limit items are kept
+		{false, nil, constant(5), 5, sequence(0, 5, 1)},
+		{true, nil, constant(5), 5, sequence(99, 94, -1)},
+		{false, constant(3), constant(2), 5, sequence(0, 5, 1)},
+		{false, nil, constant(100), 100, sequence(0, 100, 1)},
+		{false, nil, constant(1000), 1000, sequence(0, 100, 1)},
+
+		// LIMIT 0 keeps nothing
+		{false, nil, constant(0), 0, nil},
+		{false, constant(10), constant(0), 10, sequence(0, 10, 1)},
+
+		// Negative bounds are taken as 0; the Limit and Offset
+		// operators report them
+		{false, nil, constant(-1), 0, nil},
+		{false, constant(-2), constant(3), 3, sequence(0, 3, 1)},
+
+		// Bounds that are not integers, or overflow, sort every item
+		{false, nil, constant(1.5), -1, sequence(0, 100, 1)},
+		{false, nil, constant("ten"), -1, sequence(0, 100, 1)},
+		{false, constant(1.5), constant(5), -1, sequence(0, 100, 1)},
+		{false, nil, constant(1e19), -1, sequence(0, 100, 1)},
+		{false, constant(math.MaxInt64 / 2), constant(math.MaxInt64 / 2), -1, sequence(0, 100, 1)},
+
+		// Bounds are evaluated per request, and per outer value in a
+		// correlated subquery
+		{false, nil, algebra.NewPositionalParameter(1), 7, sequence(0, 7, 1)},
+		{false, nil, algebra.NewPositionalParameter(2), -1, sequence(0, 100, 1)},
+		{false, nil, expression.NewIdentifier("n"), 4, sequence(0, 4, 1)},
+		{false, nil, expression.NewIdentifier("missing"), -1, sequence(0, 100, 1)},
+	}
+
+	for i, c := range cases {
+		output := &testingOutput{}
+		context := NewContext(nil, nil, "default", false, nil,
+			value.Values{value.NewValue(7), value.NewValue("seven")},
+			nil, datastore.UNBOUNDED, nil, output)
+		order := newTestingOrder(c.descending, c.offset, c.limit)
+
+		// The heap never holds more than offset+limit items
+		done := make(chan value.AnnotatedValues)
+		go func() {
+			rv := make(value.AnnotatedValues, 0, 100)
+			for item := range order.ItemChannel() {
+				rv = append(rv, item)
+			}
+			done <- rv
+		}()
+
+		order.beforeItems(context, value.NewValue(map[string]interface{}{"n": 4}))
+		if order.topN != c.topN || order.heap != (c.topN >= 0) {
+			t.Errorf("case %d: expected top %d, got %d and heap %v", i, c.topN, order.topN, order.heap)
+		}
+
+		for _, item := range orderItems(100) {
+			order.processItem(item, context)
+			if order.heap && int64(len(order.values)) > order.topN {
+				t.Fatalf("case %d: expected at most %d values, got %d", i, order.topN, len(order.values))
+			}
+		}
+
+		order.afterItems(context)
+		close(order.itemChannel)
+		result := <-done
+
+		if len(output.errors) != 0 {
+			t.Fatalf("case %d: unexpected errors: %v", i, output.errors)
+		}
+		checkOrder(t, result, c.expected)
+
+		if context.sortMemoryUsed != 0 {
+			t.Errorf("case %d: expected sort memory to be released, got %d", i, context.sortMemoryUsed)
+		}
+	}
+}
+
+func TestOrderTopNSpill(t *testing.T) {
+	n := 5 * _SPILL_MIN_ITEMS
+	limit := _SPILL_MIN_ITEMS / 2
+
+	// When the top values exceed the sort memory, every later item is
+	// sorted and spilled, and the top values still come first
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	context.SetSortMemory(1)
+	order := newTestingOrder(false, nil, expression.NewConstant(limit))
+	result, runs := runOrder(order, orderItems(n), context)
+	if len(output.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", output.errors)
+	}
+
+	if order.heap || runs == 0 {
+		t.Errorf("expected to fall back to spilling, got heap %v and %d runs", order.heap, runs)
+	}
+
+	if len(result) < limit {
+		t.Fatalf("expected at least %d items, got %d", limit, len(result))
+	}
+	checkOrder(t, result[:limit], sequence(0, limit, 1))
+
+	for i := 1; i < len(result); i++ {
+		if order.lessValues(result[i], result[i-1]) {
+			t.Fatalf("expected sorted items, got %v before %v", result[i-1], result[i])
+		}
+	}
+
+	if context.sortMemoryUsed != 0 {
+		t.Errorf("expected sort memory to be released, got %d", context.sortMemoryUsed)
+	}
+
+	// Within the sort memory, nothing is spilled
+	output = &testingOutput{}
+	context = newTestingContext(output)
+	order = newTestingOrder(false, nil, expression.NewConstant(limit))
+	result, runs = runOrder(order, orderItems(n), context)
+	if !order.heap || runs != 0 || len(output.errors) != 0 {
+		t.Fatalf("expected no spill, got heap %v, %d runs and %v", order.heap, runs, output.errors)
+	}
+	checkOrder(t, result, sequence(0, limit, 1))
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+type GrantRole struct {
+	base
+	plan *plan.GrantRole
+}
+
+func NewGrantRole(plan *plan.GrantRole) *GrantRole {
+	rv := &GrantRole{
+		base: newBase(),
+		plan: plan,
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *GrantRole) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGrantRole(this)
+}
+
+func (this *GrantRole) Copy() Operator {
+	return &GrantRole{this.base.copy(), this.plan}
+}
+
+func (this *GrantRole) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover()       // Recover from any panic
+		defer close(this.itemChannel) // Broadcast that I have stopped
+		defer this.notify()           // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		manager, err := roleManager(context)
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		node := this.plan.Node()
+		ksref := node.Keyspace()
+		err = manager.GrantRole(node.Role(), ksref.Namespace()+":"+ksref.Keyspace(), node.Users())
+		if err != nil {
+			context.Error(err)
+		}
+	})
+}
+
+// roleManager returns the datastore, if it supports GRANT and REVOKE.
+func roleManager(context *Context) (datastore.RoleManager, errors.Error) {
+	ds := context.Datastore()
+	manager, ok := ds.(datastore.RoleManager)
+	if !ok {
+		return nil, errors.NewDatastoreRolesNotSupportedError(nil, ds.URL())
+	}
+
+	return manager, nil
+}
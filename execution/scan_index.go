@@ -53,6 +53,7 @@ func (this *IndexScan) RunOnce(context *Context, parent value.Value) {
 		defer close(this.itemChannel) // Broadcast that I have stopped
 		defer this.notify()           // Notify that I have stopped
 
+		warn(this.plan.Warnings(), context)
 		spans := this.plan.Spans()
 		n := len(spans)
 		this.childChannel = make(StopChannel, n)
@@ -137,8 +138,15 @@ func (this *spanScan) RunOnce(context *Context, parent value.Value) {
 				if ok {
 					cv := value.NewScopeValue(make(map[string]interface{}), parent)
 					av := value.NewAnnotatedValue(cv)
-					av.SetAttachment("meta", map[string]interface{}{"id": entry.PrimaryKey})
-					ok = this.sendItem(av)
+					meta := map[string]interface{}{"id": entry.PrimaryKey}
+					av.SetAttachment("meta", meta)
+
+					covers := this.plan.Covers()
+					if len(covers) > 0 {
+						ok = this.cover(av, meta, entry, covers, context)
+					}
+
+					ok = ok && this.sendItem(av)
 				}
 			case <-this.stopChannel:
 				return
@@ -147,6 +155,64 @@ func (this *spanScan) RunOnce(context *Context, parent value.Value) {
 	})
 }
 
+/*
+Builds the document of a covering scan from the keys of the index
+entry, and sets it within the item, as Fetch would.
+*/
+func (this *spanScan) cover(item value.AnnotatedValue, meta map[string]interface{},
+	entry *datastore.IndexEntry, covers expression.Expressions, context *Context) bool {
+	alias := this.plan.Term().Alias()
+	doc := make(map[string]interface{}, len(covers))
+
+	for i, cover := range covers {
+		path := plan.CoverPath(cover, alias)
+		if len(path) == 0 {
+			continue
+		}
+
+		if i >= len(entry.EntryKey) {
+			context.Error(errors.NewError(nil, fmt.Sprintf(
+				"Index %s did not return the key %v for covering.",
+				this.plan.Index().Name(), cover)))
+			return false
+		}
+
+		// Documents lacking a key are indexed with a MISSING value
+		key := entry.EntryKey[i]
+		if key == nil || key.Type() == value.MISSING {
+			continue
+		}
+
+		fields := doc
+		for _, name := range path[:len(path)-1] {
+			switch field := fields[name].(type) {
+			case map[string]interface{}:
+				fields = field
+			case nil:
+				child := make(map[string]interface{})
+				fields[name] = child
+				fields = child
+			default:
+				// Already covered by a shorter path
+				fields = nil
+			}
+
+			if fields == nil {
+				break
+			}
+		}
+
+		if fields != nil {
+			fields[path[len(path)-1]] = key
+		}
+	}
+
+	fv := value.NewAnnotatedValue(doc)
+	fv.SetAttachment("meta", meta)
+	item.SetField(alias, fv)
+	return true
+}
+
 func (this *spanScan) scan(context *Context, conn *datastore.IndexConnection) {
 	defer context.Recover() // Recover from any panic
 
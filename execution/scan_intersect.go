@@ -90,7 +90,10 @@ func (this *IntersectScan) RunOnce(context *Context, parent value.Value) {
 				}
 			case <-this.childChannel:
 				n--
-				break loop
+				if n == 0 {
+					ok = this.drainKeys(channel, context)
+					break loop
+				}
 			case <-this.stopChannel:
 				this.values = nil
 				break loop
@@ -117,6 +120,24 @@ func (this *IntersectScan) ChildChannel() StopChannel {
 	return this.childChannel
 }
 
+// Process items still buffered after all the scans have stopped.
+func (this *IntersectScan) drainKeys(channel *Channel, context *Context) bool {
+	for {
+		select {
+		case item, ok := <-channel.ItemChannel():
+			if !ok {
+				return true
+			}
+
+			if !this.processKey(item, context) {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
 func (this *IntersectScan) processKey(item value.AnnotatedValue, context *Context) bool {
 	m := item.GetAttachment("meta")
 	meta, ok := m.(map[string]interface{})
@@ -0,0 +1,160 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+// keyItems returns an index entry for each key.
+func keyItems(keys ...string) value.AnnotatedValues {
+	items := make(value.AnnotatedValues, len(keys))
+	for i, key := range keys {
+		items[i] = value.NewAnnotatedValue(nil)
+		items[i].SetAttachment("meta", map[string]interface{}{"id": key})
+	}
+
+	return items
+}
+
+// itemKeys returns the sorted keys of the items.
+func itemKeys(items value.AnnotatedValues) string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.GetAttachment("meta").(map[string]interface{})["id"].(string)
+	}
+
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
+
+func runIntersect(scan *IntersectScan, context *Context) value.AnnotatedValues {
+	go scan.RunOnce(context, nil)
+
+	rv := make(value.AnnotatedValues, 0, 16)
+	for item := range scan.ItemChannel() {
+		rv = append(rv, item)
+	}
+
+	return rv
+}
+
+func TestIntersectScan(t *testing.T) {
+	cases := []struct {
+		scans    [][]string
+		expected string
+	}{
+		{[][]string{{"k1", "k2", "k3"}, {"k3", "k2", "k4"}}, "k2 k3"},
+		{[][]string{{"k1", "k2", "k3"}, {"k3", "k2", "k4"}, {"k2", "k5"}}, "k2"},
+		{[][]string{{"k1", "k2"}, {"k3", "k4"}}, ""},
+		{[][]string{{"k1", "k2"}, {}}, ""},
+		{[][]string{{"k1", "k2"}, {"k2", "k1"}}, "k1 k2"},
+	}
+
+	for _, c := range cases {
+		scans := make([]Operator, len(c.scans))
+		for i, keys := range c.scans {
+			scans[i] = newTestingSource(keyItems(keys...))
+		}
+
+		output := &testingOutput{}
+		result := runIntersect(NewIntersectScan(scans), newTestingContext(output))
+		if len(output.errors) != 0 {
+			t.Fatalf("%v: unexpected errors: %v", c.scans, output.errors)
+		}
+
+		if keys := itemKeys(result); keys != c.expected {
+			t.Errorf("%v: expected %q, got %q", c.scans, c.expected, keys)
+		}
+	}
+
+	// More keys than the channels hold
+	n := 3 * _ITEM_CAP
+	left := make([]string, 0, n)
+	right := make([]string, 0, n)
+	expected := make([]string, 0, n/2)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("k%05d", i)
+		left = append(left, key)
+		if i%2 == 0 {
+			right = append(right, key)
+			expected = append(expected, key)
+		}
+	}
+
+	output := &testingOutput{}
+	scan := NewIntersectScan([]Operator{
+		newTestingSource(keyItems(left...)),
+		newTestingSource(keyItems(right...)),
+	})
+	result := runIntersect(scan, newTestingContext(output))
+	if len(output.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", output.errors)
+	}
+
+	if keys := itemKeys(result); keys != strings.Join(expected, " ") {
+		t.Errorf("expected %d keys, got %d", len(expected), len(result))
+	}
+}
+
+func TestIntersectScanDrainKeys(t *testing.T) {
+	scan := NewIntersectScan([]Operator{newTestingSource(nil), newTestingSource(nil)})
+	scan.counts = make(map[string]int)
+	scan.values = make(map[string]value.AnnotatedValue)
+
+	// Keys left in the channel after both scans have stopped
+	channel := NewChannel()
+	for _, item := range keyItems("k1", "k2", "k1", "k3") {
+		channel.ItemChannel() <- item
+	}
+
+	output := &testingOutput{}
+	context := newTestingContext(output)
+	if !scan.drainKeys(channel, context) {
+		t.Fatalf("expected keys to be drained, got %v", output.errors)
+	}
+
+	if len(channel.ItemChannel()) != 0 {
+		t.Errorf("expected the channel to be empty, got %d items", len(channel.ItemChannel()))
+	}
+
+	close(scan.itemChannel)
+	result := make(value.AnnotatedValues, 0, 1)
+	for item := range scan.ItemChannel() {
+		result = append(result, item)
+	}
+
+	if keys := itemKeys(result); keys != "k1" {
+		t.Errorf("expected k1, got %q", keys)
+	}
+
+	if len(scan.values) != 2 || scan.counts["k2"] != 1 || scan.counts["k3"] != 1 {
+		t.Errorf("expected k2 and k3 to be pending, got %v", scan.counts)
+	}
+
+	// A closed channel ends the drain
+	channel = NewChannel()
+	close(channel.ItemChannel())
+	if !scan.drainKeys(channel, context) {
+		t.Errorf("expected a closed channel to be drained")
+	}
+
+	// Items without a primary key fail the scan
+	channel = NewChannel()
+	channel.ItemChannel() <- value.NewAnnotatedValue(nil)
+	if scan.drainKeys(channel, context) || len(output.errors) != 1 {
+		t.Errorf("expected missing key error, got %v", output.errors)
+	}
+}
@@ -46,6 +46,7 @@ func (this *PrimaryScan) RunOnce(context *Context, parent value.Value) {
 		defer close(this.itemChannel) // Broadcast that I have stopped
 		defer this.notify()           // Notify that I have stopped
 
+		warn(this.plan.Warnings(), context)
 		this.scanPrimary(context, parent)
 	})
 }
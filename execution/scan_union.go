@@ -87,7 +87,10 @@ func (this *UnionScan) RunOnce(context *Context, parent value.Value) {
 				}
 			case <-this.childChannel:
 				n--
-				break loop
+				if n == 0 {
+					ok = this.drainKeys(channel, context)
+					break loop
+				}
 			case <-this.stopChannel:
 				this.values = nil
 				break loop
@@ -112,6 +115,24 @@ func (this *UnionScan) ChildChannel() StopChannel {
 	return this.childChannel
 }
 
+// Process items still buffered after all the scans have stopped.
+func (this *UnionScan) drainKeys(channel *Channel, context *Context) bool {
+	for {
+		select {
+		case item, ok := <-channel.ItemChannel():
+			if !ok {
+				return true
+			}
+
+			if !this.processKey(item, context) {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
 func (this *UnionScan) processKey(item value.AnnotatedValue, context *Context) bool {
 	m := item.GetAttachment("meta")
 	meta, ok := m.(map[string]interface{})
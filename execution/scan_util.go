@@ -40,3 +40,9 @@ func notifyConn(conn *datastore.IndexConnection) {
 	default:
 	}
 }
+
+func warn(warnings []string, context *Context) {
+	for _, warning := range warnings {
+		context.warnOnce(errors.NewIndexHintWarning(warning))
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/errors"
+)
+
+// warningOutput keeps the warnings of a request.
+type warningOutput struct {
+	testingOutput
+	warnings errors.Errors
+}
+
+func (this *warningOutput) Warning(wrn errors.Error) {
+	this.warnings = append(this.warnings, wrn)
+}
+
+func TestWarn(t *testing.T) {
+	output := &warningOutput{}
+	context := newTestingContext(output)
+
+	// Scans that run again, e.g. in each branch of a UNION or for each
+	// outer item, warn once per request
+	for i := 0; i < 3; i++ {
+		warn([]string{"hint on a not honored", "hint on b not honored"}, context)
+	}
+
+	if len(output.warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", output.warnings)
+	}
+
+	for i, msg := range []string{"hint on a not honored", "hint on b not honored"} {
+		if output.warnings[i].Code() != 4060 || output.warnings[i].Error() != msg {
+			t.Errorf("expected index hint warning %s, got %v", msg, output.warnings[i])
+		}
+	}
+
+	// Each request warns again
+	warn([]string{"hint on a not honored"}, newTestingContext(output))
+	if len(output.warnings) != 3 {
+		t.Errorf("expected a warning for another request, got %v", output.warnings)
+	}
+}
@@ -0,0 +1,207 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// Number of documents sampled, and number of histogram bins
+const (
+	_STATISTICS_SAMPLE_SIZE = 8192
+	_STATISTICS_BINS        = 32
+	_STATISTICS_BATCH_SIZE  = 256
+)
+
+type UpdateStatistics struct {
+	base
+	plan *plan.UpdateStatistics
+}
+
+func NewUpdateStatistics(plan *plan.UpdateStatistics) *UpdateStatistics {
+	rv := &UpdateStatistics{
+		base: newBase(),
+		plan: plan,
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *UpdateStatistics) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitUpdateStatistics(this)
+}
+
+func (this *UpdateStatistics) Copy() Operator {
+	return &UpdateStatistics{this.base.copy(), this.plan}
+}
+
+func (this *UpdateStatistics) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover()       // Recover from any panic
+		defer close(this.itemChannel) // Broadcast that I have stopped
+		defer this.notify()           // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		keyspace := this.plan.Keyspace()
+		keys, total, ok := this.sampleKeys(keyspace, context)
+		if !ok {
+			return
+		}
+
+		terms := this.plan.Node().Terms()
+		samples := make([]value.Values, len(terms))
+
+		for start := 0; start < len(keys); start += _STATISTICS_BATCH_SIZE {
+			end := start + _STATISTICS_BATCH_SIZE
+			if end > len(keys) {
+				end = len(keys)
+			}
+
+			pairs, err := keyspace.Fetch(keys[start:end])
+			if err != nil {
+				context.Error(err)
+				return
+			}
+
+			for _, pair := range pairs {
+				for i, term := range terms {
+					v, e := term.Evaluate(pair.Value, context)
+					if e != nil {
+						context.Error(errors.NewError(e, "Error evaluating statistics term."))
+						return
+					}
+
+					// Documents are not indexed on MISSING values
+					if v.Type() != value.MISSING {
+						samples[i] = append(samples[i], v)
+					}
+				}
+			}
+		}
+
+		for i, term := range terms {
+			// Scale the counts of values to the whole keyspace
+			count := int64(0)
+			if len(keys) > 0 {
+				count = int64(math.Floor(float64(len(samples[i]))*float64(total)/float64(len(keys)) + 0.5))
+			}
+
+			histogram := datastore.NewHistogram(keyspace.NamespaceId(), keyspace.Name(),
+				expression.NewStringer().Visit(term), samples[i], count, _STATISTICS_BINS)
+			datastore.SetHistogram(histogram)
+
+			// Persist the histogram, so that it outlives the server and
+			// is shared with the other query nodes
+			if !this.storeHistogram(histogram, context) {
+				return
+			}
+		}
+	})
+}
+
+func (this *UpdateStatistics) storeHistogram(histogram *datastore.Histogram, context *Context) bool {
+	store := context.HistogramStore()
+	if store == nil {
+		return true
+	}
+
+	bytes, e := histogram.Value().MarshalJSON()
+	if e != nil {
+		context.Error(errors.NewError(e, "Error encoding histogram "+histogram.Key()))
+		return false
+	}
+
+	err := store.SetHistogram(histogram.Key(), bytes)
+	if err != nil {
+		context.Error(err)
+		return false
+	}
+
+	return true
+}
+
+/*
+Scans the primary index of the keyspace, and returns a uniform
+random sample of the keys, together with the total number of keys.
+*/
+func (this *UpdateStatistics) sampleKeys(keyspace datastore.Keyspace, context *Context) (
+	keys []string, total int64, ok bool) {
+	index, err := primaryIndex(keyspace)
+	if err != nil {
+		context.Error(err)
+		return nil, 0, false
+	}
+
+	conn := datastore.NewIndexConnection(context)
+	defer notifyConn(conn) // Notify index that I have stopped
+
+	go func() {
+		defer context.Recover() // Recover from any panic
+		index.ScanEntries(math.MaxInt64, context.ScanConsistency(), context.ScanVector(), conn)
+	}()
+
+	keys = make([]string, 0, _STATISTICS_SAMPLE_SIZE)
+	for {
+		select {
+		case <-this.stopChannel:
+			return nil, 0, false
+		case entry, open := <-conn.EntryChannel():
+			if !open {
+				return keys, total, true
+			}
+
+			total++
+
+			// Reservoir sampling
+			if len(keys) < _STATISTICS_SAMPLE_SIZE {
+				keys = append(keys, entry.PrimaryKey)
+			} else if r := rand.Int63n(total); r < _STATISTICS_SAMPLE_SIZE {
+				keys[r] = entry.PrimaryKey
+			}
+		}
+	}
+}
+
+func primaryIndex(keyspace datastore.Keyspace) (datastore.PrimaryIndex, errors.Error) {
+	indexers, err := keyspace.Indexers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, indexer := range indexers {
+		indexes, err := indexer.PrimaryIndexes()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, index := range indexes {
+			state, _, err := index.State()
+			if err == nil && state == datastore.ONLINE {
+				return index, nil
+			}
+		}
+	}
+
+	return nil, errors.NewError(nil, fmt.Sprintf(
+		"No online primary index on keyspace %s. Use CREATE PRIMARY INDEX to create one.",
+		keyspace.Name()))
+}
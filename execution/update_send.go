@@ -49,6 +49,11 @@ func (this *SendUpdate) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *SendUpdate) processItem(item value.AnnotatedValue, context *Context) bool {
+	if this.limit != 0 && !context.reserveMutation() {
+		context.Error(errors.NewMutationLimitError(context.MutationLimit()))
+		return false
+	}
+
 	rv := this.limit != 0 && this.enbatch(item, this, context)
 
 	if this.limit > 0 {
@@ -98,6 +103,22 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 		}
 
 		pairs[i].Key = key
+		pairs[i].Cas = this.fetchedCas(av)
+
+		if this.plan.Expiration() != nil {
+			exp, err := this.plan.Expiration().Evaluate(av, context)
+			if err != nil {
+				context.Error(errors.NewError(err, "Error evaluating UPDATE expiration."))
+				return false
+			}
+
+			var e errors.Error
+			pairs[i].Expiration, e = expiration(exp)
+			if e != nil {
+				context.Error(e)
+				return false
+			}
+		}
 		clone := av.GetAttachment("clone")
 		switch clone := clone.(type) {
 		case value.AnnotatedValue:
@@ -109,12 +130,21 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 		}
 	}
 
-	pairs, e := this.plan.Keyspace().Update(pairs)
+	var errs errors.Errors
+	if keyspace, ok := this.plan.Keyspace().(datastore.CasKeyspace); ok {
+		pairs, errs = keyspace.UpdateCas(pairs)
+	} else {
+		var e errors.Error
+		pairs, e = this.plan.Keyspace().Update(pairs)
+		if e != nil {
+			errs = errors.Errors{e}
+		}
+	}
 
 	// Update mutation count with number of updated docs
 	context.AddMutationCount(uint64(len(pairs)))
 
-	if e != nil {
+	for _, e := range errs {
 		context.Error(e)
 	}
 
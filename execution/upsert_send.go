@@ -60,8 +60,9 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 
 	keyExpr := this.plan.Key()
 	valExpr := this.plan.Value()
+	optsExpr := this.plan.Options()
 	dpairs := make([]datastore.Pair, len(this.batch))
-	var key, val value.Value
+	var key, val, opts value.Value
 	var err error
 	var ok bool
 	i := 0
@@ -88,6 +89,15 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 			} else {
 				val = av
 			}
+
+			if optsExpr != nil {
+				opts, err = optsExpr.Evaluate(av, context)
+				if err != nil {
+					context.Error(errors.NewError(err,
+						fmt.Sprintf("Error evaluating UPSERT options for %v", av.GetValue())))
+					continue
+				}
+			}
 		} else {
 			// UPSERT ... VALUES
 			key, ok = av.GetAttachment("key").(value.Value)
@@ -103,6 +113,8 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 					fmt.Sprintf("No UPSERT value for %v", av.GetValue())))
 				continue
 			}
+
+			opts, _ = av.GetAttachment("options").(value.Value)
 		}
 
 		dpair.Key, ok = key.Actual().(string)
@@ -113,6 +125,14 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 		}
 
 		dpair.Value = val
+
+		var e errors.Error
+		dpair.Expiration, e = optionsExpiration(opts)
+		if e != nil {
+			context.Error(e)
+			continue
+		}
+
 		i++
 	}
 
@@ -26,6 +26,8 @@ type Visitor interface {
 
 	// Join
 	VisitJoin(op *Join) (interface{}, error)
+	VisitIndexJoin(op *IndexJoin) (interface{}, error)
+	VisitHashJoin(op *HashJoin) (interface{}, error)
 	VisitNest(op *Nest) (interface{}, error)
 	VisitUnnest(op *Unnest) (interface{}, error)
 
@@ -40,6 +42,9 @@ type Visitor interface {
 	VisitIntermediateGroup(op *IntermediateGroup) (interface{}, error)
 	VisitFinalGroup(op *FinalGroup) (interface{}, error)
 
+	// Window
+	VisitWindow(op *Window) (interface{}, error)
+
 	// Project
 	VisitInitialProject(op *InitialProject) (interface{}, error)
 	VisitFinalProject(op *FinalProject) (interface{}, error)
@@ -94,6 +99,17 @@ type Visitor interface {
 	VisitAlterIndex(op *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(op *BuildIndexes) (interface{}, error)
 
+	// Functions
+	VisitCreateFunction(op *CreateFunction) (interface{}, error)
+	VisitDropFunction(op *DropFunction) (interface{}, error)
+
+	// Statistics
+	VisitUpdateStatistics(op *UpdateStatistics) (interface{}, error)
+
+	// Roles
+	VisitGrantRole(op *GrantRole) (interface{}, error)
+	VisitRevokeRole(op *RevokeRole) (interface{}, error)
+
 	// Explain
 	VisitExplain(op *Explain) (interface{}, error)
 
@@ -0,0 +1,218 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"sort"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// Computes window functions. All the items are collected; for each
+// distinct window, they are sorted by partition and ORDER BY terms,
+// and each partition is computed in turn. The items are then sent in
+// their original order, with the results in the "windows" attachment.
+type Window struct {
+	base
+	plan   *plan.Window
+	values value.AnnotatedValues
+}
+
+const _WINDOW_CAP = 1024
+
+func NewWindow(plan *plan.Window) *Window {
+	rv := &Window{
+		base:   newBase(),
+		plan:   plan,
+		values: make(value.AnnotatedValues, 0, _WINDOW_CAP),
+	}
+
+	rv.output = rv
+	return rv
+}
+
+func (this *Window) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitWindow(this)
+}
+
+func (this *Window) Copy() Operator {
+	return &Window{
+		base:   this.base.copy(),
+		plan:   this.plan,
+		values: make(value.AnnotatedValues, 0, _WINDOW_CAP),
+	}
+}
+
+func (this *Window) RunOnce(context *Context, parent value.Value) {
+	this.runConsumer(this, context, parent)
+}
+
+func (this *Window) processItem(item value.AnnotatedValue, context *Context) bool {
+	this.values = append(this.values, item)
+	return true
+}
+
+func (this *Window) afterItems(context *Context) {
+	defer func() { this.values = nil }()
+
+	for _, av := range this.values {
+		av.SetAttachment("windows", make(map[string]value.Value, len(this.plan.Functions())))
+	}
+
+	// Functions over the same window share the sorting
+	windows := make(map[string]algebra.WindowFunctions)
+	keys := make([]string, 0, len(this.plan.Functions()))
+	for _, fn := range this.plan.Functions() {
+		key := fn.Window().String()
+		if _, ok := windows[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		windows[key] = append(windows[key], fn)
+	}
+
+	for _, key := range keys {
+		if !this.computeWindow(windows[key], context) {
+			return
+		}
+	}
+
+	for _, av := range this.values {
+		if !this.sendItem(av) {
+			return
+		}
+	}
+}
+
+func (this *Window) computeWindow(functions algebra.WindowFunctions, context *Context) bool {
+	window := functions[0].Window()
+	partitionBy := window.PartitionBy()
+	orderBy := window.OrderBy()
+
+	rows := make([]*windowRow, len(this.values))
+	for i, av := range this.values {
+		row := &windowRow{
+			item:      av,
+			partition: make(value.Values, len(partitionBy)),
+			order:     make(value.Values, len(orderBy)),
+		}
+
+		for j, expr := range partitionBy {
+			v, e := expr.Evaluate(av, context)
+			if e != nil {
+				context.Error(errors.NewError(e, "Error evaluating window PARTITION BY."))
+				return false
+			}
+
+			row.partition[j] = v
+		}
+
+		for j, term := range orderBy {
+			v, e := term.Expression().Evaluate(av, context)
+			if e != nil {
+				context.Error(errors.NewError(e, "Error evaluating window ORDER BY."))
+				return false
+			}
+
+			row.order[j] = v
+		}
+
+		rows[i] = row
+	}
+
+	sort.Stable(&windowSorter{rows, orderBy})
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && collateValues(rows[end].partition, rows[start].partition) == 0 {
+			end++
+		}
+
+		items := make(value.AnnotatedValues, end-start)
+		orders := make([]value.Values, end-start)
+		for i, row := range rows[start:end] {
+			items[i], orders[i] = row.item, row.order
+		}
+
+		partition := algebra.NewWindowPartition(items, orders, orderBy)
+		for _, fn := range functions {
+			vals, e := fn.ComputeWindow(partition, context)
+			if e != nil {
+				context.Error(errors.NewError(e, "Error computing window function."))
+				return false
+			}
+
+			name := fn.String()
+			for i, item := range items {
+				item.GetAttachment("windows").(map[string]value.Value)[name] = vals[i]
+			}
+		}
+
+		start = end
+	}
+
+	return true
+}
+
+type windowRow struct {
+	item      value.AnnotatedValue
+	partition value.Values
+	order     value.Values
+}
+
+// Sorts rows by partition, and then by the window ORDER BY terms.
+type windowSorter struct {
+	rows  []*windowRow
+	terms algebra.SortTerms
+}
+
+func (this *windowSorter) Len() int {
+	return len(this.rows)
+}
+
+func (this *windowSorter) Less(i, j int) bool {
+	r1, r2 := this.rows[i], this.rows[j]
+
+	c := collateValues(r1.partition, r2.partition)
+	if c != 0 {
+		return c < 0
+	}
+
+	for k, term := range this.terms {
+		c = r1.order[k].Collate(r2.order[k])
+		if c == 0 {
+			continue
+		} else if term.Descending() {
+			return c > 0
+		} else {
+			return c < 0
+		}
+	}
+
+	return false
+}
+
+func (this *windowSorter) Swap(i, j int) {
+	this.rows[i], this.rows[j] = this.rows[j], this.rows[i]
+}
+
+func collateValues(v1, v2 value.Values) int {
+	for i, v := range v1 {
+		c := v.Collate(v2[i])
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
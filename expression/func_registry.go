@@ -10,6 +10,7 @@
 package expression
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -26,6 +27,20 @@ func GetFunction(name string) (Function, bool) {
 	return rv, ok
 }
 
+/*
+Returns the names of all the functions, in lower case and sorted,
+e.g. for completion in the shell.
+*/
+func FunctionNames() []string {
+	rv := make([]string, 0, len(_FUNCTIONS))
+	for name, _ := range _FUNCTIONS {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
 /*
 The variable _FUNCTIONS represents a map from string to
 Function. Each string returns a pointer to that function.
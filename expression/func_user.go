@@ -0,0 +1,282 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// UserFunction
+//
+///////////////////////////////////////////////////
+
+/*
+This represents a call to a user-defined function, created by
+CREATE FUNCTION name(a, b) { expr }. The body is an expression over
+the parameters of the function. It is evaluated against an object
+that binds each parameter to the corresponding argument, so the body
+cannot refer to anything but its parameters.
+*/
+type UserFunction struct {
+	FunctionBase
+	parameters []string
+	body       Expression
+}
+
+/*
+The function NewUserFunction returns a call of the function with the
+given name, parameters and body, applied to the input operands.
+*/
+func NewUserFunction(name string, parameters []string, body Expression, operands ...Expression) Function {
+	rv := &UserFunction{
+		FunctionBase: *NewFunctionBase(name, operands...),
+		parameters:   parameters,
+		body:         body,
+	}
+
+	rv.conditional = true
+	rv.expr = rv
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *UserFunction) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+The return type depends on the body and arguments, so it is JSON.
+*/
+func (this *UserFunction) Type() value.Type { return value.JSON }
+
+/*
+Evaluates the arguments, binds them to the parameters, and evaluates
+the body. MISSING arguments leave their parameters unbound.
+*/
+func (this *UserFunction) Evaluate(item value.Value, context Context) (value.Value, error) {
+	bindings := make(map[string]interface{}, len(this.parameters))
+	for i, op := range this.operands {
+		arg, err := op.Evaluate(item, context)
+		if err != nil {
+			return nil, err
+		}
+
+		if arg.Type() != value.MISSING {
+			bindings[this.parameters[i]] = arg
+		}
+	}
+
+	return this.body.Evaluate(value.NewValue(bindings), context)
+}
+
+/*
+User-defined functions can be dropped and re-created, so they are
+not indexable.
+*/
+func (this *UserFunction) Indexable() bool {
+	return false
+}
+
+/*
+Calls of different functions with the same arguments are not
+equivalent.
+*/
+func (this *UserFunction) EquivalentTo(other Expression) bool {
+	that, ok := other.(*UserFunction)
+	return ok && this.name == that.name && this.FunctionBase.EquivalentTo(other)
+}
+
+/*
+The number of arguments is the number of parameters.
+*/
+func (this *UserFunction) MinArgs() int { return len(this.parameters) }
+
+/*
+The number of arguments is the number of parameters.
+*/
+func (this *UserFunction) MaxArgs() int { return len(this.parameters) }
+
+/*
+Return a NewUserFunction with the same definition, as
+FunctionConstructor.
+*/
+func (this *UserFunction) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewUserFunction(this.name, this.parameters, this.body, operands...)
+	}
+}
+
+/*
+Returns the parameter names of the function.
+*/
+func (this *UserFunction) Parameters() []string {
+	return this.parameters
+}
+
+/*
+Returns the body of the function.
+*/
+func (this *UserFunction) Body() Expression {
+	return this.body
+}
+
+///////////////////////////////////////////////////
+//
+// Registry
+//
+///////////////////////////////////////////////////
+
+/*
+This method is used to retrieve a user-defined function by the
+parser. The returned function has no operands; the parser calls
+its Constructor() with the arguments.
+*/
+func GetUserFunction(name string) (Function, bool) {
+	_USER_FUNCTIONS.RLock()
+	defer _USER_FUNCTIONS.RUnlock()
+
+	rv, ok := _USER_FUNCTIONS.functions[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+
+	return rv, true
+}
+
+/*
+Adds a user-defined function. Returns false if a built-in or
+user-defined function with the same name already exists.
+*/
+func RegisterUserFunction(name string, parameters []string, body Expression) bool {
+	name = strings.ToLower(name)
+	if _, ok := _FUNCTIONS[name]; ok {
+		return false
+	}
+
+	_USER_FUNCTIONS.Lock()
+	defer _USER_FUNCTIONS.Unlock()
+
+	if _, ok := _USER_FUNCTIONS.functions[name]; ok {
+		return false
+	}
+
+	_USER_FUNCTIONS.functions[name] = NewUserFunction(name, parameters, body).(*UserFunction)
+	return true
+}
+
+/*
+Removes a user-defined function. Returns false if there is no such
+function. Expressions that already call the function are not
+affected; prepared statements that call it must be invalidated by
+the caller.
+*/
+func UnregisterUserFunction(name string) bool {
+	_USER_FUNCTIONS.Lock()
+	defer _USER_FUNCTIONS.Unlock()
+
+	name = strings.ToLower(name)
+	if _, ok := _USER_FUNCTIONS.functions[name]; !ok {
+		return false
+	}
+
+	delete(_USER_FUNCTIONS.functions, name)
+	return true
+}
+
+/*
+Returns the names of all the user-defined functions, in lower case
+and sorted.
+*/
+func UserFunctionNames() []string {
+	_USER_FUNCTIONS.RLock()
+	defer _USER_FUNCTIONS.RUnlock()
+
+	rv := make([]string, 0, len(_USER_FUNCTIONS.functions))
+	for name, _ := range _USER_FUNCTIONS.functions {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+/*
+UserFunctionLister is a Visitor for enumerating the user-defined
+functions called within an expression tree, including the functions
+called by their bodies.
+*/
+type UserFunctionLister struct {
+	TraverserBase
+
+	functions map[string]bool
+}
+
+func NewUserFunctionLister() *UserFunctionLister {
+	rv := &UserFunctionLister{
+		functions: make(map[string]bool),
+	}
+
+	rv.traverser = rv
+	return rv
+}
+
+func (this *UserFunctionLister) VisitFunction(expr Function) (interface{}, error) {
+	if fn, ok := expr.(*UserFunction); ok && !this.functions[fn.name] {
+		this.functions[fn.name] = true
+		err := this.Traverse(fn.body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, this.TraverseList(expr.Children())
+}
+
+/*
+Returns the names of the functions, sorted.
+*/
+func (this *UserFunctionLister) Functions() []string {
+	rv := make([]string, 0, len(this.functions))
+	for name, _ := range this.functions {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+func ListUserFunctions(exprs Expressions) ([]string, error) {
+	lister := NewUserFunctionLister()
+
+	for _, expr := range exprs {
+		err := lister.Traverse(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lister.Functions(), nil
+}
+
+var _USER_FUNCTIONS = struct {
+	sync.RWMutex
+	functions map[string]*UserFunction
+}{
+	functions: make(map[string]*UserFunction),
+}
@@ -67,6 +67,20 @@ type Function interface {
 	Constructor() FunctionConstructor
 }
 
+/*
+A windowed function, such as RANK() OVER (ORDER BY x), is computed
+over a window of items. Its representation as a N1QL string includes
+the window.
+*/
+type Windowed interface {
+	Function
+
+	/*
+	   Representation of the window as a N1QL string.
+	*/
+	WindowString() string
+}
+
 /*
 FunctionConstructor enables dynamic construction of functions.
 It represents a function that takes input expressions as
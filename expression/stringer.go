@@ -532,6 +532,11 @@ func (this *Stringer) VisitFunction(expr Function) (interface{}, error) {
 	}
 
 	buf.WriteString(")")
+
+	if windowed, ok := expr.(Windowed); ok {
+		buf.WriteString(windowed.WindowString())
+	}
+
 	return buf.String(), nil
 }
 
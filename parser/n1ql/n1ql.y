@@ -45,6 +45,9 @@ projection       *algebra.Projection
 order            *algebra.Order
 sortTerm         *algebra.SortTerm
 sortTerms        algebra.SortTerms
+windowTerm       *algebra.WindowTerm
+windowFrame      *algebra.WindowFrame
+frameBound       *algebra.FrameBound
 
 keyspaceRef      *algebra.KeyspaceRef
 
@@ -65,6 +68,8 @@ createIndex      *algebra.CreateIndex
 dropIndex        *algebra.DropIndex
 alterIndex       *algebra.AlterIndex
 indexType        datastore.IndexType
+indexRef         *algebra.IndexRef
+indexRefs        algebra.IndexRefs
 val              value.Value
 }
 
@@ -287,6 +292,11 @@ val              value.Value
 
 %type <expr>             function_expr
 %type <s>                function_name
+%type <windowTerm>       window_spec
+%type <exprs>            opt_window_partition
+%type <sortTerms>        opt_window_order
+%type <windowFrame>      opt_window_frame window_frame_extent
+%type <frameBound>       frame_bound
 
 %type <expr>             paren_or_subquery_expr paren_or_subquery
 
@@ -296,12 +306,14 @@ val              value.Value
 %type <subselect>        select_from
 %type <subselect>        from_select
 %type <fromTerm>         from_term from opt_from
-%type <keyspaceTerm>     keyspace_term join_term
+%type <keyspaceTerm>     keyspace_term join_term join_keyspace
 %type <subqueryTerm>     subquery_term
 %type <b>                opt_join_type
 %type <path>             path opt_subpath
 %type <s>                namespace_name keyspace_name
 %type <expr>             use_keys opt_use_keys on_keys
+%type <indexRefs>        use_index index_refs
+%type <indexRef>         index_ref
 %type <bindings>         opt_let let
 %type <expr>             opt_where where
 %type <group>            opt_group group
@@ -320,10 +332,13 @@ val              value.Value
 %type <statement>        stmt explain prepare execute select_stmt dml_stmt ddl_stmt
 %type <statement>        insert upsert delete update merge
 %type <statement>        index_stmt create_index drop_index alter_index build_index
+%type <statement>        function_stmt create_function drop_function
+%type <statement>        update_statistics
+%type <statement>        role_stmt grant_role revoke_role
 
 %type <keyspaceRef>      keyspace_ref
 %type <pairs>            values values_list
-%type <expr>             key_expr opt_value_expr
+%type <expr>             key_expr opt_value_expr opt_expiration
 %type <projection>       returns returning opt_returning
 %type <binding>          update_binding
 %type <bindings>         update_bindings
@@ -349,8 +364,13 @@ val              value.Value
 %type <indexType>        index_using opt_index_using
 %type <val>              index_with opt_index_with
 %type <s>                rename
+%type <s>                opt_name
 %type <expr>             index_expr index_where
 %type <exprs>            index_exprs
+%type <ss>               function_params opt_function_params
+%type <ss>               user_names
+%type <s>                role_name
+%type <keyspaceRef>      role_keyspace
 
 %start input
 
@@ -390,9 +410,26 @@ EXPLAIN stmt
 ;
 
 prepare:
-PREPARE stmt
+PREPARE opt_name stmt
 {
-    $$ = algebra.NewPrepare($2)
+    $$ = algebra.NewPrepare($2, $3)
+}
+;
+
+opt_name:
+/* empty */
+{
+    $$ = ""
+}
+|
+IDENTIFIER FROM
+{
+    $$ = $1
+}
+|
+STRING FROM
+{
+    $$ = $1
 }
 ;
 
@@ -401,6 +438,16 @@ EXECUTE object
 {
     $$ = algebra.NewExecute($2)
 }
+|
+EXECUTE IDENTIFIER
+{
+    $$ = algebra.NewExecute(expression.NewConstant($2))
+}
+|
+EXECUTE STRING
+{
+    $$ = algebra.NewExecute(expression.NewConstant($2))
+}
 ;
 
 select_stmt:
@@ -424,6 +471,12 @@ merge
 
 ddl_stmt:
 index_stmt
+|
+function_stmt
+|
+update_statistics
+|
+role_stmt
 ;
 
 index_stmt:
@@ -648,6 +701,11 @@ from_term opt_join_type JOIN join_term
     $$ = algebra.NewJoin($1, $2, $4)
 }
 |
+from_term opt_join_type JOIN join_keyspace ON expr
+{
+    $$ = algebra.NewAnsiJoin($1, $2, $4, $6)
+}
+|
 from_term opt_join_type NEST join_term
 {
     $$ = algebra.NewNest($1, $2, $4)
@@ -680,6 +738,24 @@ SYSTEM COLON keyspace_name opt_subpath opt_as_alias opt_use_keys
 {
     $$ = algebra.NewKeyspaceTerm("#system", $3, $4, $5, $6)
 }
+|
+keyspace_name opt_subpath opt_as_alias use_index
+{
+    $$ = algebra.NewKeyspaceTerm("", $1, $2, $3, nil)
+    $$.SetIndexes($4)
+}
+|
+namespace_name COLON keyspace_name opt_subpath opt_as_alias use_index
+{
+    $$ = algebra.NewKeyspaceTerm($1, $3, $4, $5, nil)
+    $$.SetIndexes($6)
+}
+|
+SYSTEM COLON keyspace_name opt_subpath opt_as_alias use_index
+{
+    $$ = algebra.NewKeyspaceTerm("#system", $3, $4, $5, nil)
+    $$.SetIndexes($6)
+}
 ;
 
 subquery_term:
@@ -694,19 +770,26 @@ LPAREN fullselect RPAREN opt_as_alias
 ;
 
 join_term:
-keyspace_name opt_subpath opt_as_alias on_keys
+join_keyspace on_keys
 {
-    $$ = algebra.NewKeyspaceTerm("", $1, $2, $3, $4)
+    $$ = algebra.NewKeyspaceTerm($1.Namespace(), $1.Keyspace(), $1.Projection(), $1.As(), $2)
+}
+;
+
+join_keyspace:
+keyspace_name opt_subpath opt_as_alias
+{
+    $$ = algebra.NewKeyspaceTerm("", $1, $2, $3, nil)
 }
 |
-namespace_name COLON keyspace_name opt_subpath opt_as_alias on_keys
+namespace_name COLON keyspace_name opt_subpath opt_as_alias
 {
-    $$ = algebra.NewKeyspaceTerm($1, $3, $4, $5, $6)
+    $$ = algebra.NewKeyspaceTerm($1, $3, $4, $5, nil)
 }
 |
-SYSTEM COLON keyspace_name opt_subpath opt_as_alias on_keys
+SYSTEM COLON keyspace_name opt_subpath opt_as_alias
 {
-    $$ = algebra.NewKeyspaceTerm("#system", $3, $4, $5, $6)
+    $$ = algebra.NewKeyspaceTerm("#system", $3, $4, $5, nil)
 }
 ;
 
@@ -746,6 +829,32 @@ USE opt_primary KEYS expr
 }
 ;
 
+use_index:
+USE INDEX LPAREN index_refs RPAREN
+{
+    $$ = $4
+}
+;
+
+index_refs:
+index_ref
+{
+    $$ = algebra.IndexRefs{$1}
+}
+|
+index_refs COMMA index_ref
+{
+    $$ = append($1, $3)
+}
+;
+
+index_ref:
+index_name opt_index_using
+{
+    $$ = algebra.NewIndexRef($1, $2)
+}
+;
+
 opt_primary:
 /* empty */
 {
@@ -1045,7 +1154,17 @@ INSERT INTO keyspace_ref opt_values_header values_list opt_returning
 |
 INSERT INTO keyspace_ref LPAREN key_expr opt_value_expr RPAREN fullselect opt_returning
 {
-    $$ = algebra.NewInsertSelect($3, $5, $6, $8, $9)
+    $$ = algebra.NewInsertSelect($3, $5, $6, nil, $8, $9)
+}
+|
+INSERT INTO keyspace_ref LPAREN key_expr COMMA options expr RPAREN fullselect opt_returning
+{
+    $$ = algebra.NewInsertSelect($3, $5, nil, $8, $10, $11)
+}
+|
+INSERT INTO keyspace_ref LPAREN key_expr COMMA VALUE expr COMMA options expr RPAREN fullselect opt_returning
+{
+    $$ = algebra.NewInsertSelect($3, $5, $8, $11, $13, $14)
 }
 ;
 
@@ -1055,6 +1174,11 @@ namespace_name COLON keyspace_name opt_as_alias
     $$ = algebra.NewKeyspaceRef($1, $3, $4)
 }
 |
+SYSTEM COLON keyspace_name opt_as_alias
+{
+    $$ = algebra.NewKeyspaceRef("#system", $3, $4)
+}
+|
 keyspace_name opt_as_alias
 {
     $$ = algebra.NewKeyspaceRef("", $1, $2)
@@ -1067,6 +1191,19 @@ opt_values_header:
 LPAREN KEY COMMA VALUE RPAREN
 |
 LPAREN PRIMARY KEY COMMA VALUE RPAREN
+|
+LPAREN KEY COMMA VALUE COMMA options RPAREN
+|
+LPAREN PRIMARY KEY COMMA VALUE COMMA options RPAREN
+;
+
+options:
+IDENTIFIER
+{
+    if strings.ToLower($1) != "options" {
+        yylex.Error("Expected OPTIONS, found " + $1 + ".")
+    }
+}
 ;
 
 key:
@@ -1089,6 +1226,11 @@ VALUES LPAREN expr COMMA expr RPAREN
 {
     $$ = algebra.Pairs{&algebra.Pair{Key: $3, Value: $5}}
 }
+|
+VALUES LPAREN expr COMMA expr COMMA expr RPAREN
+{
+    $$ = algebra.Pairs{&algebra.Pair{Key: $3, Value: $5, Options: $7}}
+}
 ;
 
 opt_returning:
@@ -1153,7 +1295,17 @@ UPSERT INTO keyspace_ref opt_values_header values_list opt_returning
 |
 UPSERT INTO keyspace_ref LPAREN key_expr opt_value_expr RPAREN fullselect opt_returning
 {
-    $$ = algebra.NewUpsertSelect($3, $5, $6, $8, $9)
+    $$ = algebra.NewUpsertSelect($3, $5, $6, nil, $8, $9)
+}
+|
+UPSERT INTO keyspace_ref LPAREN key_expr COMMA options expr RPAREN fullselect opt_returning
+{
+    $$ = algebra.NewUpsertSelect($3, $5, nil, $8, $10, $11)
+}
+|
+UPSERT INTO keyspace_ref LPAREN key_expr COMMA VALUE expr COMMA options expr RPAREN fullselect opt_returning
+{
+    $$ = algebra.NewUpsertSelect($3, $5, $8, $11, $13, $14)
 }
 ;
 
@@ -1179,19 +1331,34 @@ DELETE FROM keyspace_ref opt_use_keys opt_where opt_limit opt_returning
  *************************************************/
 
 update:
-UPDATE keyspace_ref opt_use_keys set unset opt_where opt_limit opt_returning
+UPDATE keyspace_ref opt_use_keys set unset opt_expiration opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3, $4, $5, $6, $7, $8)
+    $$ = algebra.NewUpdate($2, $3, $4, $5, $6, $7, $8, $9)
 }
 |
-UPDATE keyspace_ref opt_use_keys set opt_where opt_limit opt_returning
+UPDATE keyspace_ref opt_use_keys set opt_expiration opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3, $4, nil, $5, $6, $7)
+    $$ = algebra.NewUpdate($2, $3, $4, nil, $5, $6, $7, $8)
 }
 |
-UPDATE keyspace_ref opt_use_keys unset opt_where opt_limit opt_returning
+UPDATE keyspace_ref opt_use_keys unset opt_expiration opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3, nil, $4, $5, $6, $7)
+    $$ = algebra.NewUpdate($2, $3, nil, $4, $5, $6, $7, $8)
+}
+;
+
+opt_expiration:
+/* empty */
+{
+    $$ = nil
+}
+|
+WITH IDENTIFIER expr
+{
+    if strings.ToLower($2) != "expiration" {
+        yylex.Error("Expected WITH EXPIRATION, found WITH " + $2 + ".")
+    }
+    $$ = $3
 }
 ;
 
@@ -1613,6 +1780,151 @@ index_names COMMA index_name
 ;
 
 
+/*************************************************
+ *
+ * CREATE FUNCTION
+ *
+ *************************************************/
+
+function_stmt:
+create_function
+|
+drop_function
+;
+
+create_function:
+CREATE FUNCTION function_name LPAREN opt_function_params RPAREN LBRACE expr RBRACE
+{
+    $$ = algebra.NewCreateFunction($3, $5, $8)
+}
+;
+
+opt_function_params:
+/* empty */
+{
+    $$ = nil
+}
+|
+function_params
+;
+
+function_params:
+IDENTIFIER
+{
+    $$ = []string{$1}
+}
+|
+function_params COMMA IDENTIFIER
+{
+    $$ = append($1, $3)
+}
+;
+
+
+/*************************************************
+ *
+ * DROP FUNCTION
+ *
+ *************************************************/
+
+drop_function:
+DROP FUNCTION function_name
+{
+    $$ = algebra.NewDropFunction($3)
+}
+;
+
+
+/*************************************************
+ *
+ * UPDATE STATISTICS
+ *
+ *************************************************/
+
+update_statistics:
+UPDATE STATISTICS FOR keyspace_ref LPAREN exprs RPAREN
+{
+    $$ = algebra.NewUpdateStatistics($4, $6)
+}
+|
+ANALYZE KEYSPACE keyspace_ref LPAREN exprs RPAREN
+{
+    $$ = algebra.NewUpdateStatistics($3, $5)
+}
+;
+
+
+/*************************************************
+ *
+ * GRANT / REVOKE
+ *
+ *************************************************/
+
+role_stmt:
+grant_role
+|
+revoke_role
+;
+
+grant_role:
+GRANT opt_role role_name ON role_keyspace TO opt_user user_names
+{
+    role, _ := datastore.PrivilegeByName($3)
+    $$ = algebra.NewGrantRole(role, $5, $8)
+}
+;
+
+revoke_role:
+REVOKE opt_role role_name ON role_keyspace FROM opt_user user_names
+{
+    role, _ := datastore.PrivilegeByName($3)
+    $$ = algebra.NewRevokeRole(role, $5, $8)
+}
+;
+
+opt_role:
+/* empty */
+|
+ROLE
+;
+
+role_name:
+IDENTIFIER
+{
+    if _, ok := datastore.PrivilegeByName($1); !ok {
+        yylex.Error(fmt.Sprintf("Invalid role %s.", $1))
+    }
+}
+;
+
+role_keyspace:
+named_keyspace_ref
+|
+namespace_name COLON STAR
+{
+    $$ = algebra.NewKeyspaceRef($1, "*", "")
+}
+;
+
+opt_user:
+/* empty */
+|
+USER
+;
+
+user_names:
+IDENTIFIER
+{
+    $$ = []string{$1}
+}
+|
+user_names COMMA IDENTIFIER
+{
+    $$ = append($1, $3)
+}
+;
+
+
 /*************************************************
  *
  * Path
@@ -2234,6 +2546,10 @@ function_name LPAREN opt_exprs RPAREN
 {
     $$ = nil;
     f, ok := expression.GetFunction($1);
+    if !ok {
+        f, ok = expression.GetUserFunction($1);
+    }
+
     if !ok && yylex.(*lexer).parsingStatement() {
         f, ok = algebra.GetAggregate($1, false);
     }
@@ -2282,6 +2598,41 @@ function_name LPAREN STAR RPAREN
         }
     }
 }
+|
+function_name LPAREN opt_exprs RPAREN OVER LPAREN window_spec RPAREN
+{
+    $$ = nil;
+    if !yylex.(*lexer).parsingStatement() {
+        yylex.Error("Cannot use window function as an inline expression.");
+    } else if f, ok := algebra.GetWindowFunction($1); ok {
+        if len($3) < f.MinArgs() || len($3) > f.MaxArgs() {
+            yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", $1));
+        } else {
+            $$ = f.WindowConstructor()($7, $3...);
+        }
+    } else if agg, ok := algebra.GetAggregate($1, false); ok {
+        if len($3) < agg.MinArgs() || len($3) > agg.MaxArgs() {
+            yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", $1));
+        } else {
+            $$ = algebra.NewWindowAggregate(agg.Constructor()($3...).(algebra.Aggregate), $7);
+        }
+    } else {
+        yylex.Error(fmt.Sprintf("Invalid window function %s.", $1));
+    }
+}
+|
+function_name LPAREN STAR RPAREN OVER LPAREN window_spec RPAREN
+{
+    $$ = nil;
+    if !yylex.(*lexer).parsingStatement() {
+        yylex.Error("Cannot use window function as an inline expression.");
+    } else if strings.ToLower($1) != "count" {
+        yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", $1));
+    } else {
+        agg, _ := algebra.GetAggregate($1, false);
+        $$ = algebra.NewWindowAggregate(agg.Constructor()(nil).(algebra.Aggregate), $7);
+    }
+}
 ;
 
 function_name:
@@ -2289,6 +2640,114 @@ IDENTIFIER
 ;
 
 
+/*************************************************
+ *
+ * Window
+ *
+ *************************************************/
+
+window_spec:
+opt_window_partition opt_window_order opt_window_frame
+{
+    $$ = algebra.NewWindowTerm($1, $2, $3);
+    err := $$.Validate();
+    if err != nil {
+        yylex.Error(err.Error());
+    }
+}
+;
+
+opt_window_partition:
+/* empty */
+{
+    $$ = nil
+}
+|
+PARTITION BY exprs
+{
+    $$ = $3
+}
+;
+
+opt_window_order:
+/* empty */
+{
+    $$ = nil
+}
+|
+ORDER BY sort_terms
+{
+    $$ = $3
+}
+;
+
+/*
+The frame words ROWS, RANGE, BETWEEN ... AND, UNBOUNDED, PRECEDING,
+FOLLOWING and CURRENT ROW are not reserved, and are matched as
+identifiers.
+*/
+opt_window_frame:
+/* empty */
+{
+    $$ = nil
+}
+|
+IDENTIFIER window_frame_extent
+{
+    $$ = nil;
+    switch strings.ToLower($1) {
+    case "rows":
+        $$ = algebra.NewWindowFrame(true, $2.Start(), $2.End());
+    case "range":
+        $$ = algebra.NewWindowFrame(false, $2.Start(), $2.End());
+    default:
+        yylex.Error(fmt.Sprintf("Invalid window frame %s; expected ROWS or RANGE.", $1));
+    }
+}
+;
+
+window_frame_extent:
+frame_bound
+{
+    $$ = algebra.NewWindowFrame(false, $1, algebra.NewFrameBound(algebra.CURRENT_ROW, nil))
+}
+|
+BETWEEN frame_bound AND frame_bound
+{
+    $$ = algebra.NewWindowFrame(false, $2, $4)
+}
+;
+
+frame_bound:
+expr IDENTIFIER
+{
+    $$ = nil;
+    word := ""
+    if ident, ok := $1.(*expression.Identifier); ok {
+        word = strings.ToLower(ident.Identifier())
+    }
+
+    switch word + " " + strings.ToLower($2) {
+    case "unbounded preceding":
+        $$ = algebra.NewFrameBound(algebra.UNBOUNDED_PRECEDING, nil);
+    case "unbounded following":
+        $$ = algebra.NewFrameBound(algebra.UNBOUNDED_FOLLOWING, nil);
+    case "current row":
+        $$ = algebra.NewFrameBound(algebra.CURRENT_ROW, nil);
+    default:
+        switch strings.ToLower($2) {
+        case "preceding":
+            $$ = algebra.NewFrameBound(algebra.PRECEDING, $1);
+        case "following":
+            $$ = algebra.NewFrameBound(algebra.FOLLOWING, $1);
+        default:
+            yylex.Error(fmt.Sprintf("Invalid window frame bound %s %s.", $1, $2));
+        }
+    }
+}
+;
+
+
 /*************************************************
  *
  * Collection
@@ -66,3 +66,23 @@ func UnmarshalUnquoted(s string) (t string, e error) {
 
 	return t, e
 }
+
+/*
+Returns the reserved words of N1QL. These are the tokens declared in
+n1ql.y ahead of INT, which begins the literal and punctuation tokens.
+*/
+func Keywords() []string {
+	rv := make([]string, 0, len(yyToknames))
+	for _, name := range yyToknames {
+		switch name {
+		case "$end", "error", "$unk":
+			continue
+		case "INT":
+			return rv
+		}
+
+		rv = append(rv, name)
+	}
+
+	return rv
+}
@@ -1,9 +1,12 @@
+// Code generated by goyacc n1ql.y. DO NOT EDIT.
+
 //line n1ql.y:2
 package n1ql
 
 import __yyfmt__ "fmt"
 
 //line n1ql.y:2
+
 import "fmt"
 import "strings"
 import "github.com/couchbaselabs/clog"
@@ -49,6 +52,9 @@ type yySymType struct {
 	order        *algebra.Order
 	sortTerm     *algebra.SortTerm
 	sortTerms    algebra.SortTerms
+	windowTerm   *algebra.WindowTerm
+	windowFrame  *algebra.WindowFrame
+	frameBound   *algebra.FrameBound
 
 	keyspaceRef *algebra.KeyspaceRef
 
@@ -69,6 +75,8 @@ type yySymType struct {
 	dropIndex   *algebra.DropIndex
 	alterIndex  *algebra.AlterIndex
 	indexType   datastore.IndexType
+	indexRef    *algebra.IndexRef
+	indexRefs   algebra.IndexRefs
 	val         value.Value
 }
 
@@ -261,7 +269,10 @@ const MOD = 57531
 const UMINUS = 57532
 const DOT = 57533
 
-var yyToknames = []string{
+var yyToknames = [...]string{
+	"$end",
+	"error",
+	"$unk",
 	"ALL",
 	"ALTER",
 	"ANALYZE",
@@ -451,732 +462,850 @@ var yyToknames = []string{
 	"UMINUS",
 	"DOT",
 }
-var yyStatenames = []string{}
+
+var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
-const yyMaxDepth = 200
+const yyInitialStackSize = 16
 
 //line yacctab:1
-var yyExca = []int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 25,
-	168, 326,
-	-2, 271,
-	-1, 119,
-	176, 72,
-	-2, 73,
-	-1, 157,
-	54, 81,
-	73, 81,
-	92, 81,
-	144, 81,
-	-2, 57,
-	-1, 186,
+	-1, 28,
+	168, 379,
+	-2, 322,
+	-1, 135,
+	176, 85,
+	-2, 86,
+	-1, 179,
+	54, 98,
+	73, 98,
+	92, 98,
+	144, 98,
+	-2, 65,
+	-1, 208,
 	178, 0,
 	179, 0,
 	180, 0,
-	-2, 235,
-	-1, 187,
+	-2, 286,
+	-1, 209,
 	178, 0,
 	179, 0,
 	180, 0,
-	-2, 236,
-	-1, 188,
+	-2, 287,
+	-1, 210,
 	178, 0,
 	179, 0,
 	180, 0,
-	-2, 237,
-	-1, 189,
+	-2, 288,
+	-1, 211,
 	181, 0,
 	182, 0,
 	183, 0,
 	184, 0,
-	-2, 238,
-	-1, 190,
+	-2, 289,
+	-1, 212,
 	181, 0,
 	182, 0,
 	183, 0,
 	184, 0,
-	-2, 239,
-	-1, 191,
+	-2, 290,
+	-1, 213,
 	181, 0,
 	182, 0,
 	183, 0,
 	184, 0,
-	-2, 240,
-	-1, 192,
+	-2, 291,
+	-1, 214,
 	181, 0,
 	182, 0,
 	183, 0,
 	184, 0,
-	-2, 241,
-	-1, 199,
+	-2, 292,
+	-1, 221,
 	81, 0,
-	-2, 244,
-	-1, 200,
+	-2, 295,
+	-1, 222,
 	63, 0,
 	159, 0,
-	-2, 246,
-	-1, 201,
+	-2, 297,
+	-1, 223,
 	63, 0,
 	159, 0,
-	-2, 248,
-	-1, 304,
+	-2, 299,
+	-1, 338,
 	81, 0,
-	-2, 245,
-	-1, 305,
+	-2, 296,
+	-1, 339,
 	63, 0,
 	159, 0,
-	-2, 247,
-	-1, 306,
+	-2, 298,
+	-1, 340,
 	63, 0,
 	159, 0,
-	-2, 249,
+	-2, 300,
 }
 
-const yyNprod = 342
 const yyPrivate = 57344
 
-var yyTokenNames []string
-var yyStates []string
-
-const yyLast = 3130
-
-var yyAct = []int{
-
-	173, 3, 661, 649, 478, 659, 650, 590, 334, 318,
-	333, 506, 101, 102, 556, 601, 611, 337, 432, 420,
-	230, 586, 247, 146, 548, 231, 497, 373, 450, 226,
-	106, 279, 434, 431, 418, 165, 142, 16, 168, 483,
-	250, 326, 459, 273, 272, 158, 370, 75, 169, 417,
-	144, 232, 145, 139, 328, 242, 60, 125, 213, 296,
-	129, 280, 356, 354, 499, 374, 10, 143, 252, 377,
-	519, 150, 151, 95, 299, 300, 301, 518, 295, 355,
-	177, 178, 179, 180, 181, 182, 183, 184, 185, 186,
-	187, 188, 189, 190, 191, 192, 467, 130, 199, 200,
-	201, 451, 495, 593, 282, 467, 117, 193, 481, 594,
-	79, 296, 298, 251, 79, 466, 451, 281, 284, 148,
-	149, 583, 143, 98, 466, 82, 83, 84, 244, 78,
-	295, 562, 100, 78, 174, 175, 261, 563, 258, 257,
-	262, 97, 229, 176, 538, 161, 162, 376, 496, 81,
-	494, 118, 484, 485, 412, 259, 256, 255, 395, 269,
-	259, 65, 194, 607, 479, 216, 218, 220, 577, 288,
-	401, 402, 553, 539, 535, 119, 253, 291, 505, 403,
-	439, 467, 117, 117, 117, 233, 391, 174, 175, 117,
-	160, 261, 162, 482, 290, 296, 176, 304, 305, 306,
-	466, 283, 285, 287, 345, 286, 274, 100, 302, 297,
-	299, 300, 301, 343, 295, 320, 321, 245, 449, 99,
-	331, 119, 329, 327, 81, 121, 163, 118, 118, 118,
-	298, 248, 79, 388, 118, 521, 522, 462, 344, 147,
-	340, 119, 347, 234, 348, 85, 80, 82, 83, 84,
-	584, 78, 263, 592, 271, 332, 336, 633, 359, 152,
-	360, 660, 194, 363, 364, 365, 119, 271, 655, 351,
-	243, 317, 375, 81, 322, 587, 323, 419, 324, 330,
-	559, 195, 378, 578, 537, 341, 508, 393, 536, 342,
-	228, 260, 639, 595, 399, 357, 346, 404, 335, 387,
-	386, 674, 673, 303, 669, 140, 640, 79, 629, 394,
-	358, 221, 77, 296, 362, 336, 76, 481, 368, 369,
-	85, 80, 82, 83, 84, 141, 78, 297, 299, 300,
-	301, 197, 295, 392, 561, 110, 338, 126, 234, 426,
-	428, 429, 427, 339, 620, 219, 585, 134, 264, 196,
-	442, 425, 551, 319, 385, 435, 79, 421, 109, 116,
-	437, 194, 602, 76, 194, 194, 194, 194, 194, 194,
-	80, 82, 83, 84, 558, 78, 215, 446, 457, 448,
-	424, 241, 464, 217, 411, 617, 77, 552, 438, 112,
-	133, 501, 422, 444, 445, 389, 390, 76, 452, 443,
-	353, 132, 400, 638, 473, 405, 406, 407, 408, 409,
-	410, 161, 470, 327, 471, 275, 465, 352, 453, 455,
-	456, 468, 469, 463, 487, 458, 274, 198, 274, 488,
-	108, 254, 667, 77, 491, 76, 490, 500, 492, 493,
-	265, 266, 503, 383, 131, 313, 664, 236, 461, 461,
-	315, 310, 514, 665, 477, 143, 160, 671, 509, 670,
-	510, 212, 379, 240, 214, 489, 298, 77, 523, 447,
-	630, 154, 512, 223, 224, 225, 529, 502, 194, 215,
-	235, 380, 214, 534, 504, 520, 635, 372, 436, 524,
-	525, 516, 517, 460, 460, 540, 545, 542, 543, 588,
-	277, 541, 615, 486, 120, 77, 557, 294, 374, 616,
-	278, 74, 114, 113, 530, 435, 554, 532, 677, 476,
-	533, 550, 676, 549, 651, 249, 308, 246, 544, 546,
-	307, 311, 314, 382, 573, 136, 135, 576, 566, 600,
-	76, 115, 609, 515, 565, 672, 580, 95, 513, 296,
-	367, 366, 361, 239, 570, 571, 634, 454, 222, 423,
-	384, 381, 302, 297, 299, 300, 301, 2, 295, 312,
-	1, 555, 581, 575, 596, 605, 582, 579, 50, 589,
-	606, 103, 104, 632, 608, 560, 597, 156, 309, 298,
-	591, 507, 619, 618, 105, 557, 614, 98, 511, 603,
-	604, 623, 613, 612, 612, 350, 100, 549, 610, 621,
-	86, 646, 654, 498, 433, 430, 95, 547, 622, 628,
-	624, 480, 531, 81, 625, 626, 42, 41, 40, 39,
-	22, 21, 567, 568, 557, 644, 645, 631, 20, 19,
-	18, 636, 637, 17, 642, 9, 8, 648, 643, 647,
-	653, 652, 662, 641, 656, 658, 657, 663, 7, 86,
-	6, 5, 233, 4, 666, 95, 98, 413, 414, 668,
-	316, 325, 296, 107, 111, 100, 675, 662, 662, 679,
-	680, 678, 164, 599, 97, 302, 297, 299, 300, 301,
-	598, 295, 81, 99, 564, 371, 96, 270, 153, 227,
-	276, 159, 155, 87, 157, 72, 79, 73, 33, 128,
-	32, 55, 28, 58, 57, 98, 31, 124, 123, 85,
-	80, 82, 83, 84, 100, 78, 122, 30, 137, 138,
-	27, 51, 24, 97, 23, 67, 70, 0, 0, 0,
-	0, 81, 0, 0, 0, 96, 0, 0, 56, 0,
-	0, 0, 87, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 99, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 69, 0, 79, 526, 527, 0, 45,
-	71, 88, 89, 90, 91, 92, 93, 94, 85, 80,
-	82, 83, 84, 86, 78, 0, 209, 0, 0, 95,
-	0, 211, 206, 0, 0, 0, 0, 0, 0, 0,
-	0, 99, 0, 0, 0, 234, 29, 44, 0, 0,
-	0, 43, 47, 0, 79, 0, 0, 0, 0, 0,
-	88, 89, 90, 91, 92, 93, 94, 85, 80, 82,
-	83, 84, 86, 78, 0, 0, 415, 0, 95, 98,
-	0, 0, 0, 0, 26, 0, 0, 68, 100, 0,
-	49, 0, 0, 0, 0, 0, 46, 97, 0, 0,
-	0, 0, 416, 0, 0, 81, 0, 204, 0, 96,
-	203, 202, 207, 210, 0, 0, 87, 0, 0, 48,
-	25, 0, 52, 53, 54, 59, 0, 65, 98, 66,
-	0, 0, 0, 0, 0, 0, 0, 100, 0, 0,
-	0, 0, 0, 0, 0, 0, 97, 0, 0, 0,
-	208, 0, 0, 0, 81, 0, 0, 0, 96, 0,
-	0, 0, 0, 0, 0, 87, 0, 0, 0, 205,
-	0, 0, 0, 0, 0, 99, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 79, 474,
-	0, 0, 475, 0, 88, 89, 90, 91, 92, 93,
-	94, 85, 80, 82, 83, 84, 86, 78, 0, 0,
-	0, 0, 95, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 99, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 79, 0, 0,
-	0, 0, 0, 88, 89, 90, 91, 92, 93, 94,
-	85, 80, 82, 83, 84, 86, 78, 0, 0, 0,
-	0, 95, 98, 0, 0, 0, 0, 0, 0, 0,
-	0, 100, 63, 0, 0, 0, 0, 0, 0, 0,
-	97, 0, 0, 0, 0, 64, 0, 0, 81, 0,
-	0, 0, 96, 0, 0, 0, 61, 0, 0, 87,
-	0, 0, 36, 0, 0, 0, 0, 0, 62, 0,
-	0, 98, 0, 0, 0, 0, 15, 0, 13, 0,
-	100, 0, 0, 76, 0, 0, 0, 0, 0, 97,
-	0, 0, 0, 0, 0, 0, 34, 81, 0, 0,
-	0, 96, 0, 0, 0, 0, 0, 0, 87, 0,
-	0, 0, 0, 0, 0, 38, 0, 0, 99, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 79, 396, 397, 0, 14, 0, 88, 89, 90,
-	91, 92, 93, 94, 85, 80, 82, 83, 84, 86,
-	78, 0, 233, 77, 0, 95, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 99, 0, 0,
-	0, 0, 0, 37, 35, 0, 0, 0, 0, 0,
-	79, 292, 0, 0, 293, 0, 88, 89, 90, 91,
-	92, 93, 94, 85, 80, 82, 83, 84, 0, 78,
-	0, 0, 0, 0, 0, 98, 0, 0, 0, 0,
-	0, 0, 0, 0, 100, 0, 0, 0, 0, 0,
-	0, 0, 167, 97, 0, 0, 67, 70, 0, 0,
-	0, 81, 0, 0, 0, 96, 0, 0, 0, 56,
-	0, 0, 87, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 166, 0, 0,
-	0, 171, 0, 0, 69, 0, 0, 0, 12, 0,
-	45, 71, 0, 0, 0, 0, 0, 0, 86, 0,
-	0, 0, 0, 0, 95, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 99, 0, 0, 0, 234, 0, 29, 44, 0,
-	0, 11, 43, 47, 79, 0, 0, 0, 0, 0,
-	88, 89, 90, 91, 92, 93, 94, 85, 80, 82,
-	83, 84, 170, 289, 98, 86, 0, 0, 0, 0,
-	0, 95, 0, 100, 0, 26, 0, 0, 68, 0,
-	0, 49, 97, 0, 0, 0, 0, 46, 0, 0,
-	81, 0, 0, 0, 96, 0, 0, 0, 0, 0,
-	0, 87, 0, 0, 0, 0, 0, 0, 0, 0,
-	48, 25, 0, 52, 53, 54, 59, 0, 65, 0,
-	66, 98, 0, 0, 0, 0, 0, 0, 0, 0,
-	100, 0, 0, 0, 0, 172, 0, 0, 86, 97,
-	0, 0, 0, 0, 95, 0, 0, 81, 0, 0,
-	0, 96, 0, 0, 0, 0, 0, 271, 87, 0,
-	99, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 79, 0, 0, 0, 0, 0, 88,
-	89, 90, 91, 92, 93, 94, 85, 80, 82, 83,
-	84, 0, 78, 0, 98, 0, 0, 0, 0, 0,
-	86, 0, 0, 100, 0, 0, 95, 0, 0, 0,
-	0, 0, 97, 0, 0, 0, 0, 99, 0, 0,
-	81, 0, 0, 0, 96, 0, 0, 627, 0, 0,
-	79, 87, 0, 0, 0, 0, 88, 89, 90, 91,
-	92, 93, 94, 85, 80, 82, 83, 84, 499, 78,
-	0, 0, 0, 0, 0, 0, 98, 0, 0, 0,
-	0, 0, 0, 0, 0, 100, 0, 86, 0, 0,
-	0, 0, 0, 95, 97, 0, 0, 0, 0, 0,
-	0, 0, 81, 0, 0, 0, 96, 0, 0, 0,
-	99, 0, 0, 87, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 79, 0, 0, 574, 0, 0, 88,
-	89, 90, 91, 92, 93, 94, 85, 80, 82, 83,
-	84, 0, 78, 98, 0, 0, 0, 86, 0, 0,
-	0, 0, 100, 95, 0, 0, 0, 0, 0, 0,
-	0, 97, 0, 0, 0, 0, 0, 0, 0, 81,
-	0, 0, 99, 96, 0, 0, 0, 0, 0, 0,
-	87, 0, 0, 0, 0, 79, 0, 0, 0, 0,
-	0, 88, 89, 90, 91, 92, 93, 94, 85, 80,
-	82, 83, 84, 98, 78, 0, 0, 0, 0, 0,
-	0, 0, 100, 0, 86, 0, 0, 0, 0, 0,
-	95, 97, 0, 0, 0, 0, 0, 0, 0, 81,
-	0, 0, 0, 96, 0, 0, 0, 0, 0, 99,
-	87, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 79, 572, 0, 0, 0, 0, 88, 89,
-	90, 91, 92, 93, 94, 85, 80, 82, 83, 84,
-	98, 78, 0, 0, 86, 0, 0, 0, 0, 100,
-	95, 0, 0, 0, 0, 0, 0, 0, 97, 0,
-	0, 0, 0, 0, 0, 0, 81, 0, 0, 99,
-	96, 0, 0, 0, 0, 0, 0, 87, 0, 0,
-	0, 0, 79, 569, 0, 0, 0, 0, 88, 89,
-	90, 91, 92, 93, 94, 85, 80, 82, 83, 84,
-	98, 78, 0, 0, 0, 0, 0, 0, 0, 100,
-	0, 86, 0, 0, 0, 0, 0, 95, 97, 0,
-	0, 0, 0, 0, 0, 0, 81, 0, 0, 0,
-	96, 0, 0, 0, 0, 0, 99, 87, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 79,
-	472, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 94, 85, 80, 82, 83, 84, 98, 78, 0,
-	0, 86, 441, 0, 0, 0, 100, 95, 0, 0,
-	0, 0, 0, 0, 0, 97, 0, 0, 0, 0,
-	0, 0, 0, 81, 0, 0, 99, 96, 0, 0,
-	0, 0, 0, 0, 87, 0, 0, 0, 0, 79,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 94, 85, 80, 82, 83, 84, 98, 78, 0,
-	0, 0, 0, 0, 0, 0, 100, 0, 0, 0,
-	0, 0, 0, 0, 0, 97, 86, 0, 0, 0,
-	0, 0, 95, 81, 0, 0, 0, 96, 0, 0,
-	0, 0, 0, 99, 87, 0, 0, 0, 0, 0,
-	0, 0, 0, 440, 0, 0, 79, 0, 0, 0,
-	0, 0, 88, 89, 90, 91, 92, 93, 94, 85,
-	80, 82, 83, 84, 268, 78, 0, 0, 0, 349,
-	0, 0, 98, 0, 0, 0, 86, 0, 0, 0,
-	0, 100, 95, 0, 0, 0, 0, 0, 0, 0,
-	97, 0, 0, 99, 0, 0, 0, 0, 81, 0,
-	0, 0, 96, 0, 0, 0, 79, 0, 0, 87,
-	0, 0, 88, 89, 90, 91, 92, 93, 94, 85,
-	80, 82, 83, 84, 267, 78, 0, 0, 0, 0,
-	0, 0, 98, 0, 0, 0, 0, 0, 0, 0,
-	0, 100, 0, 86, 0, 0, 0, 0, 0, 95,
-	97, 0, 0, 0, 0, 0, 0, 0, 81, 0,
-	0, 0, 96, 0, 0, 0, 0, 0, 99, 87,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 79, 0, 0, 0, 0, 0, 88, 89, 90,
-	91, 92, 93, 94, 85, 80, 82, 83, 84, 98,
-	78, 0, 0, 86, 0, 0, 0, 0, 100, 95,
-	0, 0, 0, 0, 0, 0, 0, 97, 0, 0,
-	0, 0, 0, 0, 0, 81, 0, 0, 99, 96,
-	0, 0, 0, 0, 0, 0, 87, 0, 0, 0,
-	0, 79, 0, 0, 0, 0, 0, 88, 89, 90,
-	91, 92, 93, 94, 85, 80, 82, 83, 84, 98,
-	78, 0, 0, 0, 0, 0, 0, 0, 100, 0,
-	0, 0, 0, 0, 0, 0, 0, 97, 0, 0,
-	0, 0, 0, 0, 0, 81, 0, 0, 0, 96,
-	0, 127, 0, 0, 0, 99, 87, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 79, 0,
-	0, 0, 0, 0, 88, 89, 90, 91, 92, 93,
-	94, 85, 80, 82, 83, 84, 0, 78, 67, 70,
-	0, 0, 0, 0, 0, 0, 0, 86, 0, 0,
-	0, 56, 0, 95, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 99, 0, 0, 0, 0,
-	0, 0, 0, 171, 0, 0, 69, 0, 79, 0,
-	12, 0, 45, 71, 88, 89, 90, 91, 92, 93,
-	94, 85, 80, 82, 83, 84, 0, 78, 0, 0,
-	0, 0, 0, 98, 0, 95, 0, 0, 0, 0,
-	0, 0, 100, 0, 0, 0, 0, 0, 0, 29,
-	44, 97, 0, 11, 43, 47, 0, 0, 0, 81,
-	0, 0, 0, 96, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 170, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 98, 0, 26, 0, 0,
-	68, 0, 0, 49, 100, 0, 0, 0, 0, 46,
-	0, 0, 0, 97, 0, 0, 0, 0, 0, 0,
-	0, 81, 0, 0, 0, 96, 0, 0, 0, 0,
-	0, 0, 48, 25, 0, 52, 53, 54, 59, 99,
-	65, 0, 66, 67, 70, 0, 0, 0, 0, 0,
-	0, 0, 79, 0, 0, 0, 56, 172, 88, 89,
-	90, 91, 92, 93, 94, 85, 80, 82, 83, 84,
-	0, 78, 0, 0, 237, 0, 0, 0, 0, 0,
-	0, 69, 0, 0, 0, 12, 0, 45, 71, 0,
-	0, 99, 0, 0, 67, 70, 0, 0, 0, 0,
-	0, 0, 0, 0, 79, 0, 0, 56, 0, 0,
-	88, 89, 90, 91, 92, 93, 94, 85, 80, 82,
-	83, 84, 0, 78, 29, 44, 0, 0, 11, 43,
-	47, 0, 69, 0, 0, 0, 12, 0, 45, 71,
+const yyLast = 3753
+
+var yyAct = [...]int16{
+	165, 3, 811, 796, 694, 809, 766, 367, 797, 352,
+	535, 368, 111, 112, 571, 638, 16, 685, 713, 460,
+	359, 714, 164, 473, 703, 608, 728, 258, 313, 168,
+	625, 503, 556, 120, 371, 251, 413, 521, 648, 632,
+	475, 472, 360, 458, 187, 540, 190, 410, 468, 513,
+	257, 512, 306, 499, 307, 500, 180, 191, 457, 166,
+	167, 161, 85, 10, 270, 142, 238, 130, 146, 68,
+	362, 132, 215, 314, 394, 392, 332, 417, 589, 259,
+	174, 172, 173, 375, 147, 414, 558, 588, 195, 579,
+	199, 200, 201, 202, 203, 204, 205, 206, 207, 208,
+	209, 210, 211, 212, 213, 214, 330, 650, 221, 222,
+	223, 89, 524, 524, 281, 332, 330, 89, 554, 393,
+	292, 333, 334, 335, 504, 329, 92, 93, 94, 504,
+	88, 523, 523, 196, 197, 329, 88, 750, 170, 171,
+	720, 697, 198, 751, 682, 272, 721, 698, 671, 295,
+	646, 538, 316, 315, 672, 134, 647, 435, 183, 330,
+	110, 627, 563, 294, 291, 451, 256, 293, 293, 216,
+	255, 275, 336, 331, 333, 334, 335, 91, 329, 296,
+	293, 452, 293, 303, 745, 91, 318, 612, 416, 195,
+	195, 322, 241, 243, 245, 248, 249, 250, 330, 325,
+	570, 182, 317, 524, 262, 263, 289, 536, 280, 555,
+	284, 553, 331, 333, 334, 335, 547, 329, 542, 338,
+	339, 340, 523, 541, 282, 290, 282, 441, 442, 640,
+	295, 778, 757, 319, 321, 320, 443, 693, 689, 631,
+	354, 355, 134, 134, 134, 613, 324, 332, 361, 607,
+	569, 134, 134, 196, 197, 431, 308, 659, 309, 568,
+	89, 382, 198, 119, 380, 565, 381, 273, 89, 564,
+	384, 539, 385, 95, 90, 92, 93, 94, 498, 88,
+	478, 216, 90, 92, 93, 94, 366, 88, 390, 379,
+	337, 365, 399, 118, 400, 184, 388, 403, 404, 405,
+	75, 363, 138, 184, 351, 614, 415, 593, 594, 276,
+	516, 131, 356, 133, 357, 773, 358, 610, 418, 195,
+	364, 433, 373, 116, 135, 133, 610, 260, 439, 482,
+	330, 444, 135, 383, 299, 300, 609, 185, 427, 135,
+	377, 395, 135, 336, 331, 333, 334, 335, 610, 329,
+	736, 428, 398, 115, 135, 169, 402, 282, 704, 375,
+	641, 408, 409, 135, 426, 551, 261, 493, 287, 134,
+	374, 376, 278, 434, 696, 466, 432, 370, 683, 467,
+	469, 305, 286, 771, 810, 465, 217, 488, 805, 297,
+	469, 271, 216, 686, 476, 216, 216, 216, 216, 216,
+	216, 440, 483, 643, 445, 446, 447, 448, 449, 450,
+	370, 459, 63, 673, 495, 611, 497, 470, 510, 573,
+	378, 305, 519, 464, 649, 479, 522, 480, 253, 369,
+	429, 430, 347, 782, 700, 481, 219, 349, 344, 578,
+	827, 505, 826, 822, 530, 490, 370, 494, 143, 783,
+	183, 502, 162, 361, 218, 762, 86, 87, 525, 526,
+	157, 282, 520, 282, 544, 163, 511, 508, 501, 501,
+	518, 509, 517, 506, 684, 545, 548, 550, 559, 552,
+	261, 353, 527, 124, 528, 298, 246, 538, 566, 645,
+	372, 515, 515, 182, 308, 534, 308, 642, 741, 562,
+	634, 560, 269, 592, 423, 582, 123, 2, 496, 486,
+	574, 546, 575, 342, 629, 216, 549, 341, 345, 348,
+	577, 113, 244, 419, 533, 595, 87, 484, 425, 461,
+	584, 691, 220, 601, 514, 514, 734, 126, 86, 195,
+	606, 288, 420, 587, 781, 567, 372, 590, 242, 630,
+	585, 561, 619, 820, 489, 622, 346, 397, 617, 616,
+	618, 282, 620, 277, 462, 396, 283, 391, 389, 639,
+	591, 285, 239, 615, 86, 343, 596, 597, 122, 476,
+	602, 628, 636, 604, 153, 605, 654, 240, 626, 240,
+	817, 268, 824, 152, 422, 621, 623, 818, 150, 328,
+	86, 823, 763, 237, 264, 635, 666, 149, 87, 669,
+	670, 732, 239, 311, 84, 675, 676, 176, 733, 651,
+	655, 653, 234, 312, 668, 655, 412, 677, 137, 678,
+	674, 679, 661, 663, 662, 664, 151, 709, 656, 477,
+	687, 699, 156, 543, 87, 136, 128, 414, 127, 830,
+	148, 829, 798, 279, 274, 680, 681, 155, 708, 688,
+	154, 701, 580, 236, 235, 86, 129, 254, 715, 702,
+	87, 726, 583, 581, 407, 406, 705, 401, 267, 716,
+	825, 332, 772, 737, 692, 722, 710, 711, 725, 507,
+	247, 800, 463, 424, 496, 731, 421, 739, 719, 639,
+	178, 1, 58, 657, 658, 491, 492, 729, 729, 746,
+	747, 730, 626, 727, 742, 738, 637, 158, 117, 770,
+	114, 644, 744, 695, 572, 740, 576, 387, 793, 752,
+	804, 557, 474, 471, 755, 624, 537, 768, 361, 603,
+	50, 760, 761, 49, 756, 25, 24, 758, 47, 759,
+	46, 23, 45, 44, 764, 775, 43, 777, 769, 42,
+	779, 748, 749, 96, 330, 22, 776, 21, 768, 105,
+	774, 780, 639, 789, 785, 20, 19, 336, 331, 333,
+	334, 335, 784, 329, 18, 787, 17, 788, 9, 794,
+	8, 7, 801, 795, 799, 6, 96, 5, 4, 812,
+	453, 768, 105, 806, 808, 454, 807, 814, 802, 803,
+	813, 71, 48, 815, 816, 350, 819, 121, 125, 108,
+	186, 821, 707, 706, 72, 652, 411, 304, 110, 828,
+	812, 812, 832, 833, 831, 69, 96, 107, 175, 260,
+	712, 39, 105, 586, 252, 91, 310, 70, 181, 106,
+	177, 179, 108, 82, 83, 15, 97, 13, 36, 145,
+	35, 110, 86, 765, 73, 735, 690, 633, 31, 66,
+	107, 65, 34, 141, 140, 37, 139, 33, 91, 159,
+	160, 30, 106, 59, 27, 26, 0, 0, 0, 97,
+	0, 0, 108, 0, 41, 0, 0, 0, 0, 0,
+	0, 110, 0, 0, 0, 105, 0, 0, 0, 0,
+	107, 0, 0, 0, 14, 109, 0, 0, 91, 0,
+	0, 0, 106, 0, 0, 753, 74, 0, 89, 97,
+	0, 754, 87, 0, 98, 99, 100, 101, 102, 103,
+	104, 95, 90, 92, 93, 94, 0, 88, 109, 0,
+	0, 0, 40, 38, 0, 108, 0, 0, 0, 0,
+	0, 89, 598, 599, 110, 0, 0, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 96,
+	88, 91, 0, 0, 0, 105, 0, 0, 109, 0,
+	0, 0, 261, 0, 0, 0, 0, 0, 0, 0,
+	0, 89, 0, 0, 0, 0, 0, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 96,
+	88, 0, 0, 455, 0, 105, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 108, 0, 0, 0, 0,
+	0, 0, 231, 0, 110, 0, 0, 233, 228, 456,
+	96, 109, 0, 107, 0, 0, 105, 0, 0, 0,
+	0, 91, 0, 0, 89, 106, 0, 0, 0, 0,
+	0, 0, 97, 0, 0, 108, 0, 95, 90, 92,
+	93, 94, 0, 88, 110, 0, 0, 0, 0, 0,
+	0, 0, 0, 107, 0, 0, 0, 0, 0, 0,
+	0, 91, 0, 0, 0, 106, 108, 0, 0, 0,
+	0, 0, 97, 0, 0, 110, 0, 0, 0, 0,
+	0, 0, 0, 226, 107, 0, 225, 224, 229, 232,
+	0, 109, 91, 0, 0, 0, 106, 0, 0, 0,
+	0, 0, 0, 97, 89, 531, 0, 0, 532, 0,
+	98, 99, 100, 101, 102, 103, 104, 95, 90, 92,
+	93, 94, 0, 88, 0, 0, 230, 0, 0, 0,
+	0, 109, 0, 0, 0, 0, 0, 96, 0, 0,
+	0, 0, 0, 105, 89, 227, 0, 0, 0, 0,
+	98, 99, 100, 101, 102, 103, 104, 95, 90, 92,
+	93, 94, 109, 88, 0, 0, 0, 0, 96, 0,
+	0, 260, 0, 0, 105, 89, 436, 437, 0, 0,
+	0, 98, 99, 100, 101, 102, 103, 104, 95, 90,
+	92, 93, 94, 108, 88, 0, 0, 0, 0, 0,
+	0, 0, 110, 0, 0, 0, 0, 0, 0, 0,
+	0, 107, 0, 0, 0, 0, 0, 0, 0, 91,
+	0, 0, 0, 106, 108, 0, 0, 0, 0, 0,
+	97, 0, 0, 110, 0, 0, 0, 0, 0, 0,
+	0, 0, 107, 0, 0, 0, 0, 0, 0, 0,
+	91, 0, 0, 0, 106, 0, 0, 0, 0, 0,
+	0, 97, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 189, 109,
+	0, 0, 77, 80, 0, 0, 0, 0, 0, 0,
+	0, 0, 89, 326, 0, 64, 327, 0, 98, 99,
+	100, 101, 102, 103, 104, 95, 90, 92, 93, 94,
+	109, 88, 0, 188, 261, 0, 96, 193, 0, 0,
+	79, 0, 105, 89, 12, 0, 53, 81, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 0, 323, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 96, 0, 0, 0, 0, 0, 105, 0,
+	0, 0, 0, 32, 52, 0, 0, 11, 51, 55,
+	0, 0, 108, 0, 0, 0, 0, 0, 0, 0,
+	0, 110, 0, 0, 0, 0, 0, 0, 192, 0,
+	107, 0, 0, 96, 0, 0, 0, 0, 91, 105,
+	0, 29, 106, 0, 78, 0, 0, 57, 108, 97,
+	0, 0, 0, 54, 0, 0, 0, 110, 0, 0,
+	0, 0, 0, 0, 0, 0, 107, 0, 0, 0,
+	0, 0, 0, 0, 91, 0, 56, 28, 106, 60,
+	61, 62, 67, 0, 75, 97, 76, 0, 0, 108,
+	0, 0, 0, 0, 0, 0, 0, 0, 110, 0,
+	0, 194, 0, 0, 0, 305, 0, 107, 109, 0,
+	0, 0, 0, 0, 0, 91, 0, 0, 0, 106,
+	0, 89, 0, 0, 0, 0, 97, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 0,
+	88, 0, 0, 0, 109, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 792, 0, 0, 89, 0, 0,
+	0, 0, 0, 98, 99, 100, 101, 102, 103, 104,
+	95, 90, 92, 93, 94, 96, 88, 0, 0, 0,
+	0, 105, 0, 0, 0, 109, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 791, 0, 0, 89, 0,
+	0, 0, 0, 0, 98, 99, 100, 101, 102, 103,
+	104, 95, 90, 92, 93, 94, 96, 88, 0, 0,
+	0, 0, 105, 0, 0, 0, 0, 0, 0, 0,
+	0, 108, 0, 0, 0, 0, 0, 0, 0, 0,
+	110, 0, 0, 0, 0, 0, 0, 0, 0, 107,
+	96, 0, 0, 0, 0, 0, 105, 91, 0, 0,
+	0, 106, 0, 0, 0, 0, 0, 0, 97, 0,
+	0, 0, 108, 0, 0, 0, 0, 0, 0, 0,
+	0, 110, 0, 0, 0, 0, 0, 0, 0, 0,
+	107, 0, 0, 0, 0, 0, 0, 0, 91, 0,
+	0, 0, 106, 0, 0, 0, 108, 0, 0, 97,
+	0, 0, 0, 0, 0, 110, 0, 0, 0, 0,
+	0, 0, 0, 0, 107, 0, 0, 109, 0, 0,
+	0, 0, 91, 0, 0, 0, 106, 790, 0, 0,
+	89, 0, 0, 97, 0, 0, 98, 99, 100, 101,
+	102, 103, 104, 95, 90, 92, 93, 94, 0, 88,
+	0, 0, 0, 0, 0, 0, 0, 0, 109, 0,
+	0, 0, 786, 0, 0, 0, 0, 96, 0, 0,
+	0, 89, 0, 105, 0, 0, 0, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 0,
+	88, 0, 109, 0, 0, 0, 0, 0, 0, 0,
+	96, 0, 0, 0, 743, 89, 105, 0, 0, 0,
+	0, 98, 99, 100, 101, 102, 103, 104, 95, 90,
+	92, 93, 94, 108, 88, 0, 0, 0, 0, 0,
+	0, 96, 110, 0, 0, 0, 0, 105, 0, 0,
+	0, 107, 0, 0, 0, 0, 0, 0, 0, 91,
+	0, 0, 0, 106, 0, 0, 108, 0, 0, 0,
+	97, 0, 0, 0, 0, 110, 0, 0, 0, 0,
+	0, 0, 0, 0, 107, 0, 0, 0, 0, 0,
+	0, 0, 91, 0, 0, 0, 106, 108, 0, 0,
+	0, 0, 0, 97, 0, 0, 110, 0, 0, 0,
+	0, 0, 0, 0, 0, 107, 0, 0, 0, 0,
+	0, 0, 0, 91, 0, 0, 0, 106, 0, 109,
+	0, 0, 0, 0, 97, 0, 0, 0, 0, 724,
+	0, 0, 89, 0, 0, 0, 0, 0, 98, 99,
+	100, 101, 102, 103, 104, 95, 90, 92, 93, 94,
+	0, 88, 109, 0, 0, 0, 0, 0, 96, 0,
+	0, 0, 0, 0, 105, 89, 0, 0, 723, 0,
+	0, 98, 99, 100, 101, 102, 103, 104, 95, 90,
+	92, 93, 94, 109, 88, 0, 0, 0, 0, 96,
+	0, 0, 0, 0, 0, 105, 89, 0, 0, 718,
+	0, 0, 98, 99, 100, 101, 102, 103, 104, 95,
+	90, 92, 93, 94, 108, 88, 0, 0, 0, 0,
+	96, 0, 0, 110, 0, 0, 105, 0, 0, 0,
+	0, 0, 107, 0, 0, 0, 0, 558, 0, 0,
+	91, 0, 0, 0, 106, 108, 0, 0, 0, 0,
+	0, 97, 0, 0, 110, 0, 0, 0, 0, 0,
+	0, 0, 0, 107, 0, 0, 0, 0, 0, 0,
+	0, 91, 0, 0, 0, 106, 108, 0, 0, 0,
+	0, 0, 97, 0, 0, 110, 0, 0, 0, 0,
+	0, 0, 0, 0, 107, 0, 0, 0, 0, 0,
+	0, 0, 91, 0, 0, 0, 106, 0, 0, 0,
+	109, 0, 0, 97, 0, 0, 0, 0, 0, 0,
+	717, 0, 0, 89, 0, 0, 0, 0, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 109, 88, 0, 0, 0, 0, 96, 0, 0,
+	0, 0, 0, 105, 89, 0, 0, 0, 0, 0,
+	98, 99, 100, 101, 102, 103, 104, 95, 90, 92,
+	93, 94, 109, 88, 0, 0, 0, 0, 96, 0,
+	0, 0, 0, 0, 105, 89, 0, 0, 667, 0,
+	0, 98, 99, 100, 101, 102, 103, 104, 95, 90,
+	92, 93, 94, 108, 88, 0, 0, 0, 0, 96,
+	0, 0, 110, 0, 0, 105, 0, 0, 0, 0,
+	0, 107, 0, 0, 0, 0, 0, 0, 0, 91,
+	0, 0, 0, 106, 108, 0, 0, 0, 0, 0,
+	97, 0, 0, 110, 0, 0, 0, 0, 0, 0,
+	0, 0, 107, 0, 0, 0, 0, 0, 0, 0,
+	91, 0, 0, 0, 106, 108, 0, 0, 0, 0,
+	0, 97, 0, 0, 110, 0, 0, 0, 0, 0,
+	0, 0, 0, 107, 0, 0, 0, 0, 0, 0,
+	0, 91, 0, 0, 0, 106, 0, 0, 0, 109,
+	0, 0, 97, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 89, 665, 0, 0, 0, 0, 98, 99,
+	100, 101, 102, 103, 104, 95, 90, 92, 93, 94,
+	109, 88, 0, 0, 0, 0, 96, 0, 0, 0,
+	0, 0, 105, 89, 660, 0, 0, 0, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 109, 88, 0, 0, 0, 0, 96, 0, 0,
+	0, 0, 0, 105, 89, 529, 0, 0, 0, 0,
+	98, 99, 100, 101, 102, 103, 104, 95, 90, 92,
+	93, 94, 108, 88, 0, 0, 0, 0, 96, 0,
+	0, 110, 0, 0, 105, 0, 0, 0, 0, 0,
+	107, 0, 0, 0, 0, 0, 0, 0, 91, 0,
+	0, 0, 106, 108, 0, 0, 0, 0, 0, 97,
+	0, 0, 110, 0, 0, 0, 0, 0, 0, 0,
+	0, 107, 0, 0, 0, 0, 0, 0, 0, 91,
+	0, 0, 0, 106, 108, 0, 0, 0, 0, 0,
+	97, 0, 0, 110, 487, 0, 0, 0, 0, 0,
+	0, 0, 107, 0, 0, 0, 0, 0, 0, 0,
+	91, 0, 0, 0, 106, 0, 0, 0, 109, 0,
+	0, 97, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 89, 0, 0, 0, 0, 0, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 109,
+	88, 0, 0, 0, 0, 0, 386, 0, 0, 485,
+	0, 0, 89, 0, 0, 0, 0, 105, 98, 99,
+	100, 101, 102, 103, 104, 95, 90, 92, 93, 94,
+	109, 88, 96, 0, 0, 0, 0, 0, 105, 0,
+	0, 0, 0, 89, 0, 0, 0, 0, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 0, 88, 96, 0, 0, 0, 108, 0, 105,
+	0, 0, 0, 0, 0, 0, 110, 0, 0, 0,
+	302, 0, 0, 0, 0, 107, 0, 0, 108, 0,
+	0, 0, 0, 91, 0, 0, 0, 110, 0, 0,
+	0, 96, 0, 0, 0, 0, 107, 105, 0, 0,
+	0, 301, 0, 0, 91, 0, 0, 0, 106, 108,
+	0, 0, 0, 0, 0, 97, 0, 0, 110, 0,
+	0, 0, 0, 0, 0, 0, 0, 107, 0, 0,
+	0, 0, 0, 0, 0, 91, 0, 0, 0, 106,
+	0, 0, 0, 0, 0, 0, 97, 108, 0, 0,
+	0, 0, 0, 109, 0, 0, 110, 0, 0, 0,
+	0, 0, 0, 0, 0, 107, 89, 0, 0, 0,
+	0, 0, 0, 91, 109, 0, 0, 106, 0, 95,
+	90, 92, 93, 94, 97, 88, 0, 89, 0, 0,
+	0, 0, 0, 98, 99, 100, 101, 102, 103, 104,
+	95, 90, 92, 93, 94, 109, 88, 0, 77, 80,
+	0, 0, 0, 0, 0, 0, 0, 0, 89, 0,
+	0, 64, 0, 0, 98, 99, 100, 101, 102, 103,
+	104, 95, 90, 92, 93, 94, 96, 88, 0, 144,
+	0, 0, 105, 109, 0, 0, 79, 0, 0, 0,
+	12, 0, 53, 81, 0, 0, 89, 0, 0, 0,
+	0, 0, 98, 99, 100, 101, 102, 103, 104, 95,
+	90, 92, 93, 94, 0, 88, 0, 0, 96, 0,
+	0, 0, 0, 0, 105, 0, 0, 0, 0, 32,
+	52, 0, 108, 11, 51, 55, 0, 0, 0, 0,
+	0, 110, 0, 0, 0, 0, 0, 0, 0, 0,
+	107, 0, 0, 77, 80, 0, 0, 0, 91, 0,
+	0, 0, 106, 0, 0, 0, 64, 29, 0, 97,
+	78, 0, 0, 57, 108, 0, 0, 0, 0, 54,
+	0, 0, 0, 110, 0, 0, 0, 0, 193, 0,
+	0, 79, 107, 0, 0, 12, 0, 53, 81, 0,
+	91, 0, 56, 28, 106, 60, 61, 62, 67, 0,
+	75, 0, 76, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 194, 109, 0,
+	0, 0, 0, 0, 32, 52, 0, 0, 11, 51,
+	55, 89, 0, 0, 0, 0, 0, 98, 99, 100,
+	101, 102, 103, 104, 95, 90, 92, 93, 94, 192,
+	88, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	109, 0, 29, 0, 0, 78, 0, 0, 57, 0,
+	0, 0, 0, 89, 54, 0, 0, 0, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 0, 88, 77, 80, 0, 0, 56, 28, 0,
+	60, 61, 62, 67, 0, 75, 64, 76, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 26, 0, 0, 68, 0, 0, 49, 0,
-	0, 0, 0, 0, 46, 29, 44, 0, 0, 11,
-	43, 47, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 48, 25, 0,
-	52, 53, 54, 59, 0, 65, 0, 66, 0, 0,
-	0, 0, 0, 26, 0, 0, 68, 0, 0, 49,
-	0, 0, 238, 0, 0, 46, 0, 0, 0, 0,
-	0, 63, 0, 0, 67, 70, 0, 0, 0, 0,
-	0, 95, 0, 0, 64, 0, 0, 56, 48, 25,
-	0, 52, 53, 54, 59, 61, 65, 0, 66, 0,
-	0, 36, 0, 0, 0, 0, 0, 62, 0, 0,
-	0, 0, 69, 172, 0, 15, 12, 13, 45, 71,
-	0, 0, 76, 0, 0, 0, 0, 0, 0, 0,
-	0, 98, 0, 0, 0, 34, 0, 0, 0, 0,
-	100, 0, 0, 0, 0, 0, 0, 0, 0, 97,
-	0, 0, 0, 0, 38, 29, 44, 81, 0, 11,
-	43, 47, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 14, 0, 0, 0, 0, 0,
-	0, 67, 70, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 77, 26, 56, 0, 68, 67, 70, 49,
-	0, 0, 0, 0, 0, 46, 0, 0, 0, 0,
-	56, 0, 37, 35, 0, 0, 0, 0, 0, 69,
-	0, 0, 0, 12, 0, 45, 71, 99, 48, 25,
-	0, 52, 53, 54, 59, 69, 65, 0, 66, 12,
-	79, 45, 71, 0, 0, 0, 0, 0, 0, 91,
-	92, 93, 94, 85, 80, 82, 83, 84, 0, 78,
-	0, 0, 29, 44, 0, 0, 11, 43, 47, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 29, 44,
-	0, 0, 11, 43, 47, 67, 70, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 56, 0,
-	26, 0, 0, 68, 0, 0, 49, 0, 0, 0,
-	0, 0, 46, 0, 0, 0, 26, 0, 0, 68,
-	0, 0, 49, 69, 0, 0, 0, 12, 46, 45,
-	71, 0, 0, 76, 0, 48, 25, 0, 52, 53,
-	54, 59, 0, 65, 0, 66, 528, 67, 70, 0,
-	0, 48, 25, 0, 52, 53, 54, 59, 0, 65,
-	56, 66, 398, 0, 0, 0, 29, 44, 0, 0,
-	11, 43, 47, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 69, 0, 0, 0, 12,
-	0, 45, 71, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 77, 26, 0, 0, 68, 0, 0,
-	49, 0, 0, 0, 0, 0, 46, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 29, 44,
-	0, 0, 11, 43, 47, 67, 70, 0, 0, 48,
-	25, 0, 52, 53, 54, 59, 0, 65, 56, 66,
+	0, 0, 194, 0, 265, 0, 0, 0, 0, 0,
+	0, 79, 0, 0, 0, 12, 0, 53, 81, 0,
+	0, 0, 0, 0, 0, 0, 71, 48, 0, 77,
+	80, 0, 0, 0, 0, 0, 0, 0, 0, 72,
+	0, 0, 64, 0, 105, 0, 0, 0, 0, 0,
+	69, 0, 0, 0, 32, 52, 39, 0, 11, 51,
+	55, 0, 70, 0, 0, 0, 0, 79, 0, 0,
+	15, 12, 13, 53, 81, 0, 0, 86, 0, 73,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 26, 0, 0, 68,
-	0, 0, 49, 69, 0, 0, 0, 12, 46, 45,
-	71, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 127, 0, 0, 0, 0, 0,
-	0, 48, 25, 0, 52, 53, 54, 59, 0, 65,
-	0, 66, 0, 0, 0, 0, 29, 44, 0, 0,
-	11, 43, 47, 0, 0, 0, 0, 0, 0, 0,
+	37, 0, 29, 0, 108, 78, 105, 0, 57, 0,
+	0, 0, 0, 110, 54, 0, 0, 0, 0, 41,
+	32, 52, 107, 0, 11, 51, 55, 0, 0, 0,
+	91, 0, 0, 0, 106, 0, 0, 56, 28, 14,
+	60, 61, 62, 67, 0, 75, 0, 76, 0, 0,
+	0, 74, 0, 0, 0, 0, 108, 87, 29, 0,
+	0, 78, 266, 0, 57, 110, 0, 0, 0, 0,
+	54, 0, 77, 80, 107, 0, 0, 40, 38, 0,
+	0, 0, 91, 0, 0, 64, 0, 0, 0, 0,
+	0, 0, 0, 56, 28, 0, 60, 61, 62, 67,
+	109, 75, 0, 76, 0, 0, 0, 0, 0, 0,
+	79, 0, 0, 89, 12, 0, 53, 81, 0, 98,
+	99, 100, 101, 102, 103, 104, 95, 90, 92, 93,
+	94, 0, 88, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 109, 32, 52, 0, 0, 11, 51, 55,
+	77, 80, 0, 0, 0, 89, 0, 0, 0, 0,
+	0, 0, 0, 64, 101, 102, 103, 104, 95, 90,
+	92, 93, 94, 0, 88, 0, 0, 0, 0, 0,
+	0, 29, 0, 0, 78, 0, 0, 57, 79, 0,
+	0, 0, 12, 54, 53, 81, 0, 0, 0, 0,
+	0, 77, 80, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 64, 0, 56, 28, 0, 60,
+	61, 62, 67, 0, 75, 0, 76, 600, 0, 0,
+	0, 32, 52, 0, 0, 11, 51, 55, 0, 79,
+	0, 0, 0, 12, 0, 53, 81, 0, 0, 86,
+	0, 0, 77, 80, 0, 0, 0, 767, 0, 0,
+	0, 0, 0, 0, 0, 64, 0, 0, 0, 29,
+	0, 0, 78, 0, 0, 57, 0, 0, 0, 0,
+	0, 54, 32, 52, 0, 0, 11, 51, 55, 0,
+	79, 0, 0, 0, 12, 0, 53, 81, 0, 0,
+	0, 0, 0, 0, 56, 28, 0, 60, 61, 62,
+	67, 0, 75, 0, 76, 438, 0, 0, 0, 87,
+	29, 0, 0, 78, 0, 0, 57, 0, 0, 0,
+	0, 0, 54, 32, 52, 0, 0, 11, 51, 55,
+	77, 80, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 64, 0, 56, 28, 0, 60, 61,
+	62, 67, 0, 75, 0, 76, 0, 0, 0, 0,
+	0, 29, 0, 0, 78, 0, 0, 57, 79, 0,
+	0, 0, 12, 54, 53, 81, 0, 0, 0, 0,
+	0, 77, 80, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 77, 80, 64, 0, 56, 28, 0, 60,
+	61, 62, 67, 0, 75, 64, 76, 0, 0, 0,
+	0, 32, 52, 0, 0, 11, 51, 55, 0, 79,
+	0, 0, 0, 12, 0, 53, 81, 0, 0, 0,
+	79, 372, 0, 0, 12, 0, 53, 81, 77, 80,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 29,
+	0, 64, 78, 0, 0, 57, 0, 0, 0, 0,
+	0, 54, 32, 52, 0, 0, 11, 51, 55, 0,
+	0, 0, 0, 32, 52, 0, 79, 11, 51, 55,
+	0, 0, 53, 81, 56, 28, 0, 60, 61, 62,
+	67, 0, 75, 0, 76, 0, 0, 0, 0, 0,
+	29, 0, 0, 78, 0, 0, 57, 0, 0, 0,
+	0, 29, 54, 0, 78, 0, 0, 57, 0, 32,
+	52, 0, 0, 54, 51, 55, 0, 0, 144, 0,
+	0, 0, 0, 0, 0, 56, 28, 0, 60, 61,
+	62, 67, 0, 75, 0, 76, 56, 28, 0, 60,
+	61, 62, 67, 0, 75, 0, 76, 29, 0, 0,
+	78, 0, 0, 57, 0, 0, 0, 0, 0, 54,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 26, 0, 0, 68, 0, 0,
-	49, 0, 0, 0, 0, 0, 46, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 48,
-	25, 0, 52, 53, 54, 59, 0, 65, 0, 66,
+	0, 0, 56, 28, 0, 60, 61, 62, 67, 0,
+	75, 0, 76,
 }
-var yyPact = []int{
 
-	2596, -1000, -1000, 2116, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, 2957, 2957, 1037, 1037, -9, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 2957,
-	-1000, -1000, -1000, 288, 442, 441, 485, 78, 433, -1000,
+var yyPact = [...]int16{
+	3061, -1000, -1000, 2779, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 3534, 3534, 806, 190, 130, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, 57, 2869, -1000, -1000, 2807,
-	-1000, 335, 281, 470, 469, 172, 2957, 76, 76, 76,
-	2957, 2957, -1000, -1000, 392, 484, 58, 1228, 24, 2957,
-	2957, 2957, 2957, 2957, 2957, 2957, 2957, 2957, 2957, 2957,
-	2957, 2957, 2957, 2957, 2957, 727, 268, 2957, 2957, 2957,
-	787, 2302, 135, -1000, -1000, -1000, -58, 382, 379, 341,
-	307, -1000, 539, 78, 78, 78, 142, -34, 175, -1000,
-	78, 2405, 508, -1000, -1000, 2056, 226, 2957, 48, 2116,
-	-1000, 461, 68, 459, 78, 78, 333, -14, -19, -1000,
-	-37, -35, -20, 2116, 16, -1000, 189, -1000, 16, 16,
-	1989, 1929, 98, -1000, 80, 392, -1000, 432, -1000, -1000,
-	-130, -59, -72, 260, -1000, -57, 2240, 2456, 2957, -1000,
-	-1000, -1000, -1000, 1152, -1000, -1000, 2957, 1018, -62, -62,
-	-58, -58, -58, 184, 2302, 2250, 2598, 2598, 2598, 60,
-	60, 60, 60, 500, -1000, 727, 2957, 2957, 2957, 534,
-	135, 135, -1000, 436, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 279, 400, 2957, 2957, -1000, 260, -1000, 260,
-	-1000, 260, 2957, 54, 52, 142, 170, -1000, 227, 77,
-	-1000, -1000, -1000, 80, -1000, 139, 44, 2957, 35, -1000,
-	226, 2957, -1000, 2957, 1854, -1000, 68, 319, -1000, 302,
-	-128, -1000, -97, -129, 78, -1000, 172, 2957, -1000, 2957,
-	507, 76, 2957, 2957, 2957, 506, 505, 76, 76, 428,
-	-1000, 2957, -28, -1000, -109, 98, 389, -1000, 252, 175,
-	70, 77, 77, 17, 2456, -57, 2957, -57, 652, -29,
-	-1000, 969, -1000, 2719, 727, 7, 2957, 727, 727, 727,
-	727, 727, 727, 377, 534, 135, 135, -1000, -1000, -1000,
+	-1000, -1000, 3534, -1000, -1000, -1000, 436, 577, 575, 610,
+	179, 574, -1000, -1000, -1000, -1000, -1000, -1000, 552, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	2116, 2116, -1000, -1000, -1000, -21, -1000, 835, 124, 283,
-	124, 283, 98, 111, 98, 70, 70, 413, -1000, 175,
-	-1000, -1000, 12, -1000, 1794, -1000, -1000, 1727, 2116, 2957,
-	301, -1000, 78, 78, 68, 77, 68, 50, -1000, 2116,
-	2116, -1000, -1000, 2116, 2116, 2116, -1000, -1000, -39, -39,
-	186, -1000, 538, -1000, 80, 2116, 80, 2957, 428, 103,
-	103, 2957, -1000, -1000, -1000, -1000, 142, -67, -1000, -130,
-	-130, 175, -1000, 652, -1000, -1000, -1000, -1000, -1000, 1667,
-	23, -1000, -1000, 2957, 786, -113, -113, -61, -61, -61,
-	141, 727, 2957, -1000, -1000, -1000, -1000, -11, -1000, 25,
-	-23, -22, 429, 2957, -11, -23, 400, 98, 400, 400,
-	-25, -1000, -76, -27, -1000, 9, 2957, -1000, 293, 260,
-	-1000, 2957, 2116, 78, 10, 136, 136, -1000, 136, 68,
-	503, 2957, 498, -1000, 2957, -28, -1000, 2116, -1000, -1000,
-	-130, -99, -106, -1000, 652, -1000, 72, 2957, 175, 175,
-	-1000, -1000, -1000, 603, -1000, 2703, 23, -1000, -1000, 124,
-	-1000, 2240, 2957, 5, 137, 133, -31, 2116, -1000, 4,
-	198, 400, 198, 198, 70, 2957, 70, -1000, -1000, 76,
-	2116, 278, 3, 2116, 136, 2957, -1000, -1000, 220, -1000,
-	217, -38, -1000, -1000, 2116, -1000, -15, 175, 77, 77,
-	-1000, -1000, -1000, 1600, 142, 142, -1000, -1000, -1000, 1540,
-	-1000, -1000, -57, 2957, 1411, 260, 2957, -1, 132, 260,
-	-1000, 198, -1000, -1000, -1000, 1473, -1000, -54, -1000, 187,
-	120, -1000, 425, 175, 95, -66, -1000, 2116, -1000, -1000,
-	-1000, 157, 136, 68, 478, -1000, 264, -130, -130, -1000,
-	-1000, -1000, -1000, 2116, 2957, 198, 2116, -1000, -6, 198,
-	-1000, -1000, 497, 76, 70, 70, 400, 416, -1000, 287,
-	-1000, -1000, 2957, 240, 2957, 68, -1000, -1000, -1000, -1000,
-	2957, -1000, 227, 175, 175, 1338, -1000, -1000, -1000, -1000,
-	-1000, -1000, -67, -1000, 198, 173, 384, 278, 2116, 101,
-	537, -1000, -1000, 2116, 411, 264, 264, -1000, -1000, 257,
-	171, 120, 136, 2957, 2957, 2957, -1000, -1000, 170, 98,
-	455, 400, 95, -1000, 2116, 2116, 113, 111, 98, 106,
-	-1000, 2957, 198, -1000, -1000, 360, -1000, 98, -1000, -1000,
-	339, -1000, 1281, -1000, 169, 373, -1000, 371, -1000, 510,
-	167, 166, 98, 453, 449, 106, 2957, 2957, -1000, -1000,
-	-1000,
+	-1000, -1000, -1000, 134, 3523, -1000, -1000, 3333, -1000, 541,
+	527, 594, 591, 338, 338, 319, 3534, 192, 192, 192,
+	3534, 3534, -1000, -1000, 538, 609, 169, 1324, 90, 3534,
+	3534, 3534, 3534, 3534, 3534, 3534, 3534, 3534, 3534, 3534,
+	3534, 3534, 3534, 3534, 3534, 3580, 373, 3534, 3534, 3534,
+	1033, 3071, 88, -1000, 806, 608, 607, -1000, -1000, -1000,
+	-55, 490, 544, 518, 482, -1000, 671, 191, 191, 191,
+	280, 612, -6, -10, 317, -1000, 191, 191, 3005, 633,
+	-1000, -1000, 2634, 347, 3534, 98, 2779, -1000, 588, 146,
+	209, 587, 200, 209, 200, 473, 205, -1000, 205, 35,
+	50, -1000, -12, -53, 7, 2779, 55, -1000, 326, -1000,
+	55, 55, 2596, 2565, 225, -1000, 203, 538, -1000, 545,
+	-1000, -1000, -118, -23, -24, 400, -1000, 11, 2855, 2750,
+	3534, -1000, -1000, -1000, -1000, 1201, -1000, -1000, 3534, 1170,
+	-61, -61, -55, -55, -55, 96, 3071, 2821, 3123, 3123,
+	3123, 2544, 2544, 2544, 2544, 592, -1000, 3580, 3534, 3534,
+	3534, 892, 88, 88, -1000, 423, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 492, 530, 3534,
+	3534, -1000, 400, -1000, 400, -1000, 400, 3534, 133, 123,
+	280, 301, -1000, 381, 191, 196, 196, -1000, -1000, -1000,
+	203, -1000, 270, 121, 95, 3534, 92, -1000, 347, 3534,
+	-1000, 3534, 2411, -1000, 146, 470, -1000, 120, -1000, 469,
+	-116, -1000, -57, -1000, -117, 200, 467, -1000, 459, -1000,
+	319, 3534, -1000, 3534, 632, 192, 3534, 3534, 3534, 630,
+	629, 192, 192, 567, -1000, 3534, 13, -1000, -101, 225,
+	450, -1000, 426, 317, 188, 196, 196, 86, 2750, 11,
+	3534, 11, 829, -30, -1000, 1043, -1000, 3282, 3580, 64,
+	3534, 3580, 3580, 3580, 3580, 3580, 3580, 158, 892, 88,
+	88, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, 2779, 2779, -1000, -1000, -1000, 6,
+	-1000, 1012, 258, 455, 258, 455, 225, 232, 222, 188,
+	188, 564, -1000, 112, 317, -1000, 317, -1000, 161, 3534,
+	424, 2380, 406, -1000, 2349, 2779, 3534, 456, -1000, 200,
+	204, 200, 146, 196, 146, 110, 200, 200, -1000, 2779,
+	2779, -1000, -1000, 2779, 2779, 2779, -1000, -1000, -26, -26,
+	331, -1000, 670, -1000, 203, 2779, 203, 3534, 567, 176,
+	176, 3534, -1000, -1000, -1000, -1000, 278, -59, -1000, -118,
+	-118, 317, -1000, 829, -1000, -1000, -1000, -1000, -1000, 2222,
+	-13, -1000, -1000, 3534, 972, -66, -66, -56, -56, -56,
+	26, 3580, 3534, -1000, -1000, -1000, -1000, 32, -1000, 103,
+	48, 43, 569, 3534, 32, 41, 530, 222, 225, 202,
+	225, 36, -1000, -60, 34, -1000, 31, 3534, 3534, -1000,
+	-1000, 453, 400, -7, 101, -1000, 97, 3534, 2779, 200,
+	91, 81, 25, -1000, 269, 269, -1000, 269, 146, 303,
+	-1000, -87, 606, 628, 3534, 627, -1000, 3534, 13, -1000,
+	2779, -1000, -1000, 452, -118, -89, -98, -1000, 449, 829,
+	-1000, -1000, 437, 144, 3534, 317, 317, -1000, -1000, -1000,
+	789, -1000, 3194, -13, -1000, -1000, 258, -1000, 2855, 3534,
+	80, 185, 264, 12, 2779, -1000, 76, 154, 368, 225,
+	530, 3534, 530, 188, 3534, 188, -1000, -1000, 192, 2779,
+	-8, 440, 70, -1000, 396, 396, 2779, 269, 3534, 59,
+	197, -1000, -1000, 343, -1000, 372, -19, -1000, 275, -80,
+	275, -1000, 2779, -1000, 5, 3472, -1000, 317, 196, 196,
+	381, -1000, 89, -1000, -1000, 2191, 278, 278, -1000, -1000,
+	-1000, 2160, -1000, -1000, 11, 3534, 2033, 400, 3534, 3534,
+	-1000, -21, 262, 400, 3534, 3534, -1000, 530, 368, 2779,
+	368, -1000, 2002, -1000, -31, -1000, 315, -1000, 238, -1000,
+	566, 317, 69, 430, 665, 68, 216, -28, -1000, 2779,
+	3534, -1000, -1000, -1000, -1000, 298, 269, 146, 195, -1000,
+	-1000, 195, 597, -1000, 2779, 562, -1000, -118, -118, 146,
+	-1000, -1000, -1000, -1000, -1000, -1000, 2779, 3534, 368, 1971,
+	1844, -1000, 163, -29, 368, 1813, 1780, 368, -1000, -1000,
+	-1000, 626, 192, 188, 188, 530, 525, -1000, 438, -1000,
+	187, 664, 3534, -1000, -1000, -1000, 3534, 394, 3534, 1653,
+	146, -1000, -1000, 9, -1000, 9, -1000, -1000, 3534, 3534,
+	317, 317, -32, -1000, 269, 756, -1000, 400, 163, 63,
+	-1000, 163, -1000, 163, 400, -1000, -1000, -1000, -1000, -59,
+	-1000, 368, 320, 516, 440, -1000, 3384, 3534, 7, 2779,
+	227, 663, -1000, -1000, -1000, 152, 2779, 2779, -1000, -1000,
+	-1000, 146, -1000, -1000, 3534, 368, 3534, -1000, 62, 3534,
+	368, -1000, 398, 314, 238, -1000, -1000, 3534, 1619, 6,
+	269, 3534, 3534, -1000, -1000, 1578, -1000, 1436, -1000, 1395,
+	-1000, 301, 225, 583, 530, 684, -1000, 216, -1000, 2779,
+	-1000, 400, 400, 233, 265, 225, 229, -1000, 3534, 368,
+	3534, -1000, 368, 368, -1000, 504, -1000, 225, -1000, -1000,
+	460, -1000, 1359, -1000, -1000, -1000, -1000, 308, 515, -1000,
+	506, -1000, 645, 307, 305, 225, 582, 580, 229, 3534,
+	3534, -1000, -1000, -1000,
 }
-var yyPgo = []int{
-
-	0, 734, 732, 578, 731, 730, 53, 729, 728, 0,
-	66, 107, 36, 325, 43, 44, 51, 25, 20, 23,
-	727, 726, 718, 717, 55, 337, 716, 714, 713, 52,
-	50, 291, 28, 712, 711, 710, 709, 37, 708, 56,
-	707, 705, 704, 511, 702, 45, 42, 701, 700, 18,
-	31, 68, 113, 699, 29, 15, 259, 698, 6, 697,
-	46, 695, 694, 27, 690, 683, 48, 35, 682, 47,
-	674, 673, 41, 671, 353, 9, 58, 670, 668, 667,
-	567, 663, 661, 660, 658, 646, 645, 643, 640, 639,
-	638, 631, 630, 629, 628, 627, 626, 359, 34, 49,
-	19, 39, 622, 621, 4, 24, 617, 16, 10, 33,
-	615, 8, 32, 614, 613, 26, 21, 612, 611, 3,
-	2, 5, 22, 605, 598, 40, 592, 591, 11, 590,
-	7, 585, 14, 583, 571, 570, 38, 561, 17, 560,
-	54, 559,
+
+var yyPgo = [...]int16{
+	0, 885, 884, 702, 883, 881, 61, 880, 879, 0,
+	63, 72, 22, 465, 54, 52, 79, 27, 50, 29,
+	877, 876, 874, 873, 64, 448, 872, 871, 869, 60,
+	59, 163, 31, 868, 412, 39, 867, 866, 865, 863,
+	6, 860, 859, 16, 858, 69, 854, 853, 851, 614,
+	850, 56, 51, 49, 848, 846, 23, 28, 71, 114,
+	844, 35, 843, 37, 840, 18, 80, 838, 8, 827,
+	47, 826, 825, 36, 823, 822, 57, 44, 820, 62,
+	818, 817, 42, 20, 481, 9, 66, 815, 805, 800,
+	507, 798, 797, 795, 791, 790, 788, 786, 784, 776,
+	775, 767, 765, 759, 756, 753, 752, 751, 750, 748,
+	746, 745, 743, 740, 67, 43, 58, 19, 45, 48,
+	739, 736, 10, 30, 735, 26, 7, 41, 733, 11,
+	40, 732, 731, 32, 17, 730, 728, 3, 2, 5,
+	21, 727, 726, 55, 725, 724, 14, 723, 4, 721,
+	720, 15, 719, 716, 706, 705, 24, 382, 53, 701,
+	46, 696, 34, 693, 70, 25, 692, 642, 38,
 }
-var yyR1 = []int{
-
-	0, 135, 135, 80, 80, 80, 80, 80, 80, 81,
-	82, 83, 84, 85, 85, 85, 85, 85, 86, 92,
-	92, 92, 92, 37, 37, 37, 38, 38, 38, 38,
-	38, 38, 38, 39, 39, 41, 40, 69, 68, 68,
-	68, 68, 68, 136, 136, 67, 67, 66, 66, 66,
-	18, 18, 17, 17, 16, 44, 44, 43, 42, 42,
-	42, 42, 42, 137, 137, 45, 45, 45, 47, 46,
-	46, 46, 51, 52, 50, 50, 54, 54, 53, 138,
-	138, 48, 48, 48, 139, 139, 55, 56, 56, 57,
-	15, 15, 14, 58, 58, 59, 60, 60, 61, 61,
-	12, 12, 62, 62, 63, 64, 64, 65, 71, 71,
-	70, 73, 73, 72, 79, 79, 78, 78, 75, 75,
-	74, 77, 77, 76, 87, 87, 97, 97, 140, 140,
-	140, 141, 141, 99, 99, 98, 104, 104, 103, 102,
-	102, 100, 101, 101, 88, 88, 89, 90, 90, 90,
-	108, 110, 110, 109, 115, 115, 114, 106, 106, 105,
-	105, 19, 107, 32, 32, 111, 113, 113, 112, 91,
-	91, 116, 116, 116, 116, 117, 117, 117, 121, 121,
-	118, 118, 118, 119, 120, 93, 93, 123, 123, 122,
-	125, 125, 126, 126, 128, 128, 127, 127, 130, 130,
-	129, 134, 134, 132, 133, 133, 94, 94, 95, 131,
-	131, 96, 124, 124, 49, 49, 49, 49, 9, 9,
+
+var yyR1 = [...]uint8{
+	0, 159, 159, 90, 90, 90, 90, 90, 90, 91,
+	92, 150, 150, 150, 93, 93, 93, 94, 95, 95,
+	95, 95, 95, 96, 96, 96, 96, 102, 102, 102,
+	102, 43, 43, 43, 44, 44, 44, 44, 44, 44,
+	44, 45, 45, 47, 46, 79, 78, 78, 78, 78,
+	78, 160, 160, 77, 77, 76, 76, 76, 18, 18,
+	17, 17, 16, 50, 50, 49, 48, 48, 48, 48,
+	48, 48, 161, 161, 51, 51, 51, 51, 51, 51,
+	54, 52, 53, 53, 53, 58, 59, 57, 57, 61,
+	61, 60, 63, 64, 64, 65, 162, 162, 55, 55,
+	55, 163, 163, 62, 66, 66, 67, 15, 15, 14,
+	68, 68, 69, 70, 70, 71, 71, 12, 12, 72,
+	72, 73, 74, 74, 75, 81, 81, 80, 83, 83,
+	82, 89, 89, 88, 88, 85, 85, 84, 87, 87,
+	86, 97, 97, 97, 97, 114, 114, 114, 164, 164,
+	164, 164, 164, 165, 166, 166, 116, 116, 115, 115,
+	122, 122, 121, 120, 120, 117, 118, 118, 98, 98,
+	98, 98, 99, 100, 100, 100, 119, 119, 126, 128,
+	128, 127, 133, 133, 132, 124, 124, 123, 123, 19,
+	125, 32, 32, 129, 131, 131, 130, 101, 101, 134,
+	134, 134, 134, 135, 135, 135, 139, 139, 136, 136,
+	136, 137, 138, 103, 103, 141, 141, 140, 143, 143,
+	144, 144, 146, 146, 145, 145, 148, 148, 147, 153,
+	153, 151, 152, 152, 104, 104, 105, 149, 149, 106,
+	142, 142, 107, 107, 108, 155, 155, 154, 154, 109,
+	110, 110, 111, 111, 112, 113, 167, 167, 157, 158,
+	158, 168, 168, 156, 156, 56, 56, 56, 56, 9,
+	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
 	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
 	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
 	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
 	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
-	9, 9, 9, 9, 9, 9, 9, 9, 9, 10,
-	10, 10, 10, 10, 10, 10, 10, 10, 10, 11,
+	10, 10, 10, 10, 10, 10, 10, 10, 10, 10,
 	11, 11, 11, 11, 11, 11, 11, 11, 11, 11,
-	11, 11, 11, 1, 1, 1, 1, 1, 1, 1,
-	2, 2, 3, 8, 8, 7, 7, 6, 4, 13,
-	13, 5, 5, 5, 20, 21, 21, 22, 25, 25,
-	23, 24, 24, 33, 33, 33, 34, 26, 26, 27,
-	27, 27, 30, 30, 29, 29, 31, 28, 28, 35,
-	36, 36,
+	11, 11, 11, 11, 1, 1, 1, 1, 1, 1,
+	1, 2, 2, 3, 8, 8, 7, 7, 6, 4,
+	13, 13, 5, 5, 5, 20, 21, 21, 22, 25,
+	25, 23, 24, 24, 33, 33, 33, 33, 33, 34,
+	35, 36, 36, 37, 37, 38, 38, 39, 39, 40,
+	26, 26, 27, 27, 27, 30, 30, 29, 29, 31,
+	28, 28, 41, 42, 42,
 }
-var yyR2 = []int{
 
+var yyR2 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 1, 1, 2,
-	2, 2, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 2, 4, 4, 1, 3, 4, 3,
-	4, 3, 4, 1, 1, 5, 5, 2, 1, 2,
-	2, 3, 4, 1, 1, 1, 3, 1, 3, 2,
-	0, 1, 1, 2, 1, 0, 1, 2, 1, 1,
-	4, 4, 5, 1, 1, 4, 6, 6, 4, 4,
-	6, 6, 1, 1, 0, 2, 0, 1, 4, 0,
-	1, 0, 1, 2, 0, 1, 4, 0, 1, 2,
-	1, 3, 3, 0, 1, 2, 0, 1, 5, 1,
-	1, 3, 0, 1, 2, 0, 1, 2, 0, 1,
-	3, 1, 3, 2, 0, 1, 1, 1, 0, 1,
-	2, 0, 1, 2, 6, 9, 4, 2, 0, 5,
-	6, 1, 2, 1, 3, 6, 0, 1, 2, 1,
-	2, 2, 0, 3, 6, 9, 7, 8, 7, 7,
-	2, 1, 3, 4, 0, 1, 4, 1, 3, 3,
-	3, 1, 1, 0, 2, 2, 1, 3, 2, 10,
-	13, 0, 6, 6, 6, 0, 6, 6, 0, 6,
-	2, 3, 2, 1, 2, 8, 12, 0, 1, 1,
-	1, 3, 0, 3, 0, 1, 2, 2, 0, 1,
-	2, 1, 3, 1, 0, 2, 6, 6, 7, 0,
-	3, 8, 1, 3, 1, 3, 3, 4, 1, 3,
-	3, 5, 5, 4, 5, 6, 3, 3, 3, 3,
-	3, 3, 3, 3, 2, 3, 3, 3, 3, 3,
-	3, 3, 5, 6, 3, 4, 3, 4, 3, 4,
-	3, 4, 3, 4, 3, 4, 3, 4, 3, 4,
-	3, 4, 3, 4, 3, 4, 3, 4, 2, 1,
-	1, 1, 1, 1, 1, 2, 1, 1, 1, 1,
+	3, 0, 2, 2, 2, 2, 2, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 2, 4, 4, 1, 3, 4, 3, 4, 3,
+	4, 1, 1, 5, 5, 2, 1, 2, 2, 3,
+	4, 1, 1, 1, 3, 1, 3, 2, 0, 1,
+	1, 2, 1, 0, 1, 2, 1, 1, 4, 6,
+	4, 5, 1, 1, 4, 6, 6, 4, 6, 6,
+	4, 2, 3, 5, 5, 1, 1, 0, 2, 0,
+	1, 4, 5, 1, 3, 2, 0, 1, 0, 1,
+	2, 0, 1, 4, 0, 1, 2, 1, 3, 3,
+	0, 1, 2, 0, 1, 5, 1, 1, 3, 0,
+	1, 2, 0, 1, 2, 0, 1, 3, 1, 3,
+	2, 0, 1, 1, 1, 0, 1, 2, 0, 1,
+	2, 6, 9, 11, 14, 4, 4, 2, 0, 5,
+	6, 7, 8, 1, 1, 2, 1, 3, 6, 8,
+	0, 1, 2, 1, 2, 2, 0, 3, 6, 9,
+	11, 14, 7, 9, 8, 8, 0, 3, 2, 1,
+	3, 4, 0, 1, 4, 1, 3, 3, 3, 1,
+	1, 0, 2, 2, 1, 3, 2, 10, 13, 0,
+	6, 6, 6, 0, 6, 6, 0, 6, 2, 3,
+	2, 1, 2, 8, 12, 0, 1, 1, 1, 3,
+	0, 3, 0, 1, 2, 2, 0, 1, 2, 1,
+	3, 1, 0, 2, 6, 6, 7, 0, 3, 8,
+	1, 3, 1, 1, 9, 0, 1, 1, 3, 3,
+	7, 6, 1, 1, 8, 8, 0, 1, 1, 1,
+	3, 0, 1, 1, 3, 1, 3, 3, 4, 1,
 	3, 3, 5, 5, 4, 5, 6, 3, 3, 3,
-	3, 3, 3, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 3, 0, 1, 1, 3, 3, 3, 0,
-	1, 1, 1, 1, 3, 1, 1, 3, 4, 5,
-	2, 0, 2, 4, 5, 4, 1, 1, 1, 4,
-	4, 4, 1, 3, 3, 3, 2, 6, 6, 3,
-	1, 1,
+	3, 3, 3, 3, 3, 2, 3, 3, 3, 3,
+	3, 3, 3, 5, 6, 3, 4, 3, 4, 3,
+	4, 3, 4, 3, 4, 3, 4, 3, 4, 3,
+	4, 3, 4, 3, 4, 3, 4, 3, 4, 2,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1,
+	1, 3, 3, 5, 5, 4, 5, 6, 3, 3,
+	3, 3, 3, 3, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 3, 0, 1, 1, 3, 3, 3,
+	0, 1, 1, 1, 1, 3, 1, 1, 3, 4,
+	5, 2, 0, 2, 4, 5, 4, 8, 8, 1,
+	3, 0, 3, 0, 3, 0, 2, 1, 4, 2,
+	1, 1, 4, 4, 4, 1, 3, 3, 3, 2,
+	6, 6, 3, 1, 1,
 }
-var yyChk = []int{
-
-	-1000, -135, -80, -9, -81, -82, -83, -84, -85, -86,
-	-10, 93, 50, 51, 108, 49, -37, -87, -88, -89,
-	-90, -91, -92, -1, -2, 163, 127, -5, -33, 89,
-	-20, -26, -35, -38, 69, 147, 35, 146, 88, -93,
-	-94, -95, -96, 94, 90, 52, 139, 95, 162, 133,
-	-3, -4, 165, 166, 167, -34, 21, -27, -28, 168,
-	-39, 29, 41, 5, 18, 170, 172, 8, 130, 46,
-	9, 53, -41, -40, -43, -69, 56, 126, 191, 172,
+
+var yyChk = [...]int16{
+	-1000, -159, -90, -9, -91, -92, -93, -94, -95, -96,
+	-10, 93, 50, 51, 108, 49, -43, -97, -98, -99,
+	-100, -101, -102, -107, -110, -111, -1, -2, 163, 127,
+	-5, -33, 89, -20, -26, -41, -44, 69, 147, 35,
+	146, 88, -103, -104, -105, -106, -108, -109, 6, -112,
+	-113, 94, 90, 52, 139, 95, 162, 133, -3, -4,
+	165, 166, 167, -34, 21, -27, -28, 168, -45, 29,
+	41, 5, 18, 58, 120, 170, 172, 8, 130, 46,
+	9, 53, -47, -46, -49, -79, 56, 126, 191, 172,
 	186, 89, 187, 188, 189, 185, 7, 100, 178, 179,
 	180, 181, 182, 183, 184, 13, 93, 81, 63, 159,
-	72, -9, -9, -80, -80, -3, -9, -71, 142, 70,
-	47, -70, 101, 71, 71, 56, -97, -51, -52, 163,
-	71, 168, -21, -22, -23, -9, -25, 155, -36, -9,
-	-37, 109, 66, 109, 66, 66, 66, -8, -7, -6,
-	133, -13, -12, -9, -30, -29, -19, 163, -30, -30,
-	-9, -9, -56, -57, 79, -44, -43, -42, -45, -47,
-	-52, -51, 134, 168, -68, -67, 39, 4, -136, -66,
-	114, 43, 187, -9, 163, 164, 172, -9, -9, -9,
+	72, -9, -9, -90, -150, 163, 133, -3, 163, 133,
+	-9, -81, 142, 70, 47, -80, 101, 71, 71, 56,
+	-114, 132, -58, 134, -59, 163, 71, 76, 168, -21,
+	-22, -23, -9, -25, 155, -42, -9, -43, 109, 66,
+	57, 109, 66, 57, 66, 66, -167, 122, -167, -8,
+	-7, -6, 133, -13, -12, -9, -30, -29, -19, 163,
+	-30, -30, -9, -9, -66, -67, 79, -50, -49, -48,
+	-51, -54, -59, -58, 134, 168, -78, -77, 39, 4,
+	-160, -76, 114, 43, 187, -9, 163, 164, 172, -9,
 	-9, -9, -9, -9, -9, -9, -9, -9, -9, -9,
-	-9, -9, -9, -11, -10, 13, 81, 63, 159, -9,
-	-9, -9, 94, 93, 90, 152, 15, 95, 133, 9,
-	96, 14, -74, -76, 82, 97, -39, 4, -39, 4,
-	-39, 4, 19, -97, -97, -97, -54, -53, 148, 176,
-	-18, -17, -16, 10, 163, -97, -13, 39, 187, 45,
-	-25, 155, -24, 44, -9, 169, 66, -122, 163, 66,
-	-125, -52, -51, -125, 98, 171, 175, 176, 173, 175,
-	-31, 175, 124, 63, 159, -31, -31, 55, 55, -58,
-	-59, 156, -15, -14, -16, -56, -48, 68, 78, -50,
-	191, 176, 176, -37, 175, -67, -136, -67, -9, 191,
-	-18, -9, 173, 176, 7, 191, 172, 186, 89, 187,
-	188, 189, 185, -11, -9, -9, -9, 94, 90, 152,
-	15, 95, 133, 9, 96, 14, -77, -76, -75, -74,
-	-9, -9, -39, -39, -39, -73, -72, -9, -140, 168,
-	-140, 168, -54, -108, -111, 128, 145, -138, 109, -52,
-	163, -16, 150, 169, -9, 169, -24, -9, -9, 135,
-	-123, -122, 98, 98, 191, 176, 191, -125, -6, -9,
+	-9, -9, -9, -9, -9, -11, -10, 13, 81, 63,
+	159, -9, -9, -9, 94, 93, 90, 152, 15, 95,
+	133, 9, 96, 14, -90, 56, 56, -84, -86, 82,
+	97, -45, 4, -45, 4, -45, 4, 19, -114, -114,
+	-114, -61, -60, 148, 55, 176, 176, -18, -17, -16,
+	10, 163, -114, -114, -13, 39, 187, 45, -25, 155,
+	-24, 44, -9, 169, 66, -140, 163, -34, 163, 66,
+	-143, -59, -58, -34, -143, 98, -157, 163, -157, 171,
+	175, 176, 173, 175, -31, 175, 124, 63, 159, -31,
+	-31, 55, 55, -68, -69, 156, -15, -14, -16, -66,
+	-55, 68, 78, -57, 191, 176, 176, -43, 175, -77,
+	-160, -77, -9, 191, -18, -9, 173, 176, 7, 191,
+	172, 186, 89, 187, 188, 189, 185, -11, -9, -9,
+	-9, 94, 90, 152, 15, 95, 133, 9, 96, 14,
+	-87, -86, -85, -84, -9, -9, -45, -45, -45, -83,
+	-82, -9, -164, 168, -164, 168, -61, -126, -129, 128,
+	145, -162, 109, -114, -59, 163, -59, -16, 150, 168,
+	169, -9, 169, -24, -9, -9, 135, -141, -140, 98,
+	168, 98, 191, 176, 191, -143, 98, 98, -6, -9,
 	-9, 45, -29, -9, -9, -9, 45, 45, -30, -30,
-	-60, -61, 59, -63, 80, -9, 175, 178, -58, 73,
-	92, -137, 144, 54, -139, 102, -18, -49, 163, -52,
-	-52, 169, -66, -9, -18, 187, 173, 174, 173, -9,
+	-70, -71, 59, -73, 80, -9, 175, 178, -68, 73,
+	92, -161, 144, 54, -163, 102, -18, -56, 163, -59,
+	-59, 169, -76, -9, -18, 187, 173, 174, 173, -9,
 	-11, 163, 164, 172, -9, -11, -11, -11, -11, -11,
-	-11, 7, 175, -79, -78, 11, 37, -99, -98, 153,
-	-100, 74, 109, -141, -99, -100, -58, -111, -58, -58,
-	-110, -109, -49, -113, -112, -49, 75, -18, -45, 168,
-	169, 135, -9, 98, -125, -125, -122, -52, -122, 168,
-	-32, 155, -32, -69, 19, -15, -14, -9, -60, -46,
-	-52, -51, 134, -46, -9, -54, 191, 172, -50, -50,
-	-18, -18, 173, -9, 173, 176, -11, -72, -104, 175,
-	-103, 119, 168, -101, 175, 175, 74, -9, -104, -101,
-	-75, -58, -75, -75, 175, 178, 175, -115, -114, 55,
-	-9, 98, -37, -9, -125, 168, -128, -127, 150, -128,
-	-128, -124, -122, 45, -9, 45, -12, -50, 176, 176,
-	-18, 163, 164, -9, -18, -18, 173, 174, 173, -9,
-	-98, -102, -67, -136, -9, 169, 151, 151, 175, 169,
-	-104, -75, -104, -104, -109, -9, -112, -106, -105, -19,
-	-100, 74, 109, 169, -128, -134, -132, -9, 154, 60,
-	-131, 117, 169, 175, -62, -63, -18, -52, -52, 173,
-	-54, -54, 173, -9, 175, -37, -9, 169, 151, -37,
-	-104, -115, -32, 175, 63, 159, -116, 155, 74, -17,
-	-130, -129, 158, 169, 175, 136, -128, -122, -64, -65,
-	61, -55, 98, -50, -50, -9, -104, 169, -104, 45,
-	-105, -107, -49, -107, -75, 86, 93, 98, -9, -126,
-	104, -132, -122, -9, -138, -18, -18, 169, -104, 135,
-	86, -100, -133, 156, 19, 75, -55, -55, 146, 35,
-	135, -116, -128, -132, -9, -9, -118, -108, -111, -119,
-	-58, 69, -75, -130, -117, 155, -58, -111, -58, -121,
-	155, -120, -9, -104, 86, 93, -58, 93, -58, 135,
-	86, 86, 35, 135, 135, -119, 69, 69, -121, -120,
-	-120,
+	-11, 7, 175, -89, -88, 11, 37, -116, -115, 153,
+	-117, 74, 109, -166, -116, -117, -68, -129, -119, 158,
+	-119, -128, -127, -56, -131, -130, -56, 75, 168, -18,
+	-18, -51, 168, -12, 103, 169, 103, 135, -9, 98,
+	-143, -155, -154, 163, -143, -140, -59, -140, 168, -158,
+	-143, -58, -158, -32, 155, -32, -79, 19, -15, -14,
+	-9, -70, -52, -53, -59, -58, 134, -52, -53, -9,
+	-61, -63, 148, 191, 172, -57, -57, -18, -18, 173,
+	-9, 173, 176, -11, -82, -122, 175, -121, 119, 168,
+	-118, 175, 175, 74, -9, -122, -118, 175, -85, -119,
+	-68, 163, -68, 175, 178, 175, -133, -132, 55, -9,
+	-12, 98, -43, 169, 168, 168, -9, -143, 168, 169,
+	175, -146, -145, 150, -146, -146, -142, -140, 136, 176,
+	56, 45, -9, 45, -12, 98, -62, -57, 176, 176,
+	98, -18, 66, 163, 164, -9, -18, -18, 173, 174,
+	173, -9, -115, -120, -77, -160, -9, 169, -165, 151,
+	163, 151, 175, 169, 151, -165, -122, -68, -85, -9,
+	-85, -127, -9, -130, -124, -123, -19, 169, -117, 74,
+	109, 169, -35, -36, 104, -35, -146, -153, -151, -9,
+	170, 163, 154, 60, -149, 117, 169, 175, -168, 149,
+	187, -168, -72, -73, -9, -162, -18, -59, -59, 168,
+	173, -61, -63, -61, -63, 173, -9, 175, -43, -9,
+	-9, 169, 175, 151, -43, -9, -9, -85, -122, -122,
+	-133, -32, 175, 63, 159, -134, 155, 74, -17, 169,
+	-37, 101, 19, 169, -148, -147, 158, 169, 175, -9,
+	136, -146, -140, -156, 163, -156, -74, -75, 61, 75,
+	-57, -57, -64, -65, -140, -9, -122, 169, 175, -165,
+	169, 175, -122, 175, 169, -122, 45, -123, -125, -56,
+	-125, -85, 86, 93, 98, -38, 163, 19, -12, -9,
+	-144, 104, -151, 171, -140, 175, -9, -9, -18, -18,
+	169, 175, -146, 169, 175, -43, -165, 169, -165, -165,
+	-43, -122, 135, 86, -117, -39, -40, 13, -9, -83,
+	-152, 156, 19, 163, -65, -9, -122, -9, 169, -9,
+	-122, 146, 35, 135, -134, -40, 163, -146, -151, -9,
+	169, 169, 169, -136, -126, -129, -137, -68, 69, -85,
+	7, -148, -43, -43, -135, 155, -68, -129, -68, -139,
+	155, -138, -9, -122, -40, -122, -122, 86, 93, -68,
+	93, -68, 135, 86, 86, 35, 135, 135, -137, 69,
+	69, -139, -138, -138,
 }
-var yyDef = []int{
 
+var yyDef = [...]int16{
 	0, -2, 1, 2, 3, 4, 5, 6, 7, 8,
-	218, 0, 0, 0, 0, 0, 12, 13, 14, 15,
-	16, 17, 18, 269, 270, -2, 272, 273, 274, 0,
-	276, 277, 278, 108, 0, 0, 0, 0, 0, 19,
-	20, 21, 22, 293, 294, 295, 296, 297, 298, 299,
-	300, 301, 311, 312, 313, 0, 0, 327, 328, 0,
-	26, 0, 0, 0, 0, 303, 309, 0, 0, 0,
-	0, 0, 33, 34, 87, 55, 0, 0, 0, 0,
+	269, 0, 0, 0, 11, 0, 17, 18, 19, 20,
+	21, 22, 23, 24, 25, 26, 320, 321, -2, 323,
+	324, 325, 0, 327, 328, 329, 125, 0, 0, 0,
+	0, 0, 27, 28, 29, 30, 242, 243, 0, 252,
+	253, 344, 345, 346, 347, 348, 349, 350, 351, 352,
+	362, 363, 364, 0, 0, 390, 391, 0, 34, 0,
+	0, 0, 0, 256, 256, 354, 360, 0, 0, 0,
+	0, 0, 41, 42, 104, 63, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 234, 268, 9, 10, 11, 275, 23, 0, 0,
-	0, 109, 0, 0, 0, 0, 76, 0, 50, -2,
-	0, 309, 0, 315, 316, 0, 321, 0, 0, 340,
-	341, 0, 0, 0, 0, 0, 0, 0, 304, 305,
-	0, 0, 310, 100, 0, 332, 0, 161, 0, 0,
-	0, 0, 93, 88, 0, 87, 56, -2, 58, 59,
-	74, 0, 0, 0, 37, 38, 0, 0, 0, 45,
-	43, 44, 47, 50, 219, 220, 0, 0, 226, 227,
-	228, 229, 230, 231, 232, 233, -2, -2, -2, -2,
-	-2, -2, -2, 0, 279, 0, 0, 0, 0, -2,
-	-2, -2, 250, 0, 252, 254, 256, 258, 260, 262,
-	264, 266, 121, 118, 0, 0, 27, 0, 29, 0,
-	31, 0, 0, 128, 128, 76, 0, 77, 79, 0,
-	127, 51, 52, 0, 54, 0, 0, 0, 0, 314,
-	321, 0, 320, 0, 0, 339, 187, 0, 189, 0,
-	0, 190, 0, 0, 0, 302, 0, 0, 308, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 96,
-	94, 0, 89, 90, 0, 93, 0, 82, 84, 50,
-	0, 0, 0, 0, 0, 39, 0, 40, 50, 0,
-	49, 0, 223, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, -2, -2, -2, 251, 253, 255,
-	257, 259, 261, 263, 265, 267, 24, 122, 25, 119,
-	120, 123, 28, 30, 32, 110, 111, 114, 0, 0,
-	0, 0, 93, 93, 93, 0, 0, 0, 80, 50,
-	73, 53, 0, 323, 0, 325, 317, 0, 322, 0,
-	0, 188, 0, 0, 0, 0, 0, 0, 306, 307,
-	101, 329, 333, 336, 334, 335, 330, 331, 163, 163,
-	0, 97, 0, 99, 0, 95, 0, 0, 96, 0,
-	0, 0, 63, 64, 83, 85, 76, 75, 214, 74,
-	74, 50, 46, 50, 41, 48, 221, 222, 224, 0,
-	242, 280, 281, 0, 0, 287, 288, 289, 290, 291,
-	292, 0, 0, 113, 115, 116, 117, 136, 133, 0,
-	142, 131, 0, 0, 136, 142, 118, 93, 118, 118,
-	150, 151, 0, 165, 166, 154, 0, 126, 0, 0,
-	324, 0, 318, 0, 0, 194, 194, 191, 194, 0,
-	0, 0, 0, 35, 0, 104, 91, 92, 36, 60,
-	74, 0, 0, 61, 50, 65, 0, 0, 50, 50,
-	68, 42, 225, 0, 284, 0, 243, 112, 124, 0,
-	137, 0, 0, 0, 0, 0, 132, 141, 144, 0,
-	136, 118, 136, 136, 0, 0, 0, 168, 155, 0,
-	78, 0, 0, 319, 194, 0, 206, 195, 0, 207,
-	209, 0, 212, 337, 164, 338, 102, 50, 0, 0,
-	62, 215, 216, 0, 76, 76, 282, 283, 285, 0,
-	134, 138, 139, 0, 0, 0, 0, 0, 0, 0,
-	146, 136, 148, 149, 152, 154, 167, 163, 157, 0,
-	171, 131, 0, 0, 198, 0, 201, 203, 196, 197,
-	208, 0, 194, 0, 105, 103, 0, 74, 74, 217,
-	66, 67, 286, 140, 0, 136, 143, 129, 0, 136,
-	147, 153, 0, 0, 0, 0, 118, 0, 132, 0,
-	185, 199, 0, 192, 0, 0, 211, 213, 98, 106,
-	0, 69, 79, 50, 50, 0, 125, 130, 145, 156,
-	158, 159, 162, 160, 136, 0, 0, 0, 200, 204,
-	0, 202, 210, 107, 0, 0, 0, 135, 169, 0,
-	0, 171, 194, 0, 0, 0, 70, 71, 0, 93,
-	0, 118, 198, 205, 193, 86, 175, 93, 93, 178,
-	183, 0, 136, 186, 172, 0, 180, 93, 182, 173,
-	0, 174, 93, 170, 0, 0, 181, 0, 184, 0,
-	0, 0, 93, 0, 0, 178, 0, 0, 176, 177,
-	179,
+	0, 285, 319, 9, 0, 0, 0, 14, 15, 16,
+	326, 31, 0, 0, 0, 126, 0, 0, 0, 0,
+	89, 0, 0, 0, 58, -2, 0, 0, 360, 0,
+	366, 367, 0, 372, 0, 0, 403, 404, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 257, 0, 0,
+	355, 356, 0, 0, 361, 117, 0, 395, 0, 189,
+	0, 0, 0, 0, 110, 105, 0, 104, 64, -2,
+	66, 67, 87, 0, 0, 0, 45, 46, 0, 0,
+	0, 53, 51, 52, 55, 58, 270, 271, 0, 0,
+	277, 278, 279, 280, 281, 282, 283, 284, -2, -2,
+	-2, -2, -2, -2, -2, 0, 330, 0, 0, 0,
+	0, -2, -2, -2, 301, 0, 303, 305, 307, 309,
+	311, 313, 315, 317, 10, 12, 13, 138, 135, 0,
+	0, 35, 0, 37, 0, 39, 0, 0, 148, 148,
+	89, 0, 90, 96, 0, 0, 0, 147, 59, 60,
+	0, 62, 0, 0, 0, 0, 0, 365, 372, 0,
+	371, 0, 0, 402, 215, 0, 217, 0, 379, 0,
+	0, 218, 0, 249, 0, 0, 0, 258, 0, 353,
+	0, 0, 359, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 113, 111, 0, 106, 107, 0, 110,
+	0, 99, 101, 58, 0, 0, 0, 0, 0, 47,
+	0, 48, 58, 0, 57, 0, 274, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, -2, -2,
+	-2, 302, 304, 306, 308, 310, 312, 314, 316, 318,
+	32, 139, 33, 136, 137, 140, 36, 38, 40, 127,
+	128, 131, 0, 0, 0, 0, 110, 176, 176, 0,
+	0, 0, 97, 0, 58, 86, 58, 61, 0, 0,
+	374, 0, 376, 368, 0, 373, 0, 0, 216, 0,
+	245, 0, 0, 0, 0, 0, 0, 0, 357, 358,
+	118, 392, 396, 399, 397, 398, 393, 394, 191, 191,
+	0, 114, 0, 116, 0, 112, 0, 0, 113, 0,
+	0, 0, 72, 73, 100, 102, 89, 88, 265, 87,
+	87, 58, 54, 58, 49, 56, 272, 273, 275, 0,
+	293, 331, 332, 0, 0, 338, 339, 340, 341, 342,
+	343, 0, 0, 130, 132, 133, 134, 160, 156, 0,
+	166, 154, 0, 0, 160, 166, 135, 176, 110, 0,
+	110, 178, 179, 0, 193, 194, 182, 0, 0, 145,
+	146, 0, 0, 0, 0, 375, 0, 0, 369, 0,
+	0, 0, 246, 247, 222, 222, 219, 222, 0, 0,
+	259, 0, 0, 0, 0, 0, 43, 0, 121, 108,
+	109, 44, 68, 0, 87, 0, 0, 70, 0, 58,
+	74, 77, 96, 0, 0, 58, 58, 80, 50, 276,
+	0, 335, 0, 294, 129, 141, 0, 161, 0, 0,
+	0, 0, 0, 155, 165, 168, 0, 0, 160, 110,
+	135, 0, 135, 0, 0, 0, 196, 183, 0, 91,
+	0, 0, 0, 251, 381, 381, 370, 222, 0, 0,
+	0, 234, 223, 0, 235, 237, 0, 240, 261, 0,
+	261, 400, 192, 401, 119, 96, 81, 58, 0, 0,
+	96, 71, 0, 266, 267, 0, 89, 89, 333, 334,
+	336, 0, 157, 162, 163, 0, 0, 0, 0, 0,
+	153, 0, 0, 0, 0, 0, 172, 135, 160, 177,
+	160, 180, 182, 195, 191, 185, 0, 250, 199, 154,
+	0, 0, 0, 383, 0, 0, 226, 0, 229, 231,
+	0, 248, 224, 225, 236, 0, 222, 0, 0, 262,
+	260, 0, 122, 120, 69, 0, 82, 87, 87, 0,
+	268, 75, 78, 76, 79, 337, 164, 0, 160, 0,
+	167, 149, 0, 0, 160, 167, 0, 160, 174, 175,
+	181, 0, 0, 0, 0, 135, 0, 155, 0, 377,
+	385, 0, 0, 378, 213, 227, 0, 220, 0, 0,
+	0, 239, 241, 254, 263, 255, 115, 123, 0, 0,
+	58, 58, 0, 93, 222, 0, 142, 0, 0, 0,
+	150, 0, 169, 0, 0, 173, 184, 186, 187, 190,
+	188, 160, 0, 0, 0, 380, 0, 0, 382, 228,
+	232, 0, 230, 244, 238, 0, 124, 103, 83, 84,
+	92, 0, 95, 158, 0, 160, 0, 151, 0, 0,
+	160, 197, 0, 0, 199, 386, 387, 0, 0, 384,
+	222, 0, 0, 264, 94, 0, 143, 0, 152, 0,
+	170, 0, 110, 0, 135, 0, 389, 226, 233, 221,
+	159, 0, 0, 203, 110, 110, 206, 211, 0, 160,
+	0, 214, 160, 160, 200, 0, 208, 110, 210, 201,
+	0, 202, 110, 198, 388, 144, 171, 0, 0, 209,
+	0, 212, 0, 0, 0, 110, 0, 0, 206, 0,
+	0, 204, 205, 207,
 }
-var yyTok1 = []int{
 
+var yyTok1 = [...]int8{
 	1,
 }
-var yyTok2 = []int{
 
+var yyTok2 = [...]uint8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
@@ -1197,28 +1326,56 @@ var yyTok2 = []int{
 	172, 173, 174, 175, 176, 177, 178, 179, 180, 181,
 	182, 183, 184, 185, 186, 187, 188, 189, 190, 191,
 }
-var yyTok3 = []int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
+var yyErrorMessages = [...]struct {
+	state int
+	token int
+	msg   string
+}{}
+
 //line yaccpar:1
 
 /*	parser for yacc output	*/
 
-var yyDebug = 0
+var (
+	yyDebug        = 0
+	yyErrorVerbose = false
+)
 
 type yyLexer interface {
 	Lex(lval *yySymType) int
 	Error(s string)
 }
 
+type yyParser interface {
+	Parse(yyLexer) int
+	Lookahead() int
+}
+
+type yyParserImpl struct {
+	lval  yySymType
+	stack [yyInitialStackSize]yySymType
+	char  int
+}
+
+func (p *yyParserImpl) Lookahead() int {
+	return p.char
+}
+
+func yyNewParser() yyParser {
+	return &yyParserImpl{}
+}
+
 const yyFlag = -1000
 
 func yyTokname(c int) string {
-	// 4 is TOKSTART above
-	if c >= 4 && c-4 < len(yyToknames) {
-		if yyToknames[c-4] != "" {
-			return yyToknames[c-4]
+	if c >= 1 && c-1 < len(yyToknames) {
+		if yyToknames[c-1] != "" {
+			return yyToknames[c-1]
 		}
 	}
 	return __yyfmt__.Sprintf("tok-%v", c)
@@ -1233,51 +1390,127 @@ func yyStatname(s int) string {
 	return __yyfmt__.Sprintf("state-%v", s)
 }
 
-func yylex1(lex yyLexer, lval *yySymType) int {
-	c := 0
-	char := lex.Lex(lval)
+func yyErrorMessage(state, lookAhead int) string {
+	const TOKSTART = 4
+
+	if !yyErrorVerbose {
+		return "syntax error"
+	}
+
+	for _, e := range yyErrorMessages {
+		if e.state == state && e.token == lookAhead {
+			return "syntax error: " + e.msg
+		}
+	}
+
+	res := "syntax error: unexpected " + yyTokname(lookAhead)
+
+	// To match Bison, suggest at most four expected tokens.
+	expected := make([]int, 0, 4)
+
+	// Look for shiftable tokens.
+	base := int(yyPact[state])
+	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
+			if len(expected) == cap(expected) {
+				return res
+			}
+			expected = append(expected, tok)
+		}
+	}
+
+	if yyDef[state] == -2 {
+		i := 0
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
+			i += 2
+		}
+
+		// Look for tokens that we accept or reduce.
+		for i += 2; yyExca[i] >= 0; i += 2 {
+			tok := int(yyExca[i])
+			if tok < TOKSTART || yyExca[i+1] == 0 {
+				continue
+			}
+			if len(expected) == cap(expected) {
+				return res
+			}
+			expected = append(expected, tok)
+		}
+
+		// If the default action is to accept or reduce, give up.
+		if yyExca[i+1] != 0 {
+			return res
+		}
+	}
+
+	for i, tok := range expected {
+		if i == 0 {
+			res += ", expecting "
+		} else {
+			res += " or "
+		}
+		res += yyTokname(tok)
+	}
+	return res
+}
+
+func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
+	token = 0
+	char = lex.Lex(lval)
 	if char <= 0 {
-		c = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		c = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			c = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		c = yyTok3[i+0]
-		if c == char {
-			c = yyTok3[i+1]
+		token = int(yyTok3[i+0])
+		if token == char {
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
-	if c == 0 {
-		c = yyTok2[1] /* unknown char */
+	if token == 0 {
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
-		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(c), uint(char))
+		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
 	}
-	return c
+	return char, token
 }
 
 func yyParse(yylex yyLexer) int {
+	return yyNewParser().Parse(yylex)
+}
+
+func (yyrcvr *yyParserImpl) Parse(yylex yyLexer) int {
 	var yyn int
-	var yylval yySymType
 	var yyVAL yySymType
-	yyS := make([]yySymType, yyMaxDepth)
+	var yyDollar []yySymType
+	_ = yyDollar // silence set and not used
+	yyS := yyrcvr.stack[:]
 
 	Nerrs := 0   /* number of errors */
 	Errflag := 0 /* error recovery flag */
 	yystate := 0
-	yychar := -1
+	yyrcvr.char = -1
+	yytoken := -1 // yyrcvr.char translated into internal numbering
+	defer func() {
+		// Make sure we report no lookahead when not parsing.
+		yystate = -1
+		yyrcvr.char = -1
+		yytoken = -1
+	}()
 	yyp := -1
 	goto yystack
 
@@ -1290,7 +1523,7 @@ ret1:
 yystack:
 	/* put a state and value onto the stack */
 	if yyDebug >= 4 {
-		__yyfmt__.Printf("char %v in %v\n", yyTokname(yychar), yyStatname(yystate))
+		__yyfmt__.Printf("char %v in %v\n", yyTokname(yytoken), yyStatname(yystate))
 	}
 
 	yyp++
@@ -1303,21 +1536,22 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
-	if yychar < 0 {
-		yychar = yylex1(yylex, &yylval)
+	if yyrcvr.char < 0 {
+		yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
 	}
-	yyn += yychar
+	yyn += yytoken
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yychar { /* valid shift */
-		yychar = -1
-		yyVAL = yylval
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
+		yyrcvr.char = -1
+		yytoken = -1
+		yyVAL = yyrcvr.lval
 		yystate = yyn
 		if Errflag > 0 {
 			Errflag--
@@ -1327,27 +1561,27 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
-		if yychar < 0 {
-			yychar = yylex1(yylex, &yylval)
+		if yyrcvr.char < 0 {
+			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
 		}
 
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
-			if yyn < 0 || yyn == yychar {
+			yyn = int(yyExca[xi+0])
+			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -1356,11 +1590,11 @@ yydefault:
 		/* error ... attempt to resume parsing */
 		switch Errflag {
 		case 0: /* brand new error */
-			yylex.Error("syntax error")
+			yylex.Error(yyErrorMessage(yystate, yytoken))
 			Nerrs++
 			if yyDebug >= 1 {
 				__yyfmt__.Printf("%s", yyStatname(yystate))
-				__yyfmt__.Printf(" saw %s\n", yyTokname(yychar))
+				__yyfmt__.Printf(" saw %s\n", yyTokname(yytoken))
 			}
 			fallthrough
 
@@ -1369,10 +1603,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -1388,12 +1622,13 @@ yydefault:
 
 		case 3: /* no shift yet; clobber input char */
 			if yyDebug >= 2 {
-				__yyfmt__.Printf("error recovery discards %s\n", yyTokname(yychar))
+				__yyfmt__.Printf("error recovery discards %s\n", yyTokname(yytoken))
 			}
-			if yychar == yyEofCode {
+			if yytoken == yyEofCode {
 				goto ret1
 			}
-			yychar = -1
+			yyrcvr.char = -1
+			yytoken = -1
 			goto yynewstate /* try again in the same state */
 		}
 	}
@@ -1407,1550 +1642,2066 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
+	// yyp is now the index of $0. Perform the default action. Iff the
+	// reduced production is ε, $1 is possibly out of range.
+	if yyp+1 >= len(yyS) {
+		nyys := make([]yySymType, len(yyS)*2)
+		copy(nyys, yyS)
+		yyS = nyys
+	}
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
 	switch yynt {
 
 	case 1:
-		//line n1ql.y:361
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:381
 		{
-			yylex.(*lexer).setStatement(yyS[yypt-0].statement)
+			yylex.(*lexer).setStatement(yyDollar[1].statement)
 		}
 	case 2:
-		//line n1ql.y:366
-		{
-			yylex.(*lexer).setExpression(yyS[yypt-0].expr)
-		}
-	case 3:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 4:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 5:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 6:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 7:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 8:
-		yyVAL.statement = yyS[yypt-0].statement
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:386
+		{
+			yylex.(*lexer).setExpression(yyDollar[1].expr)
+		}
 	case 9:
-		//line n1ql.y:387
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:407
 		{
-			yyVAL.statement = algebra.NewExplain(yyS[yypt-0].statement)
+			yyVAL.statement = algebra.NewExplain(yyDollar[2].statement)
 		}
 	case 10:
-		//line n1ql.y:394
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:414
 		{
-			yyVAL.statement = algebra.NewPrepare(yyS[yypt-0].statement)
+			yyVAL.statement = algebra.NewPrepare(yyDollar[2].s, yyDollar[3].statement)
 		}
 	case 11:
-		//line n1ql.y:401
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:421
 		{
-			yyVAL.statement = algebra.NewExecute(yyS[yypt-0].expr)
+			yyVAL.s = ""
 		}
 	case 12:
-		//line n1ql.y:408
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:426
 		{
-			yyVAL.statement = yyS[yypt-0].fullselect
+			yyVAL.s = yyDollar[1].s
 		}
 	case 13:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 14:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 15:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 16:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 17:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 18:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 19:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 20:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 21:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 22:
-		yyVAL.statement = yyS[yypt-0].statement
-	case 23:
-		//line n1ql.y:441
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:431
 		{
-			yyVAL.fullselect = algebra.NewSelect(yyS[yypt-1].subresult, yyS[yypt-0].order, nil, nil) /* OFFSET precedes LIMIT */
+			yyVAL.s = yyDollar[1].s
 		}
-	case 24:
-		//line n1ql.y:445
-		{
-			yyVAL.fullselect = algebra.NewSelect(yyS[yypt-3].subresult, yyS[yypt-2].order, yyS[yypt-0].expr, yyS[yypt-1].expr) /* OFFSET precedes LIMIT */
-		}
-	case 25:
-		//line n1ql.y:449
-		{
-			yyVAL.fullselect = algebra.NewSelect(yyS[yypt-3].subresult, yyS[yypt-2].order, yyS[yypt-1].expr, yyS[yypt-0].expr) /* OFFSET precedes LIMIT */
-		}
-	case 26:
-		//line n1ql.y:455
-		{
-			yyVAL.subresult = yyS[yypt-0].subselect
-		}
-	case 27:
-		//line n1ql.y:460
+	case 14:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:438
 		{
-			yyVAL.subresult = algebra.NewUnion(yyS[yypt-2].subresult, yyS[yypt-0].subselect)
+			yyVAL.statement = algebra.NewExecute(yyDollar[2].expr)
 		}
-	case 28:
-		//line n1ql.y:465
+	case 15:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:443
 		{
-			yyVAL.subresult = algebra.NewUnionAll(yyS[yypt-3].subresult, yyS[yypt-0].subselect)
+			yyVAL.statement = algebra.NewExecute(expression.NewConstant(yyDollar[2].s))
 		}
-	case 29:
-		//line n1ql.y:470
+	case 16:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:448
 		{
-			yyVAL.subresult = algebra.NewIntersect(yyS[yypt-2].subresult, yyS[yypt-0].subselect)
+			yyVAL.statement = algebra.NewExecute(expression.NewConstant(yyDollar[2].s))
 		}
-	case 30:
-		//line n1ql.y:475
+	case 17:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:455
 		{
-			yyVAL.subresult = algebra.NewIntersectAll(yyS[yypt-3].subresult, yyS[yypt-0].subselect)
+			yyVAL.statement = yyDollar[1].fullselect
 		}
 	case 31:
-		//line n1ql.y:480
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:494
 		{
-			yyVAL.subresult = algebra.NewExcept(yyS[yypt-2].subresult, yyS[yypt-0].subselect)
+			yyVAL.fullselect = algebra.NewSelect(yyDollar[1].subresult, yyDollar[2].order, nil, nil) /* OFFSET precedes LIMIT */
 		}
 	case 32:
-		//line n1ql.y:485
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:499
 		{
-			yyVAL.subresult = algebra.NewExceptAll(yyS[yypt-3].subresult, yyS[yypt-0].subselect)
+			yyVAL.fullselect = algebra.NewSelect(yyDollar[1].subresult, yyDollar[2].order, yyDollar[4].expr, yyDollar[3].expr) /* OFFSET precedes LIMIT */
 		}
 	case 33:
-		yyVAL.subselect = yyS[yypt-0].subselect
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:504
+		{
+			yyVAL.fullselect = algebra.NewSelect(yyDollar[1].subresult, yyDollar[2].order, yyDollar[3].expr, yyDollar[4].expr) /* OFFSET precedes LIMIT */
+		}
 	case 34:
-		yyVAL.subselect = yyS[yypt-0].subselect
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:511
+		{
+			yyVAL.subresult = yyDollar[1].subselect
+		}
 	case 35:
-		//line n1ql.y:498
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:516
 		{
-			yyVAL.subselect = algebra.NewSubselect(yyS[yypt-4].fromTerm, yyS[yypt-3].bindings, yyS[yypt-2].expr, yyS[yypt-1].group, yyS[yypt-0].projection)
+			yyVAL.subresult = algebra.NewUnion(yyDollar[1].subresult, yyDollar[3].subselect)
 		}
 	case 36:
-		//line n1ql.y:505
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:521
 		{
-			yyVAL.subselect = algebra.NewSubselect(yyS[yypt-3].fromTerm, yyS[yypt-2].bindings, yyS[yypt-1].expr, yyS[yypt-0].group, yyS[yypt-4].projection)
+			yyVAL.subresult = algebra.NewUnionAll(yyDollar[1].subresult, yyDollar[4].subselect)
 		}
 	case 37:
-		//line n1ql.y:520
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:526
 		{
-			yyVAL.projection = yyS[yypt-0].projection
+			yyVAL.subresult = algebra.NewIntersect(yyDollar[1].subresult, yyDollar[3].subselect)
 		}
 	case 38:
-		//line n1ql.y:527
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:531
 		{
-			yyVAL.projection = algebra.NewProjection(false, yyS[yypt-0].resultTerms)
+			yyVAL.subresult = algebra.NewIntersectAll(yyDollar[1].subresult, yyDollar[4].subselect)
 		}
 	case 39:
-		//line n1ql.y:532
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:536
 		{
-			yyVAL.projection = algebra.NewProjection(true, yyS[yypt-0].resultTerms)
+			yyVAL.subresult = algebra.NewExcept(yyDollar[1].subresult, yyDollar[3].subselect)
 		}
 	case 40:
-		//line n1ql.y:537
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:541
 		{
-			yyVAL.projection = algebra.NewProjection(false, yyS[yypt-0].resultTerms)
+			yyVAL.subresult = algebra.NewExceptAll(yyDollar[1].subresult, yyDollar[4].subselect)
 		}
-	case 41:
-		//line n1ql.y:542
+	case 43:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:554
 		{
-			yyVAL.projection = algebra.NewRawProjection(false, yyS[yypt-1].expr, yyS[yypt-0].s)
+			yyVAL.subselect = algebra.NewSubselect(yyDollar[1].fromTerm, yyDollar[2].bindings, yyDollar[3].expr, yyDollar[4].group, yyDollar[5].projection)
 		}
-	case 42:
-		//line n1ql.y:547
+	case 44:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:561
 		{
-			yyVAL.projection = algebra.NewRawProjection(true, yyS[yypt-1].expr, yyS[yypt-0].s)
+			yyVAL.subselect = algebra.NewSubselect(yyDollar[2].fromTerm, yyDollar[3].bindings, yyDollar[4].expr, yyDollar[5].group, yyDollar[1].projection)
 		}
 	case 45:
-		//line n1ql.y:560
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:576
 		{
-			yyVAL.resultTerms = algebra.ResultTerms{yyS[yypt-0].resultTerm}
+			yyVAL.projection = yyDollar[2].projection
 		}
 	case 46:
-		//line n1ql.y:565
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:583
 		{
-			yyVAL.resultTerms = append(yyS[yypt-2].resultTerms, yyS[yypt-0].resultTerm)
+			yyVAL.projection = algebra.NewProjection(false, yyDollar[1].resultTerms)
 		}
 	case 47:
-		//line n1ql.y:572
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:588
 		{
-			yyVAL.resultTerm = algebra.NewResultTerm(nil, true, "")
+			yyVAL.projection = algebra.NewProjection(true, yyDollar[2].resultTerms)
 		}
 	case 48:
-		//line n1ql.y:577
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:593
 		{
-			yyVAL.resultTerm = algebra.NewResultTerm(yyS[yypt-2].expr, true, "")
+			yyVAL.projection = algebra.NewProjection(false, yyDollar[2].resultTerms)
 		}
 	case 49:
-		//line n1ql.y:582
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:598
 		{
-			yyVAL.resultTerm = algebra.NewResultTerm(yyS[yypt-1].expr, false, yyS[yypt-0].s)
+			yyVAL.projection = algebra.NewRawProjection(false, yyDollar[2].expr, yyDollar[3].s)
 		}
 	case 50:
-		//line n1ql.y:589
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:603
 		{
-			yyVAL.s = ""
+			yyVAL.projection = algebra.NewRawProjection(true, yyDollar[3].expr, yyDollar[4].s)
 		}
-	case 51:
-		yyVAL.s = yyS[yypt-0].s
-	case 52:
-		yyVAL.s = yyS[yypt-0].s
 	case 53:
-		//line n1ql.y:600
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:616
 		{
-			yyVAL.s = yyS[yypt-0].s
+			yyVAL.resultTerms = algebra.ResultTerms{yyDollar[1].resultTerm}
 		}
 	case 54:
-		yyVAL.s = yyS[yypt-0].s
-	case 55:
-		//line n1ql.y:618
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:621
 		{
-			yyVAL.fromTerm = nil
+			yyVAL.resultTerms = append(yyDollar[1].resultTerms, yyDollar[3].resultTerm)
 		}
-	case 56:
-		yyVAL.fromTerm = yyS[yypt-0].fromTerm
-	case 57:
-		//line n1ql.y:627
+	case 55:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:628
 		{
-			yyVAL.fromTerm = yyS[yypt-0].fromTerm
+			yyVAL.resultTerm = algebra.NewResultTerm(nil, true, "")
 		}
-	case 58:
-		//line n1ql.y:634
+	case 56:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:633
 		{
-			yyVAL.fromTerm = yyS[yypt-0].keyspaceTerm
+			yyVAL.resultTerm = algebra.NewResultTerm(yyDollar[1].expr, true, "")
 		}
-	case 59:
-		//line n1ql.y:639
+	case 57:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:638
 		{
-			yyVAL.fromTerm = yyS[yypt-0].subqueryTerm
+			yyVAL.resultTerm = algebra.NewResultTerm(yyDollar[1].expr, false, yyDollar[2].s)
 		}
-	case 60:
-		//line n1ql.y:644
+	case 58:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:645
 		{
-			yyVAL.fromTerm = algebra.NewJoin(yyS[yypt-3].fromTerm, yyS[yypt-2].b, yyS[yypt-0].keyspaceTerm)
+			yyVAL.s = ""
 		}
 	case 61:
-		//line n1ql.y:649
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:656
 		{
-			yyVAL.fromTerm = algebra.NewNest(yyS[yypt-3].fromTerm, yyS[yypt-2].b, yyS[yypt-0].keyspaceTerm)
+			yyVAL.s = yyDollar[2].s
 		}
-	case 62:
-		//line n1ql.y:654
+	case 63:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:674
 		{
-			yyVAL.fromTerm = algebra.NewUnnest(yyS[yypt-4].fromTerm, yyS[yypt-3].b, yyS[yypt-1].expr, yyS[yypt-0].s)
+			yyVAL.fromTerm = nil
 		}
 	case 65:
-		//line n1ql.y:667
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:683
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("", yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = yyDollar[2].fromTerm
 		}
 	case 66:
-		//line n1ql.y:672
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:690
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyS[yypt-5].s, yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = yyDollar[1].keyspaceTerm
 		}
 	case 67:
-		//line n1ql.y:677
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:695
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("#system", yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = yyDollar[1].subqueryTerm
 		}
 	case 68:
-		//line n1ql.y:684
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:700
 		{
-			if yyS[yypt-0].s == "" {
-				yylex.Error("Subquery in FROM clause must have an alias.")
-			} else {
-				yyVAL.subqueryTerm = algebra.NewSubqueryTerm(yyS[yypt-2].fullselect, yyS[yypt-0].s)
-			}
+			yyVAL.fromTerm = algebra.NewJoin(yyDollar[1].fromTerm, yyDollar[2].b, yyDollar[4].keyspaceTerm)
 		}
 	case 69:
-		//line n1ql.y:695
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:705
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("", yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = algebra.NewAnsiJoin(yyDollar[1].fromTerm, yyDollar[2].b, yyDollar[4].keyspaceTerm, yyDollar[6].expr)
 		}
 	case 70:
-		//line n1ql.y:700
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:710
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyS[yypt-5].s, yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = algebra.NewNest(yyDollar[1].fromTerm, yyDollar[2].b, yyDollar[4].keyspaceTerm)
 		}
 	case 71:
-		//line n1ql.y:705
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:715
 		{
-			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("#system", yyS[yypt-3].s, yyS[yypt-2].path, yyS[yypt-1].s, yyS[yypt-0].expr)
+			yyVAL.fromTerm = algebra.NewUnnest(yyDollar[1].fromTerm, yyDollar[2].b, yyDollar[4].expr, yyDollar[5].s)
 		}
-	case 72:
-		yyVAL.s = yyS[yypt-0].s
-	case 73:
-		yyVAL.s = yyS[yypt-0].s
 	case 74:
-		//line n1ql.y:720
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:728
 		{
-			yyVAL.path = nil
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("", yyDollar[1].s, yyDollar[2].path, yyDollar[3].s, yyDollar[4].expr)
 		}
 	case 75:
-		//line n1ql.y:725
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:733
 		{
-			yyVAL.path = yyS[yypt-0].path
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyDollar[1].s, yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, yyDollar[6].expr)
 		}
 	case 76:
-		//line n1ql.y:732
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:738
 		{
-			yyVAL.expr = nil
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("#system", yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, yyDollar[6].expr)
 		}
 	case 77:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:743
+		{
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("", yyDollar[1].s, yyDollar[2].path, yyDollar[3].s, nil)
+			yyVAL.keyspaceTerm.SetIndexes(yyDollar[4].indexRefs)
+		}
 	case 78:
-		//line n1ql.y:741
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:749
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyDollar[1].s, yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, nil)
+			yyVAL.keyspaceTerm.SetIndexes(yyDollar[6].indexRefs)
 		}
 	case 79:
-		//line n1ql.y:748
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:755
 		{
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("#system", yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, nil)
+			yyVAL.keyspaceTerm.SetIndexes(yyDollar[6].indexRefs)
+		}
+	case 80:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:763
+		{
+			if yyDollar[4].s == "" {
+				yylex.Error("Subquery in FROM clause must have an alias.")
+			} else {
+				yyVAL.subqueryTerm = algebra.NewSubqueryTerm(yyDollar[2].fullselect, yyDollar[4].s)
+			}
 		}
 	case 81:
-		//line n1ql.y:756
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:774
 		{
-			yyVAL.b = false
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyDollar[1].keyspaceTerm.Namespace(), yyDollar[1].keyspaceTerm.Keyspace(), yyDollar[1].keyspaceTerm.Projection(), yyDollar[1].keyspaceTerm.As(), yyDollar[2].expr)
 		}
 	case 82:
-		//line n1ql.y:761
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:781
 		{
-			yyVAL.b = false
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("", yyDollar[1].s, yyDollar[2].path, yyDollar[3].s, nil)
 		}
 	case 83:
-		//line n1ql.y:766
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:786
 		{
-			yyVAL.b = true
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm(yyDollar[1].s, yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, nil)
 		}
-	case 86:
-		//line n1ql.y:779
+	case 84:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:791
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.keyspaceTerm = algebra.NewKeyspaceTerm("#system", yyDollar[3].s, yyDollar[4].path, yyDollar[5].s, nil)
 		}
 	case 87:
-		//line n1ql.y:793
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:806
 		{
-			yyVAL.bindings = nil
+			yyVAL.path = nil
 		}
 	case 88:
-		yyVAL.bindings = yyS[yypt-0].bindings
-	case 89:
-		//line n1ql.y:802
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:811
 		{
-			yyVAL.bindings = yyS[yypt-0].bindings
+			yyVAL.path = yyDollar[2].path
 		}
-	case 90:
-		//line n1ql.y:809
+	case 89:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:818
 		{
-			yyVAL.bindings = expression.Bindings{yyS[yypt-0].binding}
+			yyVAL.expr = nil
 		}
 	case 91:
-		//line n1ql.y:814
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:827
 		{
-			yyVAL.bindings = append(yyS[yypt-2].bindings, yyS[yypt-0].binding)
+			yyVAL.expr = yyDollar[4].expr
 		}
 	case 92:
-		//line n1ql.y:821
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:834
 		{
-			yyVAL.binding = expression.NewBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.indexRefs = yyDollar[4].indexRefs
 		}
 	case 93:
-		//line n1ql.y:835
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:841
 		{
-			yyVAL.expr = nil
+			yyVAL.indexRefs = algebra.IndexRefs{yyDollar[1].indexRef}
 		}
 	case 94:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:846
+		{
+			yyVAL.indexRefs = append(yyDollar[1].indexRefs, yyDollar[3].indexRef)
+		}
 	case 95:
-		//line n1ql.y:844
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:853
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.indexRef = algebra.NewIndexRef(yyDollar[1].s, yyDollar[2].indexType)
 		}
 	case 96:
-		//line n1ql.y:858
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:860
 		{
-			yyVAL.group = nil
 		}
-	case 97:
-		yyVAL.group = yyS[yypt-0].group
 	case 98:
-		//line n1ql.y:867
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:868
 		{
-			yyVAL.group = algebra.NewGroup(yyS[yypt-2].exprs, yyS[yypt-1].bindings, yyS[yypt-0].expr)
+			yyVAL.b = false
 		}
 	case 99:
-		//line n1ql.y:872
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:873
 		{
-			yyVAL.group = algebra.NewGroup(nil, yyS[yypt-0].bindings, nil)
+			yyVAL.b = false
 		}
 	case 100:
-		//line n1ql.y:879
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:878
 		{
-			yyVAL.exprs = expression.Expressions{yyS[yypt-0].expr}
-		}
-	case 101:
-		//line n1ql.y:884
-		{
-			yyVAL.exprs = append(yyS[yypt-2].exprs, yyS[yypt-0].expr)
+			yyVAL.b = true
 		}
-	case 102:
-		//line n1ql.y:891
+	case 103:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:891
 		{
-			yyVAL.bindings = nil
+			yyVAL.expr = yyDollar[4].expr
 		}
-	case 103:
-		yyVAL.bindings = yyS[yypt-0].bindings
 	case 104:
-		//line n1ql.y:900
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:905
 		{
-			yyVAL.bindings = yyS[yypt-0].bindings
+			yyVAL.bindings = nil
 		}
-	case 105:
-		//line n1ql.y:907
+	case 106:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:914
 		{
-			yyVAL.expr = nil
+			yyVAL.bindings = yyDollar[2].bindings
 		}
-	case 106:
-		yyVAL.expr = yyS[yypt-0].expr
 	case 107:
-		//line n1ql.y:916
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:921
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.bindings = expression.Bindings{yyDollar[1].binding}
 		}
 	case 108:
-		//line n1ql.y:930
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:926
 		{
-			yyVAL.order = nil
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
 		}
 	case 109:
-		yyVAL.order = yyS[yypt-0].order
-	case 110:
-		//line n1ql.y:939
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:933
 		{
-			yyVAL.order = algebra.NewOrder(yyS[yypt-0].sortTerms)
+			yyVAL.binding = expression.NewBinding(yyDollar[1].s, yyDollar[3].expr)
 		}
-	case 111:
-		//line n1ql.y:946
+	case 110:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:947
 		{
-			yyVAL.sortTerms = algebra.SortTerms{yyS[yypt-0].sortTerm}
+			yyVAL.expr = nil
 		}
 	case 112:
-		//line n1ql.y:951
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:956
 		{
-			yyVAL.sortTerms = append(yyS[yypt-2].sortTerms, yyS[yypt-0].sortTerm)
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 113:
-		//line n1ql.y:958
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:970
 		{
-			yyVAL.sortTerm = algebra.NewSortTerm(yyS[yypt-1].expr, yyS[yypt-0].b)
+			yyVAL.group = nil
 		}
-	case 114:
-		//line n1ql.y:965
+	case 115:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:979
 		{
-			yyVAL.b = false
+			yyVAL.group = algebra.NewGroup(yyDollar[3].exprs, yyDollar[4].bindings, yyDollar[5].expr)
 		}
-	case 115:
-		yyVAL.b = yyS[yypt-0].b
 	case 116:
-		//line n1ql.y:974
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:984
 		{
-			yyVAL.b = false
+			yyVAL.group = algebra.NewGroup(nil, yyDollar[1].bindings, nil)
 		}
 	case 117:
-		//line n1ql.y:979
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:991
 		{
-			yyVAL.b = true
+			yyVAL.exprs = expression.Expressions{yyDollar[1].expr}
 		}
 	case 118:
-		//line n1ql.y:993
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:996
 		{
-			yyVAL.expr = nil
+			yyVAL.exprs = append(yyDollar[1].exprs, yyDollar[3].expr)
 		}
 	case 119:
-		yyVAL.expr = yyS[yypt-0].expr
-	case 120:
-		//line n1ql.y:1002
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1003
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.bindings = nil
 		}
 	case 121:
-		//line n1ql.y:1016
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1012
 		{
-			yyVAL.expr = nil
+			yyVAL.bindings = yyDollar[2].bindings
 		}
 	case 122:
-		yyVAL.expr = yyS[yypt-0].expr
-	case 123:
-		//line n1ql.y:1025
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1019
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.expr = nil
 		}
 	case 124:
-		//line n1ql.y:1039
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1028
 		{
-			yyVAL.statement = algebra.NewInsertValues(yyS[yypt-3].keyspaceRef, yyS[yypt-1].pairs, yyS[yypt-0].projection)
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 125:
-		//line n1ql.y:1044
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1042
 		{
-			yyVAL.statement = algebra.NewInsertSelect(yyS[yypt-6].keyspaceRef, yyS[yypt-4].expr, yyS[yypt-3].expr, yyS[yypt-1].fullselect, yyS[yypt-0].projection)
+			yyVAL.order = nil
 		}
-	case 126:
-		//line n1ql.y:1051
+	case 127:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1051
 		{
-			yyVAL.keyspaceRef = algebra.NewKeyspaceRef(yyS[yypt-3].s, yyS[yypt-1].s, yyS[yypt-0].s)
+			yyVAL.order = algebra.NewOrder(yyDollar[3].sortTerms)
 		}
-	case 127:
-		//line n1ql.y:1056
+	case 128:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1058
 		{
-			yyVAL.keyspaceRef = algebra.NewKeyspaceRef("", yyS[yypt-1].s, yyS[yypt-0].s)
+			yyVAL.sortTerms = algebra.SortTerms{yyDollar[1].sortTerm}
+		}
+	case 129:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1063
+		{
+			yyVAL.sortTerms = append(yyDollar[1].sortTerms, yyDollar[3].sortTerm)
+		}
+	case 130:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1070
+		{
+			yyVAL.sortTerm = algebra.NewSortTerm(yyDollar[1].expr, yyDollar[2].b)
+		}
+	case 131:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1077
+		{
+			yyVAL.b = false
 		}
 	case 133:
-		yyVAL.pairs = yyS[yypt-0].pairs
-	case 134:
-		//line n1ql.y:1079
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1086
 		{
-			yyVAL.pairs = append(yyS[yypt-2].pairs, yyS[yypt-0].pairs...)
+			yyVAL.b = false
 		}
-	case 135:
-		//line n1ql.y:1086
+	case 134:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1091
 		{
-			yyVAL.pairs = algebra.Pairs{&algebra.Pair{Key: yyS[yypt-3].expr, Value: yyS[yypt-1].expr}}
+			yyVAL.b = true
 		}
-	case 136:
-		//line n1ql.y:1093
+	case 135:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1105
 		{
-			yyVAL.projection = nil
+			yyVAL.expr = nil
 		}
 	case 137:
-		yyVAL.projection = yyS[yypt-0].projection
-	case 138:
-		//line n1ql.y:1102
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1114
 		{
-			yyVAL.projection = yyS[yypt-0].projection
+			yyVAL.expr = yyDollar[2].expr
 		}
-	case 139:
-		//line n1ql.y:1109
+	case 138:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1128
 		{
-			yyVAL.projection = algebra.NewProjection(false, yyS[yypt-0].resultTerms)
+			yyVAL.expr = nil
 		}
 	case 140:
-		//line n1ql.y:1114
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1137
 		{
-			yyVAL.projection = algebra.NewRawProjection(false, yyS[yypt-0].expr, "")
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 141:
-		//line n1ql.y:1121
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1151
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.statement = algebra.NewInsertValues(yyDollar[3].keyspaceRef, yyDollar[5].pairs, yyDollar[6].projection)
 		}
 	case 142:
-		//line n1ql.y:1128
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line n1ql.y:1156
 		{
-			yyVAL.expr = nil
+			yyVAL.statement = algebra.NewInsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, yyDollar[6].expr, nil, yyDollar[8].fullselect, yyDollar[9].projection)
 		}
 	case 143:
-		//line n1ql.y:1133
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line n1ql.y:1161
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.statement = algebra.NewInsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, nil, yyDollar[8].expr, yyDollar[10].fullselect, yyDollar[11].projection)
 		}
 	case 144:
-		//line n1ql.y:1147
+		yyDollar = yyS[yypt-14 : yypt+1]
+//line n1ql.y:1166
 		{
-			yyVAL.statement = algebra.NewUpsertValues(yyS[yypt-3].keyspaceRef, yyS[yypt-1].pairs, yyS[yypt-0].projection)
+			yyVAL.statement = algebra.NewInsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, yyDollar[8].expr, yyDollar[11].expr, yyDollar[13].fullselect, yyDollar[14].projection)
 		}
 	case 145:
-		//line n1ql.y:1152
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1173
 		{
-			yyVAL.statement = algebra.NewUpsertSelect(yyS[yypt-6].keyspaceRef, yyS[yypt-4].expr, yyS[yypt-3].expr, yyS[yypt-1].fullselect, yyS[yypt-0].projection)
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef(yyDollar[1].s, yyDollar[3].s, yyDollar[4].s)
 		}
 	case 146:
-		//line n1ql.y:1166
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1178
 		{
-			yyVAL.statement = algebra.NewDelete(yyS[yypt-4].keyspaceRef, yyS[yypt-3].expr, yyS[yypt-2].expr, yyS[yypt-1].expr, yyS[yypt-0].projection)
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef("#system", yyDollar[3].s, yyDollar[4].s)
 		}
 	case 147:
-		//line n1ql.y:1180
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1183
 		{
-			yyVAL.statement = algebra.NewUpdate(yyS[yypt-6].keyspaceRef, yyS[yypt-5].expr, yyS[yypt-4].set, yyS[yypt-3].unset, yyS[yypt-2].expr, yyS[yypt-1].expr, yyS[yypt-0].projection)
-		}
-	case 148:
-		//line n1ql.y:1185
-		{
-			yyVAL.statement = algebra.NewUpdate(yyS[yypt-5].keyspaceRef, yyS[yypt-4].expr, yyS[yypt-3].set, nil, yyS[yypt-2].expr, yyS[yypt-1].expr, yyS[yypt-0].projection)
-		}
-	case 149:
-		//line n1ql.y:1190
-		{
-			yyVAL.statement = algebra.NewUpdate(yyS[yypt-5].keyspaceRef, yyS[yypt-4].expr, nil, yyS[yypt-3].unset, yyS[yypt-2].expr, yyS[yypt-1].expr, yyS[yypt-0].projection)
-		}
-	case 150:
-		//line n1ql.y:1197
-		{
-			yyVAL.set = algebra.NewSet(yyS[yypt-0].setTerms)
-		}
-	case 151:
-		//line n1ql.y:1204
-		{
-			yyVAL.setTerms = algebra.SetTerms{yyS[yypt-0].setTerm}
-		}
-	case 152:
-		//line n1ql.y:1209
-		{
-			yyVAL.setTerms = append(yyS[yypt-2].setTerms, yyS[yypt-0].setTerm)
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef("", yyDollar[1].s, yyDollar[2].s)
 		}
 	case 153:
-		//line n1ql.y:1216
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1202
 		{
-			yyVAL.setTerm = algebra.NewSetTerm(yyS[yypt-3].path, yyS[yypt-1].expr, yyS[yypt-0].updateFor)
-		}
-	case 154:
-		//line n1ql.y:1223
-		{
-			yyVAL.updateFor = nil
-		}
-	case 155:
-		yyVAL.updateFor = yyS[yypt-0].updateFor
-	case 156:
-		//line n1ql.y:1232
-		{
-			yyVAL.updateFor = algebra.NewUpdateFor(yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			if strings.ToLower(yyDollar[1].s) != "options" {
+				yylex.Error("Expected OPTIONS, found " + yyDollar[1].s + ".")
+			}
 		}
 	case 157:
-		//line n1ql.y:1239
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1219
 		{
-			yyVAL.bindings = expression.Bindings{yyS[yypt-0].binding}
+			yyVAL.pairs = append(yyDollar[1].pairs, yyDollar[3].pairs...)
 		}
 	case 158:
-		//line n1ql.y:1244
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1226
 		{
-			yyVAL.bindings = append(yyS[yypt-2].bindings, yyS[yypt-0].binding)
+			yyVAL.pairs = algebra.Pairs{&algebra.Pair{Key: yyDollar[3].expr, Value: yyDollar[5].expr}}
 		}
 	case 159:
-		//line n1ql.y:1251
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1231
 		{
-			yyVAL.binding = expression.NewBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.pairs = algebra.Pairs{&algebra.Pair{Key: yyDollar[3].expr, Value: yyDollar[5].expr, Options: yyDollar[7].expr}}
 		}
 	case 160:
-		//line n1ql.y:1256
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1238
 		{
-			yyVAL.binding = expression.NewDescendantBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.projection = nil
 		}
-	case 161:
-		yyVAL.s = yyS[yypt-0].s
 	case 162:
-		//line n1ql.y:1267
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1247
 		{
-			yyVAL.expr = yyS[yypt-0].path
+			yyVAL.projection = yyDollar[2].projection
 		}
 	case 163:
-		//line n1ql.y:1274
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1254
 		{
-			yyVAL.expr = nil
+			yyVAL.projection = algebra.NewProjection(false, yyDollar[1].resultTerms)
 		}
 	case 164:
-		//line n1ql.y:1279
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1259
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.projection = algebra.NewRawProjection(false, yyDollar[2].expr, "")
 		}
 	case 165:
-		//line n1ql.y:1286
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1266
 		{
-			yyVAL.unset = algebra.NewUnset(yyS[yypt-0].unsetTerms)
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 166:
-		//line n1ql.y:1293
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1273
 		{
-			yyVAL.unsetTerms = algebra.UnsetTerms{yyS[yypt-0].unsetTerm}
+			yyVAL.expr = nil
 		}
 	case 167:
-		//line n1ql.y:1298
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1278
 		{
-			yyVAL.unsetTerms = append(yyS[yypt-2].unsetTerms, yyS[yypt-0].unsetTerm)
+			yyVAL.expr = yyDollar[3].expr
 		}
 	case 168:
-		//line n1ql.y:1305
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1292
 		{
-			yyVAL.unsetTerm = algebra.NewUnsetTerm(yyS[yypt-1].path, yyS[yypt-0].updateFor)
+			yyVAL.statement = algebra.NewUpsertValues(yyDollar[3].keyspaceRef, yyDollar[5].pairs, yyDollar[6].projection)
 		}
 	case 169:
-		//line n1ql.y:1319
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line n1ql.y:1297
 		{
-			source := algebra.NewMergeSourceFrom(yyS[yypt-5].keyspaceTerm, "")
-			yyVAL.statement = algebra.NewMerge(yyS[yypt-7].keyspaceRef, source, yyS[yypt-3].expr, yyS[yypt-2].mergeActions, yyS[yypt-1].expr, yyS[yypt-0].projection)
+			yyVAL.statement = algebra.NewUpsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, yyDollar[6].expr, nil, yyDollar[8].fullselect, yyDollar[9].projection)
 		}
 	case 170:
-		//line n1ql.y:1325
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line n1ql.y:1302
 		{
-			source := algebra.NewMergeSourceSelect(yyS[yypt-7].fullselect, yyS[yypt-5].s)
-			yyVAL.statement = algebra.NewMerge(yyS[yypt-10].keyspaceRef, source, yyS[yypt-3].expr, yyS[yypt-2].mergeActions, yyS[yypt-1].expr, yyS[yypt-0].projection)
+			yyVAL.statement = algebra.NewUpsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, nil, yyDollar[8].expr, yyDollar[10].fullselect, yyDollar[11].projection)
 		}
 	case 171:
-		//line n1ql.y:1333
+		yyDollar = yyS[yypt-14 : yypt+1]
+//line n1ql.y:1307
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, nil)
+			yyVAL.statement = algebra.NewUpsertSelect(yyDollar[3].keyspaceRef, yyDollar[5].expr, yyDollar[8].expr, yyDollar[11].expr, yyDollar[13].fullselect, yyDollar[14].projection)
 		}
 	case 172:
-		//line n1ql.y:1338
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line n1ql.y:1321
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(yyS[yypt-1].mergeUpdate, yyS[yypt-0].mergeActions.Delete(), yyS[yypt-0].mergeActions.Insert())
+			yyVAL.statement = algebra.NewDelete(yyDollar[3].keyspaceRef, yyDollar[4].expr, yyDollar[5].expr, yyDollar[6].expr, yyDollar[7].projection)
 		}
 	case 173:
-		//line n1ql.y:1343
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line n1ql.y:1335
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, yyS[yypt-1].mergeDelete, yyS[yypt-0].mergeInsert)
+			yyVAL.statement = algebra.NewUpdate(yyDollar[2].keyspaceRef, yyDollar[3].expr, yyDollar[4].set, yyDollar[5].unset, yyDollar[6].expr, yyDollar[7].expr, yyDollar[8].expr, yyDollar[9].projection)
 		}
 	case 174:
-		//line n1ql.y:1348
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1340
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, yyS[yypt-0].mergeInsert)
+			yyVAL.statement = algebra.NewUpdate(yyDollar[2].keyspaceRef, yyDollar[3].expr, yyDollar[4].set, nil, yyDollar[5].expr, yyDollar[6].expr, yyDollar[7].expr, yyDollar[8].projection)
 		}
 	case 175:
-		//line n1ql.y:1355
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1345
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, nil)
+			yyVAL.statement = algebra.NewUpdate(yyDollar[2].keyspaceRef, yyDollar[3].expr, nil, yyDollar[4].unset, yyDollar[5].expr, yyDollar[6].expr, yyDollar[7].expr, yyDollar[8].projection)
 		}
 	case 176:
-		//line n1ql.y:1360
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1352
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, yyS[yypt-1].mergeDelete, yyS[yypt-0].mergeInsert)
+			yyVAL.expr = nil
 		}
 	case 177:
-		//line n1ql.y:1365
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1357
 		{
-			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, yyS[yypt-0].mergeInsert)
+			if strings.ToLower(yyDollar[2].s) != "expiration" {
+				yylex.Error("Expected WITH EXPIRATION, found WITH " + yyDollar[2].s + ".")
+			}
+			yyVAL.expr = yyDollar[3].expr
 		}
 	case 178:
-		//line n1ql.y:1372
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1367
 		{
-			yyVAL.mergeInsert = nil
+			yyVAL.set = algebra.NewSet(yyDollar[2].setTerms)
 		}
 	case 179:
-		//line n1ql.y:1377
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1374
 		{
-			yyVAL.mergeInsert = yyS[yypt-0].mergeInsert
+			yyVAL.setTerms = algebra.SetTerms{yyDollar[1].setTerm}
 		}
 	case 180:
-		//line n1ql.y:1384
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1379
 		{
-			yyVAL.mergeUpdate = algebra.NewMergeUpdate(yyS[yypt-1].set, nil, yyS[yypt-0].expr)
+			yyVAL.setTerms = append(yyDollar[1].setTerms, yyDollar[3].setTerm)
 		}
 	case 181:
-		//line n1ql.y:1389
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1386
 		{
-			yyVAL.mergeUpdate = algebra.NewMergeUpdate(yyS[yypt-2].set, yyS[yypt-1].unset, yyS[yypt-0].expr)
+			yyVAL.setTerm = algebra.NewSetTerm(yyDollar[1].path, yyDollar[3].expr, yyDollar[4].updateFor)
 		}
 	case 182:
-		//line n1ql.y:1394
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1393
 		{
-			yyVAL.mergeUpdate = algebra.NewMergeUpdate(nil, yyS[yypt-1].unset, yyS[yypt-0].expr)
-		}
-	case 183:
-		//line n1ql.y:1401
-		{
-			yyVAL.mergeDelete = algebra.NewMergeDelete(yyS[yypt-0].expr)
+			yyVAL.updateFor = nil
 		}
 	case 184:
-		//line n1ql.y:1408
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1402
 		{
-			yyVAL.mergeInsert = algebra.NewMergeInsert(yyS[yypt-1].expr, yyS[yypt-0].expr)
+			yyVAL.updateFor = algebra.NewUpdateFor(yyDollar[2].bindings, yyDollar[3].expr)
 		}
 	case 185:
-		//line n1ql.y:1422
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1409
 		{
-			yyVAL.statement = algebra.NewCreatePrimaryIndex(yyS[yypt-4].s, yyS[yypt-2].keyspaceRef, yyS[yypt-1].indexType, yyS[yypt-0].val)
+			yyVAL.bindings = expression.Bindings{yyDollar[1].binding}
 		}
 	case 186:
-		//line n1ql.y:1427
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1414
 		{
-			yyVAL.statement = algebra.NewCreateIndex(yyS[yypt-9].s, yyS[yypt-7].keyspaceRef, yyS[yypt-5].exprs, yyS[yypt-3].expr, yyS[yypt-2].expr, yyS[yypt-1].indexType, yyS[yypt-0].val)
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
 		}
 	case 187:
-		//line n1ql.y:1434
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1421
 		{
-			yyVAL.s = "#primary"
+			yyVAL.binding = expression.NewBinding(yyDollar[1].s, yyDollar[3].expr)
 		}
 	case 188:
-		yyVAL.s = yyS[yypt-0].s
-	case 189:
-		yyVAL.s = yyS[yypt-0].s
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1426
+		{
+			yyVAL.binding = expression.NewDescendantBinding(yyDollar[1].s, yyDollar[3].expr)
+		}
 	case 190:
-		//line n1ql.y:1447
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1437
 		{
-			yyVAL.keyspaceRef = algebra.NewKeyspaceRef("", yyS[yypt-0].s, "")
+			yyVAL.expr = yyDollar[1].path
 		}
 	case 191:
-		//line n1ql.y:1452
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1444
 		{
-			yyVAL.keyspaceRef = algebra.NewKeyspaceRef(yyS[yypt-2].s, yyS[yypt-0].s, "")
+			yyVAL.expr = nil
 		}
 	case 192:
-		//line n1ql.y:1459
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1449
 		{
-			yyVAL.expr = nil
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 193:
-		//line n1ql.y:1464
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1456
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.unset = algebra.NewUnset(yyDollar[2].unsetTerms)
 		}
 	case 194:
-		//line n1ql.y:1471
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1463
 		{
-			yyVAL.indexType = datastore.DEFAULT
+			yyVAL.unsetTerms = algebra.UnsetTerms{yyDollar[1].unsetTerm}
 		}
 	case 195:
-		yyVAL.indexType = yyS[yypt-0].indexType
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1468
+		{
+			yyVAL.unsetTerms = append(yyDollar[1].unsetTerms, yyDollar[3].unsetTerm)
+		}
 	case 196:
-		//line n1ql.y:1480
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1475
 		{
-			yyVAL.indexType = datastore.VIEW
+			yyVAL.unsetTerm = algebra.NewUnsetTerm(yyDollar[1].path, yyDollar[2].updateFor)
 		}
 	case 197:
-		//line n1ql.y:1485
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line n1ql.y:1489
 		{
-			yyVAL.indexType = datastore.GSI
+			source := algebra.NewMergeSourceFrom(yyDollar[5].keyspaceTerm, "")
+			yyVAL.statement = algebra.NewMerge(yyDollar[3].keyspaceRef, source, yyDollar[7].expr, yyDollar[8].mergeActions, yyDollar[9].expr, yyDollar[10].projection)
 		}
 	case 198:
-		//line n1ql.y:1492
+		yyDollar = yyS[yypt-13 : yypt+1]
+//line n1ql.y:1495
 		{
-			yyVAL.val = nil
+			source := algebra.NewMergeSourceSelect(yyDollar[6].fullselect, yyDollar[8].s)
+			yyVAL.statement = algebra.NewMerge(yyDollar[3].keyspaceRef, source, yyDollar[10].expr, yyDollar[11].mergeActions, yyDollar[12].expr, yyDollar[13].projection)
 		}
 	case 199:
-		yyVAL.val = yyS[yypt-0].val
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1503
+		{
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, nil)
+		}
 	case 200:
-		//line n1ql.y:1501
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1508
 		{
-			yyVAL.val = yyS[yypt-0].expr.Value()
-			if yyVAL.val == nil {
-				yylex.Error("WITH value must be static.")
-			}
+			yyVAL.mergeActions = algebra.NewMergeActions(yyDollar[5].mergeUpdate, yyDollar[6].mergeActions.Delete(), yyDollar[6].mergeActions.Insert())
 		}
 	case 201:
-		//line n1ql.y:1511
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1513
 		{
-			yyVAL.exprs = expression.Expressions{yyS[yypt-0].expr}
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, yyDollar[5].mergeDelete, yyDollar[6].mergeInsert)
 		}
 	case 202:
-		//line n1ql.y:1516
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1518
 		{
-			yyVAL.exprs = append(yyS[yypt-2].exprs, yyS[yypt-0].expr)
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, yyDollar[6].mergeInsert)
 		}
 	case 203:
-		//line n1ql.y:1523
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1525
 		{
-			exp := yyS[yypt-0].expr
-			if !exp.Indexable() || exp.Value() != nil {
-				yylex.Error(fmt.Sprintf("Expression not indexable: %s", exp.String()))
-			}
-
-			yyVAL.expr = exp
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, nil)
 		}
 	case 204:
-		//line n1ql.y:1534
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1530
 		{
-			yyVAL.expr = nil
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, yyDollar[5].mergeDelete, yyDollar[6].mergeInsert)
 		}
 	case 205:
-		//line n1ql.y:1539
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1535
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.mergeActions = algebra.NewMergeActions(nil, nil, yyDollar[6].mergeInsert)
 		}
 	case 206:
-		//line n1ql.y:1553
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1542
 		{
-			yyVAL.statement = algebra.NewDropIndex(yyS[yypt-1].keyspaceRef, "#primary", yyS[yypt-0].indexType)
+			yyVAL.mergeInsert = nil
 		}
 	case 207:
-		//line n1ql.y:1558
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1547
 		{
-			yyVAL.statement = algebra.NewDropIndex(yyS[yypt-3].keyspaceRef, yyS[yypt-1].s, yyS[yypt-0].indexType)
+			yyVAL.mergeInsert = yyDollar[6].mergeInsert
 		}
 	case 208:
-		//line n1ql.y:1571
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1554
 		{
-			yyVAL.statement = algebra.NewAlterIndex(yyS[yypt-4].keyspaceRef, yyS[yypt-2].s, yyS[yypt-1].indexType, yyS[yypt-0].s)
+			yyVAL.mergeUpdate = algebra.NewMergeUpdate(yyDollar[1].set, nil, yyDollar[2].expr)
 		}
 	case 209:
-		//line n1ql.y:1577
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1559
 		{
-			yyVAL.s = ""
+			yyVAL.mergeUpdate = algebra.NewMergeUpdate(yyDollar[1].set, yyDollar[2].unset, yyDollar[3].expr)
 		}
 	case 210:
-		//line n1ql.y:1582
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1564
 		{
-			yyVAL.s = yyS[yypt-0].s
+			yyVAL.mergeUpdate = algebra.NewMergeUpdate(nil, yyDollar[1].unset, yyDollar[2].expr)
 		}
 	case 211:
-		//line n1ql.y:1595
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1571
 		{
-			yyVAL.statement = algebra.NewBuildIndexes(yyS[yypt-4].keyspaceRef, yyS[yypt-0].indexType, yyS[yypt-2].ss...)
+			yyVAL.mergeDelete = algebra.NewMergeDelete(yyDollar[1].expr)
 		}
 	case 212:
-		//line n1ql.y:1602
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1578
 		{
-			yyVAL.ss = []string{yyS[yypt-0].s}
+			yyVAL.mergeInsert = algebra.NewMergeInsert(yyDollar[1].expr, yyDollar[2].expr)
 		}
 	case 213:
-		//line n1ql.y:1607
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1592
 		{
-			yyVAL.ss = append(yyS[yypt-2].ss, yyS[yypt-0].s)
+			yyVAL.statement = algebra.NewCreatePrimaryIndex(yyDollar[4].s, yyDollar[6].keyspaceRef, yyDollar[7].indexType, yyDollar[8].val)
 		}
 	case 214:
-		//line n1ql.y:1621
+		yyDollar = yyS[yypt-12 : yypt+1]
+//line n1ql.y:1597
 		{
-			yyVAL.path = expression.NewIdentifier(yyS[yypt-0].s)
+			yyVAL.statement = algebra.NewCreateIndex(yyDollar[3].s, yyDollar[5].keyspaceRef, yyDollar[7].exprs, yyDollar[9].expr, yyDollar[10].expr, yyDollar[11].indexType, yyDollar[12].val)
 		}
 	case 215:
-		//line n1ql.y:1626
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1604
 		{
-			yyVAL.path = expression.NewField(yyS[yypt-2].path, expression.NewFieldName(yyS[yypt-0].s))
-		}
-	case 216:
-		//line n1ql.y:1631
-		{
-			field := expression.NewField(yyS[yypt-2].path, expression.NewFieldName(yyS[yypt-0].s))
-			field.SetCaseInsensitive(true)
-			yyVAL.path = field
+			yyVAL.s = "#primary"
 		}
-	case 217:
-		//line n1ql.y:1638
+	case 218:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1617
 		{
-			yyVAL.path = expression.NewElement(yyS[yypt-3].path, yyS[yypt-1].expr)
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef("", yyDollar[1].s, "")
 		}
-	case 218:
-		yyVAL.expr = yyS[yypt-0].expr
 	case 219:
-		//line n1ql.y:1655
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1622
 		{
-			yyVAL.expr = expression.NewField(yyS[yypt-2].expr, expression.NewFieldName(yyS[yypt-0].s))
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef(yyDollar[1].s, yyDollar[3].s, "")
 		}
 	case 220:
-		//line n1ql.y:1660
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1629
 		{
-			field := expression.NewField(yyS[yypt-2].expr, expression.NewFieldName(yyS[yypt-0].s))
-			field.SetCaseInsensitive(true)
-			yyVAL.expr = field
+			yyVAL.expr = nil
 		}
 	case 221:
-		//line n1ql.y:1667
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1634
 		{
-			yyVAL.expr = expression.NewField(yyS[yypt-4].expr, yyS[yypt-1].expr)
+			yyVAL.expr = yyDollar[3].expr
 		}
 	case 222:
-		//line n1ql.y:1672
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1641
 		{
-			field := expression.NewField(yyS[yypt-4].expr, yyS[yypt-1].expr)
-			field.SetCaseInsensitive(true)
-			yyVAL.expr = field
-		}
-	case 223:
-		//line n1ql.y:1679
-		{
-			yyVAL.expr = expression.NewElement(yyS[yypt-3].expr, yyS[yypt-1].expr)
+			yyVAL.indexType = datastore.DEFAULT
 		}
 	case 224:
-		//line n1ql.y:1684
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1650
 		{
-			yyVAL.expr = expression.NewSlice(yyS[yypt-4].expr, yyS[yypt-2].expr)
+			yyVAL.indexType = datastore.VIEW
 		}
 	case 225:
-		//line n1ql.y:1689
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1655
 		{
-			yyVAL.expr = expression.NewSlice(yyS[yypt-5].expr, yyS[yypt-3].expr, yyS[yypt-1].expr)
+			yyVAL.indexType = datastore.GSI
 		}
 	case 226:
-		//line n1ql.y:1695
-		{
-			yyVAL.expr = expression.NewAdd(yyS[yypt-2].expr, yyS[yypt-0].expr)
-		}
-	case 227:
-		//line n1ql.y:1700
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1662
 		{
-			yyVAL.expr = expression.NewSub(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.val = nil
 		}
 	case 228:
-		//line n1ql.y:1705
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1671
 		{
-			yyVAL.expr = expression.NewMult(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.val = yyDollar[2].expr.Value()
+			if yyVAL.val == nil {
+				yylex.Error("WITH value must be static.")
+			}
 		}
 	case 229:
-		//line n1ql.y:1710
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1681
 		{
-			yyVAL.expr = expression.NewDiv(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.exprs = expression.Expressions{yyDollar[1].expr}
 		}
 	case 230:
-		//line n1ql.y:1715
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1686
 		{
-			yyVAL.expr = expression.NewMod(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.exprs = append(yyDollar[1].exprs, yyDollar[3].expr)
 		}
 	case 231:
-		//line n1ql.y:1721
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1693
 		{
-			yyVAL.expr = expression.NewConcat(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			exp := yyDollar[1].expr
+			if !exp.Indexable() || exp.Value() != nil {
+				yylex.Error(fmt.Sprintf("Expression not indexable: %s", exp.String()))
+			}
+
+			yyVAL.expr = exp
 		}
 	case 232:
-		//line n1ql.y:1727
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1704
 		{
-			yyVAL.expr = expression.NewAnd(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = nil
 		}
 	case 233:
-		//line n1ql.y:1732
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:1709
 		{
-			yyVAL.expr = expression.NewOr(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = yyDollar[2].expr
 		}
 	case 234:
-		//line n1ql.y:1737
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1723
 		{
-			yyVAL.expr = expression.NewNot(yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewDropIndex(yyDollar[5].keyspaceRef, "#primary", yyDollar[6].indexType)
 		}
 	case 235:
-		//line n1ql.y:1743
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1728
 		{
-			yyVAL.expr = expression.NewEq(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewDropIndex(yyDollar[3].keyspaceRef, yyDollar[5].s, yyDollar[6].indexType)
 		}
 	case 236:
-		//line n1ql.y:1748
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line n1ql.y:1741
 		{
-			yyVAL.expr = expression.NewEq(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewAlterIndex(yyDollar[3].keyspaceRef, yyDollar[5].s, yyDollar[6].indexType, yyDollar[7].s)
 		}
 	case 237:
-		//line n1ql.y:1753
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1747
 		{
-			yyVAL.expr = expression.NewNE(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.s = ""
 		}
 	case 238:
-		//line n1ql.y:1758
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1752
 		{
-			yyVAL.expr = expression.NewLT(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.s = yyDollar[3].s
 		}
 	case 239:
-		//line n1ql.y:1763
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1765
 		{
-			yyVAL.expr = expression.NewGT(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewBuildIndexes(yyDollar[4].keyspaceRef, yyDollar[8].indexType, yyDollar[6].ss...)
 		}
 	case 240:
-		//line n1ql.y:1768
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1772
 		{
-			yyVAL.expr = expression.NewLE(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.ss = []string{yyDollar[1].s}
 		}
 	case 241:
-		//line n1ql.y:1773
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1777
 		{
-			yyVAL.expr = expression.NewGE(yyS[yypt-2].expr, yyS[yypt-0].expr)
-		}
-	case 242:
-		//line n1ql.y:1778
-		{
-			yyVAL.expr = expression.NewBetween(yyS[yypt-4].expr, yyS[yypt-2].expr, yyS[yypt-0].expr)
-		}
-	case 243:
-		//line n1ql.y:1783
-		{
-			yyVAL.expr = expression.NewNotBetween(yyS[yypt-5].expr, yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.ss = append(yyDollar[1].ss, yyDollar[3].s)
 		}
 	case 244:
-		//line n1ql.y:1788
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line n1ql.y:1797
 		{
-			yyVAL.expr = expression.NewLike(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewCreateFunction(yyDollar[3].s, yyDollar[5].ss, yyDollar[8].expr)
 		}
 	case 245:
-		//line n1ql.y:1793
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:1804
 		{
-			yyVAL.expr = expression.NewNotLike(yyS[yypt-3].expr, yyS[yypt-0].expr)
-		}
-	case 246:
-		//line n1ql.y:1798
-		{
-			yyVAL.expr = expression.NewIn(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.ss = nil
 		}
 	case 247:
-		//line n1ql.y:1803
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1813
 		{
-			yyVAL.expr = expression.NewNotIn(yyS[yypt-3].expr, yyS[yypt-0].expr)
+			yyVAL.ss = []string{yyDollar[1].s}
 		}
 	case 248:
-		//line n1ql.y:1808
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1818
 		{
-			yyVAL.expr = expression.NewWithin(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.ss = append(yyDollar[1].ss, yyDollar[3].s)
 		}
 	case 249:
-		//line n1ql.y:1813
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1832
 		{
-			yyVAL.expr = expression.NewNotWithin(yyS[yypt-3].expr, yyS[yypt-0].expr)
+			yyVAL.statement = algebra.NewDropFunction(yyDollar[3].s)
 		}
 	case 250:
-		//line n1ql.y:1818
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line n1ql.y:1846
 		{
-			yyVAL.expr = expression.NewIsNull(yyS[yypt-2].expr)
+			yyVAL.statement = algebra.NewUpdateStatistics(yyDollar[4].keyspaceRef, yyDollar[6].exprs)
 		}
 	case 251:
-		//line n1ql.y:1823
-		{
-			yyVAL.expr = expression.NewIsNotNull(yyS[yypt-3].expr)
-		}
-	case 252:
-		//line n1ql.y:1828
-		{
-			yyVAL.expr = expression.NewIsMissing(yyS[yypt-2].expr)
-		}
-	case 253:
-		//line n1ql.y:1833
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:1851
 		{
-			yyVAL.expr = expression.NewIsNotMissing(yyS[yypt-3].expr)
+			yyVAL.statement = algebra.NewUpdateStatistics(yyDollar[3].keyspaceRef, yyDollar[5].exprs)
 		}
 	case 254:
-		//line n1ql.y:1838
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1871
 		{
-			yyVAL.expr = expression.NewIsValued(yyS[yypt-2].expr)
+			role, _ := datastore.PrivilegeByName(yyDollar[3].s)
+			yyVAL.statement = algebra.NewGrantRole(role, yyDollar[5].keyspaceRef, yyDollar[8].ss)
 		}
 	case 255:
-		//line n1ql.y:1843
-		{
-			yyVAL.expr = expression.NewIsNotValued(yyS[yypt-3].expr)
-		}
-	case 256:
-		//line n1ql.y:1848
-		{
-			yyVAL.expr = expression.NewIsBoolean(yyS[yypt-2].expr)
-		}
-	case 257:
-		//line n1ql.y:1853
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:1879
 		{
-			yyVAL.expr = expression.NewNot(expression.NewIsBoolean(yyS[yypt-3].expr))
+			role, _ := datastore.PrivilegeByName(yyDollar[3].s)
+			yyVAL.statement = algebra.NewRevokeRole(role, yyDollar[5].keyspaceRef, yyDollar[8].ss)
 		}
 	case 258:
-		//line n1ql.y:1858
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1893
 		{
-			yyVAL.expr = expression.NewIsNumber(yyS[yypt-2].expr)
-		}
-	case 259:
-		//line n1ql.y:1863
-		{
-			yyVAL.expr = expression.NewNot(expression.NewIsNumber(yyS[yypt-3].expr))
+			if _, ok := datastore.PrivilegeByName(yyDollar[1].s); !ok {
+				yylex.Error(fmt.Sprintf("Invalid role %s.", yyDollar[1].s))
+			}
 		}
 	case 260:
-		//line n1ql.y:1868
-		{
-			yyVAL.expr = expression.NewIsString(yyS[yypt-2].expr)
-		}
-	case 261:
-		//line n1ql.y:1873
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1904
 		{
-			yyVAL.expr = expression.NewNot(expression.NewIsString(yyS[yypt-3].expr))
-		}
-	case 262:
-		//line n1ql.y:1878
-		{
-			yyVAL.expr = expression.NewIsArray(yyS[yypt-2].expr)
+			yyVAL.keyspaceRef = algebra.NewKeyspaceRef(yyDollar[1].s, "*", "")
 		}
 	case 263:
-		//line n1ql.y:1883
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1917
 		{
-			yyVAL.expr = expression.NewNot(expression.NewIsArray(yyS[yypt-3].expr))
+			yyVAL.ss = []string{yyDollar[1].s}
 		}
 	case 264:
-		//line n1ql.y:1888
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1922
 		{
-			yyVAL.expr = expression.NewIsObject(yyS[yypt-2].expr)
+			yyVAL.ss = append(yyDollar[1].ss, yyDollar[3].s)
 		}
 	case 265:
-		//line n1ql.y:1893
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:1936
 		{
-			yyVAL.expr = expression.NewNot(expression.NewIsObject(yyS[yypt-3].expr))
+			yyVAL.path = expression.NewIdentifier(yyDollar[1].s)
 		}
 	case 266:
-		//line n1ql.y:1898
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1941
 		{
-			yyVAL.expr = expression.NewIsBinary(yyS[yypt-2].expr)
+			yyVAL.path = expression.NewField(yyDollar[1].path, expression.NewFieldName(yyDollar[3].s))
 		}
 	case 267:
-		//line n1ql.y:1903
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1946
 		{
-			yyVAL.expr = expression.NewNot(expression.NewIsBinary(yyS[yypt-3].expr))
+			field := expression.NewField(yyDollar[1].path, expression.NewFieldName(yyDollar[3].s))
+			field.SetCaseInsensitive(true)
+			yyVAL.path = field
 		}
 	case 268:
-		//line n1ql.y:1908
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1953
 		{
-			yyVAL.expr = expression.NewExists(yyS[yypt-0].expr)
+			yyVAL.path = expression.NewElement(yyDollar[1].path, yyDollar[3].expr)
 		}
-	case 269:
-		yyVAL.expr = yyS[yypt-0].expr
 	case 270:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1970
+		{
+			yyVAL.expr = expression.NewField(yyDollar[1].expr, expression.NewFieldName(yyDollar[3].s))
+		}
 	case 271:
-		//line n1ql.y:1922
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:1975
 		{
-			yyVAL.expr = expression.NewIdentifier(yyS[yypt-0].s)
+			field := expression.NewField(yyDollar[1].expr, expression.NewFieldName(yyDollar[3].s))
+			field.SetCaseInsensitive(true)
+			yyVAL.expr = field
 		}
 	case 272:
-		//line n1ql.y:1928
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:1982
 		{
-			yyVAL.expr = expression.NewSelf()
+			yyVAL.expr = expression.NewField(yyDollar[1].expr, yyDollar[4].expr)
 		}
 	case 273:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:1987
+		{
+			field := expression.NewField(yyDollar[1].expr, yyDollar[4].expr)
+			field.SetCaseInsensitive(true)
+			yyVAL.expr = field
+		}
 	case 274:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:1994
+		{
+			yyVAL.expr = expression.NewElement(yyDollar[1].expr, yyDollar[3].expr)
+		}
 	case 275:
-		//line n1ql.y:1940
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:1999
 		{
-			yyVAL.expr = expression.NewNeg(yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewSlice(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 276:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:2004
+		{
+			yyVAL.expr = expression.NewSlice(yyDollar[1].expr, yyDollar[3].expr, yyDollar[5].expr)
+		}
 	case 277:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2010
+		{
+			yyVAL.expr = expression.NewAdd(yyDollar[1].expr, yyDollar[3].expr)
+		}
 	case 278:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2015
+		{
+			yyVAL.expr = expression.NewSub(yyDollar[1].expr, yyDollar[3].expr)
+		}
 	case 279:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2020
+		{
+			yyVAL.expr = expression.NewMult(yyDollar[1].expr, yyDollar[3].expr)
+		}
 	case 280:
-		//line n1ql.y:1959
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2025
 		{
-			yyVAL.expr = expression.NewField(yyS[yypt-2].expr, expression.NewFieldName(yyS[yypt-0].s))
+			yyVAL.expr = expression.NewDiv(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 281:
-		//line n1ql.y:1964
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2030
 		{
-			field := expression.NewField(yyS[yypt-2].expr, expression.NewFieldName(yyS[yypt-0].s))
-			field.SetCaseInsensitive(true)
-			yyVAL.expr = field
+			yyVAL.expr = expression.NewMod(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 282:
-		//line n1ql.y:1971
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2036
 		{
-			yyVAL.expr = expression.NewField(yyS[yypt-4].expr, yyS[yypt-1].expr)
+			yyVAL.expr = expression.NewConcat(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 283:
-		//line n1ql.y:1976
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2042
 		{
-			field := expression.NewField(yyS[yypt-4].expr, yyS[yypt-1].expr)
-			field.SetCaseInsensitive(true)
-			yyVAL.expr = field
+			yyVAL.expr = expression.NewAnd(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 284:
-		//line n1ql.y:1983
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2047
 		{
-			yyVAL.expr = expression.NewElement(yyS[yypt-3].expr, yyS[yypt-1].expr)
+			yyVAL.expr = expression.NewOr(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 285:
-		//line n1ql.y:1988
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2052
 		{
-			yyVAL.expr = expression.NewSlice(yyS[yypt-4].expr, yyS[yypt-2].expr)
+			yyVAL.expr = expression.NewNot(yyDollar[2].expr)
 		}
 	case 286:
-		//line n1ql.y:1993
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2058
 		{
-			yyVAL.expr = expression.NewSlice(yyS[yypt-5].expr, yyS[yypt-3].expr, yyS[yypt-1].expr)
+			yyVAL.expr = expression.NewEq(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 287:
-		//line n1ql.y:1999
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2063
 		{
-			yyVAL.expr = expression.NewAdd(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewEq(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 288:
-		//line n1ql.y:2004
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2068
 		{
-			yyVAL.expr = expression.NewSub(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewNE(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 289:
-		//line n1ql.y:2009
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2073
 		{
-			yyVAL.expr = expression.NewMult(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewLT(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 290:
-		//line n1ql.y:2014
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2078
 		{
-			yyVAL.expr = expression.NewDiv(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewGT(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 291:
-		//line n1ql.y:2019
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2083
 		{
-			yyVAL.expr = expression.NewMod(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewLE(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 292:
-		//line n1ql.y:2025
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2088
 		{
-			yyVAL.expr = expression.NewConcat(yyS[yypt-2].expr, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewGE(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 293:
-		//line n1ql.y:2039
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2093
 		{
-			yyVAL.expr = expression.NULL_EXPR
+			yyVAL.expr = expression.NewBetween(yyDollar[1].expr, yyDollar[3].expr, yyDollar[5].expr)
 		}
 	case 294:
-		//line n1ql.y:2044
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:2098
 		{
-			yyVAL.expr = expression.MISSING_EXPR
+			yyVAL.expr = expression.NewNotBetween(yyDollar[1].expr, yyDollar[4].expr, yyDollar[6].expr)
 		}
 	case 295:
-		//line n1ql.y:2049
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2103
 		{
-			yyVAL.expr = expression.FALSE_EXPR
+			yyVAL.expr = expression.NewLike(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 296:
-		//line n1ql.y:2054
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2108
 		{
-			yyVAL.expr = expression.TRUE_EXPR
+			yyVAL.expr = expression.NewNotLike(yyDollar[1].expr, yyDollar[4].expr)
 		}
 	case 297:
-		//line n1ql.y:2059
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2113
 		{
-			yyVAL.expr = expression.NewConstant(value.NewValue(yyS[yypt-0].f))
+			yyVAL.expr = expression.NewIn(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 298:
-		//line n1ql.y:2064
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2118
 		{
-			yyVAL.expr = expression.NewConstant(value.NewValue(yyS[yypt-0].n))
+			yyVAL.expr = expression.NewNotIn(yyDollar[1].expr, yyDollar[4].expr)
 		}
 	case 299:
-		//line n1ql.y:2069
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2123
 		{
-			yyVAL.expr = expression.NewConstant(value.NewValue(yyS[yypt-0].s))
+			yyVAL.expr = expression.NewWithin(yyDollar[1].expr, yyDollar[3].expr)
 		}
 	case 300:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2128
+		{
+			yyVAL.expr = expression.NewNotWithin(yyDollar[1].expr, yyDollar[4].expr)
+		}
 	case 301:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2133
+		{
+			yyVAL.expr = expression.NewIsNull(yyDollar[1].expr)
+		}
 	case 302:
-		//line n1ql.y:2089
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2138
 		{
-			yyVAL.expr = expression.NewObjectConstruct(yyS[yypt-1].bindings)
+			yyVAL.expr = expression.NewIsNotNull(yyDollar[1].expr)
 		}
 	case 303:
-		//line n1ql.y:2096
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2143
 		{
-			yyVAL.bindings = nil
+			yyVAL.expr = expression.NewIsMissing(yyDollar[1].expr)
 		}
 	case 304:
-		yyVAL.bindings = yyS[yypt-0].bindings
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2148
+		{
+			yyVAL.expr = expression.NewIsNotMissing(yyDollar[1].expr)
+		}
 	case 305:
-		//line n1ql.y:2105
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2153
 		{
-			yyVAL.bindings = expression.Bindings{yyS[yypt-0].binding}
+			yyVAL.expr = expression.NewIsValued(yyDollar[1].expr)
 		}
 	case 306:
-		//line n1ql.y:2110
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2158
 		{
-			yyVAL.bindings = append(yyS[yypt-2].bindings, yyS[yypt-0].binding)
+			yyVAL.expr = expression.NewIsNotValued(yyDollar[1].expr)
 		}
 	case 307:
-		//line n1ql.y:2117
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2163
 		{
-			yyVAL.binding = expression.NewBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewIsBoolean(yyDollar[1].expr)
 		}
 	case 308:
-		//line n1ql.y:2124
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2168
 		{
-			yyVAL.expr = expression.NewArrayConstruct(yyS[yypt-1].exprs...)
+			yyVAL.expr = expression.NewNot(expression.NewIsBoolean(yyDollar[1].expr))
 		}
 	case 309:
-		//line n1ql.y:2131
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2173
 		{
-			yyVAL.exprs = nil
+			yyVAL.expr = expression.NewIsNumber(yyDollar[1].expr)
 		}
 	case 310:
-		yyVAL.exprs = yyS[yypt-0].exprs
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2178
+		{
+			yyVAL.expr = expression.NewNot(expression.NewIsNumber(yyDollar[1].expr))
+		}
 	case 311:
-		//line n1ql.y:2147
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2183
 		{
-			yyVAL.expr = algebra.NewNamedParameter(yyS[yypt-0].s)
+			yyVAL.expr = expression.NewIsString(yyDollar[1].expr)
 		}
 	case 312:
-		//line n1ql.y:2152
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2188
 		{
-			yyVAL.expr = algebra.NewPositionalParameter(yyS[yypt-0].n)
+			yyVAL.expr = expression.NewNot(expression.NewIsString(yyDollar[1].expr))
 		}
 	case 313:
-		//line n1ql.y:2157
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2193
 		{
-			n := yylex.(*lexer).nextParam()
-			yyVAL.expr = algebra.NewPositionalParameter(n)
+			yyVAL.expr = expression.NewIsArray(yyDollar[1].expr)
 		}
 	case 314:
-		//line n1ql.y:2172
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2198
 		{
-			yyVAL.expr = yyS[yypt-1].expr
+			yyVAL.expr = expression.NewNot(expression.NewIsArray(yyDollar[1].expr))
 		}
 	case 315:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2203
+		{
+			yyVAL.expr = expression.NewIsObject(yyDollar[1].expr)
+		}
 	case 316:
-		yyVAL.expr = yyS[yypt-0].expr
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2208
+		{
+			yyVAL.expr = expression.NewNot(expression.NewIsObject(yyDollar[1].expr))
+		}
 	case 317:
-		//line n1ql.y:2185
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2213
 		{
-			yyVAL.expr = expression.NewSimpleCase(yyS[yypt-2].expr, yyS[yypt-1].whenTerms, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewIsBinary(yyDollar[1].expr)
 		}
 	case 318:
-		//line n1ql.y:2192
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2218
 		{
-			yyVAL.whenTerms = expression.WhenTerms{&expression.WhenTerm{yyS[yypt-2].expr, yyS[yypt-0].expr}}
+			yyVAL.expr = expression.NewNot(expression.NewIsBinary(yyDollar[1].expr))
 		}
 	case 319:
-		//line n1ql.y:2197
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2223
+		{
+			yyVAL.expr = expression.NewExists(yyDollar[2].expr)
+		}
+	case 322:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2237
+		{
+			yyVAL.expr = expression.NewIdentifier(yyDollar[1].s)
+		}
+	case 323:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2243
+		{
+			yyVAL.expr = expression.NewSelf()
+		}
+	case 326:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2255
+		{
+			yyVAL.expr = expression.NewNeg(yyDollar[2].expr)
+		}
+	case 331:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2274
+		{
+			yyVAL.expr = expression.NewField(yyDollar[1].expr, expression.NewFieldName(yyDollar[3].s))
+		}
+	case 332:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2279
+		{
+			field := expression.NewField(yyDollar[1].expr, expression.NewFieldName(yyDollar[3].s))
+			field.SetCaseInsensitive(true)
+			yyVAL.expr = field
+		}
+	case 333:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2286
+		{
+			yyVAL.expr = expression.NewField(yyDollar[1].expr, yyDollar[4].expr)
+		}
+	case 334:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2291
+		{
+			field := expression.NewField(yyDollar[1].expr, yyDollar[4].expr)
+			field.SetCaseInsensitive(true)
+			yyVAL.expr = field
+		}
+	case 335:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2298
+		{
+			yyVAL.expr = expression.NewElement(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 336:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2303
+		{
+			yyVAL.expr = expression.NewSlice(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 337:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:2308
+		{
+			yyVAL.expr = expression.NewSlice(yyDollar[1].expr, yyDollar[3].expr, yyDollar[5].expr)
+		}
+	case 338:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2314
+		{
+			yyVAL.expr = expression.NewAdd(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 339:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2319
+		{
+			yyVAL.expr = expression.NewSub(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 340:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2324
+		{
+			yyVAL.expr = expression.NewMult(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 341:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2329
+		{
+			yyVAL.expr = expression.NewDiv(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 342:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2334
+		{
+			yyVAL.expr = expression.NewMod(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 343:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2340
+		{
+			yyVAL.expr = expression.NewConcat(yyDollar[1].expr, yyDollar[3].expr)
+		}
+	case 344:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2354
+		{
+			yyVAL.expr = expression.NULL_EXPR
+		}
+	case 345:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2359
+		{
+			yyVAL.expr = expression.MISSING_EXPR
+		}
+	case 346:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2364
+		{
+			yyVAL.expr = expression.FALSE_EXPR
+		}
+	case 347:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2369
 		{
-			yyVAL.whenTerms = append(yyS[yypt-4].whenTerms, &expression.WhenTerm{yyS[yypt-2].expr, yyS[yypt-0].expr})
+			yyVAL.expr = expression.TRUE_EXPR
+		}
+	case 348:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2374
+		{
+			yyVAL.expr = expression.NewConstant(value.NewValue(yyDollar[1].f))
 		}
-	case 320:
-		//line n1ql.y:2205
+	case 349:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2379
 		{
-			yyVAL.expr = expression.NewSearchedCase(yyS[yypt-1].whenTerms, yyS[yypt-0].expr)
+			yyVAL.expr = expression.NewConstant(value.NewValue(yyDollar[1].n))
 		}
-	case 321:
-		//line n1ql.y:2212
+	case 350:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2384
+		{
+			yyVAL.expr = expression.NewConstant(value.NewValue(yyDollar[1].s))
+		}
+	case 353:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2404
+		{
+			yyVAL.expr = expression.NewObjectConstruct(yyDollar[2].bindings)
+		}
+	case 354:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2411
+		{
+			yyVAL.bindings = nil
+		}
+	case 356:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2420
+		{
+			yyVAL.bindings = expression.Bindings{yyDollar[1].binding}
+		}
+	case 357:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2425
+		{
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
+		}
+	case 358:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2432
+		{
+			yyVAL.binding = expression.NewBinding(yyDollar[1].s, yyDollar[3].expr)
+		}
+	case 359:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2439
+		{
+			yyVAL.expr = expression.NewArrayConstruct(yyDollar[2].exprs...)
+		}
+	case 360:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2446
+		{
+			yyVAL.exprs = nil
+		}
+	case 362:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2462
+		{
+			yyVAL.expr = algebra.NewNamedParameter(yyDollar[1].s)
+		}
+	case 363:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2467
+		{
+			yyVAL.expr = algebra.NewPositionalParameter(yyDollar[1].n)
+		}
+	case 364:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2472
+		{
+			n := yylex.(*lexer).nextParam()
+			yyVAL.expr = algebra.NewPositionalParameter(n)
+		}
+	case 365:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2487
+		{
+			yyVAL.expr = yyDollar[2].expr
+		}
+	case 368:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2500
+		{
+			yyVAL.expr = expression.NewSimpleCase(yyDollar[1].expr, yyDollar[2].whenTerms, yyDollar[3].expr)
+		}
+	case 369:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2507
+		{
+			yyVAL.whenTerms = expression.WhenTerms{&expression.WhenTerm{yyDollar[2].expr, yyDollar[4].expr}}
+		}
+	case 370:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2512
+		{
+			yyVAL.whenTerms = append(yyDollar[1].whenTerms, &expression.WhenTerm{yyDollar[3].expr, yyDollar[5].expr})
+		}
+	case 371:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2520
+		{
+			yyVAL.expr = expression.NewSearchedCase(yyDollar[1].whenTerms, yyDollar[2].expr)
+		}
+	case 372:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2527
 		{
 			yyVAL.expr = nil
 		}
-	case 322:
-		//line n1ql.y:2217
+	case 373:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2532
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.expr = yyDollar[2].expr
 		}
-	case 323:
-		//line n1ql.y:2231
+	case 374:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2546
 		{
 			yyVAL.expr = nil
-			f, ok := expression.GetFunction(yyS[yypt-3].s)
+			f, ok := expression.GetFunction(yyDollar[1].s)
+			if !ok {
+				f, ok = expression.GetUserFunction(yyDollar[1].s)
+			}
+
 			if !ok && yylex.(*lexer).parsingStatement() {
-				f, ok = algebra.GetAggregate(yyS[yypt-3].s, false)
+				f, ok = algebra.GetAggregate(yyDollar[1].s, false)
 			}
 
 			if ok {
-				if len(yyS[yypt-1].exprs) < f.MinArgs() || len(yyS[yypt-1].exprs) > f.MaxArgs() {
-					yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", yyS[yypt-3].s))
+				if len(yyDollar[3].exprs) < f.MinArgs() || len(yyDollar[3].exprs) > f.MaxArgs() {
+					yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", yyDollar[1].s))
 				} else {
-					yyVAL.expr = f.Constructor()(yyS[yypt-1].exprs...)
+					yyVAL.expr = f.Constructor()(yyDollar[3].exprs...)
 				}
 			} else {
-				yylex.Error(fmt.Sprintf("Invalid function %s.", yyS[yypt-3].s))
+				yylex.Error(fmt.Sprintf("Invalid function %s.", yyDollar[1].s))
 			}
 		}
-	case 324:
-		//line n1ql.y:2250
+	case 375:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line n1ql.y:2569
 		{
 			yyVAL.expr = nil
 			if !yylex.(*lexer).parsingStatement() {
 				yylex.Error("Cannot use aggregate as an inline expression.")
 			} else {
-				agg, ok := algebra.GetAggregate(yyS[yypt-4].s, true)
+				agg, ok := algebra.GetAggregate(yyDollar[1].s, true)
 				if ok {
-					yyVAL.expr = agg.Constructor()(yyS[yypt-1].expr)
+					yyVAL.expr = agg.Constructor()(yyDollar[4].expr)
 				} else {
-					yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", yyS[yypt-4].s))
+					yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", yyDollar[1].s))
 				}
 			}
 		}
-	case 325:
-		//line n1ql.y:2265
+	case 376:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2584
 		{
 			yyVAL.expr = nil
 			if !yylex.(*lexer).parsingStatement() {
 				yylex.Error("Cannot use aggregate as an inline expression.")
 			} else {
-				if strings.ToLower(yyS[yypt-3].s) != "count" {
-					yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", yyS[yypt-3].s))
+				if strings.ToLower(yyDollar[1].s) != "count" {
+					yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", yyDollar[1].s))
 				} else {
-					agg, ok := algebra.GetAggregate(yyS[yypt-3].s, false)
+					agg, ok := algebra.GetAggregate(yyDollar[1].s, false)
 					if ok {
 						yyVAL.expr = agg.Constructor()(nil)
 					} else {
-						yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", yyS[yypt-3].s))
+						yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", yyDollar[1].s))
 					}
 				}
 			}
 		}
-	case 326:
-		yyVAL.s = yyS[yypt-0].s
-	case 327:
-		yyVAL.expr = yyS[yypt-0].expr
-	case 328:
-		yyVAL.expr = yyS[yypt-0].expr
-	case 329:
-		//line n1ql.y:2303
+	case 377:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:2603
 		{
-			yyVAL.expr = expression.NewAny(yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			yyVAL.expr = nil
+			if !yylex.(*lexer).parsingStatement() {
+				yylex.Error("Cannot use window function as an inline expression.")
+			} else if f, ok := algebra.GetWindowFunction(yyDollar[1].s); ok {
+				if len(yyDollar[3].exprs) < f.MinArgs() || len(yyDollar[3].exprs) > f.MaxArgs() {
+					yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", yyDollar[1].s))
+				} else {
+					yyVAL.expr = f.WindowConstructor()(yyDollar[7].windowTerm, yyDollar[3].exprs...)
+				}
+			} else if agg, ok := algebra.GetAggregate(yyDollar[1].s, false); ok {
+				if len(yyDollar[3].exprs) < agg.MinArgs() || len(yyDollar[3].exprs) > agg.MaxArgs() {
+					yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", yyDollar[1].s))
+				} else {
+					yyVAL.expr = algebra.NewWindowAggregate(agg.Constructor()(yyDollar[3].exprs...).(algebra.Aggregate), yyDollar[7].windowTerm)
+				}
+			} else {
+				yylex.Error(fmt.Sprintf("Invalid window function %s.", yyDollar[1].s))
+			}
 		}
-	case 330:
-		//line n1ql.y:2308
+	case 378:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line n1ql.y:2625
 		{
-			yyVAL.expr = expression.NewAny(yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			yyVAL.expr = nil
+			if !yylex.(*lexer).parsingStatement() {
+				yylex.Error("Cannot use window function as an inline expression.")
+			} else if strings.ToLower(yyDollar[1].s) != "count" {
+				yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", yyDollar[1].s))
+			} else {
+				agg, _ := algebra.GetAggregate(yyDollar[1].s, false)
+				yyVAL.expr = algebra.NewWindowAggregate(agg.Constructor()(nil).(algebra.Aggregate), yyDollar[7].windowTerm)
+			}
 		}
-	case 331:
-		//line n1ql.y:2313
+	case 380:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2651
 		{
-			yyVAL.expr = expression.NewEvery(yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			yyVAL.windowTerm = algebra.NewWindowTerm(yyDollar[1].exprs, yyDollar[2].sortTerms, yyDollar[3].windowFrame)
+			err := yyVAL.windowTerm.Validate()
+			if err != nil {
+				yylex.Error(err.Error())
+			}
 		}
-	case 332:
-		//line n1ql.y:2320
+	case 381:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2662
 		{
-			yyVAL.bindings = expression.Bindings{yyS[yypt-0].binding}
+			yyVAL.exprs = nil
 		}
-	case 333:
-		//line n1ql.y:2325
+	case 382:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2667
 		{
-			yyVAL.bindings = append(yyS[yypt-2].bindings, yyS[yypt-0].binding)
+			yyVAL.exprs = yyDollar[3].exprs
 		}
-	case 334:
-		//line n1ql.y:2332
+	case 383:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2674
 		{
-			yyVAL.binding = expression.NewBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.sortTerms = nil
 		}
-	case 335:
-		//line n1ql.y:2337
+	case 384:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2679
 		{
-			yyVAL.binding = expression.NewDescendantBinding(yyS[yypt-2].s, yyS[yypt-0].expr)
+			yyVAL.sortTerms = yyDollar[3].sortTerms
 		}
-	case 336:
-		//line n1ql.y:2344
+	case 385:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line n1ql.y:2691
 		{
-			yyVAL.expr = yyS[yypt-0].expr
+			yyVAL.windowFrame = nil
 		}
-	case 337:
-		//line n1ql.y:2351
+	case 386:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2696
 		{
-			yyVAL.expr = expression.NewArray(yyS[yypt-4].expr, yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			yyVAL.windowFrame = nil
+			switch strings.ToLower(yyDollar[1].s) {
+			case "rows":
+				yyVAL.windowFrame = algebra.NewWindowFrame(true, yyDollar[2].windowFrame.Start(), yyDollar[2].windowFrame.End())
+			case "range":
+				yyVAL.windowFrame = algebra.NewWindowFrame(false, yyDollar[2].windowFrame.Start(), yyDollar[2].windowFrame.End())
+			default:
+				yylex.Error(fmt.Sprintf("Invalid window frame %s; expected ROWS or RANGE.", yyDollar[1].s))
+			}
 		}
-	case 338:
-		//line n1ql.y:2356
+	case 387:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2711
 		{
-			yyVAL.expr = expression.NewFirst(yyS[yypt-4].expr, yyS[yypt-2].bindings, yyS[yypt-1].expr)
+			yyVAL.windowFrame = algebra.NewWindowFrame(false, yyDollar[1].frameBound, algebra.NewFrameBound(algebra.CURRENT_ROW, nil))
 		}
-	case 339:
-		//line n1ql.y:2370
+	case 388:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2716
 		{
-			yyVAL.expr = yyS[yypt-1].expr
+			yyVAL.windowFrame = algebra.NewWindowFrame(false, yyDollar[2].frameBound, yyDollar[4].frameBound)
 		}
-	case 340:
-		yyVAL.expr = yyS[yypt-0].expr
-	case 341:
-		//line n1ql.y:2379
+	case 389:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2723
+		{
+			yyVAL.frameBound = nil
+			word := ""
+			if ident, ok := yyDollar[1].expr.(*expression.Identifier); ok {
+				word = strings.ToLower(ident.Identifier())
+			}
+
+			switch word + " " + strings.ToLower(yyDollar[2].s) {
+			case "unbounded preceding":
+				yyVAL.frameBound = algebra.NewFrameBound(algebra.UNBOUNDED_PRECEDING, nil)
+			case "unbounded following":
+				yyVAL.frameBound = algebra.NewFrameBound(algebra.UNBOUNDED_FOLLOWING, nil)
+			case "current row":
+				yyVAL.frameBound = algebra.NewFrameBound(algebra.CURRENT_ROW, nil)
+			default:
+				switch strings.ToLower(yyDollar[2].s) {
+				case "preceding":
+					yyVAL.frameBound = algebra.NewFrameBound(algebra.PRECEDING, yyDollar[1].expr)
+				case "following":
+					yyVAL.frameBound = algebra.NewFrameBound(algebra.FOLLOWING, yyDollar[1].expr)
+				default:
+					yylex.Error(fmt.Sprintf("Invalid window frame bound %s %s.", yyDollar[1].expr, yyDollar[2].s))
+				}
+			}
+		}
+	case 392:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2765
+		{
+			yyVAL.expr = expression.NewAny(yyDollar[2].bindings, yyDollar[3].expr)
+		}
+	case 393:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2770
+		{
+			yyVAL.expr = expression.NewAny(yyDollar[2].bindings, yyDollar[3].expr)
+		}
+	case 394:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line n1ql.y:2775
+		{
+			yyVAL.expr = expression.NewEvery(yyDollar[2].bindings, yyDollar[3].expr)
+		}
+	case 395:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2782
+		{
+			yyVAL.bindings = expression.Bindings{yyDollar[1].binding}
+		}
+	case 396:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2787
+		{
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
+		}
+	case 397:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2794
+		{
+			yyVAL.binding = expression.NewBinding(yyDollar[1].s, yyDollar[3].expr)
+		}
+	case 398:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2799
+		{
+			yyVAL.binding = expression.NewDescendantBinding(yyDollar[1].s, yyDollar[3].expr)
+		}
+	case 399:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line n1ql.y:2806
+		{
+			yyVAL.expr = yyDollar[2].expr
+		}
+	case 400:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:2813
+		{
+			yyVAL.expr = expression.NewArray(yyDollar[2].expr, yyDollar[4].bindings, yyDollar[5].expr)
+		}
+	case 401:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line n1ql.y:2818
+		{
+			yyVAL.expr = expression.NewFirst(yyDollar[2].expr, yyDollar[4].bindings, yyDollar[5].expr)
+		}
+	case 402:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line n1ql.y:2832
+		{
+			yyVAL.expr = yyDollar[2].expr
+		}
+	case 404:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line n1ql.y:2841
 		{
 			yyVAL.expr = nil
 			if yylex.(*lexer).parsingStatement() {
-				yyVAL.expr = algebra.NewSubquery(yyS[yypt-0].fullselect)
+				yyVAL.expr = algebra.NewSubquery(yyDollar[1].fullselect)
 			} else {
 				yylex.Error("Cannot use subquery as an inline expression.")
 			}
@@ -50,12 +50,14 @@ type builder struct {
 	systemstore     datastore.Datastore
 	namespace       string
 	subquery        bool
-	delayProjection bool                  // Used to allow ORDER BY non-projected expressions
-	where           expression.Expression // Used for index selection
-	order           *algebra.Order        // Used to collect aggregates from ORDER BY
+	delayProjection bool                   // Used to allow ORDER BY non-projected expressions
+	where           expression.Expression  // Used for index selection
+	cover           expression.Expressions // Used for covering index selection
+	order           *algebra.Order         // Used to collect aggregates from ORDER BY
 	distinct        bool
 	children        []Operator
 	subChildren     []Operator
+	counts          map[string]float64 // Keyspace counts, negative if not known
 }
 
 func newBuilder(datastore, systemstore datastore.Datastore, namespace string, subquery bool) *builder {
@@ -0,0 +1,168 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+)
+
+/*
+Returns the expressions that an index must cover for the subselect
+to be answered from the index alone, including those of the ORDER BY
+of the enclosing select. Returns nil if no index can cover the
+subselect: only a single keyspace without a projection can be
+covered, and not by a SELECT *.
+*/
+func coverExpressions(node *algebra.Subselect, order *algebra.Order) expression.Expressions {
+	term, ok := node.From().(*algebra.KeyspaceTerm)
+	if !ok || term.Projection() != nil || term.Keys() != nil {
+		return nil
+	}
+
+	for _, result := range node.Projection().Terms() {
+		if result.Expression() == nil {
+			return nil
+		}
+	}
+
+	exprs := node.Expressions()
+	if order != nil {
+		exprs = append(exprs, order.Expressions()...)
+	}
+
+	return exprs
+}
+
+/*
+Skips the Fetch after an index scan if the keys of the index cover
+every expression of the query. The index keys become the covers of
+the scan; a primary index covers META().id alone.
+*/
+func (this *builder) coverScan(scan Operator, node *algebra.KeyspaceTerm) bool {
+	if this.cover == nil {
+		return false
+	}
+
+	if union, ok := scan.(*UnionScan); ok && len(union.Scans()) == 1 {
+		scan = union.Scans()[0]
+	}
+
+	indexScan, ok := scan.(*IndexScan)
+	if !ok {
+		return false
+	}
+
+	alias := node.Alias()
+	formalizer := expression.NewFormalizer()
+	formalizer.Keyspace = alias
+
+	rangeKey := indexScan.Index().RangeKey()
+	covers := make(expression.Expressions, 0, len(rangeKey)+1)
+
+	if len(rangeKey) == 0 || rangeKey[0] == nil {
+		// Primary index
+		covers = append(covers, expression.NewField(
+			expression.NewMeta(expression.NewIdentifier(alias)),
+			expression.NewFieldName("id")))
+	} else {
+		for _, key := range rangeKey {
+			if key == nil {
+				break
+			}
+
+			key, err := formalizer.Map(key.Copy())
+			if err != nil {
+				return false
+			}
+
+			covers = append(covers, key)
+		}
+	}
+
+	for _, expr := range this.cover {
+		if !covered(expr, covers, alias) {
+			return false
+		}
+	}
+
+	indexScan.SetCovers(covers)
+	return true
+}
+
+/*
+Returns true if every reference to the keyspace within the expression
+is to META().id, or within an index key that is a path. Subqueries
+are never covered, as they may be correlated.
+*/
+func covered(expr expression.Expression, covers expression.Expressions, alias string) bool {
+	switch expr := expr.(type) {
+	case *algebra.Subquery:
+		return false
+	case *expression.Identifier:
+		return expr.Identifier() != alias
+	case *expression.Field:
+		if _, ok := expr.First().(*expression.Meta); ok {
+			name := expr.Second().Value()
+			return name != nil && name.Actual() == "id"
+		}
+	case *expression.Meta:
+		return false
+	}
+
+	for _, cover := range covers {
+		if CoverPath(cover, alias) != nil && expr.EquivalentTo(cover) {
+			return true
+		}
+	}
+
+	for _, child := range expr.Children() {
+		if !covered(child, covers, alias) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Returns the field names of a cover that is a path within the
+keyspace, such as alias.a.b, or nil if the cover is not such a path.
+Covering scans rebuild each document from these paths.
+*/
+func CoverPath(cover expression.Expression, alias string) []string {
+	switch cover := cover.(type) {
+	case *expression.Identifier:
+		if cover.Identifier() == alias {
+			return []string{}
+		}
+	case *expression.Field:
+		if cover.CaseInsensitive() {
+			return nil
+		}
+
+		name := cover.Second().Value()
+		if name == nil {
+			return nil
+		}
+
+		field, ok := name.Actual().(string)
+		if !ok {
+			return nil
+		}
+
+		path := CoverPath(cover.First(), alias)
+		if path != nil {
+			return append(path, field)
+		}
+	}
+
+	return nil
+}
@@ -17,7 +17,11 @@ func (this *builder) VisitDelete(stmt *algebra.Delete) (interface{}, error) {
 	this.where = stmt.Where()
 
 	ksref := stmt.KeyspaceRef()
-	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	ksref.SetDefaultNamespace(this.namespace)
+
+	// System keyspaces may support deletes
+	term := algebra.NewKeyspaceTerm(ksref.Namespace(), ksref.Keyspace(), nil, ksref.As(), nil)
+	keyspace, err := this.getTermKeyspace(term)
 	if err != nil {
 		return nil, err
 	}
@@ -9,7 +9,11 @@
 
 package plan
 
-import "github.com/couchbase/query/algebra"
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
 
 func (this *builder) VisitExecute(stmt *algebra.Execute) (interface{}, error) {
 
@@ -23,10 +27,15 @@ func (this *builder) VisitExecute(stmt *algebra.Execute) (interface{}, error) {
 	}
 	if prepared != nil {
 		return prepared, nil
-	} else {
-		prepared = &Prepared{}
 	}
 
+	// a name must refer to a cached plan.Prepared
+	if prepared_object.Type() == value.STRING {
+		return nil, errors.NewNoSuchPreparedError(prepared_object.Actual().(string))
+	}
+
+	prepared = &Prepared{}
+
 	// no cached plan.Prepared => create it
 	op_bytes, err := prepared_object.MarshalJSON()
 	if err != nil {
@@ -0,0 +1,287 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/planner"
+)
+
+/*
+Plan an ANSI join, JOIN ... ON onclause. The equality terms of the
+onclause that compare an expression on the right keyspace with an
+expression on the left terms are the join keys. If an online index
+of the right keyspace leads with join keys, the join scans that index
+for each left item. Otherwise the right keyspace is read once into a
+hash table. In both cases the whole onclause is then evaluated on
+each joined item.
+*/
+func (this *builder) buildAnsiJoin(keyspace datastore.Keyspace, node *algebra.Join) (Operator, error) {
+	right := node.Right()
+	alias := right.Alias()
+
+	onclause, err := planner.NewNNF().Map(node.Onclause().Copy())
+	if err != nil {
+		return nil, err
+	}
+
+	rightExprs, leftExprs := joinKeys(onclause, alias)
+
+	index, keys, err := this.selectJoinIndex(keyspace, right, onclause, rightExprs, leftExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	if index != nil {
+		return NewIndexJoin(keyspace, node, index, keys), nil
+	}
+
+	if this.subquery {
+		return nil, errors.NewError(nil, fmt.Sprintf(
+			"JOIN in subquery must use ON KEYS or an index: JOIN %s.",
+			right.Keyspace()))
+	}
+
+	scan, err := this.selectPrimaryScan(keyspace, right)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := scan.(*PrimaryScan).Index()
+	return NewHashJoin(keyspace, node, primary, rightExprs, leftExprs), nil
+}
+
+/*
+Returns the pairs of expressions that the onclause requires to be
+equal, where the first refers only to the right keyspace and the
+second does not refer to it.
+*/
+func joinKeys(onclause expression.Expression, alias string) (rightExprs, leftExprs expression.Expressions) {
+	for _, term := range conjuncts(onclause) {
+		eq, ok := term.(*expression.Eq)
+		if !ok {
+			continue
+		}
+
+		first, second := eq.First(), eq.Second()
+		if refersOnlyTo(second, alias) {
+			first, second = second, first
+		}
+
+		if !refersOnlyTo(first, alias) || refersTo(second, alias) {
+			continue
+		}
+
+		rightExprs = append(rightExprs, first)
+		leftExprs = append(leftExprs, second)
+	}
+
+	return
+}
+
+/*
+Choose the online index of the right keyspace whose leading keys
+match the most join keys. The primary index matches a join key on
+meta().id. Returns the chosen index and the left expressions that
+give the value of each matched index key.
+*/
+func (this *builder) selectJoinIndex(keyspace datastore.Keyspace, right *algebra.KeyspaceTerm,
+	onclause expression.Expression, rightExprs, leftExprs expression.Expressions) (
+	datastore.Index, expression.Expressions, error) {
+	if len(rightExprs) == 0 {
+		return nil, nil, nil
+	}
+
+	indexers, err := keyspace.Indexers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nnf := planner.NewNNF()
+	formalizer := expression.NewFormalizer()
+	formalizer.Keyspace = right.Alias()
+	primaryKey := expression.NewField(
+		expression.NewMeta(expression.NewIdentifier(right.Alias())),
+		expression.NewFieldName("id"))
+
+	var best datastore.Index
+	var bestKeys expression.Expressions
+
+	for _, indexer := range indexers {
+		indexes, err := indexer.Indexes()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		primaries, err := indexer.PrimaryIndexes()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		primary := make(map[datastore.Index]bool, len(primaries))
+		for _, p := range primaries {
+			primary[p] = true
+		}
+
+		for _, index := range indexes {
+			state, _, er := index.State()
+			if er != nil {
+				return nil, nil, er
+			}
+
+			if state != datastore.ONLINE {
+				continue
+			}
+
+			rangeKey := expression.Expressions{primaryKey}
+			if !primary[index] {
+				exprs := append(expression.Expressions{index.Condition()}, index.RangeKey()...)
+				exprs, e := formalizeKeys(exprs, formalizer, nnf)
+				if e != nil {
+					return nil, nil, e
+				}
+
+				// The onclause must satisfy the index condition
+				if exprs[0] != nil && !planner.SubsetOf(onclause, exprs[0]) {
+					continue
+				}
+
+				rangeKey = exprs[1:]
+			}
+
+			keys := make(expression.Expressions, 0, len(rangeKey))
+			for _, key := range rangeKey {
+				if key == nil {
+					break
+				}
+
+				k := matchJoinKey(key, rightExprs, leftExprs)
+				if k == nil {
+					break
+				}
+
+				keys = append(keys, k)
+			}
+
+			if len(keys) == 0 {
+				continue
+			}
+
+			if best == nil || len(keys) > len(bestKeys) ||
+				(len(keys) == len(bestKeys) && index.Name() < best.Name()) {
+				best, bestKeys = index, keys
+			}
+		}
+	}
+
+	return best, bestKeys, nil
+}
+
+// Qualify and normalize index expressions. Nil expressions are kept.
+func formalizeKeys(exprs expression.Expressions, formalizer *expression.Formalizer,
+	nnf *planner.NNF) (expression.Expressions, error) {
+	rv := make(expression.Expressions, len(exprs))
+	for i, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+
+		expr, err := formalizer.Map(expr.Copy())
+		if err != nil {
+			return nil, err
+		}
+
+		rv[i], err = nnf.Map(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rv, nil
+}
+
+func matchJoinKey(key expression.Expression, rightExprs, leftExprs expression.Expressions) expression.Expression {
+	for i, expr := range rightExprs {
+		if key.EquivalentTo(expr) {
+			return leftExprs[i]
+		}
+	}
+
+	return nil
+}
+
+func conjuncts(expr expression.Expression) expression.Expressions {
+	and, ok := expr.(*expression.And)
+	if !ok {
+		return expression.Expressions{expr}
+	}
+
+	rv := make(expression.Expressions, 0, len(and.Operands()))
+	for _, op := range and.Operands() {
+		rv = append(rv, conjuncts(op)...)
+	}
+
+	return rv
+}
+
+/*
+Returns true if the expression refers to the alias. Subqueries are
+assumed to refer to every alias.
+*/
+func refersTo(expr expression.Expression, alias string) bool {
+	switch expr := expr.(type) {
+	case *expression.Identifier:
+		return expr.Alias() == alias
+	case expression.Subquery:
+		return true
+	}
+
+	for _, child := range expr.Children() {
+		if refersTo(child, alias) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Returns true if the expression refers to the alias and to no other
+identifier.
+*/
+func refersOnlyTo(expr expression.Expression, alias string) bool {
+	found := false
+
+	var walk func(expr expression.Expression) bool
+	walk = func(expr expression.Expression) bool {
+		switch expr := expr.(type) {
+		case *expression.Identifier:
+			found = found || expr.Alias() == alias
+			return expr.Alias() == alias
+		case expression.Subquery:
+			return false
+		}
+
+		for _, child := range expr.Children() {
+			if !walk(child) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return walk(expr) && found
+}
@@ -76,7 +76,7 @@ func (this *builder) VisitMerge(stmt *algebra.Merge) (interface{}, error) {
 			ops = append(ops, NewUnset(act.Unset()))
 		}
 
-		ops = append(ops, NewSendUpdate(keyspace, ksref.Alias(), stmt.Limit()))
+		ops = append(ops, NewSendUpdate(keyspace, ksref.Alias(), nil, stmt.Limit()))
 		update = NewSequence(ops...)
 	}
 
@@ -100,7 +100,7 @@ func (this *builder) VisitMerge(stmt *algebra.Merge) (interface{}, error) {
 			ops = append(ops, NewFilter(act.Where()))
 		}
 
-		ops = append(ops, NewSendInsert(keyspace, ksref.Alias(), stmt.Key(), nil, stmt.Limit()))
+		ops = append(ops, NewSendInsert(keyspace, ksref.Alias(), stmt.Key(), nil, nil, stmt.Limit()))
 		insert = NewSequence(ops...)
 	}
 
@@ -25,6 +25,10 @@ func (this *builder) beginMutate(keyspace datastore.Keyspace,
 
 	if keys != nil {
 		scan := NewKeyScan(keys)
+		if cardinality, ok := keyScanCardinality(keys); ok {
+			scan.SetEstimate(keyScanCost(cardinality), cardinality)
+		}
+
 		this.children = append(this.children, scan)
 	} else {
 		scan, err := this.selectScan(keyspace, term)
@@ -20,7 +20,11 @@ func (this *builder) VisitPrepare(stmt *algebra.Prepare) (interface{}, error) {
 		return nil, err
 	}
 
-	PreparedCache().AddPrepared(plan)
+	plan.SetName(stmt.Name())
+	err = PreparedCache().AddPrepared(plan)
+	if err != nil {
+		return nil, err
+	}
 
 	json_bytes, err := plan.MarshalJSON()
 	if err != nil {
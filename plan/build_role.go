@@ -0,0 +1,48 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+)
+
+func (this *builder) VisitGrantRole(stmt *algebra.GrantRole) (interface{}, error) {
+	err := this.checkRoleKeyspace(stmt.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGrantRole(stmt), nil
+}
+
+func (this *builder) VisitRevokeRole(stmt *algebra.RevokeRole) (interface{}, error) {
+	err := this.checkRoleKeyspace(stmt.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRevokeRole(stmt), nil
+}
+
+/*
+Roles are granted on keyspaces that need not exist yet, but never on
+the system keyspaces, which every user may read.
+*/
+func (this *builder) checkRoleKeyspace(ksref *algebra.KeyspaceRef) error {
+	ksref.SetDefaultNamespace(this.namespace)
+	if strings.ToLower(ksref.Namespace()) == "#system" {
+		return fmt.Errorf("Roles cannot be granted on system namespace.")
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ package plan
 import (
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/datastore"
@@ -32,12 +33,6 @@ func (this *builder) selectScan(keyspace datastore.Keyspace,
 		return nil, err
 	}
 
-	formalizer := expression.NewFormalizer()
-	formalizer.Keyspace = node.Alias()
-	primaryKey := expression.NewField(
-		expression.NewMeta(expression.NewConstant(node.Alias())),
-		expression.NewFieldName("id"))
-
 	indexers, err := keyspace.Indexers()
 	if err != nil {
 		return nil, err
@@ -64,8 +59,104 @@ func (this *builder) selectScan(keyspace datastore.Keyspace,
 		}
 	}
 
-	unfiltered := make(map[datastore.Index]expression.Expression, len(indexes))
-	filtered := make(map[datastore.Index]expression.Expression, len(indexes))
+	hints := node.Indexes()
+	hintIndexes, warnings := hintedIndexes(indexes, hints)
+
+	indexMap, err := sargableIndexes(hintIndexes, primaryIndexes, node, where, nnf)
+	if err != nil {
+		return nil, err
+	}
+
+	honored := len(hints) > 0 && len(indexMap) > 0
+	if len(hints) > 0 && len(indexMap) == 0 {
+		for _, index := range hintIndexes {
+			if primaryIndexes[index] {
+				// Scan the hinted primary index
+				return this.selectPrimaryScan(keyspace, node)
+			}
+		}
+
+		// Ignore the hint
+		warnings = append(warnings, hintWarning(node))
+		indexMap, err = sargableIndexes(indexes, primaryIndexes, node, where, nnf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(indexMap) == 0 {
+		return this.selectPrimaryScan(keyspace, node)
+	}
+
+	candidates := sargCandidates(keyspace, indexMap, where)
+
+	// The keyspace is only counted if the statistics of some index
+	// allow comparing its scan with the others and the primary scan
+	count, counted := 0.0, false
+	if candidates.anyEstimated() {
+		count, counted = this.countKeyspace(keyspace)
+	}
+
+	candidates = selectCandidates(candidates, count, counted)
+
+	cost, cardinality := candidates.estimate(count)
+	if counted && !honored && candidates.estimated() && cost > primaryScanCost(count) {
+		// Reading the whole keyspace is cheaper
+		primary, err := this.selectPrimaryScan(keyspace, node)
+		if err == nil {
+			return primary, nil
+		}
+	}
+
+	scans := make([]Operator, len(candidates))
+	for i, candidate := range candidates {
+		indexScan := NewIndexScan(candidate.index, node, candidate.spans, false, math.MaxInt64)
+		if counted {
+			indexScan.SetEstimate(candidates[i : i+1].estimate(count))
+		}
+
+		if i == 0 {
+			indexScan.SetWarnings(warnings)
+		}
+
+		var scan Operator = indexScan
+		if len(candidate.spans) > 1 {
+			// Use UnionScan to de-dup multiple spans
+			scan = NewUnionScan(scan)
+		}
+
+		scans[i] = scan
+	}
+
+	if len(scans) == 1 {
+		return scans[0], nil
+	}
+
+	intersect := NewIntersectScan(scans...)
+	if counted {
+		intersect.SetEstimate(cost, cardinality)
+	}
+
+	return intersect, nil
+}
+
+/*
+Returns the online indexes that are applicable to the WHERE clause,
+together with their formalized keys. Indexes whose condition is
+satisfied by the WHERE clause are preferred to those without a
+condition.
+*/
+func sargableIndexes(indexes []datastore.Index, primaryIndexes map[datastore.Index]bool,
+	node *algebra.KeyspaceTerm, where expression.Expression, nnf *planner.NNF) (
+	map[datastore.Index]expression.Expressions, error) {
+	formalizer := expression.NewFormalizer()
+	formalizer.Keyspace = node.Alias()
+	primaryKey := expression.NewField(
+		expression.NewMeta(expression.NewConstant(node.Alias())),
+		expression.NewFieldName("id"))
+
+	unfiltered := make(map[datastore.Index]expression.Expressions, len(indexes))
+	filtered := make(map[datastore.Index]expression.Expressions, len(indexes))
 
 	for _, index := range indexes {
 		state, _, er := index.State()
@@ -77,10 +168,11 @@ func (this *builder) selectScan(keyspace datastore.Keyspace,
 			continue
 		}
 
-		var key expression.Expression
+		var keys expression.Expressions
+		var err error
 
 		if primaryIndexes[index] {
-			key = primaryKey
+			keys = expression.Expressions{primaryKey}
 		} else {
 			rangeKey := index.RangeKey()
 			if len(rangeKey) == 0 || rangeKey[0] == nil {
@@ -88,27 +180,36 @@ func (this *builder) selectScan(keyspace datastore.Keyspace,
 				continue
 			}
 
-			key := rangeKey[0].Copy()
+			keys = make(expression.Expressions, 0, len(rangeKey))
+			for _, key := range rangeKey {
+				if key == nil {
+					break
+				}
 
-			key, err = formalizer.Map(key)
-			if err != nil {
-				return nil, err
-			}
+				key = key.Copy()
+
+				key, err = formalizer.Map(key)
+				if err != nil {
+					return nil, err
+				}
+
+				key, err = nnf.Map(key)
+				if err != nil {
+					return nil, err
+				}
 
-			key, err = nnf.Map(key)
-			if err != nil {
-				return nil, err
+				keys = append(keys, key)
 			}
 		}
 
-		if !planner.SargableFor(where, key) {
+		if !planner.SargableFor(where, keys[0]) {
 			// Index not applicable
 			continue
 		}
 
 		indexCond := index.Condition()
 		if indexCond == nil {
-			unfiltered[index] = key
+			unfiltered[index] = keys
 			continue
 		}
 
@@ -126,31 +227,285 @@ func (this *builder) selectScan(keyspace datastore.Keyspace,
 
 		if planner.SubsetOf(where, indexCond) {
 			// Index condition satisfies query condition
-			filtered[index] = key
-			break
+			filtered[index] = keys
 		}
 	}
 
-	var indexMap map[datastore.Index]expression.Expression
 	if len(filtered) > 0 {
-		indexMap = filtered
-	} else if len(unfiltered) > 0 {
-		indexMap = unfiltered
+		return filtered, nil
 	}
 
-	for index, key := range indexMap {
-		spans := planner.SargFor(where, key)
-		var scan Operator
-		scan = NewIndexScan(index, node, spans, false, math.MaxInt64)
-		if len(spans) > 1 {
-			// Use UnionScan to de-dup multiple spans
-			scan = NewUnionScan(scan)
+	return unfiltered, nil
+}
+
+/*
+Restricts the indexes to those named by the USE INDEX hint, if any.
+Returns a warning for each hinted index that does not exist.
+*/
+func hintedIndexes(indexes []datastore.Index, hints algebra.IndexRefs) (
+	[]datastore.Index, []string) {
+	if len(hints) == 0 {
+		return indexes, nil
+	}
+
+	var warnings []string
+	hinted := make([]datastore.Index, 0, len(hints))
+	for _, hint := range hints {
+		found := false
+		for _, index := range indexes {
+			if hint.Matches(index) {
+				hinted = append(hinted, index)
+				found = true
+			}
+		}
+
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("Index %s in USE INDEX hint not found.", hint))
+		}
+	}
+
+	return hinted, warnings
+}
+
+func hintWarning(node *algebra.KeyspaceTerm) string {
+	return fmt.Sprintf("USE INDEX (%s) on keyspace %s cannot be honored; no hinted index is applicable.",
+		node.Indexes(), node.Keyspace())
+}
+
+type indexCandidate struct {
+	index       datastore.Index
+	key         expression.Expression
+	spans       planner.Spans
+	cost        float64
+	sargKeys    int
+	cardinality float64
+	estimated   bool
+}
+
+type indexCandidates []*indexCandidate
+
+func (this indexCandidates) Len() int {
+	return len(this)
+}
+
+func (this indexCandidates) Less(i, j int) bool {
+	if this[i].cost != this[j].cost {
+		return this[i].cost < this[j].cost
+	}
+
+	if this[i].sargKeys != this[j].sargKeys {
+		return this[i].sargKeys > this[j].sargKeys
+	}
+
+	if this[i].index.Name() != this[j].index.Name() {
+		return this[i].index.Name() < this[j].index.Name()
+	}
+
+	return this[i].index.Id() < this[j].index.Id()
+}
+
+func (this indexCandidates) Swap(i, j int) {
+	this[i], this[j] = this[j], this[i]
+}
+
+/*
+Returns the estimated cost and cardinality of intersecting the
+candidates.
+*/
+func (this indexCandidates) estimate(count float64) (cost, cardinality float64) {
+	cardinalities := make([]float64, len(this))
+	for i, candidate := range this {
+		cardinalities[i] = candidate.cardinality
+	}
+
+	return indexScansCost(cardinalities, count)
+}
+
+/*
+Returns true if the cardinality of every candidate was estimated
+from index statistics.
+*/
+func (this indexCandidates) estimated() bool {
+	for _, candidate := range this {
+		if !candidate.estimated {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Returns true if the cardinality of any candidate was estimated from
+index statistics.
+*/
+func (this indexCandidates) anyEstimated() bool {
+	for _, candidate := range this {
+		if candidate.estimated {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Returns a candidate for each index, with the heuristic cost of its
+spans, and its cardinality if the index has statistics.
+*/
+func sargCandidates(keyspace datastore.Keyspace, indexMap map[datastore.Index]expression.Expressions,
+	where expression.Expression) indexCandidates {
+	candidates := make(indexCandidates, 0, len(indexMap))
+	for index, keys := range indexMap {
+		spans := planner.SargForKeys(where, keys)
+		candidate := &indexCandidate{
+			index:    index,
+			key:      keys[0],
+			spans:    spans,
+			cost:     spansCost(spans),
+			sargKeys: sargKeys(spans),
+		}
+
+		candidate.cardinality, candidate.estimated = spansCardinality(keyspace, index, spans)
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+/*
+Choose the indexes to scan. Candidates are ordered by span cost, then
+by the number of index keys used, then by name, so that the choice
+does not depend on map iteration order.
+If the index has statistics and the keyspace count is known, the span
+cost is the estimated fraction of the keyspace, on the same scale as
+the heuristic costs of spansCost(). Otherwise the cardinality is the
+same fraction of the keyspace, which is assumed to hold one document
+if its count is not known; the choice does not depend on the count.
+The cheapest candidate for each distinct key is retained; if more than
+one key is selective, the scans are intersected, as long as each scan
+reduces the estimated cost. Unselective candidates are only used when
+nothing better is available.
+*/
+func selectCandidates(candidates indexCandidates, count float64, counted bool) indexCandidates {
+	if !counted {
+		count = 1
+	}
+
+	for _, candidate := range candidates {
+		if !counted || !candidate.estimated {
+			candidate.cardinality = count * candidate.cost / _UNBOUNDED_COST
+			candidate.estimated = false
+		} else if count > 0 {
+			candidate.cost = _UNBOUNDED_COST * candidate.cardinality / count
+		}
+	}
+
+	sort.Sort(candidates)
+
+	keys := make(map[string]bool, len(candidates))
+	selected := make(indexCandidates, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.cost >= _UNBOUNDED_COST && len(selected) > 0 {
+			break
+		}
+
+		key := candidate.key.String()
+		if keys[key] {
+			continue
+		}
+
+		if len(selected) > 0 {
+			before, _ := selected.estimate(count)
+			after, _ := append(selected, candidate).estimate(count)
+			if after >= before {
+				continue
+			}
+		}
+
+		keys[key] = true
+		selected = append(selected, candidate)
+
+		if candidate.cost >= _UNBOUNDED_COST {
+			break
+		}
+	}
+
+	return selected
+}
+
+const (
+	_EQUALITY_COST  = 1
+	_BOUNDED_COST   = 2
+	_HALF_OPEN_COST = 4
+	_UNBOUNDED_COST = 8
+)
+
+/*
+Estimate the relative cost of scanning the given spans, in the
+absence of index statistics. Equality spans are cheapest, followed by
+ranges bounded on both sides, and then half-open ranges. Each leading
+equality on a composite key divides the cost of the trailing range.
+*/
+func spansCost(spans planner.Spans) float64 {
+	if len(spans) == 0 {
+		return _UNBOUNDED_COST
+	}
+
+	cost := 0.0
+	for _, span := range spans {
+		cost += spanCost(span)
+	}
+
+	if cost > _UNBOUNDED_COST {
+		cost = _UNBOUNDED_COST
+	}
+
+	return cost
+}
+
+func spanCost(span *planner.Span) float64 {
+	if span.Exact() {
+		return _EQUALITY_COST / math.Pow(_UNBOUNDED_COST, float64(sargKeys(planner.Spans{span})-1))
+	}
+
+	low := span.Range.Low
+	high := span.Range.High
+
+	prefix := 0
+	for prefix < len(low) && prefix < len(high) &&
+		low[prefix] != nil && low[prefix].EquivalentTo(high[prefix]) {
+		prefix++
+	}
+
+	factor := math.Pow(_UNBOUNDED_COST, float64(prefix))
+
+	switch {
+	case len(low) > prefix && len(high) > prefix:
+		return _BOUNDED_COST / factor
+	case len(low) > prefix || len(high) > prefix:
+		return _HALF_OPEN_COST / factor
+	default:
+		return _UNBOUNDED_COST / factor
+	}
+}
+
+/*
+Number of index keys constrained by the spans.
+*/
+func sargKeys(spans planner.Spans) int {
+	n := 0
+	for _, span := range spans {
+		if len(span.Range.Low) > n {
+			n = len(span.Range.Low)
 		}
 
-		return scan, err
+		if len(span.Range.High) > n {
+			n = len(span.Range.High)
+		}
 	}
 
-	return this.selectPrimaryScan(keyspace, node)
+	return n
 }
 
 func (this *builder) selectPrimaryScan(keyspace datastore.Keyspace,
@@ -160,7 +515,8 @@ func (this *builder) selectPrimaryScan(keyspace datastore.Keyspace,
 		return nil, err
 	}
 
-	var primary datastore.PrimaryIndex
+	var primary, online datastore.PrimaryIndex
+	hints := node.Indexes()
 
 	for _, indexer := range indexers {
 		indexes, err := indexer.PrimaryIndexes()
@@ -179,11 +535,26 @@ func (this *builder) selectPrimaryScan(keyspace datastore.Keyspace,
 				continue
 			}
 
-			scan := NewPrimaryScan(index, node)
-			return scan, nil
+			if online == nil || (hinted(index, hints) && !hinted(online, hints)) {
+				online = index
+			}
 		}
 	}
 
+	if online != nil {
+		scan := NewPrimaryScan(online, node)
+		if len(hints) > 0 && !hinted(online, hints) {
+			scan.SetWarnings([]string{hintWarning(node)})
+		}
+
+		// Only estimate the scan if its cost was compared with others
+		if count, ok := this.counts[keyspaceKey(keyspace)]; ok && count >= 0 {
+			scan.SetEstimate(primaryScanCost(count), count)
+		}
+
+		return scan, nil
+	}
+
 	if primary == nil {
 		return nil, fmt.Errorf(
 			"No primary index on keyspace %s. Use CREATE PRIMARY INDEX to create one.",
@@ -192,3 +563,41 @@ func (this *builder) selectPrimaryScan(keyspace datastore.Keyspace,
 
 	return nil, fmt.Errorf("Primary index %s not online.", primary.Name())
 }
+
+/*
+Returns the number of documents in the keyspace, or false if it is
+not known. Counting may be costly, so each keyspace is counted at most
+once per plan.
+*/
+func (this *builder) countKeyspace(keyspace datastore.Keyspace) (float64, bool) {
+	key := keyspaceKey(keyspace)
+	count, ok := this.counts[key]
+	if !ok {
+		count, ok = keyspaceCount(keyspace)
+		if !ok {
+			count = -1
+		}
+
+		if this.counts == nil {
+			this.counts = make(map[string]float64, 4)
+		}
+
+		this.counts[key] = count
+	}
+
+	return count, count >= 0
+}
+
+func keyspaceKey(keyspace datastore.Keyspace) string {
+	return keyspace.NamespaceId() + ":" + keyspace.Name()
+}
+
+func hinted(index datastore.Index, hints algebra.IndexRefs) bool {
+	for _, hint := range hints {
+		if hint.Matches(index) {
+			return true
+		}
+	}
+
+	return false
+}
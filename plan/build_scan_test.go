@@ -0,0 +1,550 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/file"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/value"
+)
+
+// countingStore counts the calls to Count() on its keyspaces, and
+// optionally hides the statistics of their secondary indexes.
+type countingStore struct {
+	datastore.Datastore
+	counts       *int
+	noStatistics bool
+}
+
+func (this *countingStore) NamespaceByName(name string) (datastore.Namespace, errors.Error) {
+	namespace, err := this.Datastore.NamespaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingNamespace{namespace, this}, nil
+}
+
+type countingNamespace struct {
+	datastore.Namespace
+	store *countingStore
+}
+
+func (this *countingNamespace) KeyspaceByName(name string) (datastore.Keyspace, errors.Error) {
+	keyspace, err := this.Namespace.KeyspaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingKeyspace{keyspace, this.store}, nil
+}
+
+type countingKeyspace struct {
+	datastore.Keyspace
+	store *countingStore
+}
+
+func (this *countingKeyspace) Count() (int64, errors.Error) {
+	*this.store.counts++
+	return this.Keyspace.Count()
+}
+
+func (this *countingKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	indexers, err := this.Keyspace.Indexers()
+	if err != nil || !this.store.noStatistics {
+		return indexers, err
+	}
+
+	rv := make([]datastore.Indexer, len(indexers))
+	for i, indexer := range indexers {
+		rv[i] = &noStatisticsIndexer{indexer}
+	}
+
+	return rv, nil
+}
+
+type noStatisticsIndexer struct {
+	datastore.Indexer
+}
+
+func (this *noStatisticsIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	indexes, err := this.Indexer.Indexes()
+	if err != nil {
+		return nil, err
+	}
+
+	primaryIndexes, err := this.Indexer.PrimaryIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	primary := make(map[datastore.Index]bool, len(primaryIndexes))
+	for _, index := range primaryIndexes {
+		primary[index] = true
+	}
+
+	rv := make([]datastore.Index, len(indexes))
+	for i, index := range indexes {
+		if primary[index] {
+			rv[i] = index
+		} else {
+			rv[i] = &noStatisticsIndex{index}
+		}
+	}
+
+	return rv, nil
+}
+
+type noStatisticsIndex struct {
+	datastore.Index
+}
+
+func (this *noStatisticsIndex) Statistics(span *datastore.Span) (datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+/*
+Creates a file datastore with a keyspace t of 100 documents, where a
+is i % 10, b is 1 for 10 of the documents and 0 for the others, and
+kind is "rare" for one document and "common" for the others. The
+keyspace has indexes on a, b and kind.
+*/
+func newScanTestStore(t *testing.T) (*countingStore, string) {
+	dir, er := ioutil.TempDir("", "build_scan")
+	if er != nil {
+		t.Fatalf("failed to create temp dir: %v", er)
+	}
+
+	ksdir := filepath.Join(dir, "default", "t")
+	if er = os.MkdirAll(ksdir, 0755); er != nil {
+		t.Fatalf("failed to create keyspace dir: %v", er)
+	}
+
+	for i := 0; i < 100; i++ {
+		b, kind := 0, "common"
+		if i/10 == 1 {
+			b = 1
+		}
+
+		if i == 0 {
+			kind = "rare"
+		}
+
+		doc := fmt.Sprintf(`{"a": %d, "b": %d, "kind": "%s"}`, i%10, b, kind)
+		name := filepath.Join(ksdir, fmt.Sprintf("k%03d.json", i))
+		if er = ioutil.WriteFile(name, []byte(doc), 0666); er != nil {
+			t.Fatalf("failed to write %s: %v", name, er)
+		}
+	}
+
+	store, err := file.NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, _ := store.NamespaceByName("default")
+	keyspace, err := namespace.KeyspaceByName("t")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	indexer, err := keyspace.Indexer(datastore.DEFAULT)
+	if err != nil {
+		t.Fatalf("failed to get indexer: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "kind"} {
+		_, err = indexer.CreateIndex(key+"_idx", nil,
+			expression.Expressions{expression.NewIdentifier(key)}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create index on %s: %v", key, err)
+		}
+	}
+
+	return &countingStore{store, new(int), false}, dir
+}
+
+/*
+Plans the statement, and returns the scans of the plan in pre-order.
+*/
+func planScans(t *testing.T, store datastore.Datastore, statement string) []map[string]interface{} {
+	stmt, err := n1ql.ParseStatement(statement)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", statement, err)
+	}
+
+	op, err := Build(stmt, store, nil, "default", false)
+	if err != nil {
+		t.Fatalf("failed to plan %s: %v", statement, err)
+	}
+
+	bytes, err := json.Marshal(op)
+	if err != nil {
+		t.Fatalf("failed to marshal plan of %s: %v", statement, err)
+	}
+
+	var plan interface{}
+	if err = json.Unmarshal(bytes, &plan); err != nil {
+		t.Fatalf("failed to unmarshal plan of %s: %v", statement, err)
+	}
+
+	var scans []map[string]interface{}
+	var walk func(interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			if name, ok := node["#operator"].(string); ok && strings.HasSuffix(name, "Scan") {
+				scans = append(scans, node)
+			}
+
+			for _, key := range []string{"child", "scan", "scans", "~child", "~children"} {
+				walk(node[key])
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+
+	walk(plan)
+	return scans
+}
+
+/*
+Returns the operator and index of each scan, such as IndexScan(a_idx).
+*/
+func scanNames(scans []map[string]interface{}) []string {
+	rv := make([]string, len(scans))
+	for i, scan := range scans {
+		rv[i] = scan["#operator"].(string)
+		if index, ok := scan["index"]; ok {
+			rv[i] += fmt.Sprintf("(%v)", index)
+		}
+	}
+
+	return rv
+}
+
+func TestSelectScanCost(t *testing.T) {
+	store, dir := newScanTestStore(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		statement string
+		scans     []string
+		counts    int
+	}{
+		// No candidates: the keyspace is not counted
+		{"SELECT * FROM t", []string{"PrimaryScan(#primary)"}, 0},
+		{"SELECT * FROM t WHERE c = 1", []string{"PrimaryScan(#primary)"}, 0},
+
+		// A selective equality uses the index; an unselective one the
+		// primary index, counting the keyspace only once
+		{"SELECT * FROM t WHERE kind = 'rare'", []string{"IndexScan(kind_idx)"}, 1},
+		{"SELECT * FROM t WHERE kind = 'common'", []string{"PrimaryScan(#primary)"}, 1},
+
+		// Two selective indexes are intersected; an unselective one is
+		// cut off, although the heuristic costs of both are the same
+		{"SELECT * FROM t WHERE a = 1 AND b = 1",
+			[]string{"IntersectScan", "IndexScan(a_idx)", "IndexScan(b_idx)"}, 1},
+		{"SELECT * FROM t WHERE a = 1 AND b = 0", []string{"IndexScan(a_idx)"}, 1},
+
+		// USE KEYS is estimated without counting the keyspace
+		{"SELECT * FROM t USE KEYS ['k001', 'k002']", []string{"KeyScan"}, 0},
+	}
+
+	for _, c := range cases {
+		*store.counts = 0
+		names := scanNames(planScans(t, store, c.statement))
+		if strings.Join(names, " ") != strings.Join(c.scans, " ") {
+			t.Errorf("%s: expected scans %v, got %v", c.statement, c.scans, names)
+		}
+
+		if *store.counts != c.counts {
+			t.Errorf("%s: expected %d counts of the keyspace, got %d",
+				c.statement, c.counts, *store.counts)
+		}
+	}
+}
+
+func TestSelectScanEstimate(t *testing.T) {
+	store, dir := newScanTestStore(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		statement   string
+		cost        float64
+		cardinality float64
+	}{
+		// One entry read from the index, and one document fetched
+		{"SELECT * FROM t WHERE kind = 'rare'", 6, 1},
+
+		// The primary scan reads and fetches every document
+		{"SELECT * FROM t WHERE kind = 'common'", 500, 100},
+
+		// Each index reads 10 entries; one document is in both
+		{"SELECT * FROM t WHERE a = 1 AND b = 1", 44, 1},
+
+		// Two keys are fetched
+		{"SELECT * FROM t USE KEYS ['k001', 'k002']", 8, 2},
+	}
+
+	for _, c := range cases {
+		scans := planScans(t, store, c.statement)
+		if len(scans) == 0 {
+			t.Errorf("%s: expected a scan", c.statement)
+			continue
+		}
+
+		cost, _ := scans[0]["cost"].(float64)
+		cardinality, _ := scans[0]["cardinality"].(float64)
+		if cost != c.cost || cardinality != c.cardinality {
+			t.Errorf("%s: expected cost %v and cardinality %v, got %v and %v",
+				c.statement, c.cost, c.cardinality, cost, cardinality)
+		}
+	}
+
+	// Without candidates to compare, the primary scan is not estimated
+	scans := planScans(t, store, "SELECT * FROM t")
+	if _, ok := scans[0]["cost"]; ok {
+		t.Errorf("expected no estimate of the primary scan, got %v", scans[0])
+	}
+}
+
+func TestSelectScanHistogram(t *testing.T) {
+	store, dir := newScanTestStore(t)
+	defer os.RemoveAll(dir)
+	store.noStatistics = true
+
+	// Without statistics, the index is used
+	statement := "SELECT * FROM t WHERE kind = 'common'"
+	names := scanNames(planScans(t, store, statement))
+	if strings.Join(names, " ") != "IndexScan(kind_idx)" {
+		t.Errorf("%s: expected an index scan without statistics, got %v", statement, names)
+	}
+
+	// The histogram of the leading key shows the value is unselective
+	sample := make(value.Values, 100)
+	for i := range sample {
+		sample[i] = value.NewValue("common")
+	}
+
+	sample[0] = value.NewValue("rare")
+	histogram := datastore.NewHistogram("default", "t", "`kind`", sample, 100, 32)
+	datastore.SetHistogram(histogram)
+
+	names = scanNames(planScans(t, store, statement))
+	if strings.Join(names, " ") != "PrimaryScan(#primary)" {
+		t.Errorf("%s: expected a primary scan with the histogram, got %v", statement, names)
+	}
+
+	statement = "SELECT * FROM t WHERE kind = 'rare'"
+	names = scanNames(planScans(t, store, statement))
+	if strings.Join(names, " ") != "IndexScan(kind_idx)" {
+		t.Errorf("%s: expected an index scan with the histogram, got %v", statement, names)
+	}
+}
+
+func TestSelectScanIntersect(t *testing.T) {
+	store, dir := newScanTestStore(t)
+	defer os.RemoveAll(dir)
+	store.noStatistics = true
+
+	cases := []struct {
+		statement string
+		scans     []string
+	}{
+		// Two equalities are intersected, in index name order
+		{"SELECT * FROM t WHERE a = 1 AND b = 1",
+			[]string{"IntersectScan", "IndexScan(a_idx)", "IndexScan(b_idx)"}},
+		{"SELECT * FROM t WHERE b = 1 AND a = 1",
+			[]string{"IntersectScan", "IndexScan(a_idx)", "IndexScan(b_idx)"}},
+
+		// So are two bounded ranges
+		{"SELECT * FROM t WHERE a >= 1 AND a <= 2 AND b >= 0 AND b <= 1",
+			[]string{"IntersectScan", "IndexScan(a_idx)", "IndexScan(b_idx)"}},
+
+		// A cheaper scan comes first, and a scan that does not reduce
+		// the cost is left out
+		{"SELECT * FROM t WHERE a > 1 AND kind = 'rare'", []string{"IndexScan(kind_idx)"}},
+		{"SELECT * FROM t WHERE a = 1 AND kind > 'a'", []string{"IndexScan(a_idx)"}},
+		{"SELECT * FROM t WHERE a IN [1, 2] AND b = 1", []string{"IndexScan(b_idx)"}},
+
+		// An unselective scan is only used on its own
+		{"SELECT * FROM t WHERE a IS NOT MISSING AND b = 1", []string{"IndexScan(b_idx)"}},
+	}
+
+	for _, c := range cases {
+		names := scanNames(planScans(t, store, c.statement))
+		if strings.Join(names, " ") != strings.Join(c.scans, " ") {
+			t.Errorf("%s: expected scans %v, got %v", c.statement, c.scans, names)
+		}
+	}
+}
+
+func TestSpansCost(t *testing.T) {
+	a := expression.NewIdentifier("a")
+	b := expression.NewIdentifier("b")
+
+	cases := []struct {
+		where    string
+		keys     expression.Expressions
+		cost     float64
+		sargKeys int
+	}{
+		{"a = 1", expression.Expressions{a}, _EQUALITY_COST, 1},
+		{"a IN [1, 2]", expression.Expressions{a}, 2 * _EQUALITY_COST, 1},
+		{"a >= 1 AND a <= 2", expression.Expressions{a}, _BOUNDED_COST, 1},
+		{"a > 1", expression.Expressions{a}, _HALF_OPEN_COST, 1},
+		{"a IS NOT NULL", expression.Expressions{a}, _HALF_OPEN_COST, 1},
+		{"b = 1", expression.Expressions{a}, _UNBOUNDED_COST, 0},
+
+		// Each leading equality divides the cost of the next key
+		{"a = 1 AND b = 2", expression.Expressions{a, b}, _EQUALITY_COST / 8.0, 2},
+		{"a = 1 AND b > 2", expression.Expressions{a, b}, _HALF_OPEN_COST / 8.0, 2},
+		{"a = 1", expression.Expressions{a, b}, _EQUALITY_COST, 1},
+
+		// The cost of many spans is capped
+		{"a IN [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]", expression.Expressions{a}, _UNBOUNDED_COST, 1},
+	}
+
+	for _, c := range cases {
+		where, err := n1ql.ParseExpression(c.where)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", c.where, err)
+		}
+
+		where, err = planner.NewNNF().Map(where)
+		if err != nil {
+			t.Fatalf("failed to normalize %s: %v", c.where, err)
+		}
+
+		spans := planner.SargForKeys(where, c.keys)
+		if cost := spansCost(spans); cost != c.cost {
+			t.Errorf("%s on %v: expected cost %v, got %v", c.where, c.keys, c.cost, cost)
+		}
+
+		if n := sargKeys(spans); n != c.sargKeys {
+			t.Errorf("%s on %v: expected %d keys, got %d", c.where, c.keys, c.sargKeys, n)
+		}
+	}
+}
+
+// namedIndex stands for an index in candidates that are not scanned.
+type namedIndex struct {
+	datastore.Index
+	name string
+}
+
+func (this *namedIndex) Name() string {
+	return this.name
+}
+
+func (this *namedIndex) Id() string {
+	return this.name
+}
+
+func TestSelectCandidates(t *testing.T) {
+	candidate := func(name, key string, cost float64, sargKeys int,
+		cardinality float64, estimated bool) *indexCandidate {
+		return &indexCandidate{
+			index:       &namedIndex{name: name},
+			key:         expression.NewIdentifier(key),
+			cost:        cost,
+			sargKeys:    sargKeys,
+			cardinality: cardinality,
+			estimated:   estimated,
+		}
+	}
+
+	cases := []struct {
+		comment    string
+		candidates indexCandidates
+		count      float64
+		counted    bool
+		selected   []string
+	}{
+		{"equal costs are ordered by name; a half-open range does not reduce the cost",
+			indexCandidates{
+				candidate("d_idx", "d", _UNBOUNDED_COST, 0, 0, false),
+				candidate("c_idx", "c", _HALF_OPEN_COST, 1, 0, false),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 0, false),
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 0, false),
+			}, 0, false, []string{"a_idx", "b_idx"}},
+
+		{"equal costs are ordered by the number of keys, and each key is scanned once",
+			indexCandidates{
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 0, false),
+				candidate("ab_idx", "a", _EQUALITY_COST, 2, 0, false),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 0, false),
+			}, 0, false, []string{"ab_idx", "b_idx"}},
+
+		{"an unbounded scan is chosen only if nothing else is available",
+			indexCandidates{
+				candidate("y_idx", "y", _UNBOUNDED_COST, 0, 0, false),
+				candidate("x_idx", "x", _UNBOUNDED_COST, 0, 0, false),
+			}, 0, false, []string{"x_idx"}},
+
+		{"estimated cardinalities order the candidates",
+			indexCandidates{
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 50, true),
+				candidate("b_idx", "b", _HALF_OPEN_COST, 1, 5, true),
+			}, 100, true, []string{"b_idx"}},
+
+		{"selective estimates are intersected",
+			indexCandidates{
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 10, true),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 10, true),
+			}, 100, true, []string{"a_idx", "b_idx"}},
+
+		{"an unselective estimate is left out",
+			indexCandidates{
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 10, true),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 90, true),
+			}, 100, true, []string{"a_idx"}},
+
+		{"without the keyspace count, estimates are ignored",
+			indexCandidates{
+				candidate("a_idx", "a", _HALF_OPEN_COST, 1, 1, true),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 50, true),
+			}, 0, false, []string{"b_idx"}},
+
+		{"candidates without estimates are costed as a fraction of the count",
+			indexCandidates{
+				candidate("a_idx", "a", _EQUALITY_COST, 1, 10, true),
+				candidate("b_idx", "b", _EQUALITY_COST, 1, 0, false),
+			}, 100, true, []string{"a_idx", "b_idx"}},
+	}
+
+	for _, c := range cases {
+		selected := selectCandidates(c.candidates, c.count, c.counted)
+		names := make([]string, len(selected))
+		for i, candidate := range selected {
+			names[i] = candidate.index.Name()
+		}
+
+		if strings.Join(names, " ") != strings.Join(c.selected, " ") {
+			t.Errorf("%s: expected %v, got %v", c.comment, c.selected, names)
+		}
+	}
+}
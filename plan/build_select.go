@@ -56,7 +56,17 @@ func (this *builder) VisitSelect(stmt *algebra.Select) (interface{}, error) {
 			}
 		}
 
-		children = append(children, NewOrder(order))
+		if _, ok := stmt.Subresult().(*algebra.Subselect); !ok {
+			// Window functions are computed by subselects
+			windows := make(map[string]algebra.WindowFunction)
+			collectWindows(windows, order.Expressions()...)
+			if len(windows) > 0 {
+				return nil, fmt.Errorf("Window functions not available for this ORDER BY.")
+			}
+		}
+
+		// With a LIMIT, the ORDER BY only keeps the top offset+limit items
+		children = append(children, NewOrder(order, offset, limit))
 	}
 
 	if offset != nil {
@@ -78,6 +88,7 @@ func (this *builder) VisitSelect(stmt *algebra.Select) (interface{}, error) {
 
 func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error) {
 	this.where = node.Where()
+	this.cover = coverExpressions(node, this.order)
 	this.children = make([]Operator, 0, 16)    // top-level children, executed sequentially
 	this.subChildren = make([]Operator, 0, 16) // sub-children, executed across data-parallel streams
 
@@ -100,6 +111,7 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 	}
 
 	aggs := make(map[string]algebra.Aggregate)
+	windows := make(map[string]algebra.WindowFunction)
 
 	if node.Let() != nil {
 		for _, binding := range node.Let() {
@@ -107,6 +119,11 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 			if len(aggs) > 0 {
 				return nil, fmt.Errorf("Aggregates not allowed in LET.")
 			}
+
+			collectWindows(windows, binding.Expression())
+			if len(windows) > 0 {
+				return nil, fmt.Errorf("Window functions not allowed in LET.")
+			}
 		}
 
 		this.subChildren = append(this.subChildren, NewLet(node.Let()))
@@ -118,6 +135,11 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 			return nil, fmt.Errorf("Aggregates not allowed in WHERE.")
 		}
 
+		collectWindows(windows, node.Where())
+		if len(windows) > 0 {
+			return nil, fmt.Errorf("Window functions not allowed in WHERE.")
+		}
+
 		this.subChildren = append(this.subChildren, NewFilter(node.Where()))
 	}
 
@@ -132,6 +154,34 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 	}
 
 	group := node.Group()
+	if group != nil {
+		collectWindows(windows, group.By()...)
+		for _, binding := range group.Letting() {
+			collectWindows(windows, binding.Expression())
+		}
+
+		if group.Having() != nil {
+			collectWindows(windows, group.Having())
+		}
+
+		if len(windows) > 0 {
+			return nil, fmt.Errorf("Window functions not allowed in GROUP BY, LETTING, or HAVING.")
+		}
+	}
+
+	// Check for window functions
+	if projection != nil {
+		for _, term := range projection.Terms() {
+			if term.Expression() != nil {
+				collectWindows(windows, term.Expression())
+			}
+		}
+	}
+
+	if this.order != nil {
+		collectWindows(windows, this.order.Expressions()...)
+	}
+
 	if this.order != nil && (group != nil || len(aggs) > 0) {
 		// Grouping -- include aggregates from ORDER BY
 		for _, term := range this.order.Terms() {
@@ -152,6 +202,13 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 		this.visitGroup(group, aggs)
 	}
 
+	if len(windows) > 0 {
+		err = this.visitWindows(windows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	this.subChildren = append(this.subChildren, NewInitialProject(projection))
 
 	// Initial DISTINCT (parallel)
@@ -214,6 +271,34 @@ func (this *builder) visitGroup(group *algebra.Group, aggs map[string]algebra.Ag
 	}
 }
 
+func (this *builder) visitWindows(windows map[string]algebra.WindowFunction) error {
+	windown := make(sort.StringSlice, 0, len(windows))
+	for n, fn := range windows {
+		nested := make(map[string]algebra.WindowFunction)
+		collectWindows(nested, fn.Children()...)
+		if len(nested) > 0 {
+			return fmt.Errorf("Window functions cannot be nested: %s.", n)
+		}
+
+		windown = append(windown, n)
+	}
+
+	windown.Sort()
+	windowv := make(algebra.WindowFunctions, len(windows))
+	for i, n := range windown {
+		windowv[i] = windows[n]
+	}
+
+	// Window functions see all the items, so they are computed serially
+	if len(this.subChildren) > 0 {
+		this.children = append(this.children, NewParallel(NewSequence(this.subChildren...)))
+	}
+
+	this.children = append(this.children, NewWindow(windowv))
+	this.subChildren = make([]Operator, 0, 4)
+	return nil
+}
+
 func (this *builder) VisitKeyspaceTerm(node *algebra.KeyspaceTerm) (interface{}, error) {
 	node.SetDefaultNamespace(this.namespace)
 	keyspace, err := this.getTermKeyspace(node)
@@ -223,6 +308,10 @@ func (this *builder) VisitKeyspaceTerm(node *algebra.KeyspaceTerm) (interface{},
 
 	if node.Keys() != nil {
 		scan := NewKeyScan(node.Keys())
+		if cardinality, ok := keyScanCardinality(node.Keys()); ok {
+			scan.SetEstimate(keyScanCost(cardinality), cardinality)
+		}
+
 		this.children = append(this.children, scan)
 	} else {
 		if this.subquery {
@@ -237,6 +326,11 @@ func (this *builder) VisitKeyspaceTerm(node *algebra.KeyspaceTerm) (interface{},
 		}
 
 		this.children = append(this.children, scan)
+
+		if this.coverScan(scan, node) {
+			// The index covers the query; skip the Fetch
+			return nil, nil
+		}
 	}
 
 	fetch := NewFetch(keyspace, node)
@@ -274,6 +368,16 @@ func (this *builder) VisitJoin(node *algebra.Join) (interface{}, error) {
 		return nil, err
 	}
 
+	if node.Onclause() != nil {
+		join, err := this.buildAnsiJoin(keyspace, node)
+		if err != nil {
+			return nil, err
+		}
+
+		this.subChildren = append(this.subChildren, join)
+		return nil, nil
+	}
+
 	join := NewJoin(keyspace, node)
 	this.subChildren = append(this.subChildren, join)
 	return nil, nil
@@ -461,6 +565,23 @@ func collectAggregates(aggs map[string]algebra.Aggregate, exprs ...expression.Ex
 	}
 }
 
+func collectWindows(windows map[string]algebra.WindowFunction, exprs ...expression.Expression) {
+	for _, expr := range exprs {
+		fn, ok := expr.(algebra.WindowFunction)
+		if ok {
+			windows[fn.String()] = fn
+		}
+
+		_, ok = expr.(*algebra.Subquery)
+		if !ok {
+			children := expr.Children()
+			if len(children) > 0 {
+				collectWindows(windows, children...)
+			}
+		}
+	}
+}
+
 func (this *builder) fastCount(node *algebra.Subselect) (bool, error) {
 	if node.From() == nil ||
 		node.Where() != nil ||
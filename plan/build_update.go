@@ -38,7 +38,7 @@ func (this *builder) VisitUpdate(stmt *algebra.Update) (interface{}, error) {
 		subChildren = append(subChildren, NewUnset(stmt.Unset()))
 	}
 
-	subChildren = append(subChildren, NewSendUpdate(keyspace, ksref.Alias(), stmt.Limit()))
+	subChildren = append(subChildren, NewSendUpdate(keyspace, ksref.Alias(), stmt.Expiration(), stmt.Limit()))
 
 	if stmt.Returning() != nil {
 		subChildren = append(subChildren, NewInitialProject(stmt.Returning()), NewFinalProject())
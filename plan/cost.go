@@ -0,0 +1,392 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/value"
+)
+
+/*
+The cost model. Costs are in units of reading one primary index
+entry. Secondary index entries are dearer, as the documents they
+point to are fetched in random order; a document fetch dominates
+both.
+*/
+const (
+	_PRIMARY_SCAN_COST = 1.0
+	_INDEX_SCAN_COST   = 2.0
+	_FETCH_COST        = 4.0
+)
+
+/*
+The estimated cost and cardinality of a scan, which are reported by
+EXPLAIN. Both are zero if nothing is known about the keyspace.
+*/
+type estimate struct {
+	cost        float64
+	cardinality float64
+}
+
+func (this *estimate) Cost() float64 {
+	return this.cost
+}
+
+func (this *estimate) Cardinality() float64 {
+	return this.cardinality
+}
+
+func (this *estimate) SetEstimate(cost, cardinality float64) {
+	this.cost = cost
+	this.cardinality = cardinality
+}
+
+func (this *estimate) marshalEstimate(r map[string]interface{}) {
+	if this.cost > 0 {
+		r["cost"] = this.cost
+		r["cardinality"] = this.cardinality
+	}
+}
+
+/*
+Returns the number of documents in the keyspace, or false if it is
+not known.
+*/
+func keyspaceCount(keyspace datastore.Keyspace) (float64, bool) {
+	count, err := keyspace.Count()
+	if err != nil || count < 0 {
+		return 0, false
+	}
+
+	return float64(count), true
+}
+
+func primaryScanCost(count float64) float64 {
+	return count * (_PRIMARY_SCAN_COST + _FETCH_COST)
+}
+
+func keyScanCost(cardinality float64) float64 {
+	return cardinality * _FETCH_COST
+}
+
+/*
+Index scans read the entries of every index, but only fetch the
+documents found by all of them. The selectivities of the indexes are
+assumed to be independent.
+*/
+func indexScansCost(cardinalities []float64, count float64) (cost, cardinality float64) {
+	cardinality = count
+	for _, c := range cardinalities {
+		cost += c * _INDEX_SCAN_COST
+		if count > 0 {
+			cardinality *= c / count
+		}
+	}
+
+	return cost + cardinality*_FETCH_COST, cardinality
+}
+
+/*
+Estimates the number of keys of a KEYS clause: the length of a
+constant array, or one for any other constant. Returns false if the
+keys are not constant.
+*/
+func keyScanCardinality(keys expression.Expression) (float64, bool) {
+	val := keys.Value()
+	if val == nil {
+		return 0, false
+	}
+
+	if val.Type() == value.ARRAY {
+		return float64(len(val.Actual().([]interface{}))), true
+	}
+
+	return 1, true
+}
+
+/*
+Estimates the number of index entries within the spans, using the
+index statistics. Spans with constant bounds are estimated from the
+statistics of the span itself, or failing that from the histogram
+bins of the index. Other spans are estimated from the statistics of
+the whole index: an equality selects one distinct value, and a range
+selects the same fraction as spansCost() assumes.
+If the index has no statistics, the histogram collected by UPDATE
+STATISTICS for its leading key is used instead, and only the leading
+key of each span is considered. Returns false if there are no
+statistics at all.
+*/
+func spansCardinality(keyspace datastore.Keyspace, index datastore.Index,
+	spans planner.Spans) (float64, bool) {
+	var whole datastore.Statistics
+	var total, distinct float64
+	var bins []datastore.Statistics
+	loaded, leading := false, false
+
+	rv := 0.0
+	for _, span := range spans {
+		dspan, constant := constantSpan(span)
+		if constant {
+			stats, err := index.Statistics(dspan)
+			if err == nil && stats != nil {
+				count, err := stats.Count()
+				if err == nil {
+					rv += float64(count)
+					continue
+				}
+			}
+		}
+
+		if !loaded {
+			loaded = true
+			whole, total, distinct, bins = indexStatistics(index)
+			if whole == nil {
+				whole, total, distinct, bins = histogramStatistics(keyspace, index)
+				leading = whole != nil
+			}
+		}
+
+		if whole == nil {
+			return 0, false
+		}
+
+		if leading {
+			span = leadingSpan(span)
+			dspan, constant = constantSpan(span)
+		}
+
+		if constant && len(bins) > 0 {
+			rv += binsCardinality(bins, dspan)
+		} else if span.Exact() && distinct > 0 {
+			rv += total / distinct
+		} else {
+			rv += total * spanCost(span) / _UNBOUNDED_COST
+		}
+	}
+
+	if loaded && whole != nil && rv > total {
+		rv = total
+	}
+
+	return rv, true
+}
+
+/*
+Returns the statistics of the whole index, with its count, distinct
+count and histogram bins.
+*/
+func indexStatistics(index datastore.Index) (stats datastore.Statistics, count, distinct float64,
+	bins []datastore.Statistics) {
+	stats, err := index.Statistics(nil)
+	if err != nil || stats == nil {
+		return nil, 0, 0, nil
+	}
+
+	c, err := stats.Count()
+	if err != nil {
+		return nil, 0, 0, nil
+	}
+
+	d, err := stats.DistinctCount()
+	if err != nil {
+		d = 0
+	}
+
+	bins, err = stats.Bins()
+	if err != nil {
+		bins = nil
+	}
+
+	return stats, float64(c), float64(d), bins
+}
+
+/*
+Returns the histogram of the leading key of the index, with its
+count, distinct count and bins.
+*/
+func histogramStatistics(keyspace datastore.Keyspace, index datastore.Index) (
+	stats datastore.Statistics, count, distinct float64, bins []datastore.Statistics) {
+	rangeKey := index.RangeKey()
+	if len(rangeKey) == 0 || rangeKey[0] == nil {
+		return nil, 0, 0, nil
+	}
+
+	histogram := datastore.GetHistogram(keyspace.NamespaceId(), keyspace.Name(),
+		expression.NewStringer().Visit(rangeKey[0]))
+	if histogram == nil {
+		return nil, 0, 0, nil
+	}
+
+	c, _ := histogram.Count()
+	d, _ := histogram.DistinctCount()
+	bins, _ = histogram.Bins()
+	return histogram, float64(c), float64(d), bins
+}
+
+/*
+Returns the span restricted to the leading index key.
+*/
+func leadingSpan(span *planner.Span) *planner.Span {
+	rv := &planner.Span{}
+	if len(span.Seek) > 0 {
+		rv.Seek = span.Seek[:1]
+	}
+
+	rv.Range.Inclusion = span.Range.Inclusion
+	if len(span.Range.Low) > 0 {
+		rv.Range.Low = span.Range.Low[:1]
+		if len(span.Range.Low) > 1 {
+			rv.Range.Inclusion |= datastore.LOW
+		}
+	}
+
+	if len(span.Range.High) > 0 {
+		rv.Range.High = span.Range.High[:1]
+		if len(span.Range.High) > 1 {
+			rv.Range.Inclusion |= datastore.HIGH
+		}
+	}
+
+	return rv
+}
+
+/*
+Sums the counts of the bins that overlap the span. Bins that overlap
+only in part contribute half their count, or, for an equality, their
+count per distinct value.
+*/
+func binsCardinality(bins []datastore.Statistics, span *datastore.Span) float64 {
+	low, high := span.Range.Low, span.Range.High
+	if len(span.Seek) > 0 {
+		low, high = span.Seek, span.Seek
+	}
+
+	lowIncl := len(span.Seek) > 0 || span.Range.Inclusion&datastore.LOW != 0
+	highIncl := len(span.Seek) > 0 || span.Range.Inclusion&datastore.HIGH != 0
+
+	exact := len(span.Seek) > 0 || (span.Range.Inclusion == datastore.BOTH &&
+		len(low) == len(high) && collatePrefix(low, high) == 0 && collatePrefix(high, low) == 0)
+
+	rv := 0.0
+	for _, bin := range bins {
+		count, err := bin.Count()
+		if err != nil {
+			continue
+		}
+
+		min, err := bin.Min()
+		if err != nil {
+			continue
+		}
+
+		max, err := bin.Max()
+		if err != nil {
+			continue
+		}
+
+		if (len(high) > 0 && len(min) > 0 && !below(collatePrefix(min, high), highIncl)) ||
+			(len(low) > 0 && len(max) > 0 && !below(-collatePrefix(max, low), lowIncl)) {
+			continue
+		}
+
+		if (len(low) > 0 && (len(min) == 0 || collatePrefix(min, low) < 0)) ||
+			(len(high) > 0 && (len(max) == 0 || collatePrefix(max, high) > 0)) {
+			distinct, err := bin.DistinctCount()
+			if exact && err == nil && distinct > 0 {
+				rv += float64(count) / float64(distinct)
+			} else {
+				rv += float64(count) / 2
+			}
+		} else {
+			rv += float64(count)
+		}
+	}
+
+	return rv
+}
+
+/*
+Returns true if a value that collates as c against an upper bound
+is within the bound.
+*/
+func below(c int, inclusive bool) bool {
+	return c < 0 || (c == 0 && inclusive)
+}
+
+/*
+Collates key against bound, considering only the leading positions
+that the bound constrains.
+*/
+func collatePrefix(key, bound value.Values) int {
+	for i, b := range bound {
+		if b == nil {
+			continue
+		}
+
+		if i >= len(key) || key[i] == nil {
+			return -1
+		}
+
+		c := key[i].Collate(b)
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+/*
+Converts a span whose bounds are all constant into a datastore span.
+*/
+func constantSpan(span *planner.Span) (*datastore.Span, bool) {
+	rv := &datastore.Span{}
+	var ok bool
+
+	rv.Seek, ok = constantValues(span.Seek)
+	if !ok {
+		return nil, false
+	}
+
+	rv.Range.Low, ok = constantValues(span.Range.Low)
+	if !ok {
+		return nil, false
+	}
+
+	rv.Range.High, ok = constantValues(span.Range.High)
+	if !ok {
+		return nil, false
+	}
+
+	rv.Range.Inclusion = span.Range.Inclusion
+	return rv, true
+}
+
+func constantValues(exprs expression.Expressions) (value.Values, bool) {
+	if exprs == nil {
+		return nil, true
+	}
+
+	rv := make(value.Values, len(exprs))
+	for i, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+
+		rv[i] = expr.Value()
+		if rv[i] == nil {
+			return nil, false
+		}
+	}
+
+	return rv, true
+}
@@ -175,14 +175,16 @@ func (this *DropIndex) UnmarshalJSON(body []byte) error {
 // Alter index
 type AlterIndex struct {
 	readwrite
-	index datastore.Index
-	node  *algebra.AlterIndex
+	keyspace datastore.Keyspace
+	index    datastore.Index
+	node     *algebra.AlterIndex
 }
 
-func NewAlterIndex(index datastore.Index, node *algebra.AlterIndex) *AlterIndex {
+func NewAlterIndex(keyspace datastore.Keyspace, index datastore.Index, node *algebra.AlterIndex) *AlterIndex {
 	return &AlterIndex{
-		index: index,
-		node:  node,
+		keyspace: keyspace,
+		index:    index,
+		node:     node,
 	}
 }
 
@@ -194,6 +196,10 @@ func (this *AlterIndex) New() Operator {
 	return &AlterIndex{}
 }
 
+func (this *AlterIndex) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
 func (this *AlterIndex) Index() datastore.Index {
 	return this.index
 }
@@ -204,6 +210,8 @@ func (this *AlterIndex) Node() *algebra.AlterIndex {
 
 func (this *AlterIndex) MarshalJSON() ([]byte, error) {
 	r := map[string]interface{}{"#operator": "AlterIndex"}
+	r["keyspace"] = this.keyspace.Name()
+	r["namespace"] = this.keyspace.NamespaceId()
 	r["index"] = this.index.Name()
 	r["node"] = this.node
 	return json.Marshal(r)
@@ -211,14 +219,44 @@ func (this *AlterIndex) MarshalJSON() ([]byte, error) {
 
 func (this *AlterIndex) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_     string              `json:"#operator"`
-		Index string              `json:"index"`
-		Node  *algebra.AlterIndex `json:"node"`
+		_     string `json:"#operator"`
+		Keys  string `json:"keyspace"`
+		Names string `json:"namespace"`
+		Index string `json:"index"`
+		Node  struct {
+			Name   string              `json:"name"`
+			Using  datastore.IndexType `json:"using"`
+			Rename string              `json:"rename"`
+		} `json:"node"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	node := _unmarshalled.Node
+	this.node = algebra.NewAlterIndex(algebra.NewKeyspaceRef(_unmarshalled.Names, _unmarshalled.Keys, ""),
+		node.Name, node.Using, node.Rename)
+
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
+	if err != nil {
+		return err
+	}
+
+	indexers, err := this.keyspace.Indexers()
+	if err != nil {
+		return err
+	}
+
+	for _, indexer := range indexers {
+		this.index, err = indexer.IndexByName(_unmarshalled.Index)
+		if err == nil {
+			return nil
+		}
+	}
+
 	return err
-	// TODO: recover index from index name
 }
 
 // Build indexes
@@ -23,16 +23,18 @@ type SendInsert struct {
 	alias    string
 	key      expression.Expression
 	value    expression.Expression
+	options  expression.Expression
 	limit    expression.Expression
 }
 
 func NewSendInsert(keyspace datastore.Keyspace, alias string,
-	key, value, limit expression.Expression) *SendInsert {
+	key, value, options, limit expression.Expression) *SendInsert {
 	return &SendInsert{
 		keyspace: keyspace,
 		alias:    alias,
 		key:      key,
 		value:    value,
+		options:  options,
 		limit:    limit,
 	}
 }
@@ -61,6 +63,10 @@ func (this *SendInsert) Value() expression.Expression {
 	return this.value
 }
 
+func (this *SendInsert) Options() expression.Expression {
+	return this.options
+}
+
 func (this *SendInsert) Limit() expression.Expression {
 	return this.limit
 }
@@ -79,6 +85,10 @@ func (this *SendInsert) MarshalJSON() ([]byte, error) {
 		r["value"] = this.value.String()
 	}
 
+	if this.options != nil {
+		r["options"] = this.options.String()
+	}
+
 	return json.Marshal(r)
 }
 
@@ -87,6 +97,7 @@ func (this *SendInsert) UnmarshalJSON(body []byte) error {
 		_         string `json:"#operator"`
 		KeyExpr   string `json:"key"`
 		ValueExpr string `json:"value"`
+		OptsExpr  string `json:"options"`
 		Keys      string `json:"keyspace"`
 		Names     string `json:"namespace"`
 		Alias     string `json:"alias"`
@@ -111,6 +122,13 @@ func (this *SendInsert) UnmarshalJSON(body []byte) error {
 		}
 	}
 
+	if _unmarshalled.OptsExpr != "" {
+		this.options, err = parser.Parse(_unmarshalled.OptsExpr)
+		if err != nil {
+			return err
+		}
+	}
+
 	this.alias = _unmarshalled.Alias
 	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
 	return err
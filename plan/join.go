@@ -11,6 +11,7 @@ package plan
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/datastore"
@@ -99,6 +100,329 @@ func (this *Join) UnmarshalJSON(body []byte) error {
 	return err
 }
 
+// ANSI join that scans an index of the right keyspace for each left
+// item, using the values of the left-hand keys as an equality span.
+type IndexJoin struct {
+	readonly
+	keyspace datastore.Keyspace
+	term     *algebra.KeyspaceTerm
+	outer    bool
+	onclause expression.Expression
+	index    datastore.Index
+	keys     expression.Expressions
+}
+
+func NewIndexJoin(keyspace datastore.Keyspace, join *algebra.Join,
+	index datastore.Index, keys expression.Expressions) *IndexJoin {
+	return &IndexJoin{
+		keyspace: keyspace,
+		term:     join.Right(),
+		outer:    join.Outer(),
+		onclause: join.Onclause(),
+		index:    index,
+		keys:     keys,
+	}
+}
+
+func (this *IndexJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexJoin(this)
+}
+
+func (this *IndexJoin) New() Operator {
+	return &IndexJoin{}
+}
+
+func (this *IndexJoin) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *IndexJoin) Term() *algebra.KeyspaceTerm {
+	return this.term
+}
+
+func (this *IndexJoin) Outer() bool {
+	return this.outer
+}
+
+func (this *IndexJoin) Onclause() expression.Expression {
+	return this.onclause
+}
+
+func (this *IndexJoin) Index() datastore.Index {
+	return this.index
+}
+
+// Expressions on the left items that give the span of each index key
+func (this *IndexJoin) Keys() expression.Expressions {
+	return this.keys
+}
+
+func (this *IndexJoin) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"#operator": "IndexJoin"}
+	marshalJoinTerm(r, this.term)
+	r["index"] = this.index.Name()
+	r["using"] = this.index.Type()
+	r["keys"] = marshalExprs(this.keys)
+	r["on"] = expression.NewStringer().Visit(this.onclause)
+
+	if this.outer {
+		r["outer"] = this.outer
+	}
+
+	return json.Marshal(r)
+}
+
+func (this *IndexJoin) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_     string              `json:"#operator"`
+		Names string              `json:"namespace"`
+		Keys  string              `json:"keyspace"`
+		Proj  string              `json:"projection"`
+		As    string              `json:"as"`
+		Index string              `json:"index"`
+		Using datastore.IndexType `json:"using"`
+		Exprs []string            `json:"keys"`
+		On    string              `json:"on"`
+		Outer bool                `json:"outer"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.term, err = unmarshalJoinTerm(_unmarshalled.Names, _unmarshalled.Keys,
+		_unmarshalled.Proj, _unmarshalled.As)
+	if err != nil {
+		return err
+	}
+
+	this.keys, err = unmarshalExprs(_unmarshalled.Exprs)
+	if err != nil {
+		return err
+	}
+
+	this.onclause, err = parser.Parse(_unmarshalled.On)
+	if err != nil {
+		return err
+	}
+
+	this.outer = _unmarshalled.Outer
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
+	if err != nil {
+		return err
+	}
+
+	indexer, err := this.keyspace.Indexer(_unmarshalled.Using)
+	if err != nil {
+		return err
+	}
+
+	this.index, err = indexer.IndexByName(_unmarshalled.Index)
+	return err
+}
+
+// ANSI join that builds a hash table of the right keyspace, read
+// through its primary index, and probes it with each left item.
+type HashJoin struct {
+	readonly
+	keyspace   datastore.Keyspace
+	term       *algebra.KeyspaceTerm
+	outer      bool
+	onclause   expression.Expression
+	index      datastore.PrimaryIndex
+	buildExprs expression.Expressions
+	probeExprs expression.Expressions
+}
+
+func NewHashJoin(keyspace datastore.Keyspace, join *algebra.Join, index datastore.PrimaryIndex,
+	buildExprs, probeExprs expression.Expressions) *HashJoin {
+	return &HashJoin{
+		keyspace:   keyspace,
+		term:       join.Right(),
+		outer:      join.Outer(),
+		onclause:   join.Onclause(),
+		index:      index,
+		buildExprs: buildExprs,
+		probeExprs: probeExprs,
+	}
+}
+
+func (this *HashJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitHashJoin(this)
+}
+
+func (this *HashJoin) New() Operator {
+	return &HashJoin{}
+}
+
+func (this *HashJoin) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *HashJoin) Term() *algebra.KeyspaceTerm {
+	return this.term
+}
+
+func (this *HashJoin) Outer() bool {
+	return this.outer
+}
+
+func (this *HashJoin) Onclause() expression.Expression {
+	return this.onclause
+}
+
+func (this *HashJoin) Index() datastore.PrimaryIndex {
+	return this.index
+}
+
+// Hash keys of the right documents
+func (this *HashJoin) BuildExprs() expression.Expressions {
+	return this.buildExprs
+}
+
+// Hash keys of the left items
+func (this *HashJoin) ProbeExprs() expression.Expressions {
+	return this.probeExprs
+}
+
+func (this *HashJoin) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"#operator": "HashJoin"}
+	marshalJoinTerm(r, this.term)
+	r["index"] = this.index.Name()
+	r["using"] = this.index.Type()
+	r["build_exprs"] = marshalExprs(this.buildExprs)
+	r["probe_exprs"] = marshalExprs(this.probeExprs)
+	r["on"] = expression.NewStringer().Visit(this.onclause)
+
+	if this.outer {
+		r["outer"] = this.outer
+	}
+
+	return json.Marshal(r)
+}
+
+func (this *HashJoin) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_     string              `json:"#operator"`
+		Names string              `json:"namespace"`
+		Keys  string              `json:"keyspace"`
+		Proj  string              `json:"projection"`
+		As    string              `json:"as"`
+		Index string              `json:"index"`
+		Using datastore.IndexType `json:"using"`
+		Build []string            `json:"build_exprs"`
+		Probe []string            `json:"probe_exprs"`
+		On    string              `json:"on"`
+		Outer bool                `json:"outer"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.term, err = unmarshalJoinTerm(_unmarshalled.Names, _unmarshalled.Keys,
+		_unmarshalled.Proj, _unmarshalled.As)
+	if err != nil {
+		return err
+	}
+
+	this.buildExprs, err = unmarshalExprs(_unmarshalled.Build)
+	if err != nil {
+		return err
+	}
+
+	this.probeExprs, err = unmarshalExprs(_unmarshalled.Probe)
+	if err != nil {
+		return err
+	}
+
+	this.onclause, err = parser.Parse(_unmarshalled.On)
+	if err != nil {
+		return err
+	}
+
+	this.outer = _unmarshalled.Outer
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
+	if err != nil {
+		return err
+	}
+
+	indexer, err := this.keyspace.Indexer(_unmarshalled.Using)
+	if err != nil {
+		return err
+	}
+
+	index, err := indexer.IndexByName(_unmarshalled.Index)
+	if err != nil {
+		return err
+	}
+
+	primary, ok := index.(datastore.PrimaryIndex)
+	if !ok {
+		return fmt.Errorf("Unable to unmarshal %s as primary index.", _unmarshalled.Index)
+	}
+
+	this.index = primary
+	return nil
+}
+
+func marshalJoinTerm(r map[string]interface{}, term *algebra.KeyspaceTerm) {
+	r["namespace"] = term.Namespace()
+	r["keyspace"] = term.Keyspace()
+
+	if term.Projection() != nil {
+		r["projection"] = expression.NewStringer().Visit(term.Projection())
+	}
+
+	if term.As() != "" {
+		r["as"] = term.As()
+	}
+}
+
+func unmarshalJoinTerm(namespace, keyspace, proj, as string) (*algebra.KeyspaceTerm, error) {
+	var path expression.Path
+
+	if proj != "" {
+		expr, err := parser.Parse(proj)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		path, ok = expr.(expression.Path)
+		if !ok {
+			return nil, fmt.Errorf("Cannot resolve path expression from %s", proj)
+		}
+	}
+
+	return algebra.NewKeyspaceTerm(namespace, keyspace, path, as, nil), nil
+}
+
+func marshalExprs(exprs expression.Expressions) []string {
+	rv := make([]string, len(exprs))
+	for i, expr := range exprs {
+		rv[i] = expression.NewStringer().Visit(expr)
+	}
+
+	return rv
+}
+
+func unmarshalExprs(strs []string) (expression.Expressions, error) {
+	rv := make(expression.Expressions, len(strs))
+	for i, str := range strs {
+		expr, err := parser.Parse(str)
+		if err != nil {
+			return nil, err
+		}
+
+		rv[i] = expr
+	}
+
+	return rv, nil
+}
+
 type Nest struct {
 	readonly
 	keyspace datastore.Keyspace
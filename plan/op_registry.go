@@ -53,13 +53,21 @@ var _OPERATORS = map[string]Operator{
 	"InitialGroup":       &InitialGroup{},
 	"IntermediateGroup":  &IntermediateGroup{},
 	"FinalGroup":         &FinalGroup{},
+	"Window":             &Window{},
 	"CreatePrimaryIndex": &CreatePrimaryIndex{},
 	"CreateIndex":        &CreateIndex{},
 	"DropIndex":          &DropIndex{},
 	"AlterIndex":         &AlterIndex{},
+	"CreateFunction":     &CreateFunction{},
+	"DropFunction":       &DropFunction{},
+	"UpdateStatistics":   &UpdateStatistics{},
+	"GrantRole":          &GrantRole{},
+	"RevokeRole":         &RevokeRole{},
 	"Insert":             &SendInsert{},
 	"IntersectAll":       &IntersectAll{},
 	"Join":               &Join{},
+	"IndexJoin":          &IndexJoin{},
+	"HashJoin":           &HashJoin{},
 	"Nest":               &Nest{},
 	"Unnest":             &Unnest{},
 	"Let":                &Let{},
@@ -10,13 +10,17 @@
 package plan
 
 import (
+	"container/list"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/value"
 )
 
@@ -27,13 +31,79 @@ func BuildPrepared(stmt algebra.Statement, datastore, systemstore datastore.Data
 		return nil, err
 	}
 
+	// EXECUTE yields a cached plan
+	if prepared, ok := operator.(*Prepared); ok {
+		return prepared, nil
+	}
+
 	signature := stmt.Signature()
-	return newPrepared(operator, signature), nil
+	prepared := newPrepared(operator, signature)
+	if sel, ok := stmt.(*algebra.Select); ok {
+		prepared.columns = sel.Columns()
+	}
+
+	prepared.functions, err = expression.ListUserFunctions(stmt.Expressions())
+	if err != nil {
+		return nil, err
+	}
+
+	prepared.expiration = readsExpiration(stmt.Expressions())
+	return prepared, nil
+}
+
+/*
+Returns true if the expressions, their subqueries or the user-defined
+functions they call may read META().expiration, which is either named
+or read along with the whole of META().
+*/
+func readsExpiration(exprs expression.Expressions) bool {
+	return readsExpirationIn(exprs, make(map[string]bool))
+}
+
+func readsExpirationIn(exprs expression.Expressions, functions map[string]bool) bool {
+	for _, expr := range exprs {
+		switch expr := expr.(type) {
+		case *expression.Field:
+			if _, ok := expr.First().(*expression.Meta); ok {
+				name := expr.Second().Value()
+				if name == nil {
+					return true
+				}
+
+				field, ok := name.Actual().(string)
+				if !ok || field == "expiration" ||
+					(expr.CaseInsensitive() && strings.EqualFold(field, "expiration")) {
+					return true
+				}
+
+				continue
+			}
+		case *expression.Meta:
+			return true
+		case *expression.UserFunction:
+			if !functions[expr.Name()] {
+				functions[expr.Name()] = true
+				if readsExpirationIn(expression.Expressions{expr.Body()}, functions) {
+					return true
+				}
+			}
+		}
+
+		if readsExpirationIn(expr.Children(), functions) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Prepared struct {
 	Operator
-	signature value.Value
+	signature  value.Value
+	columns    []string
+	functions  []string
+	name       string
+	expiration bool
 }
 
 func newPrepared(operator Operator, signature value.Value) *Prepared {
@@ -44,17 +114,33 @@ func newPrepared(operator Operator, signature value.Value) *Prepared {
 }
 
 func (this *Prepared) MarshalJSON() ([]byte, error) {
-	r := make(map[string]interface{}, 2)
+	r := make(map[string]interface{}, 6)
 	r["operator"] = this.Operator
 	r["signature"] = this.signature
+	if this.columns != nil {
+		r["columns"] = this.columns
+	}
+	if len(this.functions) > 0 {
+		r["functions"] = this.functions
+	}
+	if this.name != "" {
+		r["name"] = this.name
+	}
+	if this.expiration {
+		r["expiration"] = this.expiration
+	}
 
 	return json.Marshal(r)
 }
 
 func (this *Prepared) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		Operator  json.RawMessage `json:"operator"`
-		Signature json.RawMessage `json:"signature"`
+		Operator   json.RawMessage `json:"operator"`
+		Signature  json.RawMessage `json:"signature"`
+		Columns    []string        `json:"columns"`
+		Functions  []string        `json:"functions"`
+		Name       string          `json:"name"`
+		Expiration bool            `json:"expiration"`
 	}
 
 	var op_type struct {
@@ -72,6 +158,10 @@ func (this *Prepared) UnmarshalJSON(body []byte) error {
 	}
 
 	this.signature = value.NewValue(_unmarshalled.Signature)
+	this.columns = _unmarshalled.Columns
+	this.functions = _unmarshalled.Functions
+	this.name = _unmarshalled.Name
+	this.expiration = _unmarshalled.Expiration
 	this.Operator, err = MakeOperator(op_type.Operator, _unmarshalled.Operator)
 
 	return err
@@ -81,43 +171,325 @@ func (this *Prepared) Signature() value.Value {
 	return this.signature
 }
 
+/*
+Returns the names of the result terms in projection order, or nil if
+the statement has no projection.
+*/
+func (this *Prepared) Columns() []string {
+	return this.columns
+}
+
+/*
+Returns the names of the user-defined functions called by the
+statement, sorted.
+*/
+func (this *Prepared) Functions() []string {
+	return this.functions
+}
+
+/*
+Returns true if the statement may read META().expiration, which some
+keyspaces only fetch when asked to.
+*/
+func (this *Prepared) Expiration() bool {
+	return this.expiration
+}
+
+func (this *Prepared) Name() string {
+	return this.name
+}
+
+func (this *Prepared) SetName(name string) {
+	this.name = name
+}
+
+const _CACHE_LIMIT = 16384
+
+type cacheEntry struct {
+	prepared  *Prepared
+	key       string
+	indexes   map[string]bool
+	functions map[string]bool
+	uses      int64
+	lastUse   time.Time
+	element   *list.Element
+}
+
+/*
+A snapshot of a cached prepared statement and its usage.
+*/
+type PreparedEntry struct {
+	Name     string
+	Prepared *Prepared
+	Uses     int64
+	LastUse  time.Time
+}
+
 type cacheType struct {
-	sync.RWMutex
-	prepareds map[string]*Prepared
+	sync.Mutex
+	prepareds map[string]*cacheEntry
+	lru       *list.List
+	limit     int
 }
 
 var preparedCache = &cacheType{
-	prepareds: make(map[string]*Prepared),
+	prepareds: make(map[string]*cacheEntry),
+	lru:       list.New(),
+	limit:     _CACHE_LIMIT,
 }
 
 func PreparedCache() *cacheType {
 	return preparedCache
 }
 
+/*
+Set the maximum number of cached prepared statements, evicting the
+least recently used statements as necessary. A limit of zero or less
+restores the default.
+*/
+func (this *cacheType) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = _CACHE_LIMIT
+	}
+
+	this.Lock()
+	defer this.Unlock()
+	this.limit = limit
+	this.evict()
+}
+
+func (this *cacheType) Limit() int {
+	this.Lock()
+	defer this.Unlock()
+	return this.limit
+}
+
+func (this *cacheType) Count() int {
+	this.Lock()
+	defer this.Unlock()
+	return len(this.prepareds)
+}
+
+/*
+Look up a prepared statement, either by name, if value is a string or
+a prepared statement with a name, or else by the statement's JSON
+representation. Returns nil if the statement is not cached.
+*/
 func (this *cacheType) GetPrepared(value value.Value) (*Prepared, error) {
-	json_bytes, err := value.MarshalJSON()
+	key, err := valueKey(value)
 	if err != nil {
 		return nil, err
 	}
-	key := makeKey(json_bytes)
-	this.RLock()
-	prepared := this.prepareds[key]
-	this.RUnlock()
-	return prepared, nil
+
+	this.Lock()
+	defer this.Unlock()
+
+	entry := this.prepareds[key]
+	if entry == nil {
+		return nil, nil
+	}
+
+	this.lru.MoveToFront(entry.element)
+	entry.uses++
+	entry.lastUse = time.Now()
+	return entry.prepared, nil
 }
 
 func (this *cacheType) AddPrepared(plan *Prepared) error {
-	json_bytes, err := plan.MarshalJSON()
+	key, indexes, err := preparedKey(plan)
 	if err != nil {
 		return err
 	}
-	key := makeKey(json_bytes)
+
+	functions := make(map[string]bool, len(plan.functions))
+	for _, name := range plan.functions {
+		functions[name] = true
+	}
+
 	this.Lock()
-	this.prepareds[key] = plan
-	this.Unlock()
+	defer this.Unlock()
+
+	entry := this.prepareds[key]
+	if entry != nil {
+		entry.prepared = plan
+		entry.indexes = indexes
+		entry.functions = functions
+		this.lru.MoveToFront(entry.element)
+		return nil
+	}
+
+	entry = &cacheEntry{
+		prepared:  plan,
+		key:       key,
+		indexes:   indexes,
+		functions: functions,
+	}
+
+	entry.element = this.lru.PushFront(entry)
+	this.prepareds[key] = entry
+	this.evict()
 	return nil
 }
 
+/*
+Returns the keys of all cached prepared statements, most recently
+used first.
+*/
+func (this *cacheType) Names() []string {
+	this.Lock()
+	defer this.Unlock()
+
+	rv := make([]string, 0, len(this.prepareds))
+	for e := this.lru.Front(); e != nil; e = e.Next() {
+		rv = append(rv, e.Value.(*cacheEntry).key)
+	}
+
+	return rv
+}
+
+func (this *cacheType) Entry(name string) *PreparedEntry {
+	this.Lock()
+	defer this.Unlock()
+
+	entry := this.prepareds[name]
+	if entry == nil {
+		return nil
+	}
+
+	return &PreparedEntry{
+		Name:     entry.key,
+		Prepared: entry.prepared,
+		Uses:     entry.uses,
+		LastUse:  entry.lastUse,
+	}
+}
+
+func (this *cacheType) DeletePrepared(name string) bool {
+	this.Lock()
+	defer this.Unlock()
+
+	entry := this.prepareds[name]
+	if entry == nil {
+		return false
+	}
+
+	this.remove(entry)
+	return true
+}
+
+/*
+Remove all prepared statements that scan the given index.
+*/
+func (this *cacheType) InvalidateIndex(namespace, keyspace, index string) {
+	ref := indexRef(namespace, keyspace, index)
+
+	this.Lock()
+	defer this.Unlock()
+
+	for _, entry := range this.prepareds {
+		if entry.indexes[ref] {
+			this.remove(entry)
+		}
+	}
+}
+
+/*
+Remove all prepared statements that call the given user-defined
+function, directly or through other functions.
+*/
+func (this *cacheType) InvalidateFunction(name string) {
+	name = strings.ToLower(name)
+
+	this.Lock()
+	defer this.Unlock()
+
+	for _, entry := range this.prepareds {
+		if entry.functions[name] {
+			this.remove(entry)
+		}
+	}
+}
+
+func (this *cacheType) evict() {
+	for len(this.prepareds) > this.limit {
+		this.remove(this.lru.Back().Value.(*cacheEntry))
+	}
+}
+
+func (this *cacheType) remove(entry *cacheEntry) {
+	this.lru.Remove(entry.element)
+	delete(this.prepareds, entry.key)
+}
+
+func valueKey(val value.Value) (string, error) {
+	switch val.Type() {
+	case value.STRING:
+		return val.Actual().(string), nil
+	case value.OBJECT:
+		name, ok := val.Field("name")
+		if ok && name.Type() == value.STRING {
+			return name.Actual().(string), nil
+		}
+	}
+
+	json_bytes, err := val.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return makeKey(json_bytes), nil
+}
+
+func preparedKey(plan *Prepared) (string, map[string]bool, error) {
+	json_bytes, err := plan.MarshalJSON()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var body interface{}
+	err = json.Unmarshal(json_bytes, &body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	indexes := make(map[string]bool)
+	collectIndexes(body, indexes)
+
+	if plan.name != "" {
+		return plan.name, indexes, nil
+	}
+
+	return makeKey(json_bytes), indexes, nil
+}
+
+/*
+Collect the indexes scanned by a plan from its JSON representation,
+so that plans built from EXECUTE are covered as well.
+*/
+func collectIndexes(body interface{}, indexes map[string]bool) {
+	switch body := body.(type) {
+	case map[string]interface{}:
+		index, iok := body["index"].(string)
+		keyspace, kok := body["keyspace"].(string)
+		if iok && kok {
+			namespace, _ := body["namespace"].(string)
+			indexes[indexRef(namespace, keyspace, index)] = true
+		}
+
+		for _, v := range body {
+			collectIndexes(v, indexes)
+		}
+	case []interface{}:
+		for _, v := range body {
+			collectIndexes(v, indexes)
+		}
+	}
+}
+
+func indexRef(namespace, keyspace, index string) string {
+	return namespace + ":" + keyspace + ":" + index
+}
+
 func makeKey(body []byte) string {
 	hasher := md5.New()
 	hasher.Write(body)
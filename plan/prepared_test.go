@@ -0,0 +1,67 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+)
+
+func TestReadsExpiration(t *testing.T) {
+	meta, _ := n1ql.ParseExpression("meta(x)")
+	expression.RegisterUserFunction("rx_meta", []string{"x"}, meta)
+	defer expression.UnregisterUserFunction("rx_meta")
+
+	cases := []struct {
+		statement string
+		reads     bool
+	}{
+		{"SELECT meta(t).expiration FROM t", true},
+		{"SELECT t.a FROM t WHERE meta(t).expiration > 0", true},
+		{"SELECT meta(t) FROM t", true},
+		{"SELECT meta(t).id, meta(t).cas FROM t WHERE meta(t).type = 'json'", false},
+		{"SELECT t.expiration FROM t", false},
+		{"SELECT t.a FROM t ORDER BY meta(t).expiration", true},
+
+		// Subqueries, mutations and user-defined functions
+		{"SELECT t.a FROM t WHERE t.a IN (SELECT RAW meta(u).expiration FROM u USE KEYS 'k')", true},
+		{"UPDATE t SET a = 1 RETURNING meta(t).expiration", true},
+		{"DELETE FROM t WHERE t.a = 1", false},
+		{"SELECT rx_meta(t) FROM t", true},
+	}
+
+	for _, c := range cases {
+		stmt, err := n1ql.ParseStatement(c.statement)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", c.statement, err)
+		}
+
+		if reads := readsExpiration(stmt.Expressions()); reads != c.reads {
+			t.Errorf("%s: expected %v, got %v", c.statement, c.reads, reads)
+		}
+	}
+
+	// The prepared statement keeps it
+	prepared := newPrepared(NewDummyScan(), nil)
+	prepared.expiration = true
+
+	bytes, err := json.Marshal(prepared)
+	if err != nil {
+		t.Fatalf("failed to marshal prepared: %v", err)
+	}
+
+	unmarshalled := &Prepared{}
+	if err = json.Unmarshal(bytes, unmarshalled); err != nil || !unmarshalled.Expiration() {
+		t.Errorf("expected the expiration to be kept in %s, got %v", bytes, err)
+	}
+}
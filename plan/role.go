@@ -0,0 +1,126 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+)
+
+// Grant role
+type GrantRole struct {
+	readwrite
+	node *algebra.GrantRole
+}
+
+func NewGrantRole(node *algebra.GrantRole) *GrantRole {
+	return &GrantRole{
+		node: node,
+	}
+}
+
+func (this *GrantRole) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitGrantRole(this)
+}
+
+func (this *GrantRole) New() Operator {
+	return &GrantRole{}
+}
+
+func (this *GrantRole) Node() *algebra.GrantRole {
+	return this.node
+}
+
+func (this *GrantRole) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"#operator": "GrantRole"}
+	r["node"] = this.node
+	return json.Marshal(r)
+}
+
+func (this *GrantRole) UnmarshalJSON(body []byte) error {
+	role, ksref, users, err := unmarshalRole(body)
+	if err != nil {
+		return err
+	}
+
+	this.node = algebra.NewGrantRole(role, ksref, users)
+	return nil
+}
+
+// Revoke role
+type RevokeRole struct {
+	readwrite
+	node *algebra.RevokeRole
+}
+
+func NewRevokeRole(node *algebra.RevokeRole) *RevokeRole {
+	return &RevokeRole{
+		node: node,
+	}
+}
+
+func (this *RevokeRole) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRevokeRole(this)
+}
+
+func (this *RevokeRole) New() Operator {
+	return &RevokeRole{}
+}
+
+func (this *RevokeRole) Node() *algebra.RevokeRole {
+	return this.node
+}
+
+func (this *RevokeRole) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"#operator": "RevokeRole"}
+	r["node"] = this.node
+	return json.Marshal(r)
+}
+
+func (this *RevokeRole) UnmarshalJSON(body []byte) error {
+	role, ksref, users, err := unmarshalRole(body)
+	if err != nil {
+		return err
+	}
+
+	this.node = algebra.NewRevokeRole(role, ksref, users)
+	return nil
+}
+
+func unmarshalRole(body []byte) (datastore.Privilege, *algebra.KeyspaceRef, []string, error) {
+	var _unmarshalled struct {
+		_    string `json:"#operator"`
+		Node struct {
+			Role     string `json:"role"`
+			Keyspace struct {
+				Namespace string `json:"namespace"`
+				Keyspace  string `json:"keyspace"`
+			} `json:"keyspaceRef"`
+			Users []string `json:"users"`
+		} `json:"node"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	node := _unmarshalled.Node
+	role, ok := datastore.PrivilegeByName(node.Role)
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("Invalid role %s.", node.Role)
+	}
+
+	ksref := algebra.NewKeyspaceRef(node.Keyspace.Namespace, node.Keyspace.Keyspace, "")
+	return role, ksref, node.Users, nil
+}
@@ -20,8 +20,33 @@ import (
 	"github.com/couchbase/query/planner"
 )
 
+/*
+Warnings raised while planning a scan, when an index hint
+cannot be honored. They are reported by EXPLAIN, and returned with
+the results each time the scan is executed.
+*/
+type warnings struct {
+	warnings []string
+}
+
+func (this *warnings) Warnings() []string {
+	return this.warnings
+}
+
+func (this *warnings) SetWarnings(warnings []string) {
+	this.warnings = warnings
+}
+
+func (this *warnings) marshalWarnings(r map[string]interface{}) {
+	if len(this.warnings) > 0 {
+		r["warnings"] = this.warnings
+	}
+}
+
 type PrimaryScan struct {
 	readonly
+	estimate
+	warnings
 	index datastore.PrimaryIndex
 	term  *algebra.KeyspaceTerm
 }
@@ -55,6 +80,8 @@ func (this *PrimaryScan) MarshalJSON() ([]byte, error) {
 	r["namespace"] = this.term.Namespace()
 	r["keyspace"] = this.term.Keyspace()
 	r["using"] = this.index.Type()
+	this.marshalEstimate(r)
+	this.marshalWarnings(r)
 	return json.Marshal(r)
 }
 
@@ -65,6 +92,9 @@ func (this *PrimaryScan) UnmarshalJSON(body []byte) error {
 		Names string              `json:"namespace"`
 		Keys  string              `json:"keyspace"`
 		Using datastore.IndexType `json:"using"`
+		Cost  float64             `json:"cost"`
+		Card  float64             `json:"cardinality"`
+		Warns []string            `json:"warnings"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -72,6 +102,9 @@ func (this *PrimaryScan) UnmarshalJSON(body []byte) error {
 		return err
 	}
 
+	this.SetEstimate(_unmarshalled.Cost, _unmarshalled.Card)
+	this.SetWarnings(_unmarshalled.Warns)
+
 	k, err := datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
 	if err != nil {
 		return err
@@ -102,11 +135,14 @@ func (this *PrimaryScan) UnmarshalJSON(body []byte) error {
 
 type IndexScan struct {
 	readonly
+	estimate
+	warnings
 	index    datastore.Index
 	term     *algebra.KeyspaceTerm
 	spans    planner.Spans
 	distinct bool
 	limit    int64
+	covers   expression.Expressions
 }
 
 func NewIndexScan(index datastore.Index, term *algebra.KeyspaceTerm,
@@ -148,6 +184,19 @@ func (this *IndexScan) Limit() int64 {
 	return this.limit
 }
 
+/*
+Returns the index keys that cover the query, or nil if the documents
+must be fetched. A covering scan builds each item from the keys of
+its index entry, in the same order as the covers.
+*/
+func (this *IndexScan) Covers() expression.Expressions {
+	return this.covers
+}
+
+func (this *IndexScan) SetCovers(covers expression.Expressions) {
+	this.covers = covers
+}
+
 func (this *IndexScan) MarshalJSON() ([]byte, error) {
 	r := map[string]interface{}{"#operator": "IndexScan"}
 	r["index"] = this.index.Name()
@@ -166,6 +215,20 @@ func (this *IndexScan) MarshalJSON() ([]byte, error) {
 		r["limit"] = this.limit
 	}
 
+	if len(this.covers) > 0 {
+		covers := make([]string, len(this.covers))
+		for i, cover := range this.covers {
+			covers[i] = expression.NewStringer().Visit(cover)
+		}
+
+		r["covers"] = covers
+		if this.term.As() != "" {
+			r["as"] = this.term.As()
+		}
+	}
+
+	this.marshalEstimate(r)
+	this.marshalWarnings(r)
 	return json.Marshal(r)
 }
 
@@ -179,6 +242,11 @@ func (this *IndexScan) UnmarshalJSON(body []byte) error {
 		Spans    planner.Spans       `json:"spans"`
 		Distinct bool                `json:"distinct"`
 		Limit    int64               `json:"limit"`
+		Covers   []string            `json:"covers"`
+		As       string              `json:"as"`
+		Cost     float64             `json:"cost"`
+		Card     float64             `json:"cardinality"`
+		Warns    []string            `json:"warnings"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -193,10 +261,22 @@ func (this *IndexScan) UnmarshalJSON(body []byte) error {
 
 	this.term = algebra.NewKeyspaceTerm(
 		_unmarshalled.Names, _unmarshalled.Keys,
-		nil, "", nil)
+		nil, _unmarshalled.As, nil)
 	this.spans = _unmarshalled.Spans
 	this.distinct = _unmarshalled.Distinct
 	this.limit = _unmarshalled.Limit
+	this.SetEstimate(_unmarshalled.Cost, _unmarshalled.Card)
+	this.SetWarnings(_unmarshalled.Warns)
+
+	if len(_unmarshalled.Covers) > 0 {
+		this.covers = make(expression.Expressions, len(_unmarshalled.Covers))
+		for i, cover := range _unmarshalled.Covers {
+			this.covers[i], err = parser.Parse(cover)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	indexer, err := k.Indexer(_unmarshalled.Using)
 	if err != nil {
@@ -210,6 +290,7 @@ func (this *IndexScan) UnmarshalJSON(body []byte) error {
 // KeyScan is used for KEYS clauses (except after JOIN / NEST).
 type KeyScan struct {
 	readonly
+	estimate
 	keys expression.Expression
 }
 
@@ -234,13 +315,16 @@ func (this *KeyScan) Keys() expression.Expression {
 func (this *KeyScan) MarshalJSON() ([]byte, error) {
 	r := map[string]interface{}{"#operator": "KeyScan"}
 	r["keys"] = expression.NewStringer().Visit(this.keys)
+	this.marshalEstimate(r)
 	return json.Marshal(r)
 }
 
 func (this *KeyScan) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_    string `json:"#operator"`
-		Keys string `json:"keys"`
+		_    string  `json:"#operator"`
+		Keys string  `json:"keys"`
+		Cost float64 `json:"cost"`
+		Card float64 `json:"cardinality"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -248,6 +332,8 @@ func (this *KeyScan) UnmarshalJSON(body []byte) error {
 		return err
 	}
 
+	this.SetEstimate(_unmarshalled.Cost, _unmarshalled.Card)
+
 	if _unmarshalled.Keys != "" {
 		this.keys, err = parser.Parse(_unmarshalled.Keys)
 	}
@@ -424,6 +510,7 @@ func (this *CountScan) UnmarshalJSON(body []byte) error {
 // IntersectScan scans multiple indexes and intersects the results.
 type IntersectScan struct {
 	readonly
+	estimate
 	scans []Operator
 }
 
@@ -451,6 +538,7 @@ func (this *IntersectScan) MarshalJSON() ([]byte, error) {
 	// FIXME
 	r["scans"] = this.scans
 
+	this.marshalEstimate(r)
 	return json.Marshal(r)
 }
 
@@ -458,12 +546,16 @@ func (this *IntersectScan) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_     string            `json:"#operator"`
 		Scans []json.RawMessage `json:"scans"`
+		Cost  float64           `json:"cost"`
+		Card  float64           `json:"cardinality"`
 	}
 	err := json.Unmarshal(body, &_unmarshalled)
 	if err != nil {
 		return err
 	}
 
+	this.SetEstimate(_unmarshalled.Cost, _unmarshalled.Card)
+
 	this.scans = []Operator{}
 
 	for _, raw_scan := range _unmarshalled.Scans {
@@ -0,0 +1,90 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+// Update statistics
+type UpdateStatistics struct {
+	readwrite
+	keyspace datastore.Keyspace
+	node     *algebra.UpdateStatistics
+}
+
+func NewUpdateStatistics(keyspace datastore.Keyspace, node *algebra.UpdateStatistics) *UpdateStatistics {
+	return &UpdateStatistics{
+		keyspace: keyspace,
+		node:     node,
+	}
+}
+
+func (this *UpdateStatistics) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitUpdateStatistics(this)
+}
+
+func (this *UpdateStatistics) New() Operator {
+	return &UpdateStatistics{}
+}
+
+func (this *UpdateStatistics) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *UpdateStatistics) Node() *algebra.UpdateStatistics {
+	return this.node
+}
+
+func (this *UpdateStatistics) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"#operator": "UpdateStatistics"}
+	r["keyspace"] = this.keyspace.Name()
+	r["namespace"] = this.keyspace.NamespaceId()
+	r["node"] = this.node
+	return json.Marshal(r)
+}
+
+func (this *UpdateStatistics) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_     string `json:"#operator"`
+		Keys  string `json:"keyspace"`
+		Names string `json:"namespace"`
+		Node  struct {
+			Terms []string `json:"terms"`
+		} `json:"node"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
+	if err != nil {
+		return err
+	}
+
+	terms := make(expression.Expressions, len(_unmarshalled.Node.Terms))
+	for i, term := range _unmarshalled.Node.Terms {
+		terms[i], err = parser.Parse(term)
+		if err != nil {
+			return err
+		}
+	}
+
+	ksref := algebra.NewKeyspaceRef(_unmarshalled.Names, _unmarshalled.Keys, "")
+	this.node = algebra.NewUpdateStatistics(ksref, terms)
+	return nil
+}
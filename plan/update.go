@@ -39,9 +39,10 @@ type Unset struct {
 // Send to keyspace
 type SendUpdate struct {
 	readwrite
-	keyspace datastore.Keyspace
-	alias    string
-	limit    expression.Expression
+	keyspace   datastore.Keyspace
+	alias      string
+	expiration expression.Expression
+	limit      expression.Expression
 }
 
 func NewClone() *Clone {
@@ -206,11 +207,13 @@ func (this *Unset) UnmarshalJSON(body []byte) error {
 	return nil
 }
 
-func NewSendUpdate(keyspace datastore.Keyspace, alias string, limit expression.Expression) *SendUpdate {
+func NewSendUpdate(keyspace datastore.Keyspace, alias string,
+	expiration, limit expression.Expression) *SendUpdate {
 	return &SendUpdate{
-		keyspace: keyspace,
-		alias:    alias,
-		limit:    limit,
+		keyspace:   keyspace,
+		alias:      alias,
+		expiration: expiration,
+		limit:      limit,
 	}
 }
 
@@ -230,6 +233,10 @@ func (this *SendUpdate) Alias() string {
 	return this.alias
 }
 
+func (this *SendUpdate) Expiration() expression.Expression {
+	return this.expiration
+}
+
 func (this *SendUpdate) Limit() expression.Expression {
 	return this.limit
 }
@@ -239,15 +246,21 @@ func (this *SendUpdate) MarshalJSON() ([]byte, error) {
 	r["keyspace"] = this.keyspace.Name()
 	r["namespace"] = this.keyspace.NamespaceId()
 	r["alias"] = this.alias
+
+	if this.expiration != nil {
+		r["expiration"] = this.expiration.String()
+	}
+
 	return json.Marshal(r)
 }
 
 func (this *SendUpdate) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_     string `json:"#operator"`
-		Keys  string `json:"keyspace"`
-		Names string `json:"namespace"`
-		Alias string `json:"alias"`
+		_          string `json:"#operator"`
+		Keys       string `json:"keyspace"`
+		Names      string `json:"namespace"`
+		Alias      string `json:"alias"`
+		Expiration string `json:"expiration"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -255,6 +268,13 @@ func (this *SendUpdate) UnmarshalJSON(body []byte) error {
 		return err
 	}
 
+	if _unmarshalled.Expiration != "" {
+		this.expiration, err = parser.Parse(_unmarshalled.Expiration)
+		if err != nil {
+			return err
+		}
+	}
+
 	this.alias = _unmarshalled.Alias
 	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
 
@@ -23,14 +23,17 @@ type SendUpsert struct {
 	alias    string
 	key      expression.Expression
 	value    expression.Expression
+	options  expression.Expression
 }
 
-func NewSendUpsert(keyspace datastore.Keyspace, alias string, key, value expression.Expression) *SendUpsert {
+func NewSendUpsert(keyspace datastore.Keyspace, alias string,
+	key, value, options expression.Expression) *SendUpsert {
 	return &SendUpsert{
 		keyspace: keyspace,
 		alias:    alias,
 		key:      key,
 		value:    value,
+		options:  options,
 	}
 }
 
@@ -58,6 +61,10 @@ func (this *SendUpsert) Value() expression.Expression {
 	return this.value
 }
 
+func (this *SendUpsert) Options() expression.Expression {
+	return this.options
+}
+
 func (this *SendUpsert) MarshalJSON() ([]byte, error) {
 	r := map[string]interface{}{"#operator": "SendUpsert"}
 	r["keyspace"] = this.keyspace.Name()
@@ -72,6 +79,10 @@ func (this *SendUpsert) MarshalJSON() ([]byte, error) {
 		r["value"] = this.value.String()
 	}
 
+	if this.options != nil {
+		r["options"] = this.options.String()
+	}
+
 	return json.Marshal(r)
 }
 
@@ -80,6 +91,7 @@ func (this *SendUpsert) UnmarshalJSON(body []byte) error {
 		_         string `json:"#operator"`
 		KeyExpr   string `json:"key"`
 		ValueExpr string `json:"value"`
+		OptsExpr  string `json:"options"`
 		Keys      string `json:"keyspace"`
 		Names     string `json:"namespace"`
 		Alias     string `json:"alias"`
@@ -104,6 +116,13 @@ func (this *SendUpsert) UnmarshalJSON(body []byte) error {
 		}
 	}
 
+	if _unmarshalled.OptsExpr != "" {
+		this.options, err = parser.Parse(_unmarshalled.OptsExpr)
+		if err != nil {
+			return err
+		}
+	}
+
 	this.alias = _unmarshalled.Alias
 	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Names, _unmarshalled.Keys)
 	return nil
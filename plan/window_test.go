@@ -0,0 +1,39 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWindowUnmarshal(t *testing.T) {
+	// Expressions other than window functions are rejected, rather
+	// than leaving nil functions in the plan
+	for _, body := range []string{
+		`{"#operator": "Window", "functions": ["a + 1"]}`,
+		`{"#operator": "Window", "functions": ["lower(b)"]}`,
+		`{"#operator": "Window", "functions": ["ROW_NUMBER() OVER ("]}`,
+	} {
+		window := &Window{}
+		if err := json.Unmarshal([]byte(body), window); err == nil {
+			t.Errorf("expected %s to fail to unmarshal, got %v", body, window.Functions())
+		}
+	}
+
+	window := &Window{}
+	if err := json.Unmarshal([]byte(`{"#operator": "Window", "functions": []}`), window); err != nil {
+		t.Fatalf("failed to unmarshal window: %v", err)
+	}
+
+	if len(window.Functions()) != 0 {
+		t.Errorf("expected no functions, got %v", window.Functions())
+	}
+}
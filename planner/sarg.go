@@ -43,6 +43,126 @@ func SargFor(expr1, expr2 expression.Expression) Spans {
 	return nil
 }
 
+/*
+Compute spans for a composite index key. Spans on the leading key are
+extended with spans on each successive key, for as long as every span
+so far is an equality. The first key that is not an equality
+contributes its range and ends the extension.
+*/
+func SargForKeys(expr expression.Expression, keys expression.Expressions) Spans {
+	if len(keys) == 0 || keys[0] == nil {
+		return nil
+	}
+
+	spans := SargFor(expr, keys[0])
+
+	for _, key := range keys[1:] {
+		if key == nil || len(spans) == 0 || !spans.Exact() {
+			break
+		}
+
+		next := SargFor(expr, key)
+		if len(next) == 0 || len(spans)*len(next) > _MAX_COMPOSITE_SPANS {
+			break
+		}
+
+		composite := make(Spans, 0, len(spans)*len(next))
+		for _, prefix := range spans {
+			for _, span := range next {
+				if len(span.Seek) > 0 {
+					return spans
+				}
+
+				composite = append(composite, prefix.extend(span))
+			}
+		}
+
+		spans = composite
+	}
+
+	return spans
+}
+
+const _MAX_COMPOSITE_SPANS = 256
+
+/*
+Returns true if every span is an equality.
+*/
+func (this Spans) Exact() bool {
+	for _, span := range this {
+		if !span.Exact() {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Returns true if the span is an equality, i.e. its low and high bounds
+are equivalent and inclusive. Constant bounds are compared by
+collation, so that a NULL bound is equivalent to itself.
+*/
+func (this *Span) Exact() bool {
+	if len(this.Seek) > 0 {
+		return true
+	}
+
+	low := this.Range.Low
+	high := this.Range.High
+
+	if len(low) == 0 || len(low) != len(high) ||
+		this.Range.Inclusion != datastore.BOTH {
+		return false
+	}
+
+	for i, expr := range low {
+		if expr == nil || high[i] == nil || !equivalentBound(expr, high[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equivalentBound(expr1, expr2 expression.Expression) bool {
+	val1, val2 := expr1.Value(), expr2.Value()
+	if val1 != nil && val2 != nil {
+		return val1.Collate(val2) == 0
+	}
+
+	return expr1.EquivalentTo(expr2)
+}
+
+/*
+Append the bounds of span to this equality span. A missing bound on
+span leaves the prefix as an inclusive bound.
+*/
+func (this *Span) extend(span *Span) *Span {
+	rv := &Span{}
+	rv.Range.Low = this.Range.Low
+	rv.Range.High = this.Range.High
+	rv.Range.Inclusion = datastore.BOTH
+
+	if len(span.Range.Low) > 0 {
+		rv.Range.Low = concat(this.Range.Low, span.Range.Low)
+		rv.Range.Inclusion &= span.Range.Inclusion | datastore.HIGH
+	}
+
+	if len(span.Range.High) > 0 {
+		rv.Range.High = concat(this.Range.High, span.Range.High)
+		rv.Range.Inclusion &= span.Range.Inclusion | datastore.LOW
+	}
+
+	return rv
+}
+
+func concat(exprs1, exprs2 expression.Expressions) expression.Expressions {
+	rv := make(expression.Expressions, 0, len(exprs1)+len(exprs2))
+	rv = append(rv, exprs1...)
+	return append(rv, exprs2...)
+}
+
 func newSarg(expr expression.Expression) expression.Visitor {
 	s, _ := expr.Accept(_SARG_FACTORY)
 	return s.(expression.Visitor)
@@ -27,7 +27,7 @@ func newSargAnd(expr *expression.And) *sargAnd {
 
 		for _, op := range expr.Operands() {
 			s := SargFor(op, expr2)
-			if s == nil {
+			if len(s) == 0 {
 				continue
 			}
 
@@ -45,11 +45,13 @@ func newSargAnd(expr *expression.And) *sargAnd {
 }
 
 func constrain(spans1, spans2 Spans) Spans {
-	span1 := spans1[0]
+	// Copy the span, which may be shared
+	span1 := &Span{}
+	*span1 = *spans1[0]
 	span2 := spans2[0]
 
-	if span2.Range.Low != nil {
-		if span1.Range.Low == nil {
+	if len(span2.Range.Low) > 0 {
+		if len(span1.Range.Low) == 0 {
 			span1.Range.Low = span2.Range.Low
 			span1.Range.Inclusion = (span1.Range.Inclusion & datastore.HIGH) |
 				(span2.Range.Inclusion & datastore.LOW)
@@ -64,15 +66,15 @@ func constrain(spans1, spans2 Spans) Spans {
 		}
 	}
 
-	if span2.Range.High != nil {
-		if span1.Range.High == nil {
+	if len(span2.Range.High) > 0 {
+		if len(span1.Range.High) == 0 {
 			span1.Range.High = span2.Range.High
 			span1.Range.Inclusion = (span1.Range.Inclusion & datastore.LOW) |
 				(span2.Range.Inclusion & datastore.HIGH)
 		} else {
 			high1 := span1.Range.High[0].Value()
 			high2 := span2.Range.High[0].Value()
-			if high1 != nil && (high2 == nil && high1.Collate(high2) > 0) {
+			if high1 != nil && (high2 == nil || high1.Collate(high2) > 0) {
 				span1.Range.High = span2.Range.High
 				span1.Range.Inclusion = (span1.Range.Inclusion & datastore.LOW) |
 					(span2.Range.Inclusion & datastore.HIGH)
@@ -80,5 +82,8 @@ func constrain(spans1, spans2 Spans) Spans {
 		}
 	}
 
-	return spans1
+	rv := make(Spans, len(spans1))
+	copy(rv, spans1)
+	rv[0] = span1
+	return rv
 }
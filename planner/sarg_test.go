@@ -0,0 +1,175 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Returns the spans as low..high bounds with their inclusion, such as
+[1 2]..[1 3] BOTH.
+*/
+func spansString(spans Spans) string {
+	inclusions := map[datastore.Inclusion]string{
+		datastore.NEITHER: "NEITHER",
+		datastore.LOW:     "LOW",
+		datastore.HIGH:    "HIGH",
+		datastore.BOTH:    "BOTH",
+	}
+
+	rv := make([]string, len(spans))
+	for i, span := range spans {
+		rv[i] = fmt.Sprintf("%v..%v %s", span.Range.Low, span.Range.High,
+			inclusions[span.Range.Inclusion])
+	}
+
+	return strings.Join(rv, "; ")
+}
+
+func sargWhere(t *testing.T, where string) expression.Expression {
+	expr, err := n1ql.ParseExpression(where)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", where, err)
+	}
+
+	expr, err = NewNNF().Map(expr)
+	if err != nil {
+		t.Fatalf("failed to normalize %s: %v", where, err)
+	}
+
+	return expr
+}
+
+func TestSargForKeys(t *testing.T) {
+	keys := expression.Expressions{
+		expression.NewIdentifier("a"),
+		expression.NewIdentifier("b"),
+		expression.NewIdentifier("c"),
+	}
+
+	cases := []struct {
+		where    string
+		expected string
+	}{
+		// Equalities extend the spans to the next key
+		{"a = 1", "[1]..[1] BOTH"},
+		{"a = 1 AND b = 2", "[1 2]..[1 2] BOTH"},
+		{"a = 1 AND b = 2 AND c = 3", "[1 2 3]..[1 2 3] BOTH"},
+		{"a IS NULL AND b = 1", "[null 1]..[null 1] BOTH"},
+
+		// The first range ends the extension, keeping the prefix as an
+		// inclusive bound where the range has none
+		{"a = 1 AND b = 2 AND c > 3", "[1 2 3]..[1 2] HIGH"},
+		{"a = 1 AND b < 2 AND c = 3", "[1]..[1 2] LOW"},
+		{"a = 1 AND b BETWEEN 2 AND 3 AND c = 4", "[1 2]..[1 3] BOTH"},
+		{"a > 1 AND b = 2", "[1]..[] NEITHER"},
+
+		// A key that is not constrained ends the extension
+		{"a = 1 AND c = 3", "[1]..[1] BOTH"},
+		{"b = 1", ""},
+
+		// Spans on each key are combined
+		{"a IN [1, 2] AND b IN [3, 4]",
+			"[1 3]..[1 3] BOTH; [1 4]..[1 4] BOTH; [2 3]..[2 3] BOTH; [2 4]..[2 4] BOTH"},
+		{"a IN [1, 2] AND b > 3", "[1 3]..[1] HIGH; [2 3]..[2] HIGH"},
+	}
+
+	for _, c := range cases {
+		spans := SargForKeys(sargWhere(t, c.where), keys)
+		if actual := spansString(spans); actual != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.where, c.expected, actual)
+		}
+	}
+
+	if spans := SargForKeys(sargWhere(t, "a = 1"), nil); spans != nil {
+		t.Errorf("expected no spans without keys, got %s", spansString(spans))
+	}
+}
+
+func TestSargForKeysLimit(t *testing.T) {
+	keys := expression.Expressions{expression.NewIdentifier("a"), expression.NewIdentifier("b")}
+	in := func(key string, n int) string {
+		values := make([]string, n)
+		for i := range values {
+			values[i] = fmt.Sprint(i)
+		}
+
+		return fmt.Sprintf("%s IN [%s]", key, strings.Join(values, ", "))
+	}
+
+	// Up to _MAX_COMPOSITE_SPANS spans are combined
+	where := in("a", 16) + " AND " + in("b", 16)
+	spans := SargForKeys(sargWhere(t, where), keys)
+	if len(spans) != _MAX_COMPOSITE_SPANS || len(spans[0].Range.Low) != 2 {
+		t.Errorf("expected %d spans on both keys, got %d", _MAX_COMPOSITE_SPANS, len(spans))
+	}
+
+	// Beyond that, only the leading key is used
+	where = in("a", 16) + " AND " + in("b", 17)
+	spans = SargForKeys(sargWhere(t, where), keys)
+	if len(spans) != 16 || len(spans[0].Range.Low) != 1 {
+		t.Errorf("expected 16 spans on the leading key, got %s", spansString(spans))
+	}
+}
+
+func TestSpanExact(t *testing.T) {
+	constants := func(vals ...interface{}) expression.Expressions {
+		rv := make(expression.Expressions, len(vals))
+		for i, val := range vals {
+			if val != nil {
+				rv[i] = expression.NewConstant(val)
+			}
+		}
+
+		return rv
+	}
+
+	null := expression.NewConstant(value.NewNullValue())
+
+	cases := []struct {
+		span  *Span
+		exact bool
+	}{
+		{&Span{Range: Range{constants(1), constants(1), datastore.BOTH}}, true},
+		{&Span{Range: Range{constants(1, "x"), constants(1, "x"), datastore.BOTH}}, true},
+		{&Span{Range: Range{expression.Expressions{null}, expression.Expressions{null}, datastore.BOTH}}, true},
+		{&Span{Range: Range{expression.Expressions{expression.NewIdentifier("x")},
+			expression.Expressions{expression.NewIdentifier("x")}, datastore.BOTH}}, true},
+		{&Span{Seek: constants(1)}, true},
+
+		// Exclusive, different or missing bounds
+		{&Span{Range: Range{constants(1), constants(1), datastore.LOW}}, false},
+		{&Span{Range: Range{constants(1), constants(1), datastore.NEITHER}}, false},
+		{&Span{Range: Range{constants(1), constants(2), datastore.BOTH}}, false},
+		{&Span{Range: Range{constants(1, 2), constants(1), datastore.BOTH}}, false},
+		{&Span{Range: Range{constants(1), nil, datastore.BOTH}}, false},
+		{&Span{Range: Range{nil, nil, datastore.BOTH}}, false},
+		{&Span{Range: Range{constants(nil), constants(nil), datastore.BOTH}}, false},
+	}
+
+	for i, c := range cases {
+		if exact := c.span.Exact(); exact != c.exact {
+			t.Errorf("case %d %s: expected exact %v, got %v", i, spansString(Spans{c.span}), c.exact, exact)
+		}
+	}
+
+	exact := Spans{cases[0].span, cases[1].span}
+	if !exact.Exact() || (Spans{cases[0].span, cases[5].span}).Exact() {
+		t.Errorf("expected spans to be exact only if every span is")
+	}
+}
@@ -0,0 +1,257 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/execution"
+)
+
+// Default bounds of the completed requests log
+const COMPLETED_LIMIT_DEFAULT = 4000
+const COMPLETED_THRESHOLD_DEFAULT = time.Second
+
+type activeRequest struct {
+	request  Request
+	operator execution.Operator
+	stopped  bool
+}
+
+// activeRequests tracks the requests currently executing. It backs
+// system:active_requests.
+type activeRequests struct {
+	sync.RWMutex
+	requests map[string]*activeRequest
+}
+
+func newActiveRequests() *activeRequests {
+	return &activeRequests{
+		requests: make(map[string]*activeRequest),
+	}
+}
+
+func (this *activeRequests) add(request Request, operator execution.Operator) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.requests[request.Id().String()] = &activeRequest{
+		request:  request,
+		operator: operator,
+	}
+}
+
+func (this *activeRequests) remove(request Request) *activeRequest {
+	this.Lock()
+	defer this.Unlock()
+
+	id := request.Id().String()
+	entry := this.requests[id]
+	delete(this.requests, id)
+	return entry
+}
+
+func (this *activeRequests) RequestIds() []string {
+	this.RLock()
+	defer this.RUnlock()
+
+	rv := make([]string, 0, len(this.requests))
+	for id := range this.requests {
+		rv = append(rv, id)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+func (this *activeRequests) Request(id string) map[string]interface{} {
+	this.RLock()
+	defer this.RUnlock()
+
+	entry, ok := this.requests[id]
+	if !ok {
+		return nil
+	}
+
+	state := entry.request.State()
+	if entry.stopped {
+		state = STOPPED
+	}
+
+	return requestDoc(entry.request, state, time.Now())
+}
+
+// Stop a running request. The operator is notified through its
+// StopChannel, and the request stops consuming results.
+func (this *activeRequests) DeleteRequest(id string) bool {
+	this.Lock()
+	defer this.Unlock()
+
+	entry, ok := this.requests[id]
+	if !ok {
+		return false
+	}
+
+	entry.stopped = true
+
+	select {
+	case entry.operator.StopChannel() <- false:
+	default:
+	}
+
+	entry.request.Stop(STOPPED)
+	return true
+}
+
+type completedRequest struct {
+	id  string
+	doc map[string]interface{}
+}
+
+// completedRequests is a bounded history of slow or failed
+// requests. It backs system:completed_requests.
+type completedRequests struct {
+	sync.RWMutex
+	requests  map[string]*list.Element
+	lru       *list.List
+	limit     int
+	threshold time.Duration
+}
+
+func newCompletedRequests() *completedRequests {
+	return &completedRequests{
+		requests:  make(map[string]*list.Element),
+		lru:       list.New(),
+		limit:     COMPLETED_LIMIT_DEFAULT,
+		threshold: COMPLETED_THRESHOLD_DEFAULT,
+	}
+}
+
+func (this *completedRequests) setLimit(limit int) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.limit = limit
+	this.evict()
+}
+
+func (this *completedRequests) setThreshold(threshold time.Duration) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.threshold = threshold
+}
+
+// Log the request if it ran longer than the threshold, or did not
+// complete successfully.
+func (this *completedRequests) add(request Request, state State) {
+	now := time.Now()
+
+	this.Lock()
+	defer this.Unlock()
+
+	if this.limit <= 0 {
+		return
+	}
+
+	if now.Sub(request.RequestTime()) < this.threshold &&
+		request.ErrorCount() == 0 &&
+		(state == COMPLETED || state == SUCCESS || state == RUNNING) {
+		return
+	}
+
+	doc := requestDoc(request, state, now)
+	id := request.Id().String()
+
+	if elem, ok := this.requests[id]; ok {
+		this.lru.Remove(elem)
+	}
+
+	this.requests[id] = this.lru.PushFront(&completedRequest{id: id, doc: doc})
+	this.evict()
+}
+
+func (this *completedRequests) evict() {
+	for this.lru.Len() > this.limit && this.lru.Len() > 0 {
+		elem := this.lru.Back()
+		this.lru.Remove(elem)
+		delete(this.requests, elem.Value.(*completedRequest).id)
+	}
+}
+
+func (this *completedRequests) RequestIds() []string {
+	this.RLock()
+	defer this.RUnlock()
+
+	rv := make([]string, 0, len(this.requests))
+	for id := range this.requests {
+		rv = append(rv, id)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+func (this *completedRequests) Request(id string) map[string]interface{} {
+	this.RLock()
+	defer this.RUnlock()
+
+	elem, ok := this.requests[id]
+	if !ok {
+		return nil
+	}
+
+	return elem.Value.(*completedRequest).doc
+}
+
+// Forget a completed request.
+func (this *completedRequests) DeleteRequest(id string) bool {
+	this.Lock()
+	defer this.Unlock()
+
+	elem, ok := this.requests[id]
+	if !ok {
+		return false
+	}
+
+	this.lru.Remove(elem)
+	delete(this.requests, id)
+	return true
+}
+
+func requestDoc(request Request, state State, now time.Time) map[string]interface{} {
+	doc := map[string]interface{}{
+		"requestId":     request.Id().String(),
+		"requestTime":   request.RequestTime().Format(time.RFC3339Nano),
+		"elapsedTime":   now.Sub(request.RequestTime()).String(),
+		"executionTime": now.Sub(request.ServiceTime()).String(),
+		"state":         string(state),
+		"resultCount":   request.ResultCount(),
+		"errorCount":    request.ErrorCount(),
+		"mutationCount": request.MutationCount(),
+	}
+
+	if request.ClientID().IsValid() {
+		doc["clientContextID"] = request.ClientID().String()
+	}
+
+	if request.Statement() != "" {
+		doc["statement"] = request.Statement()
+	}
+
+	if prepared := request.Prepared(); prepared != nil && prepared.Name() != "" {
+		doc["preparedName"] = prepared.Name()
+	}
+
+	return doc
+}
@@ -0,0 +1,202 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/couchbase/query/datastore/mock"
+	"github.com/couchbase/query/datastore/system"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+type testingRequest struct {
+	*BaseRequest
+}
+
+func newTestingRequest(statement string) *testingRequest {
+	return &testingRequest{NewBaseRequest(statement, nil, nil, nil, "default",
+		value.NONE, value.NONE, value.NONE, nil, "", nil)}
+}
+
+func (this *testingRequest) Output() execution.Output {
+	return this
+}
+
+func (this *testingRequest) Fail(err errors.Error) {
+	this.Error(err)
+	this.Stop(FATAL)
+}
+
+func (this *testingRequest) Execute(server *Server, prepared *plan.Prepared, notifyStop chan bool) {
+}
+
+func (this *testingRequest) Failed(server *Server) {
+}
+
+func (this *testingRequest) Expire() {
+	this.Stop(TIMEOUT)
+}
+
+func TestActiveRequests(t *testing.T) {
+	active := newActiveRequests()
+	requests := []*testingRequest{newTestingRequest("SELECT 1"), newTestingRequest("SELECT 2")}
+	operators := make([]execution.Operator, len(requests))
+	for i, request := range requests {
+		operators[i] = execution.NewDummyScan()
+		active.add(request, operators[i])
+	}
+
+	ids := active.RequestIds()
+	if len(ids) != 2 || ids[0] > ids[1] {
+		t.Fatalf("expected 2 sorted request ids, got %v", ids)
+	}
+
+	id := requests[0].Id().String()
+	doc := active.Request(id)
+	if doc["requestId"] != id || doc["statement"] != "SELECT 1" || doc["state"] != string(RUNNING) {
+		t.Fatalf("unexpected document for active request: %v", doc)
+	}
+
+	if active.Request("missing") != nil || active.DeleteRequest("missing") {
+		t.Fatalf("expected missing request not to be found")
+	}
+
+	// Deleting a request stops it and its operator
+	if !active.DeleteRequest(id) {
+		t.Fatalf("failed to stop active request %s", id)
+	}
+
+	if requests[0].State() != STOPPED || active.Request(id)["state"] != string(STOPPED) {
+		t.Fatalf("expected request to be stopped, got %v", active.Request(id))
+	}
+
+	select {
+	case <-operators[0].StopChannel():
+	default:
+		t.Fatalf("expected operator to be notified to stop")
+	}
+
+	entry := active.remove(requests[0])
+	if entry == nil || !entry.stopped || len(active.RequestIds()) != 1 {
+		t.Fatalf("expected stopped request to be removed, got %v and %v", entry, active.RequestIds())
+	}
+
+	if active.remove(requests[0]) != nil {
+		t.Fatalf("expected request to be removed only once")
+	}
+}
+
+func TestCompletedRequests(t *testing.T) {
+	completed := newCompletedRequests()
+	completed.setLimit(2)
+	completed.setThreshold(time.Hour)
+
+	// Fast successful requests are not logged
+	fast := newTestingRequest("SELECT 1")
+	completed.add(fast, SUCCESS)
+	if len(completed.RequestIds()) != 0 {
+		t.Fatalf("expected fast request not to be logged, got %v", completed.RequestIds())
+	}
+
+	// Requests that fail or are stopped are
+	requests := make([]*testingRequest, 3)
+	for i := range requests {
+		requests[i] = newTestingRequest(fmt.Sprintf("SELECT %d", i))
+	}
+
+	requests[0].Error(errors.NewError(nil, "failed"))
+	completed.add(requests[0], ERRORS)
+	completed.add(requests[1], STOPPED)
+
+	doc := completed.Request(requests[0].Id().String())
+	if doc == nil || doc["state"] != string(ERRORS) || doc["errorCount"] != uint64(1) {
+		t.Fatalf("unexpected document for failed request: %v", doc)
+	}
+
+	// The least recent requests are evicted beyond the limit
+	completed.add(requests[2], TIMEOUT)
+	if len(completed.RequestIds()) != 2 || completed.Request(requests[0].Id().String()) != nil {
+		t.Fatalf("expected the oldest request to be evicted, got %v", completed.RequestIds())
+	}
+
+	completed.setLimit(1)
+	ids := completed.RequestIds()
+	if len(ids) != 1 || ids[0] != requests[2].Id().String() {
+		t.Fatalf("expected only the latest request after lowering the limit, got %v", ids)
+	}
+
+	// Slow requests are logged, even if successful
+	completed.setThreshold(0)
+	completed.add(fast, SUCCESS)
+	if completed.Request(fast.Id().String()) == nil {
+		t.Fatalf("expected slow request to be logged")
+	}
+
+	if !completed.DeleteRequest(fast.Id().String()) || completed.DeleteRequest(fast.Id().String()) {
+		t.Fatalf("expected completed request to be deleted once")
+	}
+
+	// Without a limit, nothing is logged
+	completed.setLimit(0)
+	completed.add(requests[0], ERRORS)
+	if len(completed.RequestIds()) != 0 {
+		t.Fatalf("expected no requests without a limit, got %v", completed.RequestIds())
+	}
+}
+
+func TestServerRequestLogs(t *testing.T) {
+	store, err := mock.NewDatastore("mock:namespaces=1,keyspaces=1,items=1")
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+
+	servers := make([]*Server, 2)
+	for i := range servers {
+		servers[i], err = NewServer(store, nil, nil, "default", false, nil, 1, 0,
+			false, false, KEEP_ALIVE_DEFAULT)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+	}
+
+	servers[0].active.add(newTestingRequest("SELECT 1"), execution.NewDummyScan())
+	servers[1].completed.add(newTestingRequest("SELECT 2"), FATAL)
+
+	// Each server exposes its own requests through its system datastore
+	expected := []map[string]int64{
+		{system.KEYSPACE_NAME_ACTIVE_REQUESTS: 1, system.KEYSPACE_NAME_COMPLETED_REQUESTS: 0},
+		{system.KEYSPACE_NAME_ACTIVE_REQUESTS: 0, system.KEYSPACE_NAME_COMPLETED_REQUESTS: 1},
+	}
+
+	for i, server := range servers {
+		namespace, err := server.systemstore.NamespaceByName(system.NAMESPACE_NAME)
+		if err != nil {
+			t.Fatalf("failed to get system namespace: %v", err)
+		}
+
+		for name, count := range expected[i] {
+			keyspace, err := namespace.KeyspaceByName(name)
+			if err != nil {
+				t.Fatalf("failed to get keyspace %s: %v", name, err)
+			}
+
+			n, err := keyspace.Count()
+			if err != nil || n != count {
+				t.Errorf("server %d: expected %d %s, got %d %v", i, count, name, n, err)
+			}
+		}
+	}
+}
@@ -22,11 +22,14 @@ import (
 
 	"github.com/couchbase/query/accounting"
 	acct_resolver "github.com/couchbase/query/accounting/resolver"
+	"github.com/couchbase/query/clustering"
 	config_resolver "github.com/couchbase/query/clustering/resolver"
 	datastore_package "github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/resolver"
+	"github.com/couchbase/query/execution"
 	"github.com/couchbase/query/logging"
 	log_resolver "github.com/couchbase/query/logging/resolver"
+	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/server/http"
 	"github.com/couchbase/query/util"
@@ -46,6 +49,10 @@ var REQUEST_CAP = flag.Int("request-cap", runtime.NumCPU()<<16, "Maximum number
 var THREAD_COUNT = flag.Int("threads", runtime.NumCPU()<<6, "Thread count")
 var ORDER_LIMIT = flag.Int64("order-limit", 0, "Maximum LIMIT for ORDER BY clauses; use zero or negative value to disable")
 var MUTATION_LIMIT = flag.Int64("mutation-limit", 0, "Maximum LIMIT for data modification statements; use zero or negative value to disable")
+var SORT_MEMORY = flag.Int64("sort-memory", execution.SORT_MEMORY_DEFAULT, "Memory in bytes used by ORDER BY and hash JOIN in each request before spilling to disk or failing; use zero or negative value to disable the limit")
+var PREPARED_LIMIT = flag.Int("prepared-limit", 16384, "Maximum number of cached prepared statements")
+var COMPLETED_LIMIT = flag.Int("completed-limit", server.COMPLETED_LIMIT_DEFAULT, "Maximum number of requests in system:completed_requests")
+var COMPLETED_THRESHOLD = flag.Duration("completed-threshold", server.COMPLETED_THRESHOLD_DEFAULT, "Minimum duration of requests kept in system:completed_requests, e.g. 500ms or 2s")
 var HTTP_ADDR = flag.String("http", ":8093", "HTTP service address")
 var HTTPS_ADDR = flag.String("https", ":18093", "HTTPS service address")
 var CERT_FILE = flag.String("certfile", "", "HTTPS certificate file")
@@ -166,6 +173,8 @@ func main() {
 		)
 	}
 
+	plan.PreparedCache().SetLimit(*PREPARED_LIMIT)
+
 	channel := make(server.RequestChannel, *REQUEST_CAP)
 	server, err := server.NewServer(datastore, configstore, acctstore, *NAMESPACE, *READONLY, channel,
 		*THREAD_COUNT, *TIMEOUT, *SIGNATURE, *METRICS, keep_alive_length)
@@ -174,6 +183,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	options := clustering.NewOptions(*DATASTORE, *CONFIGSTORE, *ACCTSTORE, *NAMESPACE, *READONLY,
+		*SIGNATURE, *METRICS, *REQUEST_CAP, *THREAD_COUNT, int(*ORDER_LIMIT), int(*MUTATION_LIMIT),
+		*HTTP_ADDR, *HTTPS_ADDR, *LOGGER, *DEBUG, "", *CERT_FILE, *KEY_FILE)
+	server.SetQueryNodeOptions(options)
+	server.SetSortMemory(*SORT_MEMORY)
+	server.SetCompletedLimit(*COMPLETED_LIMIT)
+	server.SetCompletedThreshold(*COMPLETED_THRESHOLD)
+
 	go server.Serve()
 
 	logging.Infop("cbq-engine started",
@@ -0,0 +1,153 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	GZIP_ENCODING    = "gzip"
+	DEFLATE_ENCODING = "deflate"
+)
+
+// compressionEncoder is implemented by gzip.Writer and zlib.Writer
+type compressionEncoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressor encodes response data. The encoded data is written to an
+// output that the responseDataManager switches from its buffer to the
+// response writer once the response starts streaming.
+type compressor struct {
+	encoding string
+	encoder  compressionEncoder
+	output   io.Writer
+	pending  int // bytes written since the last flush
+	closed   bool
+}
+
+// Flushing the encoder ends a compressed block, so streamed data is
+// flushed once this much is pending, or when no more results are ready.
+const COMPRESSION_FLUSH_SIZE = 1 << 14
+
+func newCompressor(encoding string) *compressor {
+	rv := &compressor{
+		encoding: encoding,
+	}
+
+	switch encoding {
+	case GZIP_ENCODING:
+		rv.encoder = gzip.NewWriter(rv)
+	case DEFLATE_ENCODING:
+		// http deflate is the zlib format
+		rv.encoder = zlib.NewWriter(rv)
+	default:
+		return nil
+	}
+
+	return rv
+}
+
+// Write implements io.Writer for the encoder
+func (this *compressor) Write(p []byte) (int, error) {
+	return this.output.Write(p)
+}
+
+func (this *compressor) setOutput(w io.Writer) {
+	this.output = w
+}
+
+func (this *compressor) writeString(s string) bool {
+	if this.closed {
+		return false
+	}
+	n, err := io.WriteString(this.encoder, s)
+	this.pending += n
+	return err == nil
+}
+
+// Push data held by the encoder to the output, so that streamed
+// results are not delayed
+func (this *compressor) flush() bool {
+	if this.closed {
+		return false
+	}
+	if this.pending == 0 {
+		return true
+	}
+	this.pending = 0
+	return this.encoder.Flush() == nil
+}
+
+func (this *compressor) close() bool {
+	if this.closed {
+		return true
+	}
+	this.closed = true
+	return this.encoder.Close() == nil
+}
+
+// Returns the content encoding to use for the response. Only a
+// compression of ZIP compresses the response, with gzip or deflate as
+// accepted by the client, or with gzip if it accepts any encoding.
+func getContentEncoding(compression Compression, acceptEncoding string) string {
+	if compression != ZIP {
+		return ""
+	}
+
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return GZIP_ENCODING
+	}
+
+	gzipQ, deflateQ := -1.0, -1.0
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(item, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && strings.ToLower(param[:2]) == "q=" {
+				f, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil && f >= 0 && f <= 1 {
+					q = f
+				}
+			}
+		}
+
+		switch name {
+		case GZIP_ENCODING:
+			gzipQ = q
+		case DEFLATE_ENCODING:
+			deflateQ = q
+		case "*":
+			if gzipQ < 0 {
+				gzipQ = q
+			}
+			if deflateQ < 0 {
+				deflateQ = q
+			}
+		}
+	}
+
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return GZIP_ENCODING
+	case deflateQ > 0:
+		return DEFLATE_ENCODING
+	default:
+		return ""
+	}
+}
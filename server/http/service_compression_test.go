@@ -0,0 +1,126 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetContentEncoding(t *testing.T) {
+	cases := []struct {
+		compression    Compression
+		acceptEncoding string
+		expected       string
+	}{
+		// Without ZIP, responses are not compressed
+		{NONE, "", ""},
+		{NONE, "gzip", ""},
+		{NONE, "gzip, deflate", ""},
+
+		// ZIP without Accept-Encoding uses gzip
+		{ZIP, "", GZIP_ENCODING},
+		{ZIP, " ", GZIP_ENCODING},
+
+		// Accepted encodings, preferring gzip on a tie
+		{ZIP, "gzip", GZIP_ENCODING},
+		{ZIP, "deflate", DEFLATE_ENCODING},
+		{ZIP, "gzip, deflate", GZIP_ENCODING},
+		{ZIP, "deflate, gzip", GZIP_ENCODING},
+		{ZIP, "GZIP", GZIP_ENCODING},
+		{ZIP, "br", ""},
+		{ZIP, "identity", ""},
+
+		// q-values
+		{ZIP, "gzip;q=0.5, deflate", DEFLATE_ENCODING},
+		{ZIP, "gzip;q=0.5, deflate;q=0.2", GZIP_ENCODING},
+		{ZIP, "gzip; q=0.1, deflate; Q=0.9", DEFLATE_ENCODING},
+		{ZIP, "gzip;level=1;q=0.1, deflate;q=0.2", DEFLATE_ENCODING},
+		{ZIP, "gzip;q=0", ""},
+		{ZIP, "gzip;q=0, deflate", DEFLATE_ENCODING},
+		{ZIP, "gzip;q=0.0, deflate;q=0", ""},
+
+		// Invalid q-values are ignored
+		{ZIP, "gzip;q=high, deflate;q=0.5", GZIP_ENCODING},
+		{ZIP, "gzip;q=2, deflate;q=0.5", GZIP_ENCODING},
+		{ZIP, "gzip;q=-1, deflate;q=0.5", GZIP_ENCODING},
+		{ZIP, "gzip;q=, deflate;q=0.5", GZIP_ENCODING},
+
+		// The wildcard applies to encodings not otherwise listed
+		{ZIP, "*", GZIP_ENCODING},
+		{ZIP, "*;q=0", ""},
+		{ZIP, "gzip;q=0, *", DEFLATE_ENCODING},
+		{ZIP, "*;q=0.5, deflate", DEFLATE_ENCODING},
+		{ZIP, "deflate;q=0, *;q=0.1", GZIP_ENCODING},
+	}
+
+	for _, c := range cases {
+		actual := getContentEncoding(c.compression, c.acceptEncoding)
+		if actual != c.expected {
+			t.Errorf("%s with Accept-Encoding %q: expected %q, got %q",
+				c.compression, c.acceptEncoding, c.expected, actual)
+		}
+	}
+}
+
+// closeNotifyRecorder adds http.CloseNotifier to the recorder, which
+// newHttpRequest requires.
+type closeNotifyRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (this closeNotifyRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestResponseEncodingHeaders(t *testing.T) {
+	cases := []struct {
+		compression    string
+		acceptEncoding string
+		expected       string
+	}{
+		{"", "", ""},
+		{"", "gzip", ""},
+		{"NONE", "gzip", ""},
+		{"ZIP", "", GZIP_ENCODING},
+		{"ZIP", "deflate", DEFLATE_ENCODING},
+
+		// Failed requests also vary
+		{"BOGUS", "gzip", ""},
+	}
+
+	for _, c := range cases {
+		form := url.Values{"statement": {"SELECT 1"}}
+		if c.compression != "" {
+			form.Set(COMPRESSION, c.compression)
+		}
+
+		req, _ := http.NewRequest("GET", "/query/service?"+form.Encode(), nil)
+		if c.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", c.acceptEncoding)
+		}
+
+		recorder := closeNotifyRecorder{httptest.NewRecorder()}
+		newHttpRequest(recorder, req, NewSyncPool(1<<10))
+
+		header := recorder.Header()
+		if encoding := header.Get("Content-Encoding"); encoding != c.expected {
+			t.Errorf("compression %q with Accept-Encoding %q: expected encoding %q, got %q",
+				c.compression, c.acceptEncoding, c.expected, encoding)
+		}
+
+		if vary := header["Vary"]; len(vary) != 1 || vary[0] != "Accept-Encoding" {
+			t.Errorf("compression %q with Accept-Encoding %q: expected Vary: Accept-Encoding, got %v",
+				c.compression, c.acceptEncoding, vary)
+		}
+	}
+}
@@ -35,6 +35,9 @@ type httpRequest struct {
 	resp         http.ResponseWriter
 	req          *http.Request
 	writer       responseDataManager
+	format       Format
+	tabular      *tabularWriter
+	compressor   *compressor
 	httpRespCode int
 	resultCount  int
 	resultSize   int
@@ -46,6 +49,9 @@ func newHttpRequest(resp http.ResponseWriter, req *http.Request, bp BufferPool)
 	var httpArgs httpRequestArgs
 	var err errors.Error
 
+	// The response may be compressed depending on Accept-Encoding
+	resp.Header().Add("Vary", "Accept-Encoding")
+
 	e := req.ParseForm()
 	if e != nil {
 		err = errors.NewServiceErrorBadValue(e, "request form")
@@ -112,7 +118,7 @@ func newHttpRequest(resp http.ResponseWriter, req *http.Request, bp BufferPool)
 		format, err = getFormat(httpArgs)
 	}
 
-	if err == nil && format != JSON {
+	if err == nil && format == XML {
 		err = errors.NewServiceErrorNotImplemented("format", format.String())
 	}
 
@@ -126,10 +132,15 @@ func newHttpRequest(resp http.ResponseWriter, req *http.Request, bp BufferPool)
 		compression, err = getCompression(httpArgs)
 	}
 
-	if err == nil && compression != NONE {
+	if err == nil && compression != NONE && compression != ZIP {
 		err = errors.NewServiceErrorNotImplemented("compression", compression.String())
 	}
 
+	content_encoding := ""
+	if err == nil {
+		content_encoding = getContentEncoding(compression, req.Header.Get("Accept-Encoding"))
+	}
+
 	var encoding Encoding
 	if err == nil {
 		encoding, err = getEncoding(httpArgs)
@@ -171,10 +182,20 @@ func newHttpRequest(resp http.ResponseWriter, req *http.Request, bp BufferPool)
 		BaseRequest: *base,
 		resp:        resp,
 		req:         req,
+		format:      format,
+	}
+
+	if format == CSV || format == TSV {
+		rv.tabular = newTabularWriter(format)
 	}
 
 	rv.SetTimeout(rv, timeout)
 
+	if content_encoding != "" {
+		rv.compressor = newCompressor(content_encoding)
+		resp.Header().Set("Content-Encoding", content_encoding)
+	}
+
 	rv.writer = NewBufferedWriter(rv, bp)
 
 	// Limit body size in case of denial-of-service attack
@@ -228,6 +249,11 @@ func getPrepared(a httpRequestArgs) (*plan.Prepared, errors.Error) {
 		return prepared, nil
 	}
 
+	// A name must refer to a cached prepared statement
+	if prepared_field.Type() == value.STRING {
+		return nil, errors.NewNoSuchPreparedError(prepared_field.Actual().(string))
+	}
+
 	prepared = &plan.Prepared{}
 	json_bytes, e := prepared_field.MarshalJSON()
 	if e != nil {
@@ -843,6 +869,21 @@ func (f Format) String() string {
 	return s
 }
 
+func (f Format) contentType() string {
+	var s string
+	switch f {
+	case XML:
+		s = "application/xml"
+	case CSV:
+		s = "text/csv"
+	case TSV:
+		s = "text/tab-separated-values"
+	default:
+		s = "application/json"
+	}
+	return s
+}
+
 type Compression int
 
 const (
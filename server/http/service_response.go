@@ -21,6 +21,7 @@ import (
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/value"
 )
@@ -58,6 +59,7 @@ func mapErrorToHttpResponse(err errors.Error) int {
 }
 
 func (this *httpRequest) Failed(srvr *server.Server) {
+	this.resp.Header().Set("Content-Type", JSON.contentType())
 	this.writeString("{\n")
 	this.writeRequestID()
 	this.writeClientContextID()
@@ -69,21 +71,40 @@ func (this *httpRequest) Failed(srvr *server.Server) {
 	this.writer.noMoreData()
 }
 
-func (this *httpRequest) Execute(srvr *server.Server, signature value.Value, stopNotify chan bool) {
+func (this *httpRequest) Execute(srvr *server.Server, prepared *plan.Prepared, stopNotify chan bool) {
 	defer this.Stop(server.COMPLETED)
 
 	this.NotifyStop(stopNotify)
 
+	this.resp.Header().Set("Content-Type", this.format.contentType())
 	this.httpRespCode = http.StatusOK
-	_ = this.writePrefix(srvr, signature) &&
+
+	if this.tabular != nil {
+		this.tabular.setSignature(prepared.Signature(), prepared.Columns())
 		this.writeResults()
-	this.writeSuffix(srvr.Metrics(), "")
+		this.writeTabularSuffix()
+	} else {
+		_ = this.writePrefix(srvr, prepared.Signature()) &&
+			this.writeResults()
+		this.writeSuffix(srvr.Metrics(), "")
+	}
 	this.writer.noMoreData()
 }
 
 func (this *httpRequest) Expire() {
 	defer this.Stop(server.TIMEOUT)
 
+	if this.tabular != nil {
+		if this.httpRespCode == 0 {
+			this.resp.Header().Set("Content-Type", this.format.contentType())
+		}
+		this.httpRespCode = http.StatusRequestTimeout
+		timeout := this.Timeout()
+		this.writeTabularErrors(errors.NewTimeoutError(&timeout))
+		this.writer.noMoreData()
+		return
+	}
+
 	if this.httpRespCode == 0 {
 		this.httpRespCode = http.StatusOK
 		this.writePrefix(&server.Server{}, nil)
@@ -133,6 +154,11 @@ func (this *httpRequest) writeResults() bool {
 		default:
 		}
 
+		// send compressed data held back while waiting for results
+		if this.compressor != nil && len(this.Results()) == 0 {
+			this.writer.flush()
+		}
+
 		select {
 		case item, ok = <-this.Results():
 			if ok {
@@ -152,6 +178,10 @@ func (this *httpRequest) writeResults() bool {
 }
 
 func (this *httpRequest) writeResult(item value.Value) bool {
+	if this.tabular != nil {
+		return this.writeTabularResult(item)
+	}
+
 	var rv bool
 	if this.resultCount == 0 {
 		rv = this.writeString("\n")
@@ -173,6 +203,83 @@ func (this *httpRequest) writeResult(item value.Value) bool {
 		this.writeString(string(bytes))
 }
 
+// Write a CSV or TSV record, preceded by the header record for the
+// first result.
+func (this *httpRequest) writeTabularResult(item value.Value) bool {
+	rv := true
+	if this.resultCount == 0 {
+		header, err := this.tabular.header(item)
+		if err != nil {
+			this.Errors() <- errors.NewServiceErrorInvalidJSON(err)
+			return false
+		}
+		rv = this.writeString(header)
+	}
+
+	record, err := this.tabular.row(item)
+	if err != nil {
+		this.Errors() <- errors.NewServiceErrorInvalidJSON(err)
+		return false
+	}
+
+	this.resultSize += len(record)
+	this.resultCount++
+
+	return rv && this.writeString(record)
+}
+
+func (this *httpRequest) writeTabularSuffix() bool {
+	rv := true
+	if this.resultCount == 0 {
+		header, err := this.tabular.header(nil)
+		if err == nil {
+			rv = this.writeString(header)
+		}
+	}
+
+	this.writeTabularErrors()
+	return rv
+}
+
+// Tabular responses carry the header and result records, followed by
+// a trailer record of the form #error,code,message for each error, so
+// that results cut short by an error can be told from complete ones
+// once the response has been flushed. The first error also determines
+// the http response code if the response has not been flushed yet.
+// Warnings are only counted.
+func (this *httpRequest) writeTabularErrors(errs ...errors.Error) {
+	var err errors.Error
+	ok := true
+loop:
+	for ok {
+		select {
+		case err, ok = <-this.Errors():
+			if ok {
+				errs = append(errs, err)
+			}
+		case _, ok = <-this.Warnings():
+			if ok {
+				this.warningCount++
+			}
+		default:
+			break loop
+		}
+	}
+
+	for _, err := range errs {
+		if this.errorCount == 0 && this.httpRespCode == http.StatusOK {
+			this.httpRespCode = mapErrorToHttpResponse(err)
+		}
+		this.errorCount++
+
+		record, e := this.tabular.record([]string{_TABULAR_ERROR,
+			strconv.Itoa(int(err.Code())), err.Error()})
+		if e == nil {
+			this.writeString(record)
+		}
+	}
+}
+
 func (this *httpRequest) writeValue(item value.Value) bool {
 	bytes, err := json.MarshalIndent(item, "    ", "    ")
 	if err != nil {
@@ -311,6 +418,7 @@ func (this *httpRequest) writeMetrics(metrics bool) bool {
 // the data in a response.
 type responseDataManager interface {
 	writeString(string) bool // write the given string for the response
+	flush()                  // send any data held back from a streamed response
 	noMoreData()             // action to take when there is no more data for the response
 }
 
@@ -325,12 +433,18 @@ type bufferedWriter struct {
 }
 
 func NewBufferedWriter(r *httpRequest, bp BufferPool) *bufferedWriter {
-	return &bufferedWriter{
+	rv := &bufferedWriter{
 		req:         r,
 		buffer:      bp.GetBuffer(),
 		buffer_pool: bp,
 		closed:      false,
 	}
+
+	// compressed data is buffered until the threshold is exceeded
+	if r.compressor != nil {
+		r.compressor.setOutput(rv.buffer)
+	}
+	return rv
 }
 
 func (this *bufferedWriter) writeString(s string) bool {
@@ -341,6 +455,10 @@ func (this *bufferedWriter) writeString(s string) bool {
 		return false
 	}
 
+	if this.req.compressor != nil {
+		return this.writeCompressed(s)
+	}
+
 	if len(s)+len(this.buffer.Bytes()) > this.buffer_pool.BufferCapacity() { // threshold exceeded
 		w := this.req.resp // our request's response writer
 		// write response header and data buffered so far using request's response writer:
@@ -359,6 +477,30 @@ func (this *bufferedWriter) writeString(s string) bool {
 	return err == nil
 }
 
+// Compress the string into our buffer, and switch to streaming the
+// response once the compressed data exceeds the threshold
+func (this *bufferedWriter) writeCompressed(s string) bool {
+	if !this.req.compressor.writeString(s) {
+		return false
+	}
+
+	if len(this.buffer.Bytes()) > this.buffer_pool.BufferCapacity() { // threshold exceeded
+		w := this.req.resp
+		w.WriteHeader(this.req.httpRespCode)
+		io.Copy(w, this.buffer)
+		this.req.compressor.setOutput(w)
+		this.req.writer = NewDirectWriter(this.req)
+		this.buffer_pool.PutBuffer(this.buffer)
+		this.closed = true
+		w.(http.Flusher).Flush()
+	}
+	return true
+}
+
+func (this *bufferedWriter) flush() {
+	// data stays buffered until the threshold is exceeded
+}
+
 func (this *bufferedWriter) noMoreData() {
 	this.Lock()
 	defer this.Unlock()
@@ -367,6 +509,11 @@ func (this *bufferedWriter) noMoreData() {
 		return
 	}
 
+	// flush the remaining compressed data to our buffer:
+	if this.req.compressor != nil {
+		this.req.compressor.close()
+	}
+
 	w := this.req.resp // our request's response writer
 	// calculate and set the Content-Length header:
 	content_len := strconv.Itoa(len(this.buffer.Bytes()))
@@ -403,11 +550,30 @@ func (this *directWriter) writeString(s string) bool {
 		return false
 	}
 	w := this.req.resp
+	if this.req.compressor != nil {
+		ok := this.req.compressor.writeString(s)
+		if ok && this.req.compressor.pending >= COMPRESSION_FLUSH_SIZE {
+			ok = this.req.compressor.flush()
+			w.(http.Flusher).Flush()
+		}
+		return ok
+	}
 	_, err := io.WriteString(w, s)
 	w.(http.Flusher).Flush()
 	return err == nil
 }
 
+func (this *directWriter) flush() {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.closed || this.req.compressor == nil {
+		return
+	}
+	this.req.compressor.flush()
+	this.req.resp.(http.Flusher).Flush()
+}
+
 func (this *directWriter) noMoreData() {
 	this.Lock()
 	defer this.Unlock()
@@ -415,5 +581,9 @@ func (this *directWriter) noMoreData() {
 	if this.closed {
 		return
 	}
+	if this.req.compressor != nil {
+		this.req.compressor.close()
+		this.req.resp.(http.Flusher).Flush()
+	}
 	this.closed = true
 }
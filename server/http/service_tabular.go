@@ -0,0 +1,183 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+// The first field of the trailer record written for each error
+const _TABULAR_ERROR = "#error"
+
+// tabularWriter renders result rows as CSV or TSV records. Columns are
+// derived from the statement signature, in projection order if it is
+// known; nested objects are flattened into dotted column names, using
+// the first row to discover their fields.
+type tabularWriter struct {
+	comma     rune
+	signature value.Value
+	order     []string
+	columns   []*tabularColumn
+	buffer    bytes.Buffer
+}
+
+type tabularColumn struct {
+	name string
+	path []string
+}
+
+func newTabularWriter(format Format) *tabularWriter {
+	rv := &tabularWriter{
+		comma: ',',
+	}
+
+	if format == TSV {
+		rv.comma = '\t'
+	}
+
+	return rv
+}
+
+// Sets the signature, and the names of the result terms in projection
+// order. Without the order, the terms are sorted by name.
+func (this *tabularWriter) setSignature(signature value.Value, order []string) {
+	this.signature = signature
+	this.order = order
+}
+
+// Returns the header record, computing the columns from the first row.
+func (this *tabularWriter) header(first value.Value) (string, error) {
+	this.columns = make([]*tabularColumn, 0, 16)
+
+	if this.signature != nil && this.signature.Type() == value.OBJECT {
+		fields := this.signature.Fields()
+		names := this.order
+		if len(names) == 0 {
+			names = sortedNames(fields)
+		}
+
+		expanded := false
+		for _, name := range names {
+			if name != "*" {
+				this.addColumns(first, []string{name})
+				continue
+			}
+
+			// Expand * once, using the fields of the first row
+			if !expanded && first != nil && first.Type() == value.OBJECT {
+				expanded = true
+				for _, f := range sortedNames(first.Fields()) {
+					if _, ok := fields[f]; !ok {
+						this.addColumns(first, []string{f})
+					}
+				}
+			}
+		}
+	} else {
+		this.addColumns(first, nil)
+	}
+
+	names := make([]string, len(this.columns))
+	for i, column := range this.columns {
+		names[i] = column.name
+	}
+
+	return this.record(names)
+}
+
+func (this *tabularWriter) addColumns(first value.Value, path []string) {
+	val := lookupPath(first, path)
+	if val != nil && val.Type() == value.OBJECT {
+		fields := val.Fields()
+		if len(fields) > 0 {
+			for _, f := range sortedNames(fields) {
+				this.addColumns(first, append(path[:len(path):len(path)], f))
+			}
+			return
+		}
+	}
+
+	name := strings.Join(path, ".")
+	if name == "" {
+		name = "$1"
+	}
+
+	this.columns = append(this.columns, &tabularColumn{name: name, path: path})
+}
+
+// Returns the record for a result row.
+func (this *tabularWriter) row(item value.Value) (string, error) {
+	fields := make([]string, len(this.columns))
+	for i, column := range this.columns {
+		val := lookupPath(item, column.path)
+		if val == nil {
+			continue
+		}
+
+		switch val.Type() {
+		case value.MISSING, value.NULL:
+		case value.STRING:
+			fields[i] = val.Actual().(string)
+		default:
+			bytes, err := val.MarshalJSON()
+			if err != nil {
+				return "", err
+			}
+			fields[i] = string(bytes)
+		}
+	}
+
+	return this.record(fields)
+}
+
+func (this *tabularWriter) record(fields []string) (string, error) {
+	this.buffer.Reset()
+
+	w := csv.NewWriter(&this.buffer)
+	w.Comma = this.comma
+	err := w.Write(fields)
+	if err != nil {
+		return "", err
+	}
+
+	w.Flush()
+	return this.buffer.String(), w.Error()
+}
+
+func lookupPath(item value.Value, path []string) value.Value {
+	for _, name := range path {
+		if item == nil {
+			return nil
+		}
+
+		var ok bool
+		item, ok = item.Field(name)
+		if !ok {
+			return nil
+		}
+	}
+
+	return item
+}
+
+func sortedNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
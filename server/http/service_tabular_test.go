@@ -0,0 +1,169 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/value"
+)
+
+func TestTabularWriter(t *testing.T) {
+	cases := []struct {
+		format    Format
+		statement string
+		rows      []string
+		expected  string
+	}{
+		// Columns in projection order
+		{CSV, "SELECT b, a FROM t", []string{`{"a": "x", "b": 1}`, `{"a": "y"}`},
+			"b,a\n1,x\n,y\n"},
+		{TSV, "SELECT b, a FROM t", []string{`{"a": "x", "b": 1}`},
+			"b\ta\n1\tx\n"},
+
+		// Nested objects are flattened, and * expanded, in name order
+		{CSV, "SELECT o FROM t", []string{`{"o": {"y": 2, "x": {"z": true}}}`},
+			"o.x.z,o.y\ntrue,2\n"},
+		{CSV, "SELECT id, t.*, u.* FROM t", []string{`{"id": 1, "c": 3, "b": [2]}`},
+			"id,b,c\n1,[2],3\n"},
+
+		// Raw projections have a single column
+		{CSV, "SELECT RAW a FROM t", []string{`"x"`, `{"a": 1}`},
+			"$1\nx\n\"{\"\"a\"\":1}\"\n"},
+
+		// Fields are quoted as needed; NULL and MISSING are empty
+		{CSV, "SELECT a, b FROM t", []string{`{"a": "one, \"two\"", "b": null}`},
+			"a,b\n\"one, \"\"two\"\"\",\n"},
+
+		// Without results, the header comes from the signature
+		{CSV, "SELECT b, a FROM t", nil, "b,a\n"},
+	}
+
+	for _, c := range cases {
+		stmt, err := n1ql.ParseStatement(c.statement)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", c.statement, err)
+		}
+
+		writer := newTabularWriter(c.format)
+		writer.setSignature(stmt.Signature(), stmt.(*algebra.Select).Columns())
+
+		var first value.Value
+		if len(c.rows) > 0 {
+			first = value.NewValue([]byte(c.rows[0]))
+		}
+
+		actual, err := writer.header(first)
+		if err != nil {
+			t.Fatalf("%s: failed to write header: %v", c.statement, err)
+		}
+
+		for _, row := range c.rows {
+			record, err := writer.row(value.NewValue([]byte(row)))
+			if err != nil {
+				t.Fatalf("%s: failed to write row %s: %v", c.statement, row, err)
+			}
+
+			actual += record
+		}
+
+		if actual != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.statement, c.expected, actual)
+		}
+	}
+}
+
+func TestTabularWriterOrder(t *testing.T) {
+	signature := value.NewValue(map[string]interface{}{"b": "number", "a": "string"})
+	first := value.NewValue(map[string]interface{}{"a": "x", "b": 1})
+
+	// Without the projection order, columns are sorted by name
+	writer := newTabularWriter(CSV)
+	writer.setSignature(signature, nil)
+	if header, _ := writer.header(first); header != "a,b\n" {
+		t.Errorf("expected sorted header, got %q", header)
+	}
+
+	writer.setSignature(signature, []string{"b", "a"})
+	if header, _ := writer.header(first); header != "b,a\n" {
+		t.Errorf("expected header in projection order, got %q", header)
+	}
+}
+
+func TestTabularErrorRecord(t *testing.T) {
+	for format, expected := range map[Format]string{
+		CSV: "#error,5000,\"Invalid value, \"\"x\"\"\"\n",
+		TSV: "#error\t5000\t\"Invalid value, \"\"x\"\"\"\n",
+	} {
+		record, err := newTabularWriter(format).record(
+			[]string{_TABULAR_ERROR, "5000", `Invalid value, "x"`})
+		if err != nil {
+			t.Fatalf("failed to write record: %v", err)
+		}
+
+		if record != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, record)
+		}
+	}
+}
+
+func TestTabularErrors(t *testing.T) {
+	cases := []struct {
+		bufferSize int
+		code       int
+	}{
+		// Buffered response: the error determines the status
+		{1 << 16, http.StatusInternalServerError},
+
+		// Response flushed before the error: only the trailer tells
+		{4, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		request := &httpRequest{
+			BaseRequest: *server.NewBaseRequest("", nil, nil, nil, "", value.NONE, value.NONE,
+				value.NONE, nil, "", nil),
+			resp:         recorder,
+			format:       CSV,
+			tabular:      newTabularWriter(CSV),
+			httpRespCode: http.StatusOK,
+		}
+		request.writer = NewBufferedWriter(request, NewSyncPool(c.bufferSize))
+		request.tabular.setSignature(value.NewValue(map[string]interface{}{"a": "number"}), nil)
+
+		for i := 0; i < 3; i++ {
+			request.writeResult(value.NewValue(map[string]interface{}{"a": i}))
+		}
+
+		request.Errors() <- errors.NewError(nil, "Failed, halfway")
+		request.writeTabularSuffix()
+		request.writer.noMoreData()
+
+		if recorder.Code != c.code {
+			t.Errorf("buffer of %d: expected status %d, got %d", c.bufferSize, c.code, recorder.Code)
+		}
+
+		expected := "a\n0\n1\n2\n#error,5000,\"Failed, halfway\"\n"
+		if body := recorder.Body.String(); body != expected {
+			t.Errorf("buffer of %d: expected %q, got %q", c.bufferSize, expected, body)
+		}
+
+		if request.errorCount != 1 {
+			t.Errorf("buffer of %d: expected one error, got %d", c.bufferSize, request.errorCount)
+		}
+	}
+}
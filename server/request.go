@@ -59,11 +59,15 @@ type Request interface {
 	CloseNotify() chan bool
 	Servicing()
 	Fail(err errors.Error)
-	Execute(server *Server, signature value.Value, notifyStop chan bool)
+	Execute(server *Server, prepared *plan.Prepared, notifyStop chan bool)
 	Failed(server *Server)
 	Expire()
+	Stop(state State)
 	State() State
 	Credentials() datastore.Credentials
+	ResultCount() uint64
+	ErrorCount() uint64
+	MutationCount() uint64
 }
 
 type RequestID interface {
@@ -105,6 +109,8 @@ type BaseRequest struct {
 	metrics        value.Tristate
 	consistency    ScanConfiguration
 	mutationCount  uint64
+	resultCount    uint64
+	errorCount     uint64
 	requestTime    time.Time
 	serviceTime    time.Time
 	state          State
@@ -284,6 +290,7 @@ func (this *BaseRequest) Result(item value.Value) bool {
 
 	select {
 	case this.results <- item:
+		atomic.AddUint64(&this.resultCount, 1)
 		return true
 	case <-this.stopResult:
 		return false
@@ -303,6 +310,7 @@ func (this *BaseRequest) Fatal(err errors.Error) {
 func (this *BaseRequest) Error(err errors.Error) {
 	select {
 	case this.errors <- err:
+		atomic.AddUint64(&this.errorCount, 1)
 	default:
 	}
 }
@@ -322,6 +330,14 @@ func (this *BaseRequest) MutationCount() uint64 {
 	return atomic.LoadUint64(&this.mutationCount)
 }
 
+func (this *BaseRequest) ResultCount() uint64 {
+	return atomic.LoadUint64(&this.resultCount)
+}
+
+func (this *BaseRequest) ErrorCount() uint64 {
+	return atomic.LoadUint64(&this.errorCount)
+}
+
 func (this *BaseRequest) Results() value.ValueChannel {
 	return this.results
 }
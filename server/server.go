@@ -10,19 +10,24 @@
 package server
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"runtime"
+	"strings"
 
 	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/couchbase/query/accounting"
+	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/clustering"
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/system"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/logging"
 	"github.com/couchbase/query/parser/n1ql"
 	"github.com/couchbase/query/plan"
@@ -43,6 +48,17 @@ type Server struct {
 	metrics     bool
 	keepAlive   int
 	once        sync.Once
+	active      *activeRequests
+	completed   *completedRequests
+	orderLimit  int64
+	mutLimit    int64
+	sortMemory  int64
+	functions   clustering.FunctionStore
+	definitions map[string]string // Function definitions loaded from the store, by name
+	failed      map[string]string // Function definitions that could not be loaded, by name
+	histograms  clustering.HistogramStore
+	stop        chan bool // Closed to stop reloading the stores
+	closed      sync.Once
 }
 
 // Default Keep Alive Length
@@ -65,17 +81,184 @@ func NewServer(store datastore.Datastore, config clustering.ConfigurationStore,
 		signature:   signature,
 		metrics:     metrics,
 		keepAlive:   keepAlive,
+		active:      newActiveRequests(),
+		completed:   newCompletedRequests(),
+		sortMemory:  execution.SORT_MEMORY_DEFAULT,
+		stop:        make(chan bool),
 	}
 
-	sys, err := system.NewDatastore(store)
+	sys, err := system.NewDatastore(store, rv.active, rv.completed)
 	if err != nil {
 		return nil, err
 	}
 
 	rv.systemstore = sys
+
+	functions, ok := config.(clustering.FunctionStore)
+	if ok {
+		rv.functions = functions
+		rv.definitions = make(map[string]string)
+		rv.loadFunctions()
+	}
+
+	histograms, ok := config.(clustering.HistogramStore)
+	if ok {
+		rv.histograms = histograms
+		rv.loadHistograms()
+	}
+
+	if rv.functions != nil || rv.histograms != nil {
+		go rv.refreshStores()
+	}
+
 	return rv, nil
 }
 
+// Interval at which the user-defined functions and the histograms are
+// reloaded from the configuration store, to pick up the changes made
+// by other query nodes
+const _STORE_REFRESH_INTERVAL = 10 * time.Second
+
+func (this *Server) refreshStores() {
+	ticker := time.NewTicker(_STORE_REFRESH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-this.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if this.functions != nil {
+			this.loadFunctions()
+		}
+
+		if this.histograms != nil {
+			this.loadHistograms()
+		}
+	}
+}
+
+// Stops reloading the functions and histograms from the configuration store
+func (this *Server) Close() {
+	this.closed.Do(func() {
+		close(this.stop)
+	})
+}
+
+/*
+Loads the histograms of the histogram store, unless a histogram of
+the same expression over the same keyspace was updated since.
+*/
+func (this *Server) loadHistograms() {
+	stored, err := this.histograms.Histograms()
+	if err != nil {
+		logging.Errorp("Error loading histograms", logging.Pair{"error", err})
+		return
+	}
+
+	for key, bytes := range stored {
+		histogram, err := datastore.NewHistogramFromValue(value.NewValue(bytes))
+		if err != nil {
+			logging.Errorp("Error loading histogram",
+				logging.Pair{"key", key}, logging.Pair{"error", err})
+			continue
+		}
+
+		current := datastore.HistogramByKey(histogram.Key())
+		if current == nil || histogram.Updated().After(current.Updated()) {
+			datastore.SetHistogram(histogram)
+		}
+	}
+}
+
+/*
+Synchronizes the user-defined functions with the function store.
+Functions that are no longer stored, or whose stored definition has
+changed, are unregistered, and the prepared statements that call them
+invalidated. Stored functions that are not registered are then
+registered. A function may call functions that were defined before
+it, so the definitions are parsed repeatedly until no more can be
+registered.
+*/
+func (this *Server) loadFunctions() {
+	stored, err := this.functions.FunctionDefinitions()
+	if err != nil {
+		logging.Errorp("Error loading functions", logging.Pair{"error", err})
+		return
+	}
+
+	definitions := make(map[string]string, len(stored))
+	for name, definition := range stored {
+		definitions[strings.ToLower(name)] = definition
+	}
+
+	loaded := this.definitions
+	this.definitions = make(map[string]string, len(definitions))
+	for _, name := range expression.UserFunctionNames() {
+		definition, ok := definitions[name]
+		previous, known := loaded[name]
+		if ok && (!known || previous == definition) {
+			// Created by this node, or unchanged
+			this.definitions[name] = definition
+			delete(definitions, name)
+			continue
+		}
+
+		expression.UnregisterUserFunction(name)
+		plan.PreparedCache().InvalidateFunction(name)
+	}
+
+	for len(definitions) > 0 {
+		failed := make(map[string]error, len(definitions))
+		for name, definition := range definitions {
+			e := registerFunction(definition)
+			if e != nil {
+				failed[name] = e
+			}
+		}
+
+		if len(failed) == len(definitions) {
+			// Report each failed definition once
+			for name, e := range failed {
+				if this.failed[name] != definitions[name] {
+					logging.Errorp("Error loading function",
+						logging.Pair{"name", name}, logging.Pair{"error", e})
+				}
+			}
+			break
+		}
+
+		for name, definition := range definitions {
+			if _, ok := failed[name]; !ok {
+				this.definitions[name] = definition
+				delete(definitions, name)
+			}
+		}
+	}
+
+	this.failed = definitions
+}
+
+func registerFunction(definition string) error {
+	stmt, err := n1ql.ParseStatement(definition)
+	if err != nil {
+		return err
+	}
+
+	create, ok := stmt.(*algebra.CreateFunction)
+	if !ok {
+		return fmt.Errorf("Invalid function definition %s.", definition)
+	}
+
+	if !expression.RegisterUserFunction(create.Name(), create.Parameters(), create.Body()) {
+		return fmt.Errorf("Function %s already exists.", create.Name())
+	}
+
+	return nil
+}
+
 func (this *Server) Datastore() datastore.Datastore {
 	return this.datastore
 }
@@ -104,6 +287,53 @@ func (this *Server) KeepAlive() int {
 	return this.keepAlive
 }
 
+// Maximum number of requests kept in system:completed_requests
+func (this *Server) SetCompletedLimit(limit int) {
+	this.completed.setLimit(limit)
+}
+
+// Requests running longer than the threshold are kept in
+// system:completed_requests
+func (this *Server) SetCompletedThreshold(threshold time.Duration) {
+	this.completed.setThreshold(threshold)
+}
+
+// Maximum number of items sorted by an ORDER BY; zero or negative
+// disables the limit
+func (this *Server) SetOrderLimit(limit int64) {
+	this.orderLimit = limit
+}
+
+func (this *Server) OrderLimit() int64 {
+	return this.orderLimit
+}
+
+// Maximum number of documents modified by a DELETE, UPDATE or MERGE;
+// zero or negative disables the limit
+func (this *Server) SetMutationLimit(limit int64) {
+	this.mutLimit = limit
+}
+
+func (this *Server) MutationLimit() int64 {
+	return this.mutLimit
+}
+
+// Applies the order and mutation limits of the query node options
+func (this *Server) SetQueryNodeOptions(options clustering.QueryNodeOptions) {
+	this.SetOrderLimit(int64(options.OrderLimit()))
+	this.SetMutationLimit(int64(options.UpdateLimit()))
+}
+
+// Memory budget, in bytes, of the ORDER BY and hash JOIN operators of
+// each request
+func (this *Server) SetSortMemory(size int64) {
+	this.sortMemory = size
+}
+
+func (this *Server) SortMemory() int64 {
+	return this.sortMemory
+}
+
 func (this *Server) Serve() {
 	this.once.Do(func() {
 		// Use a threading model. Do not spawn a separate
@@ -166,6 +396,7 @@ func (this *Server) serviceRequest(request Request) {
 	if request.State() == FATAL {
 		// Fail the request - Write out response - and return
 		request.Failed(this)
+		this.completed.add(request, FATAL)
 		return
 	}
 
@@ -175,15 +406,34 @@ func (this *Server) serviceRequest(request Request) {
 		defer timer.Stop()
 	}
 
-	go request.Execute(this, prepared.Signature(), operator.StopChannel())
+	this.active.add(request, operator)
+	go func() {
+		request.Execute(this, prepared, operator.StopChannel())
+		this.requestDone(request)
+	}()
 
 	context := execution.NewContext(this.datastore, this.systemstore, namespace,
 		this.readonly, request.NamedArgs(), request.PositionalArgs(), request.Credentials(),
 		request.ScanConsistency(), request.ScanVector(),
 		request.Output())
+	context.SetOrderLimit(this.orderLimit)
+	context.SetMutationLimit(this.mutLimit)
+	context.SetSortMemory(this.sortMemory)
+	context.SetFunctionStore(this.functions)
+	context.SetHistogramStore(this.histograms)
+	context.SetFetchExpiration(prepared.Expiration())
 	operator.RunOnce(context, nil)
 }
 
+func (this *Server) requestDone(request Request) {
+	state := request.State()
+	entry := this.active.remove(request)
+	if entry != nil && entry.stopped {
+		state = STOPPED
+	}
+	this.completed.add(request, state)
+}
+
 func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepared, errors.Error) {
 	prepared := request.Prepared()
 	if prepared == nil {
@@ -192,6 +442,11 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 			return nil, errors.NewParseSyntaxError(err, "")
 		}
 
+		er := this.checkLimits(stmt)
+		if er != nil {
+			return nil, er
+		}
+
 		prepared, err = plan.BuildPrepared(stmt, this.datastore, this.systemstore, namespace, false)
 		if err != nil {
 			return nil, errors.NewPlanError(err, "")
@@ -204,6 +459,92 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 	return prepared, nil
 }
 
+/*
+Rejects a statement whose constant LIMIT exceeds the order limit or
+the mutation limit, or is not a valid limit, if that limit is set.
+Prepared and explained statements are checked as well. Other
+statements, and prepared plans that are executed, are checked as
+they execute.
+*/
+func (this *Server) checkLimits(stmt algebra.Statement) errors.Error {
+	switch stmt := stmt.(type) {
+	case *algebra.Select:
+		if this.orderLimit <= 0 || stmt.Order() == nil || stmt.Limit() == nil {
+			return nil
+		}
+
+		limit, ok, err := constantLimit(stmt.Limit(), "LIMIT")
+		if !ok {
+			return err
+		}
+
+		if stmt.Offset() != nil {
+			offset, ok, err := constantLimit(stmt.Offset(), "OFFSET")
+			if !ok {
+				return err
+			}
+
+			if limit > math.MaxInt64-offset {
+				limit = math.MaxInt64
+			} else {
+				limit += offset
+			}
+		}
+
+		if limit > this.orderLimit {
+			return errors.NewOrderLimitError(this.orderLimit)
+		}
+	case *algebra.Delete:
+		return this.checkMutationLimit(stmt.Limit())
+	case *algebra.Update:
+		return this.checkMutationLimit(stmt.Limit())
+	case *algebra.Merge:
+		return this.checkMutationLimit(stmt.Limit())
+	case *algebra.Prepare:
+		return this.checkLimits(stmt.Statement())
+	case *algebra.Explain:
+		return this.checkLimits(stmt.Statement())
+	}
+
+	return nil
+}
+
+func (this *Server) checkMutationLimit(expr expression.Expression) errors.Error {
+	if this.mutLimit <= 0 || expr == nil {
+		return nil
+	}
+
+	limit, ok, err := constantLimit(expr, "LIMIT")
+	if ok && limit > this.mutLimit {
+		return errors.NewMutationLimitError(this.mutLimit)
+	}
+
+	return err
+}
+
+/*
+Returns the value of a constant LIMIT or OFFSET, clamped to the
+largest int64. Returns false if the expression is not constant, with
+an error if its value is not a non-negative integer.
+*/
+func constantLimit(expr expression.Expression, clause string) (int64, bool, errors.Error) {
+	val := expr.Value()
+	if val == nil {
+		return 0, false, nil
+	}
+
+	limit, ok := val.Actual().(float64)
+	if !ok || limit < 0 || math.Trunc(limit) != limit {
+		return 0, false, errors.NewError(nil, fmt.Sprintf("Invalid %s value %v.", clause, val.Actual()))
+	}
+
+	if limit >= math.MaxInt64 {
+		return math.MaxInt64, true, nil
+	}
+
+	return int64(limit), true, nil
+}
+
 func logExplain(prepared *plan.Prepared) {
 	var plan plan.Operator
 
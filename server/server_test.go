@@ -0,0 +1,306 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/couchbase/query/clustering"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/logging/logger_golog"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+func init() {
+	logging.SetLogger(logger_golog.NewLogger(ioutil.Discard, logging.Info, false))
+}
+
+func TestCheckLimits(t *testing.T) {
+	server := &Server{}
+	server.SetQueryNodeOptions(clustering.NewOptions("", "", "", "default", false, false, false,
+		0, 0, 10, 5, "", "", "", false, "", "", ""))
+
+	if server.OrderLimit() != 10 || server.MutationLimit() != 5 {
+		t.Fatalf("expected limits 10 and 5 from the options, got %d and %d",
+			server.OrderLimit(), server.MutationLimit())
+	}
+
+	cases := []struct {
+		statement string
+		code      int32
+	}{
+		// Order limit
+		{"SELECT a FROM t ORDER BY a LIMIT 10", 0},
+		{"SELECT a FROM t ORDER BY a LIMIT 11", 5020},
+		{"SELECT a FROM t ORDER BY a LIMIT 5 OFFSET 6", 5020},
+		{"SELECT a FROM t ORDER BY a LIMIT 1e19", 5020},
+		{"SELECT a FROM t ORDER BY a LIMIT 5 OFFSET 9223372036854775807", 5020},
+		{"SELECT a FROM t LIMIT 100", 0},
+		{"SELECT a FROM t ORDER BY a LIMIT $1", 0},
+
+		// Invalid limits
+		{"SELECT a FROM t ORDER BY a LIMIT -1", 5000},
+		{"SELECT a FROM t ORDER BY a LIMIT 1.5", 5000},
+		{"SELECT a FROM t ORDER BY a LIMIT 'ten'", 5000},
+		{"SELECT a FROM t ORDER BY a LIMIT 5 OFFSET -1", 5000},
+		{"DELETE FROM t LIMIT -1", 5000},
+
+		// Mutation limit
+		{"DELETE FROM t LIMIT 5", 0},
+		{"DELETE FROM t LIMIT 6", 5050},
+		{"UPDATE t SET a = 1 LIMIT 6", 5050},
+		{"DELETE FROM t", 0},
+
+		// Prepared and explained statements
+		{"PREPARE SELECT a FROM t ORDER BY a LIMIT 11", 5020},
+		{"PREPARE DELETE FROM t LIMIT 6", 5050},
+		{"EXPLAIN SELECT a FROM t ORDER BY a LIMIT 11", 5020},
+		{"EXPLAIN UPDATE t SET a = 1 LIMIT 1.5", 5000},
+		{"PREPARE SELECT a FROM t ORDER BY a LIMIT 10", 0},
+	}
+
+	for _, c := range cases {
+		stmt, err := n1ql.ParseStatement(c.statement)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", c.statement, err)
+		}
+
+		e := server.checkLimits(stmt)
+		switch {
+		case c.code == 0 && e != nil:
+			t.Errorf("%s: expected no error, got %v", c.statement, e)
+		case c.code != 0 && e == nil:
+			t.Errorf("%s: expected error %d, got none", c.statement, c.code)
+		case c.code != 0 && e.Code() != c.code:
+			t.Errorf("%s: expected error %d, got %d: %v", c.statement, c.code, e.Code(), e)
+		}
+	}
+
+	// Without limits, no limit is rejected before the statement executes
+	server = &Server{}
+	for statement, code := range map[string]int32{
+		"SELECT a FROM t ORDER BY a LIMIT 1000000": 0,
+		"SELECT a FROM t ORDER BY a LIMIT 1.5":     0,
+		"DELETE FROM t LIMIT 1000000":              0,
+		"DELETE FROM t LIMIT -1":                   0,
+	} {
+		stmt, err := n1ql.ParseStatement(statement)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", statement, err)
+		}
+
+		e := server.checkLimits(stmt)
+		if (code == 0 && e != nil) || (code != 0 && (e == nil || e.Code() != code)) {
+			t.Errorf("%s: expected error %d, got %v", statement, code, e)
+		}
+	}
+}
+
+type testingFunctionStore map[string]string
+
+func (this testingFunctionStore) FunctionDefinitions() (map[string]string, errors.Error) {
+	rv := make(map[string]string, len(this))
+	for name, definition := range this {
+		rv[name] = definition
+	}
+	return rv, nil
+}
+
+func (this testingFunctionStore) CreateFunctionDefinition(name, definition string) errors.Error {
+	if _, ok := this[name]; ok {
+		return errors.NewFunctionExistsError(name)
+	}
+	this[name] = definition
+	return nil
+}
+
+func (this testingFunctionStore) DeleteFunctionDefinition(name string) errors.Error {
+	delete(this, name)
+	return nil
+}
+
+func TestLoadFunctions(t *testing.T) {
+	store := testingFunctionStore{
+		"lf_double": "CREATE FUNCTION lf_double(x) { x * 2 }",
+		"lf_quad":   "CREATE FUNCTION lf_quad(x) { lf_double(lf_double(x)) }",
+		"lf_broken": "CREATE FUNCTION lf_broken(x) { lf_missing(x) }",
+	}
+
+	server := &Server{functions: store, definitions: make(map[string]string)}
+	defer func() {
+		for _, name := range []string{"lf_double", "lf_quad", "lf_triple", "lf_local"} {
+			expression.UnregisterUserFunction(name)
+		}
+	}()
+
+	// Functions may call functions loaded after them
+	server.loadFunctions()
+	checkFunction(t, "lf_quad(1.5)", 6)
+	if _, ok := expression.GetUserFunction("lf_broken"); ok {
+		t.Errorf("expected lf_broken not to be registered")
+	}
+
+	if server.failed["lf_broken"] == "" {
+		t.Errorf("expected lf_broken to be recorded as failed, got %v", server.failed)
+	}
+
+	// Prepared statements that call a function, directly or not
+	prepare := func(name, statement string) {
+		stmt, err := n1ql.ParseStatement(statement)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", statement, err)
+		}
+
+		prepared, err := plan.BuildPrepared(stmt, nil, nil, "default", false)
+		if err != nil {
+			t.Fatalf("failed to prepare %s: %v", statement, err)
+		}
+
+		prepared.SetName(name)
+		plan.PreparedCache().AddPrepared(prepared)
+	}
+
+	prepare("lf_p1", "SELECT lf_quad(1) AS q")
+	prepare("lf_p2", "SELECT lf_double(1) AS d")
+	prepare("lf_p3", "SELECT 1 AS one")
+	defer func() {
+		for _, name := range []string{"lf_p1", "lf_p2", "lf_p3"} {
+			plan.PreparedCache().DeletePrepared(name)
+		}
+	}()
+
+	// A function created by this node is kept
+	expression.RegisterUserFunction("lf_local", []string{"x"}, expression.NewIdentifier("x"))
+	store["lf_local"] = "CREATE FUNCTION lf_local(x) { x }"
+
+	// Functions dropped, redefined and created by other nodes
+	delete(store, "lf_quad")
+	store["lf_double"] = "CREATE FUNCTION lf_double(x) { x + x + x }"
+	store["lf_triple"] = "CREATE FUNCTION lf_triple(x) { x * 3 }"
+	server.loadFunctions()
+
+	if _, ok := expression.GetUserFunction("lf_quad"); ok {
+		t.Errorf("expected lf_quad to be unregistered")
+	}
+
+	checkFunction(t, "lf_double(2)", 6)
+	checkFunction(t, "lf_triple(2)", 6)
+	checkFunction(t, "lf_local(2)", 2)
+
+	for name, cached := range map[string]bool{"lf_p1": false, "lf_p2": false, "lf_p3": true} {
+		if (plan.PreparedCache().Entry(name) != nil) != cached {
+			t.Errorf("expected %s to be cached: %v", name, cached)
+		}
+	}
+
+	// Unchanged functions are kept
+	prepare("lf_p2", "SELECT lf_double(1) AS d")
+	server.loadFunctions()
+	if plan.PreparedCache().Entry("lf_p2") == nil {
+		t.Errorf("expected lf_p2 to stay cached")
+	}
+}
+
+func checkFunction(t *testing.T, expr string, expected float64) {
+	e, err := n1ql.ParseExpression(expr)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", expr, err)
+	}
+
+	v, err := e.Evaluate(value.NewValue(nil), nil)
+	if err != nil || v.Actual() != expected {
+		t.Errorf("expected %s to be %v, got %v and %v", expr, expected, v, err)
+	}
+}
+
+type testingHistogramStore map[string][]byte
+
+func (this testingHistogramStore) Histograms() (map[string][]byte, errors.Error) {
+	return this, nil
+}
+
+func (this testingHistogramStore) SetHistogram(key string, histogram []byte) errors.Error {
+	this[key] = histogram
+	return nil
+}
+
+func TestLoadHistograms(t *testing.T) {
+	store := testingHistogramStore{}
+	histogram := func(keyspace string, updated string, n float64) {
+		bytes, _ := value.NewValue(map[string]interface{}{
+			"namespace": "default", "keyspace": keyspace, "expression": "`n`",
+			"updated": updated, "count": 10, "distinct": 1,
+			"bins": []interface{}{map[string]interface{}{"count": 10, "distinct": 1, "min": n, "max": n}},
+		}).MarshalJSON()
+		store["default:"+keyspace+":`n`"] = bytes
+	}
+
+	// A histogram updated by this node since is kept
+	local := datastore.NewHistogram("default", "lh_local", "`n`", value.Values{value.NewValue(1)}, 10, 1)
+	datastore.SetHistogram(local)
+
+	histogram("lh_local", "2015-01-02T03:04:05Z", 2)
+	histogram("lh_remote", "2015-01-02T03:04:05Z", 3)
+	store["default:lh_invalid:`n`"] = []byte(`{"namespace": "default"}`)
+
+	server := &Server{histograms: store}
+	server.loadHistograms()
+
+	if datastore.GetHistogram("default", "lh_local", "`n`") != local {
+		t.Errorf("expected the local histogram to be kept")
+	}
+
+	remote := datastore.GetHistogram("default", "lh_remote", "`n`")
+	if remote == nil {
+		t.Fatalf("expected the stored histogram to be loaded")
+	}
+
+	if max, _ := remote.Max(); max[0].Actual() != 3.0 {
+		t.Errorf("expected max 3, got %v", max)
+	}
+
+	if datastore.GetHistogram("default", "lh_invalid", "`n`") != nil {
+		t.Errorf("expected the invalid histogram not to be loaded")
+	}
+
+	// A histogram updated by another node replaces it
+	histogram("lh_remote", "2015-01-03T03:04:05Z", 4)
+	server.loadHistograms()
+	remote = datastore.GetHistogram("default", "lh_remote", "`n`")
+	if max, _ := remote.Max(); max[0].Actual() != 4.0 {
+		t.Errorf("expected max 4, got %v", max)
+	}
+}
+
+func TestCloseServer(t *testing.T) {
+	server := &Server{histograms: testingHistogramStore{}, stop: make(chan bool)}
+	done := make(chan bool)
+	go func() {
+		server.refreshStores()
+		close(done)
+	}()
+
+	// Closing stops reloading the stores, and can be repeated
+	server.Close()
+	server.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the stores not to be reloaded after closing the server")
+	}
+}
@@ -0,0 +1,319 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	CMD_PREFIX = "\\"
+	MAX_SOURCE = 16 // Maximum nesting of \SOURCE
+)
+
+// Shell holds the settings applied to each statement, and the
+// destination of the results.
+type Shell struct {
+	server   string
+	named    map[string]string // Named parameters, as JSON text
+	args     string            // Positional parameters, as a JSON array
+	format   string
+	timing   bool
+	output   io.Writer
+	redirect *os.File
+	failures int // Statements and commands that failed
+	depth    int // Nesting of \SOURCE
+	names    *nameCache
+	client   *http.Client // Reused by every statement
+}
+
+func NewShell(server string) *Shell {
+	rv := &Shell{
+		server: server,
+		named:  make(map[string]string),
+		format: FORMAT_JSON,
+		output: os.Stdout,
+		client: newClient(server),
+	}
+
+	rv.names = newNameCache(rv)
+	return rv
+}
+
+func (this *Shell) Failures() int {
+	return this.failures
+}
+
+func isCommand(line string) bool {
+	return strings.HasPrefix(line, CMD_PREFIX)
+}
+
+// Execute a statement with the current settings, counting failures.
+func (this *Shell) Execute(statement string) {
+	err := this.execute(statement)
+	if err != nil {
+		this.failures++
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+	}
+}
+
+// Run a meta-command, e.g. \SET -format table
+func (this *Shell) Command(line string) {
+	err := this.command(line)
+	if err != nil {
+		this.failures++
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+	}
+}
+
+func (this *Shell) command(line string) error {
+	line = strings.TrimSpace(strings.TrimPrefix(line, CMD_PREFIX))
+	for strings.HasSuffix(line, QRY_EOL) {
+		line = strings.TrimSpace(strings.TrimSuffix(line, QRY_EOL))
+	}
+
+	name, rest := splitWord(line)
+	switch strings.ToUpper(name) {
+	case "SET":
+		return this.set(rest)
+	case "UNSET":
+		return this.unset(rest)
+	case "SOURCE":
+		return this.source(rest)
+	case "REDIRECT":
+		return this.setRedirect(rest)
+	case "TIMING":
+		return this.setTiming(rest)
+	default:
+		return fmt.Errorf("Unknown command %s%s", CMD_PREFIX, name)
+	}
+}
+
+// \SET with no arguments lists the settings. Otherwise:
+//
+//	\SET -$name value     sets a named parameter
+//	\SET -args [...]      sets the positional parameters
+//	\SET -format csv|json|table
+func (this *Shell) set(line string) error {
+	if line == "" {
+		this.listSettings()
+		return nil
+	}
+
+	option, val := splitWord(line)
+	if val == "" {
+		return fmt.Errorf("Missing value for %s", option)
+	}
+
+	switch {
+	case strings.HasPrefix(option, "-$") && len(option) > 2:
+		this.named[option[1:]] = jsonText(val)
+	case strings.EqualFold(option, "-args"):
+		var args []interface{}
+		err := json.Unmarshal([]byte(val), &args)
+		if err != nil {
+			return fmt.Errorf("-args must be a JSON array: %v", err)
+		}
+		this.args = val
+	case strings.EqualFold(option, "-format"):
+		format := strings.ToLower(val)
+		switch format {
+		case FORMAT_JSON, FORMAT_CSV, FORMAT_TABLE:
+			this.format = format
+		default:
+			return fmt.Errorf("Unknown format %s; use %s, %s or %s", val, FORMAT_CSV, FORMAT_JSON, FORMAT_TABLE)
+		}
+	default:
+		return fmt.Errorf("Unknown option %s", option)
+	}
+
+	return nil
+}
+
+// \UNSET -$name or \UNSET -args removes parameters.
+func (this *Shell) unset(line string) error {
+	option, _ := splitWord(line)
+	switch {
+	case strings.HasPrefix(option, "-$") && len(option) > 2:
+		if _, ok := this.named[option[1:]]; !ok {
+			return fmt.Errorf("Parameter %s is not set", option[1:])
+		}
+		delete(this.named, option[1:])
+	case strings.EqualFold(option, "-args"):
+		this.args = ""
+	default:
+		return fmt.Errorf("Unknown option %s", option)
+	}
+
+	return nil
+}
+
+func (this *Shell) listSettings() {
+	fmt.Fprintf(os.Stderr, "-format %s\n", this.format)
+
+	names := make([]string, 0, len(this.named))
+	for name := range this.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "-%s %s\n", name, this.named[name])
+	}
+
+	if this.args != "" {
+		fmt.Fprintf(os.Stderr, "-args %s\n", this.args)
+	}
+}
+
+// \REDIRECT file sends results to the file; \REDIRECT off restores stdout.
+func (this *Shell) setRedirect(file string) error {
+	if file == "" {
+		return fmt.Errorf("Missing file name for %sREDIRECT", CMD_PREFIX)
+	}
+
+	if this.redirect != nil {
+		this.redirect.Close()
+		this.redirect = nil
+		this.output = os.Stdout
+	}
+
+	if strings.EqualFold(file, "off") {
+		return nil
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	this.redirect = f
+	this.output = f
+	return nil
+}
+
+func (this *Shell) setTiming(val string) error {
+	switch strings.ToLower(val) {
+	case "on":
+		this.timing = true
+	case "off":
+		this.timing = false
+	default:
+		return fmt.Errorf("%sTIMING must be on or off", CMD_PREFIX)
+	}
+
+	return nil
+}
+
+// \SOURCE file runs the statements and commands in the file.
+func (this *Shell) source(file string) error {
+	if file == "" {
+		return fmt.Errorf("Missing file name for %sSOURCE", CMD_PREFIX)
+	}
+
+	if this.depth >= MAX_SOURCE {
+		return fmt.Errorf("%sSOURCE nested too deeply", CMD_PREFIX)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	this.depth++
+	defer func() { this.depth-- }()
+
+	return this.Run(f)
+}
+
+// Run the statements and commands read from r. Statements end with
+// QRY_EOL and may span lines; commands take a single line.
+func (this *Shell) Run(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	queryLines := []string{}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if len(queryLines) == 0 && isCommand(line) {
+			this.Command(line)
+			continue
+		}
+
+		queryLines = append(queryLines, line)
+		if strings.HasSuffix(line, QRY_EOL) {
+			this.executeLines(queryLines)
+			queryLines = []string{}
+		}
+	}
+
+	// A final statement need not end with QRY_EOL
+	if len(queryLines) > 0 {
+		this.executeLines(queryLines)
+	}
+
+	return scanner.Err()
+}
+
+func (this *Shell) executeLines(queryLines []string) string {
+	queryString := strings.Join(queryLines, " ")
+	for strings.HasSuffix(queryString, QRY_EOL) {
+		queryString = strings.TrimSuffix(queryString, QRY_EOL)
+	}
+
+	if queryString != "" {
+		this.Execute(queryString)
+	}
+
+	return queryString
+}
+
+func (this *Shell) Close() {
+	if this.redirect != nil {
+		this.redirect.Close()
+		this.redirect = nil
+	}
+
+	if tr, ok := this.client.Transport.(*http.Transport); ok {
+		tr.CloseIdleConnections()
+	}
+}
+
+func splitWord(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+
+	return line[:i], strings.TrimSpace(line[i+1:])
+}
+
+// Parameter values are JSON; anything else is taken as a string.
+func jsonText(val string) string {
+	var v interface{}
+	if json.Unmarshal([]byte(val), &v) == nil {
+		return val
+	}
+
+	bytes, _ := json.Marshal(val)
+	return string(bytes)
+}
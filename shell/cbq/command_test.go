@@ -0,0 +1,231 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	shell := NewShell("http://localhost:8093/")
+	defer shell.Close()
+
+	cases := []struct {
+		command string
+		ok      bool
+	}{
+		{`\SET -$name "Smith"`, true},
+		{`\set -$age 42;`, true},
+		{`\SET -$city New York`, true},
+		{`\SET -args [1, "two"]`, true},
+		{`\SET -format TABLE`, true},
+		{`\SET -$`, false},
+		{`\SET -$name`, false},
+		{`\SET -args {"a": 1}`, false},
+		{`\SET -format xml`, false},
+		{`\SET -bogus 1`, false},
+		{`\BOGUS`, false},
+	}
+
+	for _, c := range cases {
+		err := shell.command(c.command)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: expected success %v, got %v", c.command, c.ok, err)
+		}
+	}
+
+	expected := map[string]string{"$name": `"Smith"`, "$age": "42", "$city": `"New York"`}
+	for name, val := range expected {
+		if shell.named[name] != val {
+			t.Errorf("expected %s to be %s, got %s", name, val, shell.named[name])
+		}
+	}
+
+	if shell.args != `[1, "two"]` || shell.format != FORMAT_TABLE {
+		t.Errorf("expected args and table format, got %s and %s", shell.args, shell.format)
+	}
+
+	// \UNSET removes parameters
+	for _, command := range []string{`\UNSET -$name`, `\UNSET -args`} {
+		if err := shell.command(command); err != nil {
+			t.Errorf("%s: unexpected error %v", command, err)
+		}
+	}
+
+	if _, ok := shell.named["$name"]; ok || shell.args != "" {
+		t.Errorf("expected $name and args to be unset, got %v and %s", shell.named, shell.args)
+	}
+
+	if err := shell.command(`\UNSET -$name`); err == nil {
+		t.Errorf("expected unsetting a missing parameter to fail")
+	}
+}
+
+func TestSourceDepth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cbq")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A file that sources itself stops at the maximum depth
+	file := filepath.Join(dir, "self.txt")
+	script := fmt.Sprintf("\\SET -$depth 1\n\\SOURCE %s\n", file)
+	if err = ioutil.WriteFile(file, []byte(script), 0666); err != nil {
+		t.Fatalf("failed to write %s: %v", file, err)
+	}
+
+	shell := NewShell("http://localhost:8093/")
+	defer shell.Close()
+
+	if err = shell.source(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if shell.Failures() != 1 || shell.depth != 0 {
+		t.Errorf("expected one failure at depth 0, got %d at %d", shell.Failures(), shell.depth)
+	}
+
+	if shell.named["$depth"] != "1" {
+		t.Errorf("expected the commands to run, got %v", shell.named)
+	}
+
+	if err = shell.source(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Errorf("expected a missing file to fail")
+	}
+
+	if err = shell.source(""); err == nil {
+		t.Errorf("expected a missing file name to fail")
+	}
+}
+
+// testingServer answers every statement with the given response, and
+// records the requests and the number of connections.
+type testingServer struct {
+	*httptest.Server
+	sync.Mutex
+	forms       []map[string]string
+	connections int
+}
+
+func newTestingServer(response string) *testingServer {
+	rv := &testingServer{}
+	rv.Server = httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			req.ParseForm()
+			form := make(map[string]string, len(req.Form))
+			for name := range req.Form {
+				form[name] = req.Form.Get(name)
+			}
+
+			rv.Lock()
+			rv.forms = append(rv.forms, form)
+			rv.Unlock()
+
+			fmt.Fprint(w, response)
+		}))
+
+	rv.Server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			rv.Lock()
+			rv.connections++
+			rv.Unlock()
+		}
+	}
+
+	rv.Start()
+	return rv
+}
+
+func TestRun(t *testing.T) {
+	server := newTestingServer(`{"results": [{"a": 1}], "status": "success"}`)
+	defer server.Close()
+
+	shell := NewShell(server.URL + "/")
+	defer shell.Close()
+
+	output := &bytes.Buffer{}
+	shell.output = output
+
+	script := strings.Join([]string{
+		`\SET -$name "x"`,
+		`SELECT 1`,
+		`  FROM t;`,
+		``,
+		`\SET -args [2]`,
+		`SELECT 2;;`,
+		`;`,
+		`SELECT 3`,
+	}, "\n")
+
+	if err := shell.Run(strings.NewReader(script)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(server.forms) != 3 {
+		t.Fatalf("expected 3 statements, got %v", server.forms)
+	}
+
+	expected := []map[string]string{
+		{"statement": "SELECT 1 FROM t", "$name": `"x"`},
+		{"statement": "SELECT 2", "$name": `"x"`, "args": "[2]"},
+		{"statement": "SELECT 3", "$name": `"x"`, "args": "[2]"},
+	}
+
+	for i, form := range server.forms {
+		if fmt.Sprint(form) != fmt.Sprint(expected[i]) {
+			t.Errorf("expected request %v, got %v", expected[i], form)
+		}
+	}
+
+	// Every statement uses the same connection
+	server.Lock()
+	connections := server.connections
+	server.Unlock()
+
+	if connections != 1 {
+		t.Errorf("expected one connection, got %d", connections)
+	}
+
+	if shell.Failures() != 0 {
+		t.Errorf("expected no failures, got %d", shell.Failures())
+	}
+
+	if strings.Count(output.String(), `"status": "success"`) != 3 {
+		t.Errorf("expected 3 responses, got %s", output.String())
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	server := newTestingServer(`{"errors": [{"code": 3000, "msg": "syntax error"}], "status": "fatal"}`)
+	defer server.Close()
+
+	shell := NewShell(server.URL + "/")
+	defer shell.Close()
+	shell.output = ioutil.Discard
+
+	if err := shell.Run(strings.NewReader("SELECT;\n\\BOGUS\nSELECT 1;")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if shell.Failures() != 3 {
+		t.Errorf("expected 3 failures, got %d", shell.Failures())
+	}
+}
@@ -0,0 +1,307 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+)
+
+// Characters that end a word for completion
+const WORD_BREAKS = " \t()[]{},;=<>!+-*/%|\"'"
+
+var _COMMANDS = []string{"\\REDIRECT", "\\SET", "\\SOURCE", "\\TIMING", "\\UNSET"}
+
+var _SET_OPTIONS = []string{"-$", "-args", "-format"}
+
+var _FORMATS = []string{FORMAT_CSV, FORMAT_JSON, FORMAT_TABLE}
+
+// Keywords after which a keyspace is expected
+var _KEYSPACE_CONTEXT = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"INTO":   true,
+	"UPDATE": true,
+	"ON":     true,
+}
+
+// Keywords after which keyspace.index is expected
+var _INDEX_CONTEXT = map[string]bool{
+	"DROP":  true,
+	"ALTER": true,
+}
+
+// Complete the last word of the line. Returns the candidate lines.
+func (this *Shell) Complete(line string) []string {
+	if isCommand(strings.TrimSpace(line)) {
+		i := strings.LastIndexAny(line, " \t")
+		head, word := line[:i+1], line[i+1:]
+		return completions(head, word, this.completeCommand(line, word))
+	}
+
+	head, word := splitLastWord(line)
+	previous := previousWords(head, 2)
+
+	var candidates []string
+	switch {
+	case len(previous) > 1 && previous[1] == "INDEX" && _INDEX_CONTEXT[previous[0]]:
+		candidates = this.names.indexNames(word)
+	case len(previous) > 0 && previous[len(previous)-1] == "INDEX" && strings.HasSuffix(strings.TrimSpace(head), "("):
+		// USE INDEX (
+		candidates = this.names.allIndexNames()
+	case len(previous) > 0 && _KEYSPACE_CONTEXT[previous[len(previous)-1]]:
+		candidates = this.names.keyspaceNames()
+		if previous[len(previous)-1] == "ON" {
+			candidates = append(candidates, matchCase(n1ql.Keywords(), word)...)
+		}
+	default:
+		candidates = matchCase(n1ql.Keywords(), word)
+		functions := append(expression.FunctionNames(), algebra.AggregateNames()...)
+		functions = append(functions, algebra.WindowFunctionNames()...)
+		for _, name := range matchCase(functions, word) {
+			candidates = append(candidates, name+"(")
+		}
+	}
+
+	return completions(head, word, candidates)
+}
+
+func (this *Shell) completeCommand(line, word string) []string {
+	words := strings.Fields(strings.ToUpper(line))
+	if word != "" {
+		words = words[:len(words)-1]
+	}
+
+	if len(words) == 0 {
+		return matchCase(_COMMANDS, word)
+	}
+
+	switch words[len(words)-1] {
+	case "\\SET", "\\UNSET":
+		return _SET_OPTIONS
+	case "-FORMAT":
+		return _FORMATS
+	case "\\TIMING":
+		return []string{"on", "off"}
+	}
+
+	return nil
+}
+
+// Returns the line up to the last word, and the last word.
+func splitLastWord(line string) (string, string) {
+	i := strings.LastIndexAny(line, WORD_BREAKS)
+	return line[:i+1], line[i+1:]
+}
+
+// Returns up to n words before the last word, in upper case.
+func previousWords(head string, n int) []string {
+	words := strings.FieldsFunc(head, func(r rune) bool {
+		return strings.ContainsRune(WORD_BREAKS, r)
+	})
+
+	if len(words) > n {
+		words = words[len(words)-n:]
+	}
+
+	for i, word := range words {
+		words[i] = strings.ToUpper(word)
+	}
+
+	return words
+}
+
+// Keywords and functions are completed in lower case if the word
+// has lower case letters, and otherwise in upper case.
+func matchCase(names []string, word string) []string {
+	lower := word != strings.ToUpper(word)
+
+	rv := make([]string, len(names))
+	for i, name := range names {
+		if lower {
+			rv[i] = strings.ToLower(name)
+		} else {
+			rv[i] = strings.ToUpper(name)
+		}
+	}
+
+	return rv
+}
+
+// Returns the sorted lines that complete the word with a candidate.
+func completions(head, word string, candidates []string) []string {
+	seen := make(map[string]bool, len(candidates))
+	rv := make([]string, 0, 16)
+
+	for _, candidate := range candidates {
+		if !hasPrefixFold(candidate, word) || seen[candidate] {
+			continue
+		}
+
+		seen[candidate] = true
+		rv = append(rv, head+candidate)
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// Names that are not identifiers must be escaped with backticks.
+func escapeName(name string) string {
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "`" + name + "`"
+		}
+	}
+
+	return name
+}
+
+// nameCache holds the keyspace and index names of the engine. They
+// are fetched when first needed, and again after DDL statements. If
+// fetching them fails, they are not fetched again for a while, so
+// that completion does not wait on an unreachable engine.
+type nameCache struct {
+	shell     *Shell
+	loaded    bool
+	failed    time.Time           // When fetching the names last failed
+	keyspaces []string            // keyspace and namespace:keyspace
+	indexes   map[string][]string // Index names by keyspace
+}
+
+const NAME_RETRY_INTERVAL = 30 * time.Second
+
+func newNameCache(shell *Shell) *nameCache {
+	return &nameCache{shell: shell}
+}
+
+func (this *nameCache) invalidate() {
+	this.loaded = false
+	this.failed = time.Time{}
+}
+
+func (this *nameCache) load() {
+	if this.loaded || time.Since(this.failed) < NAME_RETRY_INTERVAL {
+		return
+	}
+
+	keyspaces, err := this.shell.query("SELECT namespace_id, name FROM system:keyspaces")
+	if err != nil {
+		this.failed = time.Now()
+		return
+	}
+
+	indexes, err := this.shell.query("SELECT keyspace_id, name FROM system:indexes")
+	if err != nil {
+		this.failed = time.Now()
+		return
+	}
+
+	this.keyspaces = make([]string, 0, 2*len(keyspaces))
+	for _, result := range keyspaces {
+		namespace, name := stringField(result, "namespace_id"), stringField(result, "name")
+		if name == "" {
+			continue
+		}
+
+		this.keyspaces = append(this.keyspaces, escapeName(name))
+		if namespace != "" {
+			this.keyspaces = append(this.keyspaces, escapeName(namespace)+":"+escapeName(name))
+		}
+	}
+
+	this.indexes = make(map[string][]string, len(keyspaces))
+	for _, result := range indexes {
+		keyspace, name := stringField(result, "keyspace_id"), stringField(result, "name")
+		if keyspace != "" && name != "" {
+			this.indexes[keyspace] = append(this.indexes[keyspace], escapeName(name))
+		}
+	}
+
+	this.loaded = true
+}
+
+func (this *nameCache) keyspaceNames() []string {
+	this.load()
+	return this.keyspaces
+}
+
+// Completes keyspace.index, or the keyspace if there is no dot yet.
+func (this *nameCache) indexNames(word string) []string {
+	this.load()
+
+	dot := strings.LastIndex(word, ".")
+	if dot < 0 {
+		rv := make([]string, 0, len(this.indexes))
+		for keyspace := range this.indexes {
+			rv = append(rv, escapeName(keyspace)+".")
+		}
+		return rv
+	}
+
+	prefix := word[:dot+1]
+	keyspace := strings.Trim(word[:dot], "`")
+	if colon := strings.LastIndex(keyspace, ":"); colon >= 0 {
+		keyspace = strings.Trim(keyspace[colon+1:], "`")
+	}
+
+	names := this.indexes[keyspace]
+	rv := make([]string, len(names))
+	for i, name := range names {
+		rv[i] = prefix + name
+	}
+
+	return rv
+}
+
+func (this *nameCache) allIndexNames() []string {
+	this.load()
+
+	rv := make([]string, 0, 16)
+	for _, names := range this.indexes {
+		rv = append(rv, names...)
+	}
+
+	return rv
+}
+
+func stringField(result interface{}, name string) string {
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	s, _ := fields[name].(string)
+	return s
+}
+
+func isDDL(statement string) bool {
+	words := strings.Fields(statement)
+	if len(words) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(words[0]) {
+	case "CREATE", "DROP", "ALTER", "BUILD":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,182 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitLastWord(t *testing.T) {
+	cases := []struct {
+		line, head, word string
+	}{
+		{"", "", ""},
+		{"SEL", "", "SEL"},
+		{"SELECT ", "SELECT ", ""},
+		{"SELECT * FROM de", "SELECT * FROM ", "de"},
+		{"SELECT UPPER(na", "SELECT UPPER(", "na"},
+		{"SELECT a,b", "SELECT a,", "b"},
+		{"WHERE a>=b", "WHERE a>=", "b"},
+		{"FROM default:t", "FROM ", "default:t"},
+		{"DROP INDEX t.i", "DROP INDEX ", "t.i"},
+		{"SELECT 'it", "SELECT '", "it"},
+	}
+
+	for _, c := range cases {
+		head, word := splitLastWord(c.line)
+		if head != c.head || word != c.word {
+			t.Errorf("%q: expected %q and %q, got %q and %q", c.line, c.head, c.word, head, word)
+		}
+	}
+}
+
+func TestPreviousWords(t *testing.T) {
+	cases := []struct {
+		head     string
+		n        int
+		expected string
+	}{
+		{"", 2, ""},
+		{"SELECT * from ", 2, "SELECT FROM"},
+		{"select a from t join ", 2, "T JOIN"},
+		{"DROP INDEX ", 2, "DROP INDEX"},
+		{"SELECT * FROM t USE INDEX (", 2, "USE INDEX"},
+		{"SELECT count(", 1, "COUNT"},
+		{"SELECT a, b, ", 3, "SELECT A B"},
+	}
+
+	for _, c := range cases {
+		words := strings.Join(previousWords(c.head, c.n), " ")
+		if words != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.head, c.expected, words)
+		}
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	shell := NewShell("http://localhost:8093/")
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"\\", `\REDIRECT \SET \SOURCE \TIMING \UNSET`},
+		{"\\s", `\set \source`},
+		{"\\SO", `\SOURCE`},
+		{"\\SET ", `\SET -$ \SET -args \SET -format`},
+		{"\\set -f", `\set -format`},
+		{"\\UNSET -a", `\UNSET -args`},
+		{"\\SET -format ", `\SET -format csv \SET -format json \SET -format table`},
+		{"\\SET -format t", `\SET -format table`},
+		{"\\TIMING o", `\TIMING off \TIMING on`},
+		{"\\SOURCE ", ``},
+		{"\\BOGUS ", ``},
+	}
+
+	for _, c := range cases {
+		completions := strings.Join(shell.Complete(c.line), " ")
+		if completions != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.line, c.expected, completions)
+		}
+	}
+}
+
+func TestCompleteNames(t *testing.T) {
+	// The same results answer both the keyspace and the index query
+	server := newTestingServer(`{"results": [{"namespace_id": "default", "name": "t", "keyspace_id": "t"},
+		{"namespace_id": "default", "name": "my-ks", "keyspace_id": "t"}], "status": "success"}`)
+	defer server.Close()
+
+	shell := NewShell(server.URL + "/")
+	defer shell.Close()
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"SELECT * FROM ", "SELECT * FROM `my-ks`|SELECT * FROM default:`my-ks`|" +
+			"SELECT * FROM default:t|SELECT * FROM t"},
+		{"SELECT * FROM de", "SELECT * FROM default:`my-ks`|SELECT * FROM default:t"},
+		{"DROP INDEX ", "DROP INDEX t."},
+		{"DROP INDEX t.", "DROP INDEX t.`my-ks`|DROP INDEX t.t"},
+		{"DROP INDEX default:t.", "DROP INDEX default:t.`my-ks`|DROP INDEX default:t.t"},
+		{"SELECT * FROM t USE INDEX (", "SELECT * FROM t USE INDEX (`my-ks`|SELECT * FROM t USE INDEX (t"},
+	}
+
+	for _, c := range cases {
+		completions := strings.Join(shell.Complete(c.line), "|")
+		if completions != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.line, c.expected, completions)
+		}
+	}
+
+	// Functions are completed in the case of the word
+	if completions := shell.Complete("select cou"); !contains(completions, "select count(") {
+		t.Errorf("expected count( to be completed, got %v", completions)
+	}
+
+	// The names are fetched once, and again after DDL statements
+	if len(server.forms) != 2 {
+		t.Errorf("expected the names to be fetched once, got %d requests", len(server.forms))
+	}
+
+	shell.output = &strings.Builder{}
+	shell.Execute("CREATE INDEX i ON t(a)")
+	shell.Complete("SELECT * FROM ")
+	if len(server.forms) != 5 {
+		t.Errorf("expected the names to be fetched again, got %d requests", len(server.forms))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCompleteNamesFailure(t *testing.T) {
+	server := newTestingServer(`{"errors": [{"code": 4000, "msg": "failed"}], "status": "fatal"}`)
+	defer server.Close()
+
+	shell := NewShell(server.URL + "/")
+	defer shell.Close()
+
+	// A failure is not retried on every completion
+	for i := 0; i < 3; i++ {
+		if completions := shell.Complete("SELECT * FROM "); len(completions) != 0 {
+			t.Errorf("expected no completions, got %v", completions)
+		}
+	}
+
+	if len(server.forms) != 1 {
+		t.Errorf("expected one request, got %d", len(server.forms))
+	}
+
+	// It is retried after a while
+	shell.names.failed = time.Now().Add(-NAME_RETRY_INTERVAL)
+	shell.Complete("SELECT * FROM ")
+	if len(server.forms) != 2 {
+		t.Errorf("expected the names to be fetched again, got %d requests", len(server.forms))
+	}
+
+	// And after DDL statements
+	shell.output = &strings.Builder{}
+	shell.Execute("DROP INDEX t.i")
+	shell.Complete("SELECT * FROM ")
+	if len(server.forms) != 4 {
+		t.Errorf("expected the names to be fetched again, got %d requests", len(server.forms))
+	}
+}
@@ -16,7 +16,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/couchbaselabs/clog"
 	"github.com/sbinet/liner"
 )
 
@@ -43,6 +42,11 @@ func HandleInteractiveMode(tiServer, prompt string) {
 
 	LoadHistory(liner, homeDir)
 
+	shell := NewShell(tiServer)
+	defer shell.Close()
+
+	liner.SetCompleter(shell.Complete)
+
 	go signalCatcher(liner)
 
 	// state for reading a multi-line query
@@ -59,6 +63,13 @@ func HandleInteractiveMode(tiServer, prompt string) {
 			continue
 		}
 
+		// Meta-commands take a single line
+		if len(queryLines) == 0 && isCommand(line) {
+			UpdateHistory(liner, homeDir, line)
+			shell.Command(line)
+			continue
+		}
+
 		// Building query string mode: set prompt, gather current line
 		fullPrompt = QRY_PROMPT2
 		queryLines = append(queryLines, line)
@@ -66,16 +77,9 @@ func HandleInteractiveMode(tiServer, prompt string) {
 		// If the current line ends with a QRY_EOL, join all query lines,
 		// trim off trailing QRY_EOL characters, and submit the query string:
 		if strings.HasSuffix(line, QRY_EOL) {
-			queryString := strings.Join(queryLines, " ")
-			for strings.HasSuffix(queryString, QRY_EOL) {
-				queryString = strings.TrimSuffix(queryString, QRY_EOL)
-			}
+			queryString := shell.executeLines(queryLines)
 			if queryString != "" {
 				UpdateHistory(liner, homeDir, queryString+QRY_EOL)
-				err = execute_internal(tiServer, queryString, os.Stdout)
-				if err != nil {
-					clog.Error(err)
-				}
 			}
 			// reset state for multi-line query
 			queryLines = []string{}
@@ -0,0 +1,221 @@
+//  Copyright (c) 2013 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	FORMAT_JSON  = "json"
+	FORMAT_CSV   = "csv"
+	FORMAT_TABLE = "table"
+)
+
+// The name of the column holding results that are not objects
+const RAW_COLUMN = "$1"
+
+type queryResponse struct {
+	Results  []interface{}            `json:"results"`
+	Errors   []map[string]interface{} `json:"errors"`
+	Warnings []map[string]interface{} `json:"warnings"`
+	Status   string                   `json:"status"`
+	Metrics  map[string]interface{}   `json:"metrics"`
+}
+
+// Render a response in the current format. JSON is written as
+// received; CSV and table output are built from the results, with
+// errors and warnings reported on stderr. Returns an error if the
+// statement did not succeed.
+func (this *Shell) render(body []byte) error {
+	// Keep numbers as sent by the server
+	var response queryResponse
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err := decoder.Decode(&response)
+	if err != nil {
+		return fmt.Errorf("Invalid response from server: %s", strings.TrimSpace(string(body)))
+	}
+
+	switch this.format {
+	case FORMAT_CSV:
+		err = writeCSV(this.output, response.Results)
+	case FORMAT_TABLE:
+		err = writeTable(this.output, response.Results)
+	default:
+		_, err = this.output.Write(body)
+		if err == nil {
+			_, err = io.WriteString(this.output, "\n")
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if this.format != FORMAT_JSON {
+		for _, warning := range response.Warnings {
+			fmt.Fprintln(os.Stderr, "WARNING:", message(warning))
+		}
+	}
+
+	if this.timing {
+		writeMetrics(os.Stderr, response.Metrics)
+	}
+
+	if response.Status == "success" {
+		return nil
+	}
+
+	messages := make([]string, len(response.Errors))
+	for i, e := range response.Errors {
+		messages[i] = message(e)
+	}
+
+	if len(messages) == 0 {
+		return fmt.Errorf("Statement ended with status %s", response.Status)
+	}
+
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+func message(e map[string]interface{}) string {
+	return fmt.Sprintf("%v %v", e["code"], e["msg"])
+}
+
+func writeMetrics(w io.Writer, metrics map[string]interface{}) {
+	if len(metrics) == 0 {
+		return
+	}
+
+	names := sortedNames(metrics)
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = fmt.Sprintf("%s: %v", name, metrics[name])
+	}
+
+	fmt.Fprintln(w, strings.Join(fields, ", "))
+}
+
+func writeCSV(w io.Writer, results []interface{}) error {
+	columns := resultColumns(results)
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		err = writer.Write(resultRow(result, columns))
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeTable(w io.Writer, results []interface{}) error {
+	columns := resultColumns(results)
+	writer := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	rule := make([]string, len(columns))
+	for i, column := range columns {
+		rule[i] = strings.Repeat("-", len(column))
+	}
+
+	fmt.Fprintln(writer, strings.Join(columns, "\t"))
+	fmt.Fprintln(writer, strings.Join(rule, "\t"))
+
+	replacer := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for _, result := range results {
+		row := resultRow(result, columns)
+		for i, field := range row {
+			row[i] = replacer.Replace(field)
+		}
+
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "(%d rows)\n", len(results))
+	return err
+}
+
+// The columns are the sorted field names of all the result objects.
+func resultColumns(results []interface{}) []string {
+	names := make(map[string]interface{})
+	for _, result := range results {
+		if fields, ok := result.(map[string]interface{}); ok {
+			for name := range fields {
+				names[name] = nil
+			}
+		} else {
+			names[RAW_COLUMN] = nil
+		}
+	}
+
+	return sortedNames(names)
+}
+
+func resultRow(result interface{}, columns []string) []string {
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		fields = map[string]interface{}{RAW_COLUMN: result}
+	}
+
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = resultField(fields[column])
+	}
+
+	return row
+}
+
+// Strings are written as is, and missing and null values as empty
+// fields. Other values are written as JSON.
+func resultField(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+}
+
+func sortedNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,118 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testingResponse = `{
+    "results": [
+        {"name": "a, \"b\"", "n": 10000000000000000001},
+        {"name": "c\td", "o": {"x": [1, null]}, "z": null},
+        "raw"
+    ],
+    "status": "success"
+}`
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		// Columns are sorted, and numbers kept as sent
+		{FORMAT_CSV, strings.Join([]string{
+			`$1,n,name,o,z`,
+			`,10000000000000000001,"a, ""b""",,`,
+			`,,c` + "\t" + `d,"{""x"":[1,null]}",`,
+			`raw,,,,`,
+			``}, "\n")},
+
+		// Table fields are aligned, without tabs or line breaks
+		{FORMAT_TABLE, strings.Join([]string{
+			`$1   n                     name    o               z`,
+			`--   -                     ----    -               -`,
+			`     10000000000000000001  a, "b"`,
+			`                           c d     {"x":[1,null]}`,
+			`raw`,
+			`(3 rows)`,
+			``}, "\n")},
+
+		// JSON is written as received
+		{FORMAT_JSON, testingResponse + "\n"},
+	}
+
+	for _, c := range cases {
+		shell := NewShell("http://localhost:8093/")
+		output := &bytes.Buffer{}
+		shell.output = output
+		shell.format = c.format
+
+		if err := shell.render([]byte(testingResponse)); err != nil {
+			t.Errorf("%s: unexpected error: %v", c.format, err)
+		}
+
+		if actual := trimLines(output.String()); actual != c.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", c.format, c.expected, actual)
+		}
+	}
+}
+
+// Table rows are padded to the width of the last column.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestRenderEmpty(t *testing.T) {
+	shell := NewShell("http://localhost:8093/")
+	output := &bytes.Buffer{}
+	shell.output = output
+	shell.format = FORMAT_TABLE
+
+	if err := shell.render([]byte(`{"results": [], "status": "success"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output.String() != "\n\n(0 rows)\n" {
+		t.Errorf("expected an empty table, got %q", output.String())
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	cases := []struct {
+		response string
+		message  string
+	}{
+		{`{"results": [], "errors": [{"code": 4000, "msg": "No such keyspace"}], "status": "fatal"}`,
+			"4000 No such keyspace"},
+		{`{"results": [], "errors": [{"code": 1, "msg": "a"}, {"code": 2, "msg": "b"}], "status": "errors"}`,
+			"1 a\n2 b"},
+		{`{"results": [], "status": "timeout"}`, "Statement ended with status timeout"},
+		{`not json`, "Invalid response from server: not json"},
+	}
+
+	for _, c := range cases {
+		shell := NewShell("http://localhost:8093/")
+		shell.output = &bytes.Buffer{}
+		shell.format = FORMAT_CSV
+
+		err := shell.render([]byte(c.response))
+		if err == nil || err.Error() != c.message {
+			t.Errorf("%s: expected %q, got %v", c.response, c.message, err)
+		}
+	}
+}
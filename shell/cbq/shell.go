@@ -11,40 +11,119 @@ package main
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"flag"
-	"io"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 var tiServer = flag.String("engine", "http://localhost:8093/", "URL to cbq-engine")
+var scriptFile = flag.String("f", "", "File of statements to run non-interactively")
 
 func main() {
 	flag.Parse()
 	if strings.HasSuffix(*tiServer, "/") == false {
 		*tiServer = *tiServer + "/"
 	}
+
+	if *scriptFile != "" {
+		os.Exit(HandleBatchMode(*tiServer, *scriptFile))
+	}
+
 	HandleInteractiveMode(*tiServer, filepath.Base(os.Args[0]))
 }
 
-func execute_internal(tiServer, line string, w *os.File) error {
+// Run the statements in a file, returning a nonzero exit code if any
+// of them fail.
+func HandleBatchMode(tiServer, file string) int {
+	shell := NewShell(tiServer)
+	defer shell.Close()
+
+	err := shell.source(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 1
+	}
+
+	if shell.Failures() > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func (this *Shell) execute(statement string) error {
+	form := url.Values{}
+	form.Set("statement", statement)
+	for name, val := range this.named {
+		form.Set(name, val)
+	}
+
+	if this.args != "" {
+		form.Set("args", this.args)
+	}
+
+	if this.timing {
+		form.Set("metrics", "true")
+	}
+
+	body, err := this.post(form)
+	if err != nil {
+		return err
+	}
 
-	url := tiServer + "query"
+	// Names may have been created or dropped
+	if isDDL(statement) {
+		this.names.invalidate()
+	}
+
+	return this.render(body)
+}
+
+// Run a statement for the shell itself, without parameters or output.
+func (this *Shell) query(statement string) ([]interface{}, error) {
+	form := url.Values{}
+	form.Set("statement", statement)
+
+	body, err := this.post(form)
+	if err != nil {
+		return nil, err
+	}
+
+	var response queryResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("%s ended with status %s", statement, response.Status)
+	}
+
+	return response.Results, nil
+}
+
+// The client keeps connections to the server open between statements.
+func newClient(server string) *http.Client {
 	tr := &http.Transport{}
-	if strings.HasPrefix(url, "https") {
+	if strings.HasPrefix(server, "https") {
 		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	client := &http.Client{Transport: tr}
-	resp, err := client.Post(url, "text/plain", strings.NewReader(line))
+
+	return &http.Client{Transport: tr}
+}
+
+func (this *Shell) post(form url.Values) ([]byte, error) {
+	resp, err := this.client.PostForm(this.server+"query", form)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
-	io.Copy(w, resp.Body)
-	w.WriteString("\n")
-	w.Sync()
 
-	return nil
+	return ioutil.ReadAll(resp.Body)
 }
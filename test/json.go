@@ -20,6 +20,7 @@ import (
 	"github.com/couchbase/query/execution"
 	"github.com/couchbase/query/logging"
 	log_resolver "github.com/couchbase/query/logging/resolver"
+	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/value"
 )
@@ -45,10 +46,11 @@ func (this *MockQuery) Fail(err errors.Error) {
 	close(this.response.done)
 }
 
-func (this *MockQuery) Execute(srvr *server.Server, signature value.Value, stopNotify chan bool) {
+func (this *MockQuery) Execute(srvr *server.Server, prepared *plan.Prepared, stopNotify chan bool) {
 	defer this.Stop(server.COMPLETED)
 	this.NotifyStop(stopNotify)
 	this.writeResults()
+	this.writeWarnings()
 	close(this.response.done)
 }
 
@@ -106,6 +108,17 @@ func (this *MockQuery) writeResult(item value.Value) bool {
 	return true
 }
 
+func (this *MockQuery) writeWarnings() {
+	for {
+		select {
+		case wrn := <-this.Warnings():
+			this.response.warnings = append(this.response.warnings, wrn)
+		default:
+			return
+		}
+	}
+}
+
 type MockResponse struct {
 	err      errors.Error
 	results  []interface{}
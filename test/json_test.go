@@ -63,6 +63,29 @@ func TestSimpleSelect(t *testing.T) {
 	}
 }
 
+func TestIndexHintWarning(t *testing.T) {
+	qc := start()
+
+	statements := []string{
+		"SELECT g.id FROM default:game g USE INDEX (nope) WHERE g.score > 5",
+
+		// Each branch scans with the same hint, but the request warns once
+		"SELECT g.id FROM default:game g USE INDEX (nope) WHERE g.score > 5 " +
+			"UNION ALL SELECT g.id FROM default:game g USE INDEX (nope) WHERE g.score <= 5",
+	}
+
+	for _, statement := range statements {
+		r, warnings, err := Run(qc, statement)
+		if err != nil || len(r) == 0 {
+			t.Errorf("did not expect err %v for %s", err, statement)
+		}
+
+		if len(warnings) != 1 || warnings[0].Code() != 4060 {
+			t.Errorf("expected one index hint warning, got %v for %s", warnings, statement)
+		}
+	}
+}
+
 func TestAllCaseFiles(t *testing.T) {
 	qc := start()
 	matches, err := filepath.Glob("json/default/cases/case_*.json")